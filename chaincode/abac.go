@@ -0,0 +1,146 @@
+package chaincode
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/chainlaunch/chaincode-fabric-go-tmpl/chaincode/assetpb"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// assetAccessRule is the attribute policy of an asset function under WithAttributeAccessControl.
+// The argument extractors return the value to check, or "" when the arguments don't have it.
+type assetAccessRule struct {
+	admin      bool                  // only callers with the admin role
+	auditors   bool                  // auditors may call it, since it changes no assets
+	ownedAsset func([]string) string // the asset the caller must own
+	owner      func([]string) string // the owner the caller must be
+}
+
+// assetAccessRules lists the asset functions that are restricted beyond the default, which lets
+// every caller but auditors through. Admins pass every rule.
+var assetAccessRules = map[string]assetAccessRule{
+	"AgreeToSell":            {ownedAsset: argument(0)},
+	"CreateAsset":            {owner: argument(3)},
+	"CreateAssetPrivate":     {owner: argument(3)},
+	"CreateAssetProto":       {owner: protoCreateOwner},
+	"CreateAssets":           {admin: true},
+	"DeleteAsset":            {admin: true},
+	"DeleteSavedQuery":       {auditors: true},
+	"ExportAssets":           {auditors: true},
+	"GenerateAssets":         {admin: true},
+	"ImportAssets":           {admin: true},
+	"InitLedger":             {admin: true},
+	"MoveAssetSubtree":       {admin: true},
+	"PreviewTransfer":        {auditors: true},
+	"PreviewUpdate":          {auditors: true},
+	"PurgeAsset":             {admin: true},
+	"PurgePrivateAsset":      {admin: true},
+	"RandomizedWorkload":     {admin: true},
+	"RestoreAsset":           {admin: true},
+	"SaveQuery":              {auditors: true},
+	"SetAssetTokenPrice":     {ownedAsset: argument(0)},
+	"Subscribe":              {auditors: true},
+	"TransferAsset":          {ownedAsset: argument(0)},
+	"TransferAssetByColor":   {admin: true},
+	"TransferAssetProto":     {ownedAsset: protoTransferAsset},
+	"TransferAssetSecured":   {ownedAsset: argument(0)},
+	"TransferAssetWithToken": {owner: argument(1)},
+	"Unsubscribe":            {auditors: true},
+	"UpdateAsset":            {ownedAsset: argument(0)},
+	"ValidateUnchanged":      {auditors: true},
+}
+
+// authorizeAssetFunction enforces assetAccessRules on the invoked asset function from the
+// caller's role attribute: admins may call everything, auditors only functions that change no
+// assets, and everyone else only manages assets they own
+func authorizeAssetFunction(ctx contractapi.TransactionContextInterface) error {
+	function, params := ctx.GetStub().GetFunctionAndParameters()
+	if i := strings.LastIndex(function, ":"); i >= 0 {
+		function = function[i+1:]
+	}
+	isAdmin, err := callerIsAdmin(ctx)
+	if err != nil || isAdmin {
+		return err
+	}
+	rule := assetAccessRules[function]
+	if rule.admin {
+		return denyAccess(ctx, "role=admin", "%s requires the admin role", function)
+	}
+	roles, err := getCallerRoles(ctx)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(roles, roleAuditor) && !rule.auditors {
+		return denyAccess(ctx, "role=auditor is read-only", "auditors cannot call %s", function)
+	}
+
+	if rule.owner == nil && rule.ownedAsset == nil {
+		return nil
+	}
+	caller, err := getCallerEnrollmentID(ctx)
+	if err != nil {
+		return err
+	}
+	if rule.owner != nil {
+		if owner := rule.owner(params); owner != caller {
+			return denyAccess(ctx, "asset owner", "%s may only create assets they own", caller)
+		}
+	}
+	if rule.ownedAsset != nil {
+		assetID := rule.ownedAsset(params)
+		// Missing assets are left to the function, which reports them as usual
+		asset, err := getAsset(ctx, assetID)
+		if err == nil && asset.Owner != caller {
+			return denyAccess(ctx, "asset owner", "asset %s is not owned by %s", assetID, caller)
+		}
+	}
+	return nil
+}
+
+// callerIsAdmin reports whether the caller's MSP is on the admin allowlist and the caller is an admin
+func callerIsAdmin(ctx contractapi.TransactionContextInterface) (bool, error) {
+	allowed, _, err := callerAdminMSP(ctx)
+	if err != nil || !allowed {
+		return false, err
+	}
+	return callerHasAdminRole(ctx)
+}
+
+// callerHasAdminRole reports whether the caller carries the admin role attribute or was named an
+// admin by the genesis document
+func callerHasAdminRole(ctx contractapi.TransactionContextInterface) (bool, error) {
+	isAdmin, err := callerHasRole(ctx, roleAdmin)
+	if err != nil || isAdmin {
+		return isAdmin, err
+	}
+	return isGenesisAdmin(ctx)
+}
+
+// argument extracts the positional argument at index
+func argument(index int) func([]string) string {
+	return func(params []string) string {
+		if index < len(params) {
+			return params[index]
+		}
+		return ""
+	}
+}
+
+// protoCreateOwner extracts the owner of a CreateAssetProto request
+func protoCreateOwner(params []string) string {
+	req := &assetpb.CreateAssetRequest{}
+	if len(params) == 0 || decodeProtoArg(params[0], req) != nil {
+		return ""
+	}
+	return req.GetOwner()
+}
+
+// protoTransferAsset extracts the asset of a TransferAssetProto request
+func protoTransferAsset(params []string) string {
+	req := &assetpb.TransferAssetRequest{}
+	if len(params) == 0 || decodeProtoArg(params[0], req) != nil {
+		return ""
+	}
+	return req.GetId()
+}
@@ -0,0 +1,67 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAttributeAccessControl tests that asset functions enforce the admin, auditor and owner rules
+func TestAttributeAccessControl(t *testing.T) {
+	cc, err := contractapi.NewChaincode(NewAssetContract(WithAttributeAccessControl()), NewQueryContract(WithAttributeAccessControl()))
+	require.NoError(t, err)
+	stub := shimtest.NewMockStub("chaincode", cc)
+	admin := newSerializedIdentity(t, "Org1MSP", "admin", adminAttrs)
+	auditor := newSerializedIdentity(t, "Org1MSP", "auditor1", map[string]string{"role": "auditor"})
+	user1 := newSerializedIdentity(t, "Org1MSP", "user1", nil)
+	user2 := newSerializedIdentity(t, "Org1MSP", "user2", nil)
+
+	// Regular users only create assets they own
+	stub.Creator = user1
+	status, message := invoke(stub, "tx1", "CreateAsset", "asset1", "blue", "5", "user1", "100")
+	require.Equal(t, int32(shim.OK), status, message)
+	status, message = invoke(stub, "tx2", "CreateAsset", "asset2", "blue", "5", "user2", "100")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, ErrCodeAccessDenied)
+
+	// Only the owner manages an asset
+	stub.Creator = user2
+	status, message = invoke(stub, "tx3", "UpdateAsset", "asset1", "red", "5", "user1", "100", "0")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "not owned by user2")
+	status, _ = invoke(stub, "tx4", "TransferAsset", "asset1", "user2", "0")
+	assert.Equal(t, int32(shim.ERROR), status)
+	status, message = invoke(stub, "tx5", "TransferAsset", "missing", "user2", "0")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "does not exist")
+
+	// Owners may not delete, admins may
+	stub.Creator = user1
+	status, message = invoke(stub, "tx6", "DeleteAsset", "asset1")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "requires the admin role")
+	status, _ = invoke(stub, "tx7", "InitLedger")
+	assert.Equal(t, int32(shim.ERROR), status)
+
+	// Auditors read but do not write
+	stub.Creator = auditor
+	status, message = invoke(stub, "tx8", "QueryContract:ReadAsset", "asset1")
+	assert.Equal(t, int32(shim.OK), status, message)
+	status, message = invoke(stub, "tx9", "CreateAsset", "asset3", "blue", "5", "auditor1", "100")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "auditors cannot call CreateAsset")
+
+	stub.Creator = user1
+	status, message = invoke(stub, "tx10", "TransferAsset", "asset1", "user2", "0")
+	require.Equal(t, int32(shim.OK), status, message)
+
+	stub.Creator = admin
+	status, message = invoke(stub, "tx11", "UpdateAsset", "asset1", "red", "5", "user2", "100", "0")
+	require.Equal(t, int32(shim.OK), status, message)
+	status, message = invoke(stub, "tx12", "DeleteAsset", "asset1")
+	assert.Equal(t, int32(shim.OK), status, message)
+}
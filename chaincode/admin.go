@@ -0,0 +1,372 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	orgIndex      = "org~msp"
+	mspAssetIndex = "msp~id"
+
+	orgStatusActive      = "active"
+	orgStatusOffboarding = "offboarding"
+	orgStatusOffboarded  = "offboarded"
+
+	offboardModeFreeze   = "freeze"
+	offboardModeReassign = "reassign"
+)
+
+// AdminContract groups administrative transactions such as organization lifecycle management.
+// It is registered next to AssetContract and QueryContract and invoked as AdminContract:<Function>.
+type AdminContract struct {
+	contractapi.Contract
+	contractRuntime
+}
+
+// Organization is the registry record of a channel member organization
+type Organization struct {
+	DocType        string   `json:"docType"`
+	MSPID          string   `json:"mspId"`
+	Roles          []string `json:"roles"`
+	Collections    []string `json:"collections"`
+	Status         string   `json:"status"`
+	OffboardMode   string   `json:"offboardMode,omitempty" metadata:",optional"`
+	SuccessorOwner string   `json:"successorOwner,omitempty" metadata:",optional"`
+	SuccessorMSP   string   `json:"successorMsp,omitempty" metadata:",optional"`
+	Cursor         string   `json:"cursor,omitempty" metadata:",optional"`
+	ProcessedCount int      `json:"processedCount"`
+}
+
+// OffboardingProgress reports the outcome of a single offboarding batch
+type OffboardingProgress struct {
+	MSPID          string `json:"mspId"`
+	Processed      int    `json:"processed"`
+	TotalProcessed int    `json:"totalProcessed"`
+	Done           bool   `json:"done"`
+}
+
+// RegisterOrg adds a new organization to the registry, or reactivates an offboarded one.
+// Roles and collections are informational hints used by off-chain tooling.
+func (a *AdminContract) RegisterOrg(ctx contractapi.TransactionContextInterface, mspID string, roles []string, collections []string) error {
+	log.Info().Str("function", "RegisterOrg").Str("mspID", mspID).Strs("roles", roles).Strs("collections", collections).Msg("Registering organization")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if mspID == "" {
+		return fmt.Errorf("mspID must not be empty")
+	}
+
+	existing, err := getOrganization(ctx, mspID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Status != orgStatusOffboarded {
+		log.Warn().Str("mspID", mspID).Str("status", existing.Status).Msg("Organization already registered")
+		return fmt.Errorf("organization %s is already registered with status %s", mspID, existing.Status)
+	}
+
+	if roles == nil {
+		roles = []string{}
+	}
+	if collections == nil {
+		collections = []string{}
+	}
+	org := &Organization{
+		DocType:     "org",
+		MSPID:       mspID,
+		Roles:       roles,
+		Collections: collections,
+		Status:      orgStatusActive,
+	}
+	if err := putOrganization(ctx, org); err != nil {
+		return err
+	}
+
+	if err := emitOrgEvent(ctx, EventOrgRegistered, org, 0); err != nil {
+		return err
+	}
+
+	log.Info().Str("mspID", mspID).Msg("Organization registered successfully")
+	return nil
+}
+
+// GetOrg returns the registry record of an organization
+func (a *AdminContract) GetOrg(ctx contractapi.TransactionContextInterface, mspID string) (*Organization, error) {
+	log.Info().Str("function", "GetOrg").Str("mspID", mspID).Msg("Reading organization")
+
+	org, err := getOrganization(ctx, mspID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, fmt.Errorf("organization %s is not registered", mspID)
+	}
+	return org, nil
+}
+
+// StartOffboarding blocks all further writes by the organization and records how its assets
+// are handled: "freeze" marks them immutable, "reassign" hands them to a successor owner/MSP.
+// Assets are then processed by repeated ProcessOffboardingBatch calls.
+func (a *AdminContract) StartOffboarding(ctx contractapi.TransactionContextInterface, mspID, mode, successorOwner, successorMSP string) error {
+	log.Info().
+		Str("function", "StartOffboarding").
+		Str("mspID", mspID).
+		Str("mode", mode).
+		Str("successorOwner", successorOwner).
+		Str("successorMSP", successorMSP).
+		Msg("Starting organization offboarding")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	switch mode {
+	case offboardModeFreeze:
+	case offboardModeReassign:
+		if successorOwner == "" || successorMSP == "" {
+			return fmt.Errorf("reassign mode requires a successor owner and MSP")
+		}
+		if successorMSP == mspID {
+			return fmt.Errorf("successor MSP must differ from the offboarded organization")
+		}
+	default:
+		return fmt.Errorf("unknown offboarding mode %q, expected %q or %q", mode, offboardModeFreeze, offboardModeReassign)
+	}
+
+	org, err := a.GetOrg(ctx, mspID)
+	if err != nil {
+		return err
+	}
+	if org.Status != orgStatusActive {
+		return fmt.Errorf("organization %s cannot be offboarded from status %s", mspID, org.Status)
+	}
+
+	org.Status = orgStatusOffboarding
+	org.OffboardMode = mode
+	org.SuccessorOwner = successorOwner
+	org.SuccessorMSP = successorMSP
+	org.Cursor = ""
+	org.ProcessedCount = 0
+	if err := putOrganization(ctx, org); err != nil {
+		return err
+	}
+
+	if err := emitOrgEvent(ctx, EventOrgOffboardingStarted, org, 0); err != nil {
+		return err
+	}
+
+	log.Info().Str("mspID", mspID).Str("mode", mode).Msg("Organization offboarding started")
+	return nil
+}
+
+// ProcessOffboardingBatch reads the next batchSize assets of the ledger and freezes or reassigns
+// those held by an organization that is being offboarded. Call it repeatedly until Done is true,
+// which marks the org as offboarded.
+func (a *AdminContract) ProcessOffboardingBatch(ctx contractapi.TransactionContextInterface, mspID string, batchSize int) (*OffboardingProgress, error) {
+	log.Info().Str("function", "ProcessOffboardingBatch").Str("mspID", mspID).Int("batchSize", batchSize).Msg("Processing offboarding batch")
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batchSize must be positive")
+	}
+
+	org, err := a.GetOrg(ctx, mspID)
+	if err != nil {
+		return nil, err
+	}
+	if org.Status != orgStatusOffboarding {
+		return nil, fmt.Errorf("organization %s is not being offboarded", mspID)
+	}
+
+	assetIDs, cursor, exhausted, err := nextOrgAssets(ctx, mspID, org.Cursor, batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, assetID := range assetIDs {
+		if err := a.offboardAsset(ctx, org, assetID); err != nil {
+			return nil, err
+		}
+	}
+	org.Cursor = cursor
+	org.ProcessedCount += len(assetIDs)
+
+	eventName := EventOrgOffboardingBatch
+	if exhausted {
+		org.Status = orgStatusOffboarded
+		eventName = EventOrgOffboarded
+	}
+	if err := putOrganization(ctx, org); err != nil {
+		return nil, err
+	}
+	if err := emitOrgEvent(ctx, eventName, org, len(assetIDs)); err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("mspID", mspID).Int("processed", len(assetIDs)).Bool("done", exhausted).Msg("Offboarding batch completed")
+	return &OffboardingProgress{
+		MSPID:          mspID,
+		Processed:      len(assetIDs),
+		TotalProcessed: org.ProcessedCount,
+		Done:           exhausted,
+	}, nil
+}
+
+// offboardAsset applies the offboarding mode of the organization to a single asset
+func (a *AdminContract) offboardAsset(ctx contractapi.TransactionContextInterface, org *Organization, assetID string) error {
+	asset, err := getAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	switch org.OffboardMode {
+	case offboardModeFreeze:
+		asset.Frozen = true
+	case offboardModeReassign:
+		asset.Owner = org.SuccessorOwner
+		asset.OwnerMSP = org.SuccessorMSP
+	}
+
+	if err := a.saveAsset(ctx, asset); err != nil {
+		log.Error().Err(err).Str("assetID", assetID).Str("mspID", org.MSPID).Msg("Failed to update asset during offboarding")
+		return err
+	}
+
+	log.Debug().Str("assetID", assetID).Str("mspID", org.MSPID).Str("mode", org.OffboardMode).Msg("Asset offboarded")
+	return nil
+}
+
+// nextOrgAssets reads up to limit assets whose IDs sort after cursor and returns the IDs of
+// those the organization holds, the ID of the last asset read and whether every asset has been
+// read. It walks the asset keys rather than the msp~id index: composite keys cannot bound a range
+// query and paginated queries are only allowed in read-only transactions, so a batch could only
+// resume the index by skipping every entry processed before it.
+func nextOrgAssets(ctx contractapi.TransactionContextInterface, mspID, cursor string, limit int) ([]string, string, bool, error) {
+	startKey, endKey := assetKeyRange("", "")
+	if cursor != "" {
+		startKey = assetKey(cursor) + "\x00"
+	}
+	iterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		log.Error().Err(err).Str("mspID", mspID).Msg("Failed to scan assets for offboarding")
+		return nil, "", false, err
+	}
+	defer iterator.Close()
+
+	var assetIDs []string
+	scanned := 0
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, "", false, err
+		}
+		if scanned == limit {
+			return assetIDs, cursor, false, nil
+		}
+		scanned++
+		cursor = assetIDFromKey(response.Key)
+
+		asset, err := decodeAssetRecord(response.Value)
+		if err != nil {
+			log.Warn().Err(err).Str("key", response.Key).Str("mspID", mspID).Msg("Skipping unreadable record during offboarding")
+			continue
+		}
+		if asset.DocType != AssetDocType || asset.Deleted || asset.OwnerMSP != mspID || response.Key != assetKey(asset.ID) {
+			continue
+		}
+		assetIDs = append(assetIDs, asset.ID)
+	}
+	return assetIDs, cursor, true, nil
+}
+
+// getOrganization reads an organization record, returning nil when it is not registered
+func getOrganization(ctx contractapi.TransactionContextInterface, mspID string) (*Organization, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(orgIndex, []string{mspID})
+	if err != nil {
+		return nil, err
+	}
+	orgBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		log.Error().Err(err).Str("mspID", mspID).Msg("Failed to read organization")
+		return nil, fmt.Errorf("failed to read organization %s: %v", mspID, err)
+	}
+	if orgBytes == nil {
+		return nil, nil
+	}
+
+	var org Organization
+	if err := json.Unmarshal(orgBytes, &org); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func putOrganization(ctx contractapi.TransactionContextInterface, org *Organization) error {
+	key, err := ctx.GetStub().CreateCompositeKey(orgIndex, []string{org.MSPID})
+	if err != nil {
+		return err
+	}
+	orgBytes, err := json.Marshal(org)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, orgBytes); err != nil {
+		log.Error().Err(err).Str("mspID", org.MSPID).Msg("Failed to store organization")
+		return err
+	}
+	return nil
+}
+
+// emitOrgEvent publishes an organization lifecycle event. Fabric keeps only the last
+// event set in a transaction, so every lifecycle step is its own transaction.
+func emitOrgEvent(ctx contractapi.TransactionContextInterface, name string, org *Organization, processed int) error {
+	return emitEvent(ctx, name, org.MSPID, map[string]interface{}{
+		"mspId":          org.MSPID,
+		"status":         org.Status,
+		"mode":           org.OffboardMode,
+		"processed":      processed,
+		"totalProcessed": org.ProcessedCount,
+	})
+}
+
+// assertOrgCanWrite rejects writes from organizations that are being or have been offboarded.
+// Organizations missing from the registry are allowed so the registry stays opt-in.
+func assertOrgCanWrite(ctx contractapi.TransactionContextInterface) (string, error) {
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get client MSP ID")
+		return "", err
+	}
+
+	org, err := getOrganization(ctx, mspID)
+	if err != nil {
+		return "", err
+	}
+	if org != nil && org.Status != orgStatusActive {
+		return "", denyAccess(ctx, "organization status=active", "organization %s is %s and cannot submit writes", mspID, org.Status)
+	}
+	return mspID, nil
+}
+
+// requireAdmin ensures the caller's MSP is on the admin allowlist and the caller carries the admin
+// role attribute or was named an admin by the genesis document
+func requireAdmin(ctx contractapi.TransactionContextInterface) error {
+	if err := requireAdminMSP(ctx); err != nil {
+		return err
+	}
+	isAdmin, err := callerHasAdminRole(ctx)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return denyAccess(ctx, "role=admin or genesis admin", "caller is not an admin")
+	}
+	return nil
+}
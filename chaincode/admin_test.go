@@ -0,0 +1,207 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var adminAttrs = map[string]string{"role": "admin"}
+
+// TestRegisterOrgRequiresAdmin tests that only admins can manage the organization registry
+func TestRegisterOrgRequiresAdmin(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	admin := &AdminContract{}
+
+	err := admin.RegisterOrg(ctx, "Org2MSP", []string{"member"}, nil)
+	assert.Error(t, err)
+}
+
+// TestAccessDenied tests that a denial returns ACCESS_DENIED and logs who was denied and why
+func TestAccessDenied(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = logger }()
+
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	err := (&AdminContract{}).RegisterOrg(ctx, "Org2MSP", []string{"member"}, nil)
+	require.Error(t, err)
+	assert.True(t, hasErrorCode(err, ErrCodeAccessDenied))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &record))
+	assert.Equal(t, "Access denied", record["message"])
+	assert.Equal(t, "tx1", record["txId"])
+	assert.Equal(t, "Org1MSP", record["mspId"])
+	assert.Equal(t, "role=admin or genesis admin", record["policy"])
+	assert.Equal(t, "caller is not an admin", record["reason"])
+	assert.Equal(t, "user1", record["actor"])
+}
+
+// TestOffboardingFreeze tests that offboarding blocks writes and freezes assets in batches
+func TestOffboardingFreeze(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org2MSP", "user2", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	admin := &AdminContract{}
+
+	for _, id := range []string{"asset1", "asset2", "asset3"} {
+		require.NoError(t, cc.CreateAsset(ctx, id, "blue", 5, "Jane", 100))
+	}
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "admin", adminAttrs)
+	require.NoError(t, admin.RegisterOrg(ctx, "Org2MSP", []string{"member"}, []string{"Org2MSPPrivateCollection"}))
+	require.NoError(t, admin.StartOffboarding(ctx, "Org2MSP", "freeze", "", ""))
+
+	progress, err := admin.ProcessOffboardingBatch(ctx, "Org2MSP", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, progress.Processed)
+	assert.False(t, progress.Done)
+
+	progress, err = admin.ProcessOffboardingBatch(ctx, "Org2MSP", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Processed)
+	assert.Equal(t, 3, progress.TotalProcessed)
+	assert.True(t, progress.Done)
+
+	org, err := admin.GetOrg(ctx, "Org2MSP")
+	require.NoError(t, err)
+	assert.Equal(t, orgStatusOffboarded, org.Status)
+
+	asset, err := qc.ReadAsset(ctx, "asset3")
+	require.NoError(t, err)
+	assert.True(t, asset.Frozen)
+	assert.Error(t, cc.TransferAsset(ctx, "asset3", "Max", 0))
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "user2", nil)
+	assert.Error(t, cc.CreateAsset(ctx, "asset4", "red", 5, "Jane", 100))
+}
+
+// TestOffboardingBatchesResume tests that every batch reads at most batchSize assets, resumes
+// after the last asset read and skips the assets of other organizations
+func TestOffboardingBatchesResume(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	admin := &AdminContract{}
+	for _, id := range []string{"asset1", "asset3", "asset5"} {
+		require.NoError(t, cc.CreateAsset(ctx, id, "blue", 5, "John", 100))
+	}
+	switchIdentity(t, ctx, stub, "Org2MSP", "user2", nil)
+	for _, id := range []string{"asset2", "asset4"} {
+		require.NoError(t, cc.CreateAsset(ctx, id, "blue", 5, "Jane", 100))
+	}
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "admin", adminAttrs)
+	require.NoError(t, admin.RegisterOrg(ctx, "Org2MSP", nil, nil))
+	require.NoError(t, admin.StartOffboarding(ctx, "Org2MSP", "freeze", "", ""))
+
+	var processed []int
+	for i := 0; i < 3; i++ {
+		progress, err := admin.ProcessOffboardingBatch(ctx, "Org2MSP", 2)
+		require.NoError(t, err)
+		processed = append(processed, progress.Processed)
+		assert.Equal(t, i == 2, progress.Done)
+	}
+	assert.Equal(t, []int{1, 1, 0}, processed)
+
+	for id, frozen := range map[string]bool{"asset1": false, "asset2": true, "asset3": false, "asset4": true, "asset5": false} {
+		asset, err := getAsset(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, frozen, asset.Frozen, id)
+	}
+}
+
+// TestOffboardingUsesInjectedClock tests that offboarded assets are stamped by the clock of the
+// contract options rather than the transaction timestamp
+func TestOffboardingUsesInjectedClock(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org2MSP", "user2", nil)
+	require.NoError(t, (&AssetContract{}).CreateAsset(ctx, "asset1", "blue", 5, "Jane", 100))
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	admin := NewAdminContract(WithClock(fixedClock(now)))
+	switchIdentity(t, ctx, stub, "Org1MSP", "admin", adminAttrs)
+	require.NoError(t, admin.RegisterOrg(ctx, "Org2MSP", nil, nil))
+	require.NoError(t, admin.StartOffboarding(ctx, "Org2MSP", "freeze", "", ""))
+	_, err := admin.ProcessOffboardingBatch(ctx, "Org2MSP", 10)
+	require.NoError(t, err)
+
+	asset, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.True(t, asset.Frozen)
+	assert.Equal(t, now, asset.UpdatedAt)
+}
+
+// TestOffboardingReassign tests that reassign mode moves assets to the successor organization
+func TestOffboardingReassign(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org2MSP", "user2", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	admin := &AdminContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "Jane", 100))
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "admin", adminAttrs)
+	require.NoError(t, admin.RegisterOrg(ctx, "Org2MSP", nil, nil))
+	require.Error(t, admin.StartOffboarding(ctx, "Org2MSP", "reassign", "", ""))
+	require.NoError(t, admin.StartOffboarding(ctx, "Org2MSP", "reassign", "Tomoko", "Org1MSP"))
+
+	progress, err := admin.ProcessOffboardingBatch(ctx, "Org2MSP", 10)
+	require.NoError(t, err)
+	assert.True(t, progress.Done)
+
+	asset, err := qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "Tomoko", asset.Owner)
+	assert.Equal(t, "Org1MSP", asset.OwnerMSP)
+	assert.False(t, asset.Frozen)
+}
+
+// TestOffboardingFollowsTransfers tests that offboarding acts on the assets an organization holds,
+// including those transferred to it from another organization
+func TestOffboardingFollowsTransfers(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	admin := &AdminContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "blue", 5, "John", 100))
+	assert.ErrorContains(t, cc.TransferAsset(ctx, "asset1", "Org2MSP:", 0), "must be of the form")
+	require.NoError(t, cc.TransferAsset(ctx, "asset1", "Org2MSP:Jane", 0))
+	// A bare name stays within the organization, also when only other fields change
+	require.NoError(t, cc.TransferAsset(ctx, "asset2", "Max", 0))
+	switchIdentity(t, ctx, stub, "Org2MSP", "user2", nil)
+	require.NoError(t, cc.UpdateAsset(ctx, "asset2", "red", 5, "Max", 100, 0))
+
+	asset, err := qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", asset.Owner)
+	assert.Equal(t, "Org2MSP", asset.OwnerMSP)
+	asset, err = qc.ReadAsset(ctx, "asset2")
+	require.NoError(t, err)
+	assert.Equal(t, "Org1MSP", asset.OwnerMSP)
+
+	assetIDs, _, _, err := nextOrgAssets(ctx, "Org1MSP", "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"asset2"}, assetIDs)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "admin", adminAttrs)
+	require.NoError(t, admin.RegisterOrg(ctx, "Org2MSP", nil, nil))
+	require.NoError(t, admin.StartOffboarding(ctx, "Org2MSP", "freeze", "", ""))
+	progress, err := admin.ProcessOffboardingBatch(ctx, "Org2MSP", 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.TotalProcessed)
+
+	asset, err = qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.True(t, asset.Frozen)
+	asset, err = qc.ReadAsset(ctx, "asset2")
+	require.NoError(t, err)
+	assert.False(t, asset.Frozen)
+}
@@ -0,0 +1,113 @@
+package chaincode
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+// adminMSPConfig is the configuration key of the MSPs allowed to invoke administrative functions
+const adminMSPConfig = "adminmsps"
+
+// AddAdminMSP allows an MSP to invoke administrative functions. While the allowlist is empty
+// every MSP may, so the first entry has to be the caller's own organization.
+func (c *ConfigContract) AddAdminMSP(ctx contractapi.TransactionContextInterface, mspID string) error {
+	log.Info().Str("function", "AddAdminMSP").Str("mspID", mspID).Msg("Adding MSP to the admin allowlist")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if mspID == "" {
+		return fmt.Errorf("mspID must not be empty")
+	}
+	allowed, err := getAdminMSPs(ctx)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(allowed, mspID) {
+		return nil
+	}
+	allowed = append(allowed, mspID)
+	slices.Sort(allowed)
+	if err := putAdminMSPs(ctx, allowed); err != nil {
+		return err
+	}
+	return recordAudit(ctx, "AddAdminMSP", mspID, "")
+}
+
+// RemoveAdminMSP revokes an MSP from the admin allowlist. Removing the last entry lets every MSP
+// invoke administrative functions again.
+func (c *ConfigContract) RemoveAdminMSP(ctx contractapi.TransactionContextInterface, mspID string) error {
+	log.Info().Str("function", "RemoveAdminMSP").Str("mspID", mspID).Msg("Removing MSP from the admin allowlist")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	allowed, err := getAdminMSPs(ctx)
+	if err != nil {
+		return err
+	}
+	index := slices.Index(allowed, mspID)
+	if index < 0 {
+		return fmt.Errorf("MSP %s is not on the admin allowlist", mspID)
+	}
+	if err := putAdminMSPs(ctx, slices.Delete(allowed, index, index+1)); err != nil {
+		return err
+	}
+	return recordAudit(ctx, "RemoveAdminMSP", mspID, "")
+}
+
+// GetAdminMSPs returns the MSPs allowed to invoke administrative functions, empty when every MSP is
+func (c *ConfigContract) GetAdminMSPs(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	log.Info().Str("function", "GetAdminMSPs").Msg("Reading the admin allowlist")
+	return getAdminMSPs(ctx)
+}
+
+// requireAdminMSP rejects callers whose MSP is missing from a non-empty admin allowlist
+func requireAdminMSP(ctx contractapi.TransactionContextInterface) error {
+	allowed, mspID, err := callerAdminMSP(ctx)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return denyAccess(ctx, "admin MSP allowlist", "organization %s may not invoke administrative functions", mspID)
+	}
+	return nil
+}
+
+// callerAdminMSP reports whether the caller's MSP may invoke administrative functions
+func callerAdminMSP(ctx contractapi.TransactionContextInterface) (bool, string, error) {
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		return false, "", err
+	}
+	allowed, err := getAdminMSPs(ctx)
+	if err != nil {
+		return false, "", err
+	}
+	return len(allowed) == 0 || slices.Contains(allowed, mspID), mspID, nil
+}
+
+// getAdminMSPs reads the admin allowlist
+func getAdminMSPs(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	allowed := []string{}
+	if _, err := getConfig(ctx, &allowed, adminMSPConfig); err != nil {
+		return nil, err
+	}
+	return allowed, nil
+}
+
+// putAdminMSPs stores the admin allowlist. It must keep the caller's MSP unless it becomes empty,
+// so admins cannot lock their own organization out.
+func putAdminMSPs(ctx contractapi.TransactionContextInterface, allowed []string) error {
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		return err
+	}
+	if len(allowed) > 0 && !slices.Contains(allowed, mspID) {
+		return fmt.Errorf("the admin allowlist must include the caller's organization %s", mspID)
+	}
+	return putConfig(ctx, allowed, adminMSPConfig)
+}
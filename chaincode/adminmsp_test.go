@@ -0,0 +1,47 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdminMSPAllowlist tests that admin functions are limited to the allowlisted MSPs once it is set
+func TestAdminMSPAllowlist(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	config := &ConfigContract{}
+	admin := &AdminContract{}
+
+	// The first entry has to keep the caller's organization in
+	assert.ErrorContains(t, config.AddAdminMSP(ctx, "Org2MSP"), "must include the caller's organization")
+	require.NoError(t, config.AddAdminMSP(ctx, "Org1MSP"))
+	require.NoError(t, config.AddAdminMSP(ctx, "Org2MSP"))
+	allowed, err := config.GetAdminMSPs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Org1MSP", "Org2MSP"}, allowed)
+	assert.ErrorContains(t, config.RemoveAdminMSP(ctx, "Org1MSP"), "must include the caller's organization")
+
+	// Admins of other organizations are refused, even with the admin role
+	switchIdentity(t, ctx, stub, "Org3MSP", "admin3", adminAttrs)
+	err = config.SetMSPRegion(ctx, "Org3MSP", "EU")
+	assert.ErrorContains(t, err, ErrCodeAccessDenied)
+	assert.ErrorContains(t, err, "Org3MSP may not invoke administrative functions")
+	_, err = admin.RebuildIndexes(ctx, index, 10)
+	assert.Error(t, err)
+	assert.ErrorContains(t, (&AssetContract{}).InitLedger(ctx), "Org3MSP may not invoke")
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "admin2", adminAttrs)
+	require.NoError(t, config.SetMSPRegion(ctx, "Org2MSP", "US"))
+	require.NoError(t, config.RemoveAdminMSP(ctx, "Org1MSP"))
+	assert.Error(t, config.RemoveAdminMSP(ctx, "Org1MSP"))
+
+	// Removing the last entry opens the admin functions to every MSP again
+	require.NoError(t, config.RemoveAdminMSP(ctx, "Org2MSP"))
+	switchIdentity(t, ctx, stub, "Org3MSP", "admin3", adminAttrs)
+	require.NoError(t, config.SetMSPRegion(ctx, "Org3MSP", "EU"))
+	require.NoError(t, (&AssetContract{}).InitLedger(ctx))
+
+	switchIdentity(t, ctx, stub, "Org3MSP", "user3", nil)
+	assert.Error(t, config.AddAdminMSP(ctx, "Org3MSP"))
+}
@@ -0,0 +1,238 @@
+package chaincode
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assetKeyWriters are the files of the repository layer, the only ones that put or delete
+// state under assetKey. Everything else goes through putAsset, saveAsset and deleteAsset so
+// checksums, codecs, indexes and the outbox stay consistent.
+var assetKeyWriters = map[string]bool{
+	"repository.go": true,
+}
+
+// rawAssetReaders may read state under assetKey directly, bypassing getAsset, because they need
+// the stored bytes (digests, pre-images, range and index checks) or soft-deleted records
+var rawAssetReaders = map[string]bool{
+	"contract.go":   true,
+	"indexes.go":    true,
+	"invariants.go": true,
+	"ownership.go":  true,
+	"paths.go":      true,
+	"repository.go": true,
+	"softdelete.go": true,
+	"swap.go":       true,
+}
+
+// wallClockUsers may read the wall clock, to measure durations that never reach the ledger.
+// Ledger timestamps come from txClock, which every endorsing peer agrees on.
+var wallClockUsers = map[string]bool{
+	"budget.go":  true,
+	"slowlog.go": true,
+}
+
+// nondeterministicImports produce values that differ between endorsing peers. Chaincode draws
+// randomness from txRandom instead.
+var nondeterministicImports = []string{"crypto/rand", "math/rand", "math/rand/v2"}
+
+// TestArchitectureRules scans the chaincode sources, including files behind build tags, for
+// state access and nondeterminism that bypass the layers the template establishes
+func TestArchitectureRules(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+
+	fset := token.NewFileSet()
+	var violations []string
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		require.NoError(t, err)
+		violations = append(violations, architectureViolations(fset, name, file)...)
+	}
+	assert.Empty(t, violations)
+}
+
+// TestArchitectureViolations tests that the scan reports each kind of violation
+func TestArchitectureViolations(t *testing.T) {
+	src := `package chaincode
+
+import (
+	"math/rand"
+	"time"
+)
+
+func bad(ctx contractapi.TransactionContextInterface) {
+	ctx.GetStub().PutState(assetKey("asset1"), nil)
+	ctx.GetStub().GetState(assetKey("asset1"))
+	_ = time.Now()
+	_ = rand.Int()
+
+	key := assetKey("asset2")
+	ctx.GetStub().DelState(key)
+	iterator, _ := ctx.GetStub().GetStateByRange("", "")
+	response, _ := iterator.Next()
+	ctx.GetStub().PutState(response.Key, nil)
+
+	entries, _ := ctx.GetStub().GetStateByPartialCompositeKey("color~name", nil)
+	entry, _ := entries.Next()
+	ctx.GetStub().DelState(entry.Key)
+	other := "config"
+	ctx.GetStub().PutState(other, nil)
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "bad.go", src, 0)
+	require.NoError(t, err)
+	violations := architectureViolations(fset, "bad.go", file)
+	require.Len(t, violations, 6)
+	assert.Contains(t, violations[0], `imports "math/rand"`)
+	assert.Contains(t, violations[1], "PutState on an asset key")
+	assert.Contains(t, violations[2], "GetState on an asset key")
+	assert.Contains(t, violations[3], "time.Now")
+	assert.Contains(t, violations[4], "DelState on an asset key")
+	assert.Contains(t, violations[5], "PutState on an asset key")
+}
+
+// architectureViolations lists the rule violations of one source file
+func architectureViolations(fset *token.FileSet, name string, file *ast.File) []string {
+	var violations []string
+	report := func(pos token.Pos, format string, args ...interface{}) {
+		violations = append(violations, fmt.Sprintf("%s: %s", fset.Position(pos), fmt.Sprintf(format, args...)))
+	}
+
+	timeName := ""
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		for _, forbidden := range nondeterministicImports {
+			if path == forbidden {
+				report(spec.Pos(), "imports %q, use txRandom", path)
+			}
+		}
+		if path == "time" {
+			timeName = "time"
+			if spec.Name != nil {
+				timeName = spec.Name.Name
+			}
+		}
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		method := selector.Sel.Name
+
+		if pkg, ok := selector.X.(*ast.Ident); ok && timeName != "" && pkg.Name == timeName {
+			if (method == "Now" || method == "Since" || method == "Until") && !wallClockUsers[name] {
+				report(call.Pos(), "time.%s reads the wall clock, use txClock", method)
+			}
+			return true
+		}
+
+		if len(call.Args) == 0 || !isAssetKey(call.Args[0]) {
+			return true
+		}
+		switch method {
+		case "PutState", "DelState":
+			if !assetKeyWriters[name] {
+				report(call.Pos(), "%s on an asset key outside the repository layer", method)
+			}
+		case "GetState":
+			if !rawAssetReaders[name] {
+				report(call.Pos(), "GetState on an asset key outside the repository layer, use getAsset")
+			}
+		}
+		return true
+	})
+	return violations
+}
+
+// isAssetKey reports whether expr is an asset key: a call of assetKey, a variable initialized
+// with one, or the Key of a result of a range scan, which reads simple keys and so assets
+func isAssetKey(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.CallExpr:
+		ident, ok := expr.Fun.(*ast.Ident)
+		return ok && ident.Name == "assetKey"
+	case *ast.Ident:
+		value := initialValue(expr)
+		return value != nil && isAssetKey(value)
+	case *ast.SelectorExpr:
+		if expr.Sel.Name != "Key" {
+			return false
+		}
+		result, ok := expr.X.(*ast.Ident)
+		if !ok {
+			return false
+		}
+		next, ok := initialValue(result).(*ast.CallExpr)
+		if !ok || calledMethod(next) != "Next" {
+			return false
+		}
+		iterator, ok := next.Fun.(*ast.SelectorExpr).X.(*ast.Ident)
+		if !ok {
+			return false
+		}
+		scan, ok := initialValue(iterator).(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		method := calledMethod(scan)
+		return method == "GetStateByRange" || method == "GetStateByRangeWithPagination"
+	}
+	return false
+}
+
+// initialValue returns the expression a local variable is declared with, or nil for parameters
+// and variables declared without one
+func initialValue(ident *ast.Ident) ast.Expr {
+	if ident.Obj == nil {
+		return nil
+	}
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.AssignStmt:
+		for i, lhs := range decl.Lhs {
+			if name, ok := lhs.(*ast.Ident); ok && name.Name == ident.Name {
+				if len(decl.Rhs) == len(decl.Lhs) {
+					return decl.Rhs[i]
+				}
+				// Multiple results, e.g. iterator, metadata, err := ...
+				if len(decl.Rhs) == 1 {
+					return decl.Rhs[0]
+				}
+			}
+		}
+	case *ast.ValueSpec:
+		for i, name := range decl.Names {
+			if name.Name == ident.Name && i < len(decl.Values) {
+				return decl.Values[i]
+			}
+		}
+	}
+	return nil
+}
+
+// calledMethod returns the method name of a call like x.Method(...), or "" for other calls
+func calledMethod(call *ast.CallExpr) string {
+	if selector, ok := call.Fun.(*ast.SelectorExpr); ok {
+		return selector.Sel.Name
+	}
+	return ""
+}
@@ -0,0 +1,36 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// assetReaders pools the readers that feed asset records to json.Decoder, since records are
+// decoded on every read and query result
+var assetReaders = sync.Pool{
+	New: func() interface{} { return new(bytes.Reader) },
+}
+
+// decodeAssetJSON decodes a JSON asset record into record, an *Asset or a *storedAsset, with a
+// json.Decoder over a pooled reader. Like json.Unmarshal it rejects anything after the object
+// other than whitespace.
+func decodeAssetJSON(data []byte, record interface{}) error {
+	reader := assetReaders.Get().(*bytes.Reader)
+	reader.Reset(data)
+	defer func() {
+		// Drop the reference to data before the reader is reused
+		reader.Reset(nil)
+		assetReaders.Put(reader)
+	}()
+
+	decoder := json.NewDecoder(reader)
+	if err := decoder.Decode(record); err != nil {
+		return err
+	}
+	if rest := bytes.TrimLeft(data[decoder.InputOffset():], " \t\r\n"); len(rest) > 0 {
+		return fmt.Errorf("invalid character %q after asset record", rest[0])
+	}
+	return nil
+}
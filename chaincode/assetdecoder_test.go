@@ -0,0 +1,114 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDecodeAssetJSONMatchesUnmarshal tests that the pooled decoder agrees with json.Unmarshal
+func TestDecodeAssetJSONMatchesUnmarshal(t *testing.T) {
+	inputs := []string{
+		`{"docType":"asset","ID":"asset1","color":"blue","size":5,"owner":"Tom","appraisedValue":100}`,
+		`{"docType":"asset","ID":"asset1","color":"blue","size":-5,"owner":"Tom","appraisedValue":0,"ownerMSP":"Org1MSP","frozen":true,"residency":"EU"}`,
+		` { "ID" : "a" , "size" : 1 , "frozen" : false } `,
+		`{}`,
+		`{"ID":null,"size":null}`,
+		`{"ID":"a\"b","owner":"Zoë"}`,
+		`{"id":"lowercase","OWNER":"Tom"}`,
+		`{"ID":"a","extra":{"nested":[1,2]}}`,
+		`{"ID":"a","size":1,"ID":"b"}`,
+	}
+	for _, input := range inputs {
+		var expected, actual Asset
+		require.NoError(t, json.Unmarshal([]byte(input), &expected), input)
+		require.NoError(t, decodeAssetJSON([]byte(input), &actual), input)
+		assert.Equal(t, expected, actual, input)
+	}
+
+	for _, input := range []string{`{"ID":"a"`, `{"ID":"a"} x`, `{"ID":"a"}{}`, `{"size":"5"}`, `{"ID":"a","size":1.5}`, `[]`, ``} {
+		var asset Asset
+		assert.Error(t, decodeAssetJSON([]byte(input), &asset), input)
+	}
+}
+
+// TestDecodeAssetJSONCoversFields tests that every Asset field written by json.Marshal decodes
+// back, also when the pooled reader was used for another record before
+func TestDecodeAssetJSONCoversFields(t *testing.T) {
+	asset := Asset{}
+	value := reflect.ValueOf(&asset).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		if !value.Type().Field(i).IsExported() {
+			continue
+		}
+		switch field := value.Field(i); field.Kind() {
+		case reflect.String:
+			field.SetString("x")
+		case reflect.Int:
+			field.SetInt(7)
+		case reflect.Bool:
+			field.SetBool(true)
+		case reflect.Struct:
+			field.Set(reflect.ValueOf(time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)))
+		default:
+			t.Fatalf("unsupported field kind %s of %s", field.Kind(), value.Type().Field(i).Name)
+		}
+	}
+	assetBytes, err := json.Marshal(asset)
+	require.NoError(t, err)
+
+	var other Asset
+	require.NoError(t, decodeAssetJSON([]byte(`{"ID":"other","size":1}`), &other))
+	var decoded Asset
+	require.NoError(t, decodeAssetJSON(assetBytes, &decoded), string(assetBytes))
+	assert.Equal(t, asset, decoded)
+
+	record, err := decodeAssetRecord(assetBytes)
+	require.NoError(t, err)
+	assert.Equal(t, asset, *record)
+}
+
+// TestDecodeAssetRecordAbsentFields tests that records tell the always-encoded fields they lack
+// from those stored with a zero value, in both codecs
+func TestDecodeAssetRecordAbsentFields(t *testing.T) {
+	asset, err := decodeAssetRecord([]byte(`{"docType":"asset","ID":"asset1","color":"","size":0,"owner":"Tom"}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"appraisedValue"}, asset.absent)
+
+	cborBytes, err := stateCodecs[CodecCBOR].marshal(map[string]interface{}{"docType": "asset", "ID": "asset1", "size": 0, "appraisedValue": 9})
+	require.NoError(t, err)
+	asset, err = decodeAssetRecord(cborBytes)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"color", "owner"}, asset.absent)
+	assert.Equal(t, 9, asset.AppraisedValue)
+}
+
+var benchmarkAsset *Asset
+
+func BenchmarkDecodeAsset(b *testing.B) {
+	record := []byte(`{"docType":"asset","ID":"asset1","color":"blue","size":5,"owner":"Tom","appraisedValue":100,"ownerMSP":"Org1MSP"}`)
+	b.Run("decoder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			asset, err := decodeAsset(record)
+			if err != nil {
+				b.Fatal(err)
+			}
+			benchmarkAsset = asset
+		}
+	})
+	b.Run("unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			asset := &Asset{}
+			if err := json.Unmarshal(record, asset); err != nil {
+				b.Fatal(err)
+			}
+			benchmarkAsset = asset
+		}
+	})
+}
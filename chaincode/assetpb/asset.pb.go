@@ -0,0 +1,504 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: asset/v1/asset.proto
+
+package assetpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AssetEvent_Type int32
+
+const (
+	AssetEvent_TYPE_UNSPECIFIED AssetEvent_Type = 0
+	AssetEvent_TYPE_CREATED     AssetEvent_Type = 1
+	AssetEvent_TYPE_TRANSFERRED AssetEvent_Type = 2
+)
+
+// Enum value maps for AssetEvent_Type.
+var (
+	AssetEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_CREATED",
+		2: "TYPE_TRANSFERRED",
+	}
+	AssetEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"TYPE_CREATED":     1,
+		"TYPE_TRANSFERRED": 2,
+	}
+)
+
+func (x AssetEvent_Type) Enum() *AssetEvent_Type {
+	p := new(AssetEvent_Type)
+	*p = x
+	return p
+}
+
+func (x AssetEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AssetEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_asset_v1_asset_proto_enumTypes[0].Descriptor()
+}
+
+func (AssetEvent_Type) Type() protoreflect.EnumType {
+	return &file_asset_v1_asset_proto_enumTypes[0]
+}
+
+func (x AssetEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AssetEvent_Type.Descriptor instead.
+func (AssetEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_asset_v1_asset_proto_rawDescGZIP(), []int{4, 0}
+}
+
+type Asset struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Color          string                 `protobuf:"bytes,2,opt,name=color,proto3" json:"color,omitempty"`
+	Size           int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Owner          string                 `protobuf:"bytes,4,opt,name=owner,proto3" json:"owner,omitempty"`
+	AppraisedValue int64                  `protobuf:"varint,5,opt,name=appraised_value,json=appraisedValue,proto3" json:"appraised_value,omitempty"`
+	OwnerMsp       string                 `protobuf:"bytes,6,opt,name=owner_msp,json=ownerMsp,proto3" json:"owner_msp,omitempty"`
+	Frozen         bool                   `protobuf:"varint,7,opt,name=frozen,proto3" json:"frozen,omitempty"`
+	Residency      string                 `protobuf:"bytes,8,opt,name=residency,proto3" json:"residency,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Asset) Reset() {
+	*x = Asset{}
+	mi := &file_asset_v1_asset_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Asset) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Asset) ProtoMessage() {}
+
+func (x *Asset) ProtoReflect() protoreflect.Message {
+	mi := &file_asset_v1_asset_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Asset.ProtoReflect.Descriptor instead.
+func (*Asset) Descriptor() ([]byte, []int) {
+	return file_asset_v1_asset_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Asset) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Asset) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
+}
+
+func (x *Asset) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Asset) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Asset) GetAppraisedValue() int64 {
+	if x != nil {
+		return x.AppraisedValue
+	}
+	return 0
+}
+
+func (x *Asset) GetOwnerMsp() string {
+	if x != nil {
+		return x.OwnerMsp
+	}
+	return ""
+}
+
+func (x *Asset) GetFrozen() bool {
+	if x != nil {
+		return x.Frozen
+	}
+	return false
+}
+
+func (x *Asset) GetResidency() string {
+	if x != nil {
+		return x.Residency
+	}
+	return ""
+}
+
+type CreateAssetRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Color          string                 `protobuf:"bytes,2,opt,name=color,proto3" json:"color,omitempty"`
+	Size           int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Owner          string                 `protobuf:"bytes,4,opt,name=owner,proto3" json:"owner,omitempty"`
+	AppraisedValue int64                  `protobuf:"varint,5,opt,name=appraised_value,json=appraisedValue,proto3" json:"appraised_value,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateAssetRequest) Reset() {
+	*x = CreateAssetRequest{}
+	mi := &file_asset_v1_asset_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAssetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAssetRequest) ProtoMessage() {}
+
+func (x *CreateAssetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_asset_v1_asset_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAssetRequest.ProtoReflect.Descriptor instead.
+func (*CreateAssetRequest) Descriptor() ([]byte, []int) {
+	return file_asset_v1_asset_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateAssetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CreateAssetRequest) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
+}
+
+func (x *CreateAssetRequest) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *CreateAssetRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *CreateAssetRequest) GetAppraisedValue() int64 {
+	if x != nil {
+		return x.AppraisedValue
+	}
+	return 0
+}
+
+type TransferAssetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	NewOwner      string                 `protobuf:"bytes,2,opt,name=new_owner,json=newOwner,proto3" json:"new_owner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferAssetRequest) Reset() {
+	*x = TransferAssetRequest{}
+	mi := &file_asset_v1_asset_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferAssetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferAssetRequest) ProtoMessage() {}
+
+func (x *TransferAssetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_asset_v1_asset_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferAssetRequest.ProtoReflect.Descriptor instead.
+func (*TransferAssetRequest) Descriptor() ([]byte, []int) {
+	return file_asset_v1_asset_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TransferAssetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TransferAssetRequest) GetNewOwner() string {
+	if x != nil {
+		return x.NewOwner
+	}
+	return ""
+}
+
+type ReadAssetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadAssetRequest) Reset() {
+	*x = ReadAssetRequest{}
+	mi := &file_asset_v1_asset_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadAssetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadAssetRequest) ProtoMessage() {}
+
+func (x *ReadAssetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_asset_v1_asset_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadAssetRequest.ProtoReflect.Descriptor instead.
+func (*ReadAssetRequest) Descriptor() ([]byte, []int) {
+	return file_asset_v1_asset_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ReadAssetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type AssetEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          AssetEvent_Type        `protobuf:"varint,1,opt,name=type,proto3,enum=chainlaunch.asset.v1.AssetEvent_Type" json:"type,omitempty"`
+	TxId          string                 `protobuf:"bytes,2,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	Asset         *Asset                 `protobuf:"bytes,3,opt,name=asset,proto3" json:"asset,omitempty"`
+	PreviousOwner string                 `protobuf:"bytes,4,opt,name=previous_owner,json=previousOwner,proto3" json:"previous_owner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssetEvent) Reset() {
+	*x = AssetEvent{}
+	mi := &file_asset_v1_asset_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssetEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssetEvent) ProtoMessage() {}
+
+func (x *AssetEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_asset_v1_asset_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssetEvent.ProtoReflect.Descriptor instead.
+func (*AssetEvent) Descriptor() ([]byte, []int) {
+	return file_asset_v1_asset_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AssetEvent) GetType() AssetEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return AssetEvent_TYPE_UNSPECIFIED
+}
+
+func (x *AssetEvent) GetTxId() string {
+	if x != nil {
+		return x.TxId
+	}
+	return ""
+}
+
+func (x *AssetEvent) GetAsset() *Asset {
+	if x != nil {
+		return x.Asset
+	}
+	return nil
+}
+
+func (x *AssetEvent) GetPreviousOwner() string {
+	if x != nil {
+		return x.PreviousOwner
+	}
+	return ""
+}
+
+var File_asset_v1_asset_proto protoreflect.FileDescriptor
+
+const file_asset_v1_asset_proto_rawDesc = "" +
+	"\n" +
+	"\x14asset/v1/asset.proto\x12\x14chainlaunch.asset.v1\"\xd3\x01\n" +
+	"\x05Asset\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05color\x18\x02 \x01(\tR\x05color\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x14\n" +
+	"\x05owner\x18\x04 \x01(\tR\x05owner\x12'\n" +
+	"\x0fappraised_value\x18\x05 \x01(\x03R\x0eappraisedValue\x12\x1b\n" +
+	"\towner_msp\x18\x06 \x01(\tR\bownerMsp\x12\x16\n" +
+	"\x06frozen\x18\a \x01(\bR\x06frozen\x12\x1c\n" +
+	"\tresidency\x18\b \x01(\tR\tresidency\"\x8d\x01\n" +
+	"\x12CreateAssetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05color\x18\x02 \x01(\tR\x05color\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x14\n" +
+	"\x05owner\x18\x04 \x01(\tR\x05owner\x12'\n" +
+	"\x0fappraised_value\x18\x05 \x01(\x03R\x0eappraisedValue\"C\n" +
+	"\x14TransferAssetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tnew_owner\x18\x02 \x01(\tR\bnewOwner\"\"\n" +
+	"\x10ReadAssetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xfc\x01\n" +
+	"\n" +
+	"AssetEvent\x129\n" +
+	"\x04type\x18\x01 \x01(\x0e2%.chainlaunch.asset.v1.AssetEvent.TypeR\x04type\x12\x13\n" +
+	"\x05tx_id\x18\x02 \x01(\tR\x04txId\x121\n" +
+	"\x05asset\x18\x03 \x01(\v2\x1b.chainlaunch.asset.v1.AssetR\x05asset\x12%\n" +
+	"\x0eprevious_owner\x18\x04 \x01(\tR\rpreviousOwner\"D\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fTYPE_CREATED\x10\x01\x12\x14\n" +
+	"\x10TYPE_TRANSFERRED\x10\x02BKZIgithub.com/chainlaunch/chaincode-fabric-go-tmpl/chaincode/assetpb;assetpbb\x06proto3"
+
+var (
+	file_asset_v1_asset_proto_rawDescOnce sync.Once
+	file_asset_v1_asset_proto_rawDescData []byte
+)
+
+func file_asset_v1_asset_proto_rawDescGZIP() []byte {
+	file_asset_v1_asset_proto_rawDescOnce.Do(func() {
+		file_asset_v1_asset_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_asset_v1_asset_proto_rawDesc), len(file_asset_v1_asset_proto_rawDesc)))
+	})
+	return file_asset_v1_asset_proto_rawDescData
+}
+
+var file_asset_v1_asset_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_asset_v1_asset_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_asset_v1_asset_proto_goTypes = []any{
+	(AssetEvent_Type)(0),         // 0: chainlaunch.asset.v1.AssetEvent.Type
+	(*Asset)(nil),                // 1: chainlaunch.asset.v1.Asset
+	(*CreateAssetRequest)(nil),   // 2: chainlaunch.asset.v1.CreateAssetRequest
+	(*TransferAssetRequest)(nil), // 3: chainlaunch.asset.v1.TransferAssetRequest
+	(*ReadAssetRequest)(nil),     // 4: chainlaunch.asset.v1.ReadAssetRequest
+	(*AssetEvent)(nil),           // 5: chainlaunch.asset.v1.AssetEvent
+}
+var file_asset_v1_asset_proto_depIdxs = []int32{
+	0, // 0: chainlaunch.asset.v1.AssetEvent.type:type_name -> chainlaunch.asset.v1.AssetEvent.Type
+	1, // 1: chainlaunch.asset.v1.AssetEvent.asset:type_name -> chainlaunch.asset.v1.Asset
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_asset_v1_asset_proto_init() }
+func file_asset_v1_asset_proto_init() {
+	if File_asset_v1_asset_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_asset_v1_asset_proto_rawDesc), len(file_asset_v1_asset_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_asset_v1_asset_proto_goTypes,
+		DependencyIndexes: file_asset_v1_asset_proto_depIdxs,
+		EnumInfos:         file_asset_v1_asset_proto_enumTypes,
+		MessageInfos:      file_asset_v1_asset_proto_msgTypes,
+	}.Build()
+	File_asset_v1_asset_proto = out.File
+	file_asset_v1_asset_proto_goTypes = nil
+	file_asset_v1_asset_proto_depIdxs = nil
+}
@@ -0,0 +1,154 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// auditIndex is the composite key namespace of the audit trail, keyed by tx time and tx ID
+	// so a partial key scan returns entries in chronological order.
+	auditIndex = "audit"
+	// auditTimeFormat is a fixed-width UTC timestamp; RFC3339Nano trims trailing zeros, so its
+	// keys would not sort chronologically
+	auditTimeFormat = "2006-01-02T15:04:05.000000000Z"
+	// maxAuditPageSize caps the number of entries returned by a single audit page
+	maxAuditPageSize = 100
+)
+
+// AuditEntry records an administrative action together with who performed it
+type AuditEntry struct {
+	TxID      string    `json:"txId"`
+	Timestamp time.Time `json:"timestamp"`
+	Actor     string    `json:"actor"`
+	MSPID     string    `json:"mspId"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	Details   string    `json:"details"`
+}
+
+// AuditPage is one page of the audit trail. Pass Bookmark back unchanged to get the next page;
+// it is empty after the last page.
+type AuditPage struct {
+	Entries  []*AuditEntry `json:"entries"`
+	Bookmark string        `json:"bookmark"`
+}
+
+// recordAudit appends an entry to the audit trail for the current transaction
+func recordAudit(ctx contractapi.TransactionContextInterface, action, target, details string) error {
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	actor, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity: %v", err)
+	}
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		return err
+	}
+
+	entry := AuditEntry{
+		TxID:      ctx.GetStub().GetTxID(),
+		Timestamp: txTimestamp.AsTime().UTC(),
+		Actor:     actor,
+		MSPID:     mspID,
+		Action:    action,
+		Target:    target,
+		Details:   details,
+	}
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(auditIndex, []string{entry.Timestamp.Format(auditTimeFormat), entry.TxID, action, target})
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, entryBytes); err != nil {
+		log.Error().Err(err).Str("action", action).Str("target", target).Msg("Failed to store audit entry")
+		return err
+	}
+
+	log.Debug().Str("action", action).Str("target", target).Str("txId", entry.TxID).Msg("Audit entry recorded")
+	return nil
+}
+
+// GetAuditEntries returns up to pageSize audit entries in chronological order, starting at the
+// entry a bookmark of a previous page points to
+func (a *AdminContract) GetAuditEntries(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*AuditPage, error) {
+	log.Info().Str("function", "GetAuditEntries").Int("pageSize", pageSize).Str("bookmark", bookmark).Msg("Reading audit trail")
+
+	readsAll, err := callerReadsAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !readsAll {
+		return nil, denyAccess(ctx, "role=admin or role=auditor", "the audit trail requires the admin or auditor role")
+	}
+	if pageSize <= 0 || pageSize > maxAuditPageSize {
+		return nil, fmt.Errorf("pageSize must be between 1 and %d", maxAuditPageSize)
+	}
+	queryHash := queryIdentity(auditIndex)
+	resumeKey, err := decodeCursor(envConfig{}, bookmark, queryHash, int32(pageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	// GetAuditEntries writes nothing, so the peer allows the paginated query, which starts at
+	// the bookmarked entry instead of walking the trail from its first entry
+	iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(auditIndex, []string{}, int32(pageSize), resumeKey)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to query audit trail")
+		return nil, err
+	}
+	defer iterator.Close()
+
+	entries := []*AuditEntry{}
+	for iterator.HasNext() {
+		result, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(result.Value, &entry); err != nil {
+			log.Error().Err(err).Str("key", result.Key).Msg("Failed to unmarshal audit entry")
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+	cursor, err := encodeCursor(envConfig{}, metadata.Bookmark, queryHash, int32(pageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Int("count", len(entries)).Msg("Audit trail read successfully")
+	return &AuditPage{Entries: entries, Bookmark: cursor}, nil
+}
+
+// denyAccess records an authorization denial and returns the ACCESS_DENIED error for it.
+// policy names the rule that was evaluated, e.g. "role=admin". Fabric discards the writes and
+// event of an invocation that returns an error, so a denial cannot be committed to the audit
+// trail; it is logged as a structured "Access denied" record instead, for the peer's log
+// pipeline to forward to security monitoring.
+func denyAccess(ctx contractapi.TransactionContextInterface, policy, format string, args ...interface{}) error {
+	reason := fmt.Sprintf(format, args...)
+	operation, _ := ctx.GetStub().GetFunctionAndParameters()
+	record := log.Warn().
+		Str("txId", ctx.GetStub().GetTxID()).
+		Str("operation", operation).
+		Str("policy", policy).
+		Str("reason", reason)
+	// The denial is reported even if the caller's identity cannot be read
+	if mspID, actor, err := getCallerSubmitter(ctx); err == nil {
+		record = record.Str("actor", actor).Str("mspId", mspID)
+	}
+	record.Msg("Access denied")
+	return newChaincodeError(ErrCodeAccessDenied, "%s", reason)
+}
@@ -0,0 +1,64 @@
+package chaincode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TestAuditEntriesPagination tests that audit pages follow transaction time, also when
+// timestamps differ in trailing fractional digits
+func TestAuditEntriesPagination(t *testing.T) {
+	ctx, stub := newPaginationContext(t, "Org1MSP", "admin", adminAttrs)
+	base := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i, offset := range []time.Duration{150 * time.Millisecond, 100 * time.Millisecond, 0} {
+		stub.MockTransactionStart("tx" + string(rune('a'+i)))
+		stub.TxTimestamp = timestamppb.New(base.Add(offset))
+		require.NoError(t, recordAudit(ctx, "Action", "target", string(rune('a'+i))))
+	}
+
+	admin := &AdminContract{}
+	_, err := admin.GetAuditEntries(ctx, 0, "")
+	assert.Error(t, err)
+
+	var details []string
+	bookmark := ""
+	for {
+		page, err := admin.GetAuditEntries(ctx, 2, bookmark)
+		require.NoError(t, err)
+		for _, entry := range page.Entries {
+			details = append(details, entry.Details)
+		}
+		if page.Bookmark == "" {
+			break
+		}
+		bookmark = page.Bookmark
+		_, err = admin.GetAuditEntries(ctx, 1, bookmark)
+		assert.ErrorContains(t, err, "page size")
+	}
+	assert.Equal(t, []string{"c", "b", "a"}, details)
+}
+
+// TestAuditReadAccess tests that only admins and auditors read the audit trail and the outbox
+// checkpoints
+func TestAuditReadAccess(t *testing.T) {
+	ctx, stub := newPaginationContext(t, "Org1MSP", "admin", adminAttrs)
+	require.NoError(t, recordAudit(ctx, "Action", "target", "details"))
+	admin := &AdminContract{}
+
+	switchIdentity(t, ctx, stub.MockStub, "Org1MSP", "user1", nil)
+	_, err := admin.GetAuditEntries(ctx, 10, "")
+	assert.True(t, hasErrorCode(err, ErrCodeAccessDenied))
+	_, err = admin.GetOutboxCheckpoints(ctx)
+	assert.True(t, hasErrorCode(err, ErrCodeAccessDenied))
+
+	switchIdentity(t, ctx, stub.MockStub, "Org2MSP", "auditor2", map[string]string{"role": "auditor"})
+	page, err := admin.GetAuditEntries(ctx, 10, "")
+	require.NoError(t, err)
+	assert.Len(t, page.Entries, 1)
+	_, err = admin.GetOutboxCheckpoints(ctx)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,61 @@
+package chaincode
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// BatchItemError is the failure of one item of a CreateAssets batch
+type BatchItemError struct {
+	Index   int    `json:"index"`
+	AssetID string `json:"assetId,omitempty"`
+	Error   string `json:"error"`
+}
+
+// CreateAssets creates every asset of a JSON array, with its index entries, in one transaction.
+// The batch is all or nothing: every item is checked, and if any fails the transaction returns
+// a single BATCH_INVALID error listing each failed item, so a bulk onboarding can be fixed in
+// one round trip.
+func (t *AssetContract) CreateAssets(ctx contractapi.TransactionContextInterface, assetsJSON string) ([]string, error) {
+	t.logger(ctx).Info().Str("function", "CreateAssets").Msg("Creating asset batch")
+
+	items, err := parseAssetBatch(ctx, assetsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]string, 0, len(items))
+	failures := []BatchItemError{}
+	seen := map[string]bool{}
+	for i, item := range items {
+		assetID, err := t.importAsset(ctx, item, seen)
+		if err != nil {
+			// A failed transaction commits none of the writes, so the remaining items are still
+			// checked to report every failure at once
+			failure := BatchItemError{Index: i, Error: err.Error()}
+			var asset Asset
+			if json.Unmarshal(item, &asset) == nil {
+				failure.AssetID = asset.ID
+			}
+			failures = append(failures, failure)
+			continue
+		}
+		created = append(created, assetID)
+	}
+	if len(failures) > 0 {
+		failuresJSON, err := json.Marshal(failures)
+		if err != nil {
+			return nil, err
+		}
+		t.logger(ctx).Warn().Int("items", len(items)).Int("failed", len(failures)).Msg("Asset batch rejected")
+		return nil, newChaincodeError(ErrCodeBatchInvalid, "%d of %d items are invalid: %s", len(failures), len(items), failuresJSON)
+	}
+
+	if err := t.emitAssetEvent(ctx, EventAssetCreated, created...); err != nil {
+		return nil, err
+	}
+
+	t.logger(ctx).Info().Int("created", len(created)).Msg("Asset batch created")
+	return created, nil
+}
@@ -0,0 +1,54 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateAssets tests that a batch is created with its index entries
+func TestCreateAssets(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+
+	created, err := cc.CreateAssets(ctx, `[{"ID":"asset1","color":"blue","size":5,"owner":"John","appraisedValue":100},{"ID":"asset2","color":"red","owner":"Jane"}]`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"asset1", "asset2"}, created)
+
+	asset, err := getAsset(ctx, "asset2")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", asset.Owner)
+	indexKey, err := stub.CreateCompositeKey(index, []string{"blue", "asset1"})
+	require.NoError(t, err)
+	assert.Contains(t, stub.State, indexKey)
+}
+
+// TestCreateAssetsAggregatesErrors tests that every failed item is reported in one structured error
+func TestCreateAssetsAggregatesErrors(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "existing", "blue", 5, "John", 100))
+
+	_, err := cc.CreateAssets(ctx, `[
+		{"ID":"asset1","color":"blue","owner":"John"},
+		{"ID":"existing","color":"red"},
+		{"color":"green"},
+		"not an asset"
+	]`)
+	require.Error(t, err)
+	assert.True(t, hasErrorCode(err, ErrCodeBatchInvalid))
+
+	message := err.Error()
+	var failures []BatchItemError
+	require.NoError(t, json.Unmarshal([]byte(message[strings.Index(message, "["):]), &failures))
+	require.Len(t, failures, 3)
+	assert.Equal(t, 1, failures[0].Index)
+	assert.Equal(t, "existing", failures[0].AssetID)
+	assert.Contains(t, failures[0].Error, "already exists")
+	assert.Equal(t, 2, failures[1].Index)
+	assert.Equal(t, 3, failures[2].Index)
+	assert.Empty(t, failures[2].AssetID)
+}
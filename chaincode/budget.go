@@ -0,0 +1,71 @@
+package chaincode
+
+import (
+	"strconv"
+	"time"
+)
+
+// Default per-invocation query budget, kept well below the peer's default 30s execute timeout
+// and the default 4MB gRPC message size.
+const (
+	defaultQueryMaxDuration = 20 * time.Second
+	defaultQueryMaxRecords  = 10000
+	defaultQueryMaxBytes    = 4 * 1024 * 1024
+)
+
+// queryBudget bounds the work an iterator helper may do in a single invocation
+type queryBudget struct {
+	started     time.Time
+	maxDuration time.Duration
+	maxRecords  int
+	maxBytes    int
+	records     int
+	bytes       int
+}
+
+// newQueryBudget starts a budget using the CHAINCODE_QUERY_MAX_DURATION,
+// CHAINCODE_QUERY_MAX_RECORDS and CHAINCODE_QUERY_MAX_BYTES limits
+func newQueryBudget(config ConfigProvider) *queryBudget {
+	return &queryBudget{
+		started:     time.Now(),
+		maxDuration: getDurationConfig(config, "CHAINCODE_QUERY_MAX_DURATION", defaultQueryMaxDuration),
+		maxRecords:  getIntConfig(config, "CHAINCODE_QUERY_MAX_RECORDS", defaultQueryMaxRecords),
+		maxBytes:    getIntConfig(config, "CHAINCODE_QUERY_MAX_BYTES", defaultQueryMaxBytes),
+	}
+}
+
+// consume accounts for one scanned record of the given size and returns a
+// RETRY_WITH_PAGINATION error once any limit is exceeded
+func (b *queryBudget) consume(size int) error {
+	b.records++
+	b.bytes += size
+
+	if b.records > b.maxRecords {
+		return newChaincodeError(ErrCodeRetryWithPagination, "query scanned more than %d records", b.maxRecords)
+	}
+	if b.bytes > b.maxBytes {
+		return newChaincodeError(ErrCodeRetryWithPagination, "query result exceeds %d bytes", b.maxBytes)
+	}
+	if elapsed := time.Since(b.started); elapsed > b.maxDuration {
+		return newChaincodeError(ErrCodeRetryWithPagination, "query exceeded %s execution time", b.maxDuration)
+	}
+	return nil
+}
+
+// getIntConfig reads a positive integer setting, falling back to defaultVal
+func getIntConfig(config ConfigProvider, name string, defaultVal int) int {
+	parsed, err := strconv.Atoi(config.Get(name))
+	if err != nil || parsed <= 0 {
+		return defaultVal
+	}
+	return parsed
+}
+
+// getDurationConfig reads a positive duration setting (e.g. "15s"), falling back to defaultVal
+func getDurationConfig(config ConfigProvider, name string, defaultVal time.Duration) time.Duration {
+	parsed, err := time.ParseDuration(config.Get(name))
+	if err != nil || parsed <= 0 {
+		return defaultVal
+	}
+	return parsed
+}
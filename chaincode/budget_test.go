@@ -0,0 +1,34 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQueryBudgetRecords tests that a range query aborts once the record budget is exceeded
+func TestQueryBudgetRecords(t *testing.T) {
+	t.Setenv("CHAINCODE_QUERY_MAX_RECORDS", "2")
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	for _, id := range []string{"asset1", "asset2", "asset3"} {
+		require.NoError(t, cc.CreateAsset(ctx, id, "blue", 5, "John", 100))
+	}
+
+	_, err := qc.GetAssetsByRange(ctx, "asset1", "asset3")
+	assert.NoError(t, err)
+
+	_, err = qc.GetAssetsByRange(ctx, "asset1", "asset4")
+	require.Error(t, err)
+	assert.True(t, hasErrorCode(err, ErrCodeRetryWithPagination))
+}
+
+// TestQueryBudgetBytes tests that the serialized size limit is enforced
+func TestQueryBudgetBytes(t *testing.T) {
+	t.Setenv("CHAINCODE_QUERY_MAX_BYTES", "10")
+	budget := newQueryBudget(envConfig{})
+	assert.NoError(t, budget.consume(10))
+	assert.Error(t, budget.consume(1))
+}
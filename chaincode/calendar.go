@@ -0,0 +1,228 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// calendarConfig is the configuration key prefix of the business calendar per region
+	calendarConfig = "calendar"
+	// defaultCalendarRegion names the calendar used for regions without one of their own
+	defaultCalendarRegion = "default"
+	// calendarDateLayout is the layout of holiday dates
+	calendarDateLayout = "2006-01-02"
+	// calendarTimeLayout is the layout of business hours
+	calendarTimeLayout = "15:04"
+	// businessDaySuffix marks expiries given in business days, e.g. "5bd"
+	businessDaySuffix = "bd"
+	// maxBusinessDays bounds AddBusinessDays so a calendar without working days cannot loop
+	maxBusinessDays = 3660
+)
+
+// Calendar holds the business days and hours of a region. Times are evaluated at a fixed UTC
+// offset rather than a named time zone, since tz data may differ between peers.
+type Calendar struct {
+	Region           string         `json:"region"`
+	UTCOffsetMinutes int            `json:"utcOffsetMinutes"`
+	Weekend          []time.Weekday `json:"weekend,omitempty" metadata:",optional"`
+	Holidays         []string       `json:"holidays,omitempty" metadata:",optional"`
+	OpenTime         string         `json:"openTime,omitempty" metadata:",optional"`
+	CloseTime        string         `json:"closeTime,omitempty" metadata:",optional"`
+}
+
+// defaultCalendar is used when neither the region nor the default region has a calendar
+var defaultCalendar = Calendar{Region: defaultCalendarRegion, Weekend: []time.Weekday{time.Saturday, time.Sunday}}
+
+// SetCalendar stores the business calendar of a region, a JSON Calendar document, e.g.
+// {"region":"EU","utcOffsetMinutes":60,"holidays":["2026-12-25"],"openTime":"09:00","closeTime":"17:00"}.
+// An empty weekend defaults to Saturday and Sunday; the "default" region applies to unmapped regions.
+func (c *ConfigContract) SetCalendar(ctx contractapi.TransactionContextInterface, calendarJSON string) error {
+	log.Info().Str("function", "SetCalendar").Str("calendar", calendarJSON).Msg("Setting business calendar")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	var calendar Calendar
+	if err := json.Unmarshal([]byte(calendarJSON), &calendar); err != nil {
+		return fmt.Errorf("invalid calendar: %v", err)
+	}
+	if err := calendar.validate(); err != nil {
+		return err
+	}
+	if calendar.Weekend == nil {
+		calendar.Weekend = defaultCalendar.Weekend
+	}
+	if err := putConfig(ctx, &calendar, calendarConfig, calendar.Region); err != nil {
+		return err
+	}
+	return recordAudit(ctx, "SetCalendar", calendar.Region, fmt.Sprintf("holidays=%d", len(calendar.Holidays)))
+}
+
+// GetCalendar returns the business calendar that applies to a region
+func (c *ConfigContract) GetCalendar(ctx contractapi.TransactionContextInterface, region string) (*Calendar, error) {
+	log.Info().Str("function", "GetCalendar").Str("region", region).Msg("Reading business calendar")
+	return getCalendar(ctx, region)
+}
+
+// IsBusinessDay reports whether timestamp (RFC3339) falls on a business day of the region
+func (q *QueryContract) IsBusinessDay(ctx contractapi.TransactionContextInterface, region, timestamp string) (bool, error) {
+	q.logger(ctx).Info().Str("function", "IsBusinessDay").Str("region", region).Str("timestamp", timestamp).Msg("Checking business day")
+
+	ts, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return false, fmt.Errorf("invalid timestamp %q: %v", timestamp, err)
+	}
+	calendar, err := getCalendar(ctx, region)
+	if err != nil {
+		return false, err
+	}
+	return calendar.IsBusinessDay(ts), nil
+}
+
+// getCalendar reads the calendar of a region, falling back to the default region and then to
+// a Monday to Friday calendar without holidays
+func getCalendar(ctx contractapi.TransactionContextInterface, region string) (*Calendar, error) {
+	for _, candidate := range []string{region, defaultCalendarRegion} {
+		if candidate == "" {
+			continue
+		}
+		calendar := &Calendar{}
+		found, err := getConfig(ctx, calendar, calendarConfig, candidate)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return calendar, nil
+		}
+	}
+	calendar := defaultCalendar
+	return &calendar, nil
+}
+
+// validate checks the calendar fields
+func (c *Calendar) validate() error {
+	if c.Region == "" {
+		return fmt.Errorf("calendar region must not be empty")
+	}
+	if c.UTCOffsetMinutes < -14*60 || c.UTCOffsetMinutes > 14*60 {
+		return fmt.Errorf("utcOffsetMinutes must be between -840 and 840")
+	}
+	if len(c.Weekend) >= 7 {
+		return fmt.Errorf("calendar must have at least one working weekday")
+	}
+	for _, day := range c.Weekend {
+		if day < time.Sunday || day > time.Saturday {
+			return fmt.Errorf("invalid weekend day %d", day)
+		}
+	}
+	for _, holiday := range c.Holidays {
+		if _, err := time.Parse(calendarDateLayout, holiday); err != nil {
+			return fmt.Errorf("invalid holiday %q, expected YYYY-MM-DD", holiday)
+		}
+	}
+	if (c.OpenTime == "") != (c.CloseTime == "") {
+		return fmt.Errorf("openTime and closeTime must be set together")
+	}
+	if c.OpenTime != "" {
+		open, err := time.Parse(calendarTimeLayout, c.OpenTime)
+		if err != nil {
+			return fmt.Errorf("invalid openTime %q, expected HH:MM", c.OpenTime)
+		}
+		closing, err := time.Parse(calendarTimeLayout, c.CloseTime)
+		if err != nil {
+			return fmt.Errorf("invalid closeTime %q, expected HH:MM", c.CloseTime)
+		}
+		if !closing.After(open) {
+			return fmt.Errorf("closeTime must be after openTime")
+		}
+	}
+	return nil
+}
+
+// local converts ts to the calendar's fixed offset
+func (c *Calendar) local(ts time.Time) time.Time {
+	return ts.In(time.FixedZone(c.Region, c.UTCOffsetMinutes*60))
+}
+
+// IsBusinessDay reports whether ts falls on a working weekday that is not a holiday
+func (c *Calendar) IsBusinessDay(ts time.Time) bool {
+	local := c.local(ts)
+	for _, day := range c.Weekend {
+		if local.Weekday() == day {
+			return false
+		}
+	}
+	date := local.Format(calendarDateLayout)
+	for _, holiday := range c.Holidays {
+		if holiday == date {
+			return false
+		}
+	}
+	return true
+}
+
+// IsBusinessHours reports whether ts falls on a business day within the opening hours.
+// Calendars without opening hours treat the whole business day as open.
+func (c *Calendar) IsBusinessHours(ts time.Time) bool {
+	if !c.IsBusinessDay(ts) {
+		return false
+	}
+	if c.OpenTime == "" {
+		return true
+	}
+	clock := c.local(ts).Format(calendarTimeLayout)
+	return clock >= c.OpenTime && clock < c.CloseTime
+}
+
+// AddBusinessDays returns ts moved forward by days business days, keeping the time of day
+func (c *Calendar) AddBusinessDays(ts time.Time, days int) (time.Time, error) {
+	if days < 0 || days > maxBusinessDays {
+		return time.Time{}, fmt.Errorf("business days must be between 0 and %d", maxBusinessDays)
+	}
+	for steps := 0; days > 0; steps++ {
+		if steps > 2*maxBusinessDays {
+			return time.Time{}, fmt.Errorf("calendar %s has too few business days", c.Region)
+		}
+		ts = ts.AddDate(0, 0, 1)
+		if c.IsBusinessDay(ts) {
+			days--
+		}
+	}
+	return ts, nil
+}
+
+// parseBusinessDays parses an expiry given in business days, e.g. "5bd"
+func parseBusinessDays(value string) (int, bool) {
+	if !strings.HasSuffix(value, businessDaySuffix) {
+		return 0, false
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(value, businessDaySuffix))
+	if err != nil {
+		return 0, false
+	}
+	return days, true
+}
+
+// addCallerBusinessDays moves ts forward by business days of the calendar of the caller's region
+func addCallerBusinessDays(ctx contractapi.TransactionContextInterface, ts time.Time, days int) (time.Time, error) {
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	region, err := getMSPRegion(ctx, mspID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	calendar, err := getCalendar(ctx, region)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return calendar.AddBusinessDays(ts, days)
+}
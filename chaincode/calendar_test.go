@@ -0,0 +1,52 @@
+package chaincode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCalendar tests business days, hours and holidays of a regional calendar
+func TestCalendar(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	config := &ConfigContract{}
+	qc := &QueryContract{}
+
+	assert.Error(t, config.SetCalendar(ctx, `{"region":"EU","openTime":"09:00"}`))
+	assert.Error(t, config.SetCalendar(ctx, `{"region":"EU","holidays":["25/12/2026"]}`))
+	require.NoError(t, config.SetCalendar(ctx, `{"region":"EU","utcOffsetMinutes":60,"holidays":["2026-12-25"],"openTime":"09:00","closeTime":"17:00"}`))
+
+	calendar, err := config.GetCalendar(ctx, "EU")
+	require.NoError(t, err)
+	assert.Equal(t, []time.Weekday{time.Saturday, time.Sunday}, calendar.Weekend)
+
+	christmas, err := qc.IsBusinessDay(ctx, "EU", "2026-12-25T10:00:00Z")
+	require.NoError(t, err)
+	assert.False(t, christmas)
+	// 23:30 UTC on Thursday is already the Friday holiday at UTC+1
+	assert.False(t, calendar.IsBusinessDay(time.Date(2026, 12, 24, 23, 30, 0, 0, time.UTC)))
+	assert.True(t, calendar.IsBusinessHours(time.Date(2026, 12, 23, 8, 30, 0, 0, time.UTC)))
+	assert.False(t, calendar.IsBusinessHours(time.Date(2026, 12, 23, 16, 30, 0, 0, time.UTC)))
+
+	next, err := calendar.AddBusinessDays(time.Date(2026, 12, 24, 12, 0, 0, 0, time.UTC), 1)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 12, 28, 12, 0, 0, 0, time.UTC), next)
+
+	unmapped, err := qc.IsBusinessDay(ctx, "US", "2026-12-25T10:00:00Z")
+	require.NoError(t, err)
+	assert.True(t, unmapped)
+}
+
+// TestConsentBusinessDayExpiry tests that consent expiries can be given in business days
+func TestConsentBusinessDayExpiry(t *testing.T) {
+	friday := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cc := NewAssetContract(WithClock(fixedClock(friday)))
+	ctx, _ := newTestContext(t, "Org1MSP", "alice", nil)
+
+	require.NoError(t, cc.CreateConsent(ctx, "asset1", "purchase", "2bd"))
+	consent, err := getActiveConsent(ctx, friday, "asset1", "purchase", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC), consent.Expiry)
+}
@@ -0,0 +1,105 @@
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+// circuitBreakerConfig is the configuration key prefix for per-function kill switches
+const circuitBreakerConfig = "circuit"
+
+// CircuitBreaker is the kill switch state of a single contract function
+type CircuitBreaker struct {
+	Function string `json:"function"`
+	Disabled bool   `json:"disabled"`
+	Reason   string `json:"reason"`
+	TxID     string `json:"txId"`
+}
+
+// DisableFunction trips the circuit breaker of a contract function so that every invocation is
+// rejected by the before transaction hook until EnableFunction is called. The function is named
+// Contract:Function; a name without a contract is a function of the default AssetContract.
+func (a *AdminContract) DisableFunction(ctx contractapi.TransactionContextInterface, function, reason string) error {
+	log.Info().Str("function", "DisableFunction").Str("target", function).Str("reason", reason).Msg("Disabling contract function")
+	return setCircuitBreaker(ctx, function, true, reason)
+}
+
+// EnableFunction resets the circuit breaker of a contract function
+func (a *AdminContract) EnableFunction(ctx contractapi.TransactionContextInterface, function, reason string) error {
+	log.Info().Str("function", "EnableFunction").Str("target", function).Str("reason", reason).Msg("Enabling contract function")
+	return setCircuitBreaker(ctx, function, false, reason)
+}
+
+// GetCircuitBreaker returns the kill switch state of a contract function
+func (a *AdminContract) GetCircuitBreaker(ctx contractapi.TransactionContextInterface, function string) (*CircuitBreaker, error) {
+	log.Info().Str("function", "GetCircuitBreaker").Str("target", function).Msg("Reading circuit breaker")
+	if function == "" {
+		return nil, fmt.Errorf("function must not be empty")
+	}
+	function = qualifyFunction(function)
+
+	breaker := &CircuitBreaker{}
+	found, err := getConfig(ctx, breaker, circuitBreakerConfig, function)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &CircuitBreaker{Function: function}, nil
+	}
+	return breaker, nil
+}
+
+func setCircuitBreaker(ctx contractapi.TransactionContextInterface, function string, disabled bool, reason string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if function == "" {
+		return fmt.Errorf("function must not be empty")
+	}
+	function = qualifyFunction(function)
+
+	breaker := &CircuitBreaker{
+		Function: function,
+		Disabled: disabled,
+		Reason:   reason,
+		TxID:     ctx.GetStub().GetTxID(),
+	}
+	if err := putConfig(ctx, breaker, circuitBreakerConfig, function); err != nil {
+		return err
+	}
+
+	action := "EnableFunction"
+	if disabled {
+		action = "DisableFunction"
+	}
+	if err := recordAudit(ctx, action, function, reason); err != nil {
+		log.Error().Err(err).Str("target", function).Msg("Failed to audit circuit breaker change")
+		return err
+	}
+
+	log.Info().Str("target", function).Bool("disabled", disabled).Msg("Circuit breaker updated")
+	return nil
+}
+
+// checkCircuitBreaker rejects the invocation when the called function has been disabled. Breakers
+// are keyed by contract and function, so contracts sharing a method name are switched apart.
+func checkCircuitBreaker(ctx contractapi.TransactionContextInterface) error {
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	if function == "" {
+		return nil
+	}
+	function = qualifyFunction(function)
+
+	breaker := &CircuitBreaker{}
+	found, err := getConfig(ctx, breaker, circuitBreakerConfig, function)
+	if err != nil {
+		return err
+	}
+	if found && breaker.Disabled {
+		log.Warn().Str("target", function).Str("reason", breaker.Reason).Msg("Invocation rejected by circuit breaker")
+		return fmt.Errorf("function %s is temporarily disabled: %s", function, breaker.Reason)
+	}
+	return nil
+}
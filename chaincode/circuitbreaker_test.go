@@ -0,0 +1,86 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func invoke(stub *shimtest.MockStub, txID string, args ...string) (int32, string) {
+	byteArgs := make([][]byte, len(args))
+	for i, arg := range args {
+		byteArgs[i] = []byte(arg)
+	}
+	response := stub.MockInvoke(txID, byteArgs)
+	return response.Status, response.Message
+}
+
+// TestCircuitBreaker tests that disabled functions are rejected by the before transaction hook
+func TestCircuitBreaker(t *testing.T) {
+	cc, err := contractapi.NewChaincode(&AssetContract{}, &AdminContract{})
+	require.NoError(t, err)
+	stub := shimtest.NewMockStub("chaincode", cc)
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "admin", adminAttrs)
+
+	status, _ := invoke(stub, "tx1", "CreateAsset", "asset1", "blue", "5", "John", "100")
+	require.Equal(t, int32(shim.OK), status)
+
+	status, _ = invoke(stub, "tx2", "AdminContract:DisableFunction", "TransferAsset", "incident 42")
+	require.Equal(t, int32(shim.OK), status)
+
+	status, message := invoke(stub, "tx3", "TransferAsset", "asset1", "Jane", "0")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "incident 42")
+
+	status, message = invoke(stub, "tx3b", "AssetContract:TransferAsset", "asset1", "Jane", "0")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "incident 42")
+
+	status, _ = invoke(stub, "tx4", "AdminContract:EnableFunction", "TransferAsset", "resolved")
+	require.Equal(t, int32(shim.OK), status)
+
+	status, _ = invoke(stub, "tx5", "AssetContract:TransferAsset", "asset1", "Jane", "0")
+	assert.Equal(t, int32(shim.OK), status)
+
+	ctx, _ := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	ctx.SetStub(&paginationStub{MockStub: stub})
+	stub.MockTransactionStart("tx6")
+	page, err := (&AdminContract{}).GetAuditEntries(ctx, 10, "")
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, "DisableFunction", page.Entries[0].Action)
+	assert.Equal(t, "EnableFunction", page.Entries[1].Action)
+}
+
+// TestCircuitBreakerPerContract tests that a breaker only disables the function of the contract
+// it names when several contracts share the method name
+func TestCircuitBreakerPerContract(t *testing.T) {
+	cc, err := contractapi.NewChaincode(&AssetContract{}, &AdminContract{}, &TokenContract{}, &NFTContract{})
+	require.NoError(t, err)
+	stub := shimtest.NewMockStub("chaincode", cc)
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "admin", adminAttrs)
+
+	status, _ := invoke(stub, "tx1", "AdminContract:DisableFunction", "TokenContract:BalanceOf", "incident 42")
+	require.Equal(t, int32(shim.OK), status)
+
+	status, message := invoke(stub, "tx2", "TokenContract:BalanceOf", "alice")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "incident 42")
+
+	_, message = invoke(stub, "tx3", "NFTContract:BalanceOf", "alice")
+	assert.NotContains(t, message, "temporarily disabled")
+
+	ctx, _ := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	ctx.SetStub(stub)
+	stub.MockTransactionStart("tx4")
+	breaker, err := (&AdminContract{}).GetCircuitBreaker(ctx, "NFTContract:BalanceOf")
+	require.NoError(t, err)
+	assert.False(t, breaker.Disabled)
+	breaker, err = (&AdminContract{}).GetCircuitBreaker(ctx, "TokenContract:BalanceOf")
+	require.NoError(t, err)
+	assert.True(t, breaker.Disabled)
+}
@@ -0,0 +1,237 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// codecConfig is the configuration key prefix of the state codec used per docType
+	codecConfig = "codec"
+	// CodecJSON stores records as JSON, the default. Required for CouchDB rich queries.
+	CodecJSON = "json"
+	// CodecCBOR stores records as deterministic CBOR, which is smaller than JSON but cannot be
+	// searched with CouchDB selectors
+	CodecCBOR = "cbor"
+	// maxCodecMigrationBatch caps the number of keys MigrateStateCodec scans per call
+	maxCodecMigrationBatch = 500
+)
+
+// stateCodec encodes records for world state
+type stateCodec interface {
+	name() string
+	marshal(v interface{}) ([]byte, error)
+	unmarshal(data []byte, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) name() string                               { return CodecJSON }
+func (jsonCodec) marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// cborCodec uses the core deterministic encoding of RFC 8949, so every endorser produces
+// identical bytes for the same record. Field names follow the json struct tags, and times are
+// RFC 3339 strings with nanoseconds like in JSON, so checksums survive a codec change.
+type cborCodec struct {
+	encMode cbor.EncMode
+}
+
+func (cborCodec) name() string                               { return CodecCBOR }
+func (c cborCodec) marshal(v interface{}) ([]byte, error)    { return c.encMode.Marshal(v) }
+func (cborCodec) unmarshal(data []byte, v interface{}) error { return cbor.Unmarshal(data, v) }
+
+var stateCodecs = map[string]stateCodec{
+	CodecJSON: jsonCodec{},
+	CodecCBOR: newCBORCodec(),
+}
+
+func newCBORCodec() cborCodec {
+	options := cbor.CoreDetEncOptions()
+	options.Time = cbor.TimeRFC3339Nano
+	encMode, err := options.EncMode()
+	if err != nil {
+		panic(fmt.Sprintf("invalid CBOR encoding options: %v", err))
+	}
+	return cborCodec{encMode: encMode}
+}
+
+// codecForBytes detects the codec of a stored record. JSON records are objects starting with
+// '{', which is never the first byte of a CBOR map, so both can coexist during a migration.
+func codecForBytes(data []byte) stateCodec {
+	if len(bytes.TrimSpace(data)) > 0 && bytes.TrimSpace(data)[0] == '{' {
+		return stateCodecs[CodecJSON]
+	}
+	return stateCodecs[CodecCBOR]
+}
+
+// decodeAsset decodes an asset stored with any codec and verifies its checksum
+func decodeAsset(data []byte) (*Asset, error) {
+	asset, err := decodeAssetRecord(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyAsset(asset); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+// storedAsset decodes an asset record and tells which of the fields Asset always encodes the
+// record lacks. Its fields shadow those of the embedded Asset in both codecs.
+type storedAsset struct {
+	*Asset
+	Color          *string `json:"color"`
+	Size           *int    `json:"size"`
+	Owner          *string `json:"owner"`
+	AppraisedValue *int    `json:"appraisedValue"`
+}
+
+// decodeAssetRecord decodes an asset stored with any codec without verifying its checksum, for
+// records that are trusted or about to be sealed
+func decodeAssetRecord(data []byte) (*Asset, error) {
+	var asset Asset
+	record := storedAsset{Asset: &asset}
+	codec := codecForBytes(data)
+	if codec.name() == CodecJSON {
+		if err := decodeAssetJSON(data, &record); err != nil {
+			return nil, err
+		}
+	} else if err := codec.unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	if record.Color != nil {
+		asset.Color = *record.Color
+	} else {
+		asset.absent = append(asset.absent, "color")
+	}
+	if record.Size != nil {
+		asset.Size = *record.Size
+	} else {
+		asset.absent = append(asset.absent, "size")
+	}
+	if record.Owner != nil {
+		asset.Owner = *record.Owner
+	} else {
+		asset.absent = append(asset.absent, "owner")
+	}
+	if record.AppraisedValue != nil {
+		asset.AppraisedValue = *record.AppraisedValue
+	} else {
+		asset.absent = append(asset.absent, "appraisedValue")
+	}
+	return &asset, nil
+}
+
+// getCodec returns the codec configured for a docType
+func getCodec(ctx contractapi.TransactionContextInterface, docType string) (stateCodec, error) {
+	name := CodecJSON
+	if _, err := getConfig(ctx, &name, codecConfig, docType); err != nil {
+		return nil, err
+	}
+	codec, ok := stateCodecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown state codec %s", name)
+	}
+	return codec, nil
+}
+
+// SetStateCodec selects the codec new writes of a docType use. Existing records stay readable
+// and are converted by AdminContract:MigrateStateCodec.
+func (c *ConfigContract) SetStateCodec(ctx contractapi.TransactionContextInterface, docType, codec string) error {
+	log.Info().Str("function", "SetStateCodec").Str("docType", docType).Str("codec", codec).Msg("Setting state codec")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if docType == "" {
+		return fmt.Errorf("docType must not be empty")
+	}
+	if _, ok := stateCodecs[codec]; !ok {
+		return fmt.Errorf("state codec must be %s or %s", CodecJSON, CodecCBOR)
+	}
+	if err := putConfig(ctx, codec, codecConfig, docType); err != nil {
+		return err
+	}
+	return recordAudit(ctx, "SetStateCodec", docType, codec)
+}
+
+// CodecMigration reports the progress of a MigrateStateCodec batch
+type CodecMigration struct {
+	Scanned  int    `json:"scanned"`
+	Migrated int    `json:"migrated"`
+	NextKey  string `json:"nextKey"`
+}
+
+// MigrateStateCodec re-encodes up to limit asset records of a docType, starting at startKey,
+// with the docType's configured codec. Call it again with NextKey until NextKey is empty.
+func (a *AdminContract) MigrateStateCodec(ctx contractapi.TransactionContextInterface, docType, startKey string, limit int) (*CodecMigration, error) {
+	log.Info().Str("function", "MigrateStateCodec").Str("docType", docType).Str("startKey", startKey).Int("limit", limit).Msg("Migrating state codec")
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxCodecMigrationBatch {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxCodecMigrationBatch)
+	}
+	codec, err := getCodec(ctx, docType)
+	if err != nil {
+		return nil, err
+	}
+
+	firstKey, endKey := assetKeyRange("", "")
+	if startKey == "" {
+		startKey = firstKey
+	}
+	iterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan assets: %v", err)
+	}
+	defer iterator.Close()
+
+	migration := &CodecMigration{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if migration.Scanned == limit {
+			migration.NextKey = response.Key
+			break
+		}
+		migration.Scanned++
+
+		// Composite keys hold indexes and configuration, never assets
+		if strings.HasPrefix(response.Key, "\x00") {
+			continue
+		}
+		if codecForBytes(response.Value).name() == codec.name() {
+			continue
+		}
+		asset, err := decodeAsset(response.Value)
+		if err != nil {
+			log.Error().Err(err).Str("key", response.Key).Msg("Failed to decode record during codec migration")
+			return nil, fmt.Errorf("failed to decode %s: %v", response.Key, err)
+		}
+		// Only records stored under the key of their own ID are asset records
+		if asset.DocType != docType || response.Key != assetKey(asset.ID) {
+			continue
+		}
+		if err := storeAssetRecord(ctx, asset); err != nil {
+			return nil, err
+		}
+		migration.Migrated++
+	}
+
+	if err := recordAudit(ctx, "MigrateStateCodec", docType, fmt.Sprintf("%s migrated=%d", codec.name(), migration.Migrated)); err != nil {
+		return nil, err
+	}
+	log.Info().Str("docType", docType).Int("scanned", migration.Scanned).Int("migrated", migration.Migrated).Str("nextKey", migration.NextKey).Msg("State codec migration batch completed")
+	return migration, nil
+}
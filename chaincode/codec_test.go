@@ -0,0 +1,73 @@
+package chaincode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCBORCodecDeterministic tests that CBOR encoding is canonical and smaller than JSON
+func TestCBORCodecDeterministic(t *testing.T) {
+	asset := &Asset{DocType: "asset", ID: "asset1", Color: "blue", Size: 5, Owner: "Tom", AppraisedValue: 100}
+	asset.CreatedAt = time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+	require.NoError(t, sealAsset(asset))
+	first, err := stateCodecs[CodecCBOR].marshal(asset)
+	require.NoError(t, err)
+	second, err := stateCodecs[CodecCBOR].marshal(asset)
+	require.NoError(t, err)
+	jsonBytes, err := stateCodecs[CodecJSON].marshal(asset)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Less(t, len(first), len(jsonBytes))
+	assert.Equal(t, CodecCBOR, codecForBytes(first).name())
+	assert.Equal(t, CodecJSON, codecForBytes(jsonBytes).name())
+
+	decoded, err := decodeAsset(first)
+	require.NoError(t, err)
+	assert.Equal(t, asset, decoded)
+}
+
+// TestMigrateStateCodec tests that assets are re-encoded in batches and stay readable throughout
+func TestMigrateStateCodec(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	for _, id := range []string{"asset1", "asset2", "asset3"} {
+		require.NoError(t, cc.CreateAsset(ctx, id, "blue", 5, "Tom", 100))
+	}
+
+	config := &ConfigContract{}
+	assert.Error(t, config.SetStateCodec(ctx, "asset", "xml"))
+	require.NoError(t, config.SetStateCodec(ctx, "asset", CodecCBOR))
+	require.NoError(t, cc.CreateAsset(ctx, "asset4", "red", 6, "Ann", 200))
+	assert.Equal(t, CodecCBOR, codecForBytes(stub.State["asset4"]).name())
+	assert.Equal(t, CodecJSON, codecForBytes(stub.State["asset1"]).name())
+
+	admin := &AdminContract{}
+	migration, err := admin.MigrateStateCodec(ctx, "asset", "asset", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, migration.Migrated)
+	assert.Equal(t, "asset3", migration.NextKey)
+	migration, err = admin.MigrateStateCodec(ctx, "asset", migration.NextKey, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, migration.Migrated)
+	for _, id := range []string{"asset1", "asset2", "asset3"} {
+		assert.Equal(t, CodecCBOR, codecForBytes(stub.State[id]).name(), id)
+	}
+
+	assets, err := qc.GetAssetsByRange(ctx, "asset1", "asset5")
+	require.NoError(t, err)
+	require.Len(t, assets, 4)
+	assert.Equal(t, "Ann", assets[3].Owner)
+
+	require.NoError(t, config.SetStateCodec(ctx, "asset", CodecJSON))
+	_, err = admin.MigrateStateCodec(ctx, "asset", "asset", 10)
+	require.NoError(t, err)
+	asset, err := qc.ReadAsset(ctx, "asset4")
+	require.NoError(t, err)
+	assert.Equal(t, CodecJSON, codecForBytes(stub.State["asset4"]).name())
+	assert.Equal(t, 200, asset.AppraisedValue)
+}
@@ -0,0 +1,80 @@
+package chaincode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// Response encodings a caller can accept
+	EncodingIdentity = "identity"
+	EncodingGzip     = "gzip"
+
+	// minCompressedResponseSize is the JSON size from which an accepted gzip encoding is applied;
+	// smaller pages are returned as is, since compressing them saves little
+	minCompressedResponseSize = 64 * 1024
+)
+
+// EncodedResponse carries a query response in the encoding negotiated with the caller. Data is
+// the JSON response itself for identity and its base64-encoded gzip stream for gzip. Size is
+// the length of the JSON response, so clients can size their buffers.
+type EncodedResponse struct {
+	Encoding string `json:"encoding"`
+	Data     string `json:"data"`
+	Size     int    `json:"size"`
+}
+
+// GetAssetsByRangeWithPaginationEncoded is GetAssetsByRangeWithPagination with a negotiated
+// response encoding. With acceptEncoding "gzip" pages of 64 KiB or more come back gzipped, which
+// keeps multi-megabyte pages under gateway gRPC message limits.
+func (q *QueryContract) GetAssetsByRangeWithPaginationEncoded(ctx contractapi.TransactionContextInterface, startKey, endKey string, pageSize int, bookmark, acceptEncoding string) (*EncodedResponse, error) {
+	result, err := q.GetAssetsByRangeWithPagination(ctx, startKey, endKey, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	return q.encodeResponse(ctx, result, acceptEncoding)
+}
+
+// QueryAssetsWithPaginationEncoded is QueryAssetsWithPagination with a negotiated response encoding
+func (q *QueryContract) QueryAssetsWithPaginationEncoded(ctx contractapi.TransactionContextInterface, queryString string, pageSize int, bookmark, acceptEncoding string) (*EncodedResponse, error) {
+	result, err := q.QueryAssetsWithPagination(ctx, queryString, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	return q.encodeResponse(ctx, result, acceptEncoding)
+}
+
+// encodeResponse marshals a response and gzips it when the caller accepts gzip and the response
+// is large enough. acceptEncoding is "identity", "gzip" or empty for identity.
+func (r *contractRuntime) encodeResponse(ctx contractapi.TransactionContextInterface, response interface{}, acceptEncoding string) (*EncodedResponse, error) {
+	if acceptEncoding != "" && acceptEncoding != EncodingIdentity && acceptEncoding != EncodingGzip {
+		return nil, fmt.Errorf("accepted encoding must be %s or %s", EncodingIdentity, EncodingGzip)
+	}
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	if acceptEncoding != EncodingGzip || len(responseBytes) < minCompressedResponseSize {
+		return &EncodedResponse{Encoding: EncodingIdentity, Data: string(responseBytes), Size: len(responseBytes)}, nil
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(responseBytes); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	r.logger(ctx).Debug().Int("size", len(responseBytes)).Int("compressedSize", compressed.Len()).Msg("Compressed query response")
+	return &EncodedResponse{
+		Encoding: EncodingGzip,
+		Data:     base64.StdEncoding.EncodeToString(compressed.Bytes()),
+		Size:     len(responseBytes),
+	}, nil
+}
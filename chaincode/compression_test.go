@@ -0,0 +1,57 @@
+package chaincode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEncodeResponse tests that large responses are gzipped only when the caller accepts it
+func TestEncodeResponse(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	qc := &QueryContract{}
+
+	small := &PaginatedQueryResult{Records: []*Asset{{ID: "asset1"}}}
+	encoded, err := qc.encodeResponse(ctx, small, EncodingGzip)
+	require.NoError(t, err)
+	assert.Equal(t, EncodingIdentity, encoded.Encoding)
+	assert.Contains(t, encoded.Data, `"ID":"asset1"`)
+
+	large := &PaginatedQueryResult{Records: []*Asset{}}
+	for i := 0; i < 2000; i++ {
+		large.Records = append(large.Records, &Asset{DocType: "asset", ID: fmt.Sprintf("asset%d", i), Color: "blue", Owner: "John"})
+	}
+	encoded, err = qc.encodeResponse(ctx, large, "")
+	require.NoError(t, err)
+	assert.Equal(t, EncodingIdentity, encoded.Encoding)
+
+	encoded, err = qc.encodeResponse(ctx, large, EncodingGzip)
+	require.NoError(t, err)
+	assert.Equal(t, EncodingGzip, encoded.Encoding)
+	assert.Less(t, len(encoded.Data), encoded.Size)
+
+	compressed, err := base64.StdEncoding.DecodeString(encoded.Data)
+	require.NoError(t, err)
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	decompressed, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Len(t, decompressed, encoded.Size)
+	var decoded PaginatedQueryResult
+	require.NoError(t, json.Unmarshal(decompressed, &decoded))
+	assert.Len(t, decoded.Records, 2000)
+
+	again, err := qc.encodeResponse(ctx, large, EncodingGzip)
+	require.NoError(t, err)
+	assert.Equal(t, encoded.Data, again.Data, "endorsers must produce identical responses")
+
+	_, err = qc.encodeResponse(ctx, large, "br")
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+// configIndex is the composite key namespace for chaincode configuration records.
+// Configuration lives under composite keys so it never shows up in asset range queries.
+const configIndex = "config"
+
+// getConfig reads a configuration record into value, reporting whether it was set
+func getConfig(ctx contractapi.TransactionContextInterface, value interface{}, parts ...string) (bool, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(configIndex, parts)
+	if err != nil {
+		return false, err
+	}
+	configBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		log.Error().Err(err).Strs("config", parts).Msg("Failed to read configuration")
+		return false, fmt.Errorf("failed to read configuration %v: %v", parts, err)
+	}
+	if configBytes == nil {
+		return false, nil
+	}
+	if err := json.Unmarshal(configBytes, value); err != nil {
+		log.Error().Err(err).Strs("config", parts).Msg("Failed to unmarshal configuration")
+		return false, err
+	}
+	return true, nil
+}
+
+// putConfig stores a configuration record
+func putConfig(ctx contractapi.TransactionContextInterface, value interface{}, parts ...string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(configIndex, parts)
+	if err != nil {
+		return err
+	}
+	configBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, configBytes); err != nil {
+		log.Error().Err(err).Strs("config", parts).Msg("Failed to store configuration")
+		return err
+	}
+	return nil
+}
+
+// regionConfig is the configuration key prefix of the MSP to region mapping
+const regionConfig = "region"
+
+// ConfigContract manages chaincode configuration and is invoked as ConfigContract:<Function>
+type ConfigContract struct {
+	contractapi.Contract
+	contractRuntime
+}
+
+// SetMSPRegion maps an MSP to a data residency region, e.g. "EU" or "US"
+func (c *ConfigContract) SetMSPRegion(ctx contractapi.TransactionContextInterface, mspID, region string) error {
+	log.Info().Str("function", "SetMSPRegion").Str("mspID", mspID).Str("region", region).Msg("Mapping MSP to region")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if mspID == "" || region == "" {
+		return fmt.Errorf("mspID and region must not be empty")
+	}
+	if err := putConfig(ctx, region, regionConfig, mspID); err != nil {
+		return err
+	}
+	return recordAudit(ctx, "SetMSPRegion", mspID, region)
+}
+
+// RemoveMSPRegion deletes the region mapping of an MSP
+func (c *ConfigContract) RemoveMSPRegion(ctx contractapi.TransactionContextInterface, mspID string) error {
+	log.Info().Str("function", "RemoveMSPRegion").Str("mspID", mspID).Msg("Removing MSP region mapping")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(configIndex, []string{regionConfig, mspID})
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return err
+	}
+	return recordAudit(ctx, "RemoveMSPRegion", mspID, "")
+}
+
+// GetMSPRegion returns the region an MSP is mapped to, or an empty string
+func (c *ConfigContract) GetMSPRegion(ctx contractapi.TransactionContextInterface, mspID string) (string, error) {
+	log.Info().Str("function", "GetMSPRegion").Str("mspID", mspID).Msg("Reading MSP region mapping")
+	return getMSPRegion(ctx, mspID)
+}
+
+// getMSPRegion reads the region mapping of an MSP, returning an empty string when unmapped
+func getMSPRegion(ctx contractapi.TransactionContextInterface, mspID string) (string, error) {
+	var region string
+	if _, err := getConfig(ctx, &region, regionConfig, mspID); err != nil {
+		return "", err
+	}
+	return region, nil
+}
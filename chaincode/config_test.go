@@ -0,0 +1,62 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResidencyEnforcement tests that assets tagged for a region are hidden from other regions
+func TestResidencyEnforcement(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	config := &ConfigContract{}
+
+	require.NoError(t, config.SetMSPRegion(ctx, "Org1MSP", "EU"))
+	require.NoError(t, config.SetMSPRegion(ctx, "Org2MSP", "US"))
+	region, err := config.GetMSPRegion(ctx, "Org2MSP")
+	require.NoError(t, err)
+	assert.Equal(t, "US", region)
+
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	asset, err := qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "EU", asset.Residency)
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "user2", nil)
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "red", 5, "Jane", 100))
+	_, err = qc.ReadAsset(ctx, "asset1")
+	assert.Error(t, err)
+
+	assets, err := qc.GetAssetsByRange(ctx, "asset0", "asset9")
+	require.NoError(t, err)
+	require.Len(t, assets, 1)
+	assert.Equal(t, "asset2", assets[0].ID)
+
+	switchIdentity(t, ctx, stub, "Org3MSP", "user3", nil)
+	_, err = qc.ReadAsset(ctx, "asset2")
+	assert.Error(t, err)
+	assert.Error(t, config.SetMSPRegion(ctx, "Org3MSP", "US"))
+}
+
+// TestResidencyEnforcedOnWrites tests that other regions cannot transfer, update or delete an asset
+func TestResidencyEnforcedOnWrites(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	cc := &AssetContract{}
+	config := &ConfigContract{}
+	require.NoError(t, config.SetMSPRegion(ctx, "Org1MSP", "EU"))
+	require.NoError(t, config.SetMSPRegion(ctx, "Org2MSP", "US"))
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "user2", nil)
+	assert.ErrorContains(t, cc.TransferAsset(ctx, "asset1", "Jane", 0), ErrCodeAccessDenied)
+	assert.ErrorContains(t, cc.UpdateAsset(ctx, "asset1", "red", 5, "Jane", 100, 0), ErrCodeAccessDenied)
+	assert.ErrorContains(t, cc.DeleteAsset(ctx, "asset1"), ErrCodeAccessDenied)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "user1", nil)
+	require.NoError(t, cc.TransferAsset(ctx, "asset1", "Jane", 0))
+	require.NoError(t, cc.UpdateAsset(ctx, "asset1", "red", 5, "Jane", 100, 0))
+	require.NoError(t, cc.DeleteAsset(ctx, "asset1"))
+}
@@ -0,0 +1,246 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// consentIndex keys consent records by subject, action and grantor
+	consentIndex = "consent~subject~action~grantor"
+	// consentGrantorIndex lets a grantor list every consent they have given
+	consentGrantorIndex = "consent~grantor~subject~action"
+)
+
+// Consent records that a grantor agreed to an action on a subject (e.g. "swap" on a swap ID,
+// "purchase" on an asset ID). Terms optionally pins what exactly was agreed to.
+type Consent struct {
+	Grantor   string    `json:"grantor"`
+	Subject   string    `json:"subject"`
+	Action    string    `json:"action"`
+	Terms     string    `json:"terms,omitempty" metadata:",optional"`
+	CreatedAt time.Time `json:"createdAt"`
+	Expiry    time.Time `json:"expiry,omitzero" metadata:",optional"`
+	TxID      string    `json:"txId"`
+}
+
+// CreateConsent records the caller's consent to perform action on subject until expiry (RFC3339,
+// business days in the calendar of the caller's region such as "5bd", or empty for no expiry).
+// An existing consent for the same subject and action is replaced.
+func (t *AssetContract) CreateConsent(ctx contractapi.TransactionContextInterface, subject, action, expiry string) error {
+	t.logger(ctx).Info().Str("function", "CreateConsent").Str("subject", subject).Str("action", action).Str("expiry", expiry).Msg("Creating consent")
+
+	grantor, err := getCallerEnrollmentID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := t.now(ctx)
+	if err != nil {
+		return err
+	}
+	var expiryTime time.Time
+	if days, ok := parseBusinessDays(expiry); ok {
+		expiryTime, err = addCallerBusinessDays(ctx, now, days)
+		if err != nil {
+			return err
+		}
+	} else if expiry != "" {
+		expiryTime, err = time.Parse(time.RFC3339, expiry)
+		if err != nil {
+			return fmt.Errorf("invalid expiry %q: %v", expiry, err)
+		}
+	}
+	_, err = createConsent(ctx, now, grantor, subject, action, "", expiryTime)
+	return err
+}
+
+// CheckConsent reports whether grantor currently consents to action on subject
+func (q *QueryContract) CheckConsent(ctx contractapi.TransactionContextInterface, subject, action, grantor string) (bool, error) {
+	q.logger(ctx).Info().Str("function", "CheckConsent").Str("subject", subject).Str("action", action).Str("grantor", grantor).Msg("Checking consent")
+
+	now, err := q.now(ctx)
+	if err != nil {
+		return false, err
+	}
+	_, err = getActiveConsent(ctx, now, subject, action, grantor)
+	if err != nil {
+		q.logger(ctx).Debug().Err(err).Str("subject", subject).Str("action", action).Str("grantor", grantor).Msg("No active consent")
+		return false, nil
+	}
+	return true, nil
+}
+
+// RevokeConsent withdraws the caller's consent to action on subject
+func (t *AssetContract) RevokeConsent(ctx contractapi.TransactionContextInterface, subject, action string) error {
+	t.logger(ctx).Info().Str("function", "RevokeConsent").Str("subject", subject).Str("action", action).Msg("Revoking consent")
+
+	grantor, err := getCallerEnrollmentID(ctx)
+	if err != nil {
+		return err
+	}
+	consent, err := getConsent(ctx, subject, action, grantor)
+	if err != nil {
+		return err
+	}
+	if consent == nil {
+		return fmt.Errorf("%s has no consent for %s on %s", grantor, action, subject)
+	}
+	return deleteConsent(ctx, consent)
+}
+
+// GetConsentsBySubject returns all consents recorded for a subject
+func (q *QueryContract) GetConsentsBySubject(ctx contractapi.TransactionContextInterface, subject string) ([]*Consent, error) {
+	q.logger(ctx).Info().Str("function", "GetConsentsBySubject").Str("subject", subject).Msg("Listing consents by subject")
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(consentIndex, []string{subject})
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	consents := []*Consent{}
+	for iterator.HasNext() {
+		result, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var consent Consent
+		if err := json.Unmarshal(result.Value, &consent); err != nil {
+			return nil, err
+		}
+		consents = append(consents, &consent)
+	}
+	return consents, nil
+}
+
+// GetConsentsByGrantor returns all consents given by a grantor
+func (q *QueryContract) GetConsentsByGrantor(ctx contractapi.TransactionContextInterface, grantor string) ([]*Consent, error) {
+	q.logger(ctx).Info().Str("function", "GetConsentsByGrantor").Str("grantor", grantor).Msg("Listing consents by grantor")
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(consentGrantorIndex, []string{grantor})
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	consents := []*Consent{}
+	for iterator.HasNext() {
+		result, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(result.Key)
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) < 3 {
+			continue
+		}
+		consent, err := getConsent(ctx, parts[1], parts[2], grantor)
+		if err != nil {
+			return nil, err
+		}
+		if consent != nil {
+			consents = append(consents, consent)
+		}
+	}
+	return consents, nil
+}
+
+// createConsent stores a consent record created at now and its grantor index entry
+func createConsent(ctx contractapi.TransactionContextInterface, now time.Time, grantor, subject, action, terms string, expiry time.Time) (*Consent, error) {
+	if subject == "" || action == "" {
+		return nil, fmt.Errorf("subject and action must not be empty")
+	}
+	if !expiry.IsZero() && !expiry.After(now) {
+		return nil, fmt.Errorf("consent expiry must be in the future")
+	}
+
+	consent := &Consent{
+		Grantor:   grantor,
+		Subject:   subject,
+		Action:    action,
+		Terms:     terms,
+		CreatedAt: now,
+		Expiry:    expiry,
+		TxID:      ctx.GetStub().GetTxID(),
+	}
+	consentBytes, err := json.Marshal(consent)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(consentIndex, []string{subject, action, grantor})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(key, consentBytes); err != nil {
+		log.Error().Err(err).Str("subject", subject).Str("action", action).Msg("Failed to store consent")
+		return nil, err
+	}
+	grantorKey, err := ctx.GetStub().CreateCompositeKey(consentGrantorIndex, []string{grantor, subject, action})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(grantorKey, []byte{0x00}); err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("grantor", grantor).Str("subject", subject).Str("action", action).Msg("Consent recorded")
+	return consent, nil
+}
+
+// getConsent reads a consent record, returning nil when none exists
+func getConsent(ctx contractapi.TransactionContextInterface, subject, action, grantor string) (*Consent, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(consentIndex, []string{subject, action, grantor})
+	if err != nil {
+		return nil, err
+	}
+	consentBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read consent: %v", err)
+	}
+	if consentBytes == nil {
+		return nil, nil
+	}
+	var consent Consent
+	if err := json.Unmarshal(consentBytes, &consent); err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+// getActiveConsent returns a consent that exists and has not expired at now
+func getActiveConsent(ctx contractapi.TransactionContextInterface, now time.Time, subject, action, grantor string) (*Consent, error) {
+	consent, err := getConsent(ctx, subject, action, grantor)
+	if err != nil {
+		return nil, err
+	}
+	if consent == nil {
+		return nil, fmt.Errorf("%s has not consented to %s on %s", grantor, action, subject)
+	}
+	if !consent.Expiry.IsZero() && !now.Before(consent.Expiry) {
+		return nil, fmt.Errorf("consent of %s to %s on %s expired at %s", grantor, action, subject, consent.Expiry.Format(time.RFC3339))
+	}
+	return consent, nil
+}
+
+// deleteConsent removes a consent record and its grantor index entry
+func deleteConsent(ctx contractapi.TransactionContextInterface, consent *Consent) error {
+	key, err := ctx.GetStub().CreateCompositeKey(consentIndex, []string{consent.Subject, consent.Action, consent.Grantor})
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return err
+	}
+	grantorKey, err := ctx.GetStub().CreateCompositeKey(consentGrantorIndex, []string{consent.Grantor, consent.Subject, consent.Action})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().DelState(grantorKey)
+}
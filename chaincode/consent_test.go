@@ -0,0 +1,59 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConsentLifecycle tests creating, looking up, expiring and revoking consents
+func TestConsentLifecycle(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "alice", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	stub.TxTimestamp, _ = ptypes.TimestampProto(now)
+
+	require.NoError(t, cc.CreateConsent(ctx, "asset1", "purchase", now.Add(time.Hour).Format(time.RFC3339)))
+	require.NoError(t, cc.CreateConsent(ctx, "asset2", "transfer", ""))
+	assert.Error(t, cc.CreateConsent(ctx, "asset3", "transfer", now.Add(-time.Hour).Format(time.RFC3339)))
+
+	ok, err := qc.CheckConsent(ctx, "asset1", "purchase", "alice")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	bySubject, err := qc.GetConsentsBySubject(ctx, "asset1")
+	require.NoError(t, err)
+	require.Len(t, bySubject, 1)
+	assert.Equal(t, "alice", bySubject[0].Grantor)
+
+	byGrantor, err := qc.GetConsentsByGrantor(ctx, "alice")
+	require.NoError(t, err)
+	assert.Len(t, byGrantor, 2)
+
+	// A consent without expiry stores none
+	open, err := getConsent(ctx, "asset2", "transfer", "alice")
+	require.NoError(t, err)
+	openBytes, err := json.Marshal(open)
+	require.NoError(t, err)
+	assert.NotContains(t, string(openBytes), "expiry")
+
+	stub.TxTimestamp, _ = ptypes.TimestampProto(now.Add(2 * time.Hour))
+	ok, err = qc.CheckConsent(ctx, "asset1", "purchase", "alice")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, cc.RevokeConsent(ctx, "asset2", "transfer"))
+	ok, _ = qc.CheckConsent(ctx, "asset2", "transfer", "alice")
+	assert.False(t, ok)
+	byGrantor, err = qc.GetConsentsByGrantor(ctx, "alice")
+	require.NoError(t, err)
+	assert.Len(t, byGrantor, 1)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "bob", nil)
+	assert.Error(t, cc.RevokeConsent(ctx, "asset1", "purchase"))
+}
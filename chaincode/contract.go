@@ -1,39 +1,76 @@
 package chaincode
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"time"
+	"unicode/utf8"
 
-	"github.com/golang/protobuf/ptypes"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 )
 
-func init() {
-	// Pretty logging for development
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339})
-	// Set global log level
-	zerolog.SetGlobalLevel(zerolog.DebugLevel)
-}
-
 // GetClientIdentity returns the client identity from the transaction context
-func (t *SimpleChaincode) GetClientIdentity(ctx contractapi.TransactionContextInterface) (string, error) {
+func (q *QueryContract) GetClientIdentity(ctx contractapi.TransactionContextInterface) (string, error) {
 	clientIdentity, err := ctx.GetClientIdentity().GetID()
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get client identity")
+		q.logger(ctx).Error().Err(err).Msg("Failed to get client identity")
 		return "", err
 	}
 	return clientIdentity, nil
 }
+
 const index = "color~name"
 
-// SimpleChaincode implements the fabric-contract-api-go programming model
-type SimpleChaincode struct {
+// maxAllAssetsPageSize caps the page size of GetAllAssets
+const maxAllAssetsPageSize = 1000
+
+// maxReadAssetsBatch caps the asset IDs of one ReadAssets call
+const maxReadAssetsBatch = 100
+
+// contractRuntime holds the dependencies and settings shared by the contracts that work on
+// assets. Its methods are promoted to each contract; none are exported, so contractapi does
+// not expose them as transactions.
+type contractRuntime struct {
+	baseLogger *zerolog.Logger
+
+	clock  Clock
+	ids    IDGenerator
+	config ConfigProvider
+
+	skipIndexes      bool
+	skipValidation   bool
+	skipEvents       bool
+	skipQueryScoping bool
+	skipRawQueries   bool
+	skipSampleData   bool
+
+	hooks               *HookRegistry
+	namespace           string
+	initRequired        bool
+	keyLevelEndorsement bool
+	usageStats          bool
+	softDelete          bool
+	tenantUsage         bool
+	tenantQuotas        bool
+
+	attributeAccessControl bool
+	invariants             *InvariantRegistry
+}
+
+// AssetContract holds the transactions that write assets and is the default contract of the
+// chaincode. Reads are served by QueryContract and administration by AdminContract and
+// ConfigContract, so each namespace can have its own endorsement policy and ACLs while all
+// of them share the storage layer in repository.go.
+type AssetContract struct {
 	contractapi.Contract
+	contractRuntime
+}
+
+// QueryContract holds the read-only asset transactions and is invoked as QueryContract:<Function>
+type QueryContract struct {
+	contractapi.Contract
+	contractRuntime
 }
 
 type Asset struct {
@@ -43,6 +80,24 @@ type Asset struct {
 	Size           int    `json:"size"`
 	Owner          string `json:"owner"`
 	AppraisedValue int    `json:"appraisedValue"`
+	OwnerMSP       string `json:"ownerMSP,omitempty" metadata:",optional"`  // MSP of the organization that holds the asset
+	Frozen         bool   `json:"frozen,omitempty" metadata:",optional"`    // frozen assets cannot be transferred or deleted
+	Residency      string `json:"residency,omitempty" metadata:",optional"` // region whose MSPs may read the asset, empty for everyone
+	Checksum       string `json:"checksum,omitempty" metadata:",optional"`  // integrity hash set when the asset is stored
+	Version        int    `json:"version,omitempty" metadata:",optional"`   // incremented by every write, for optimistic concurrency
+	// Transaction timestamps of the creation and the last write. omitzero keeps them out of the
+	// encoding of records written before they existed, whose checksums would no longer match.
+	CreatedAt time.Time `json:"createdAt,omitzero" metadata:",optional"`
+	UpdatedAt time.Time `json:"updatedAt,omitzero" metadata:",optional"`
+	// Set on tombstones left by DeleteAsset in soft-delete mode, see WithSoftDelete
+	Deleted   bool      `json:"deleted,omitempty" metadata:",optional"`
+	DeletedAt time.Time `json:"deletedAt,omitzero" metadata:",optional"`
+	// Computed for responses and never stored, see assetEnricher
+	ValuationBand string `json:"valuationBand,omitempty" metadata:",optional"`
+	AgeDays       int    `json:"ageDays,omitempty" metadata:",optional"`
+	// Always-encoded fields the stored record lacks, set by decodeAssetRecord for
+	// assetEnricher.withDefaults
+	absent []string
 }
 
 // HistoryQueryResult structure used for returning result of history query
@@ -53,7 +108,9 @@ type HistoryQueryResult struct {
 	IsDelete  bool      `json:"isDelete"`
 }
 
-// PaginatedQueryResult structure used for returning paginated query results and metadata
+// PaginatedQueryResult structure used for returning paginated query results and metadata.
+// Bookmark is an opaque cursor bound to the query and page size that produced it; pass it
+// back unchanged to fetch the next page.
 type PaginatedQueryResult struct {
 	Records             []*Asset `json:"records"`
 	FetchedRecordsCount int32    `json:"fetchedRecordsCount"`
@@ -61,8 +118,8 @@ type PaginatedQueryResult struct {
 }
 
 // CreateAsset initializes a new asset in the ledger
-func (t *SimpleChaincode) CreateAsset(ctx contractapi.TransactionContextInterface, assetID, color string, size int, owner string, appraisedValue int) error {
-	log.Info().
+func (t *AssetContract) CreateAsset(ctx contractapi.TransactionContextInterface, assetID, color string, size int, owner string, appraisedValue int) error {
+	t.logger(ctx).Info().
 		Str("function", "CreateAsset").
 		Str("assetID", assetID).
 		Str("color", color).
@@ -71,148 +128,231 @@ func (t *SimpleChaincode) CreateAsset(ctx contractapi.TransactionContextInterfac
 		Int("appraisedValue", appraisedValue).
 		Msg("Creating new asset")
 
-	exists, err := t.AssetExists(ctx, assetID)
+	mspID, err := assertOrgCanWrite(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.validateAsset(assetID, color, size, appraisedValue); err != nil {
+		return err
+	}
+	if err := checkIDRange(ctx, mspID, assetID); err != nil {
+		return err
+	}
+
+	exists, err := t.assetExists(ctx, assetID)
 	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to check if asset exists")
+		t.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to check if asset exists")
 		return fmt.Errorf("failed to get asset: %v", err)
 	}
 	if exists {
-		log.Warn().Str("assetID", assetID).Msg("Asset already exists")
+		t.logger(ctx).Warn().Str("assetID", assetID).Msg("Asset already exists")
 		return fmt.Errorf("asset already exists: %s", assetID)
 	}
 
 	asset := &Asset{
-		DocType:        "asset",
+		DocType:        AssetDocType,
 		ID:             assetID,
 		Color:          color,
 		Size:           size,
-		Owner:          owner,
 		AppraisedValue: appraisedValue,
 	}
-	assetBytes, err := json.Marshal(asset)
-	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to marshal asset to JSON")
+	if err := setAssetOwner(ctx, asset, owner); err != nil {
 		return err
 	}
-
-	err = ctx.GetStub().PutState(assetID, assetBytes)
+	asset.CreatedAt, err = t.now(ctx)
 	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to put asset in ledger")
 		return err
 	}
 
-	log.Debug().Str("assetID", assetID).Msg("Asset successfully stored in ledger")
-
-	//  Create an index to enable color-based range queries, e.g. return all blue assets.
-	//  An 'index' is a normal key-value entry in the ledger.
-	//  The key is a composite key, with the elements that you want to range query on listed first.
-	//  In our case, the composite key is based on indexName~color~name.
-	//  This will enable very efficient state range queries based on composite keys matching indexName~color~*
-	colorNameIndexKey, err := ctx.GetStub().CreateCompositeKey(index, []string{asset.Color, asset.ID})
+	// Tag the asset with the region of the creating organization, if one is configured
+	asset.Residency, err = getMSPRegion(ctx, mspID)
 	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Str("color", color).Msg("Failed to create composite key for color index")
 		return err
 	}
-	//  Save index entry to world state. Only the key name is needed, no need to store a duplicate copy of the asset.
-	//  Note - passing a 'nil' value will effectively delete the key from state, therefore we pass null character as value
-	value := []byte{0x00}
-	err = ctx.GetStub().PutState(colorNameIndexKey, value)
+	if err := t.hooks.runBeforeCreate(ctx, asset); err != nil {
+		t.logger(ctx).Warn().Err(err).Str("assetID", assetID).Msg("Asset creation rejected by hook")
+		return err
+	}
+	if err := t.hooks.runValidate(ctx, asset); err != nil {
+		t.logger(ctx).Warn().Err(err).Str("assetID", assetID).Msg("Asset rejected by validation hook")
+		return err
+	}
+	// Stores the asset with its entries in the color~name, msp~id and other registered indexes
+	err = t.saveAsset(ctx, asset)
 	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Str("color", color).Msg("Failed to store color index")
 		return err
 	}
 
-	log.Info().Str("assetID", assetID).Str("color", color).Msg("Asset created successfully with color index")
+	t.logger(ctx).Debug().Str("assetID", assetID).Msg("Asset successfully stored in ledger")
+
+	if t.keyLevelEndorsement {
+		if err := requireOrgEndorsement(ctx, assetID, mspID); err != nil {
+			t.logger(ctx).Error().Err(err).Str("assetID", assetID).Str("mspID", mspID).Msg("Failed to set key-level endorsement policy")
+			return err
+		}
+	}
+
+	if err := t.emitAssetChange(ctx, EventAssetCreated, asset); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("assetID", assetID).Str("color", color).Msg("Asset created successfully with color index")
 	return nil
 }
 
 // ReadAsset retrieves an asset from the ledger
-func (t *SimpleChaincode) ReadAsset(ctx contractapi.TransactionContextInterface, assetID string) (*Asset, error) {
-	log.Info().Str("function", "ReadAsset").Str("assetID", assetID).Msg("Reading asset from ledger")
+func (q *QueryContract) ReadAsset(ctx contractapi.TransactionContextInterface, assetID string) (*Asset, error) {
+	q.logger(ctx).Info().Str("function", "ReadAsset").Str("assetID", assetID).Msg("Reading asset from ledger")
 
-	assetBytes, err := ctx.GetStub().GetState(assetID)
+	asset, err := getAsset(ctx, assetID)
 	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to get asset from ledger")
-		return nil, fmt.Errorf("failed to get asset %s: %v", assetID, err)
+		return nil, err
 	}
-	if assetBytes == nil {
-		log.Warn().Str("assetID", assetID).Msg("Asset does not exist")
-		return nil, fmt.Errorf("asset %s does not exist", assetID)
+	presenter, err := q.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !presenter.visible(asset) {
+		return nil, denyAccess(ctx, "data residency", "asset %s is restricted to region %s", assetID, asset.Residency)
 	}
 
-	var asset Asset
-	err = json.Unmarshal(assetBytes, &asset)
+	q.logger(ctx).Info().Str("assetID", assetID).Str("owner", asset.Owner).Str("color", asset.Color).Msg("Asset read successfully")
+	return presenter.present(asset), nil
+}
+
+// AssetBatch is the result of ReadAssets: the assets found, in request order, and the IDs that
+// were not
+type AssetBatch struct {
+	Assets  []*Asset `json:"assets"`
+	Missing []string `json:"missing"`
+}
+
+// ReadAssets retrieves several assets in one call. IDs that do not exist, were soft-deleted or
+// whose asset is restricted to another region are listed in Missing instead of failing the call.
+func (q *QueryContract) ReadAssets(ctx contractapi.TransactionContextInterface, assetIDs []string) (*AssetBatch, error) {
+	q.logger(ctx).Info().Str("function", "ReadAssets").Strs("assetIDs", assetIDs).Msg("Reading assets from ledger")
+
+	if len(assetIDs) == 0 || len(assetIDs) > maxReadAssetsBatch {
+		return nil, fmt.Errorf("between 1 and %d asset IDs must be given", maxReadAssetsBatch)
+	}
+	presenter, err := q.presenter(ctx)
 	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to unmarshal asset from JSON")
 		return nil, err
 	}
 
-	log.Info().Str("assetID", assetID).Str("owner", asset.Owner).Str("color", asset.Color).Msg("Asset read successfully")
-	return &asset, nil
+	batch := &AssetBatch{Assets: []*Asset{}, Missing: []string{}}
+	for _, assetID := range assetIDs {
+		assetBytes, err := ctx.GetStub().GetState(assetKey(assetID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get asset %s: %v", assetID, err)
+		}
+		if assetBytes == nil {
+			batch.Missing = append(batch.Missing, assetID)
+			continue
+		}
+		asset, err := decodeAsset(assetBytes)
+		if err != nil {
+			q.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to decode asset")
+			return nil, err
+		}
+		if asset.Deleted || !presenter.visible(asset) {
+			batch.Missing = append(batch.Missing, assetID)
+			continue
+		}
+		batch.Assets = append(batch.Assets, presenter.present(asset))
+	}
+
+	q.logger(ctx).Info().Int("found", len(batch.Assets)).Int("missing", len(batch.Missing)).Msg("Assets read successfully")
+	return batch, nil
 }
 
 // DeleteAsset removes an asset key-value pair from the ledger
-func (t *SimpleChaincode) DeleteAsset(ctx contractapi.TransactionContextInterface, assetID string) error {
-	log.Info().Str("function", "DeleteAsset").Str("assetID", assetID).Msg("Deleting asset from ledger")
+func (t *AssetContract) DeleteAsset(ctx contractapi.TransactionContextInterface, assetID string) error {
+	t.logger(ctx).Info().Str("function", "DeleteAsset").Str("assetID", assetID).Msg("Deleting asset from ledger")
 
-	asset, err := t.ReadAsset(ctx, assetID)
-	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to read asset before deletion")
+	if _, err := assertOrgCanWrite(ctx); err != nil {
 		return err
 	}
 
-	err = ctx.GetStub().DelState(assetID)
+	asset, err := getAsset(ctx, assetID)
 	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to delete asset from ledger")
-		return fmt.Errorf("failed to delete asset %s: %v", assetID, err)
+		t.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to read asset before deletion")
+		return err
+	}
+	if err := assertResident(ctx, asset); err != nil {
+		return err
+	}
+	if asset.Frozen {
+		t.logger(ctx).Warn().Str("assetID", assetID).Msg("Cannot delete frozen asset")
+		return fmt.Errorf("asset %s is frozen", assetID)
 	}
 
-	colorNameIndexKey, err := ctx.GetStub().CreateCompositeKey(index, []string{asset.Color, asset.ID})
+	if t.softDelete {
+		err = t.tombstoneAsset(ctx, asset)
+	} else {
+		err = t.removeAsset(ctx, assetID)
+	}
 	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Str("color", asset.Color).Msg("Failed to create composite key for color index deletion")
 		return err
 	}
 
-	// Delete index entry
-	err = ctx.GetStub().DelState(colorNameIndexKey)
-	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Str("color", asset.Color).Msg("Failed to delete color index")
+	if err := t.emitAssetChange(ctx, EventAssetDeleted, asset); err != nil {
 		return err
 	}
 
-	log.Info().Str("assetID", assetID).Str("color", asset.Color).Msg("Asset and color index deleted successfully")
+	t.logger(ctx).Info().Str("assetID", assetID).Str("color", asset.Color).Msg("Asset and color index deleted successfully")
 	return nil
 }
 
-// TransferAsset transfers an asset by setting a new owner name on the asset
-func (t *SimpleChaincode) TransferAsset(ctx contractapi.TransactionContextInterface, assetID, newOwner string) error {
-	log.Info().
+// TransferAsset transfers an asset by setting a new owner name on the asset. A non-zero
+// expectedVersion must match the asset's current version, see checkVersion.
+func (t *AssetContract) TransferAsset(ctx contractapi.TransactionContextInterface, assetID, newOwner string, expectedVersion int) error {
+	t.logger(ctx).Info().
 		Str("function", "TransferAsset").
 		Str("assetID", assetID).
 		Str("newOwner", newOwner).
+		Int("expectedVersion", expectedVersion).
 		Msg("Transferring asset ownership")
 
-	asset, err := t.ReadAsset(ctx, assetID)
-	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to read asset for transfer")
+	if _, err := assertOrgCanWrite(ctx); err != nil {
 		return err
 	}
 
-	oldOwner := asset.Owner
-	asset.Owner = newOwner
-	assetBytes, err := json.Marshal(asset)
+	asset, err := getAsset(ctx, assetID)
 	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to marshal asset for transfer")
+		t.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to read asset for transfer")
+		return err
+	}
+	if err := assertResident(ctx, asset); err != nil {
 		return err
 	}
+	if err := checkVersion(asset, expectedVersion); err != nil {
+		t.logger(ctx).Warn().Err(err).Str("assetID", assetID).Msg("Asset transfer conflicts with a concurrent change")
+		return err
+	}
+	if asset.Frozen {
+		t.logger(ctx).Warn().Str("assetID", assetID).Msg("Cannot transfer frozen asset")
+		return fmt.Errorf("asset %s is frozen", assetID)
+	}
 
-	err = ctx.GetStub().PutState(assetID, assetBytes)
+	oldOwner := asset.Owner
+	if err := setAssetOwner(ctx, asset, newOwner); err != nil {
+		return err
+	}
+	err = t.saveAsset(ctx, asset)
 	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to update asset in ledger during transfer")
+		t.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to update asset in ledger during transfer")
+		return err
+	}
+	if err := t.hooks.runAfterTransfer(ctx, asset, oldOwner); err != nil {
+		t.logger(ctx).Warn().Err(err).Str("assetID", assetID).Msg("Asset transfer rejected by hook")
+		return err
+	}
+	if err := t.emitAssetChange(ctx, EventAssetTransferred, asset); err != nil {
 		return err
 	}
 
-	log.Info().
+	t.logger(ctx).Info().
 		Str("assetID", assetID).
 		Str("oldOwner", oldOwner).
 		Str("newOwner", newOwner).
@@ -220,29 +360,139 @@ func (t *SimpleChaincode) TransferAsset(ctx contractapi.TransactionContextInterf
 	return nil
 }
 
-// constructQueryResponseFromIterator constructs a slice of assets from the resultsIterator
-func constructQueryResponseFromIterator(resultsIterator shim.StateQueryIteratorInterface) ([]*Asset, error) {
-	log.Debug().Msg("Constructing query response from iterator")
+// UpdateAsset replaces the mutable fields of an asset. When the color changes the
+// color~name index entry is moved so color range queries do not return stale results.
+// A non-zero expectedVersion must match the asset's current version, see checkVersion.
+func (t *AssetContract) UpdateAsset(ctx contractapi.TransactionContextInterface, assetID, color string, size int, owner string, appraisedValue int, expectedVersion int) error {
+	t.logger(ctx).Info().
+		Str("function", "UpdateAsset").
+		Str("assetID", assetID).
+		Str("color", color).
+		Int("size", size).
+		Str("owner", owner).
+		Int("appraisedValue", appraisedValue).
+		Int("expectedVersion", expectedVersion).
+		Msg("Updating asset")
+
+	if _, err := assertOrgCanWrite(ctx); err != nil {
+		return err
+	}
+
+	asset, err := getAsset(ctx, assetID)
+	if err != nil {
+		t.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to read asset for update")
+		return err
+	}
+	if err := assertResident(ctx, asset); err != nil {
+		return err
+	}
+	if err := checkVersion(asset, expectedVersion); err != nil {
+		t.logger(ctx).Warn().Err(err).Str("assetID", assetID).Msg("Asset update conflicts with a concurrent change")
+		return err
+	}
+	if asset.Frozen {
+		t.logger(ctx).Warn().Str("assetID", assetID).Msg("Cannot update frozen asset")
+		return fmt.Errorf("asset %s is frozen", assetID)
+	}
+	if err := t.validateAsset(assetID, color, size, appraisedValue); err != nil {
+		return err
+	}
+
+	asset.Color = color
+	asset.Size = size
+	if err := setAssetOwner(ctx, asset, owner); err != nil {
+		return err
+	}
+	asset.AppraisedValue = appraisedValue
+	if err := t.hooks.runValidate(ctx, asset); err != nil {
+		t.logger(ctx).Warn().Err(err).Str("assetID", assetID).Msg("Asset rejected by validation hook")
+		return err
+	}
+	err = t.saveAsset(ctx, asset)
+	if err != nil {
+		t.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to update asset in ledger")
+		return err
+	}
+
+	if err := t.emitAssetChange(ctx, EventAssetUpdated, asset); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("assetID", assetID).Str("color", color).Str("owner", owner).Msg("Asset updated successfully")
+	return nil
+}
+
+// checkVersion rejects a write based on a stale read of asset. Clients pass the version they
+// read to get a VERSION_CONFLICT error instead of overwriting a concurrent change, and 0 to
+// write whatever the current version is.
+func checkVersion(asset *Asset, expectedVersion int) error {
+	if expectedVersion != 0 && expectedVersion != asset.Version {
+		return newChaincodeError(ErrCodeVersionConflict, "asset %s is at version %d, expected %d", asset.ID, asset.Version, expectedVersion)
+	}
+	return nil
+}
+
+// validateAsset checks the fields of an asset being created or updated, unless validation is disabled
+func (r *contractRuntime) validateAsset(assetID, color string, size, appraisedValue int) error {
+	if r.skipValidation {
+		return nil
+	}
+	if assetID == "" {
+		return fmt.Errorf("asset ID must not be empty")
+	}
+	if color == "" {
+		return fmt.Errorf("color of asset %s must not be empty", assetID)
+	}
+	if size < 0 {
+		return fmt.Errorf("size of asset %s must not be negative", assetID)
+	}
+	if appraisedValue < 0 {
+		return fmt.Errorf("appraised value of asset %s must not be negative", assetID)
+	}
+	return nil
+}
+
+// constructQueryResponseFromIterator constructs a slice of assets from the resultsIterator.
+// It aborts with a RETRY_WITH_PAGINATION error once the query budget is exhausted, rather
+// than letting the endorsement run into the peer timeout. Assets are presented for the
+// calling identity, so restricted roles receive masked records.
+func (r *contractRuntime) constructQueryResponseFromIterator(ctx contractapi.TransactionContextInterface, resultsIterator shim.StateQueryIteratorInterface) ([]*Asset, error) {
+	r.logger(ctx).Debug().Msg("Constructing query response from iterator")
 
+	presenter, err := r.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	budget := newQueryBudget(r.configProvider())
 	var assets []*Asset
 	assetCount := 0
 	for resultsIterator.HasNext() {
 		queryResult, err := resultsIterator.Next()
 		if err != nil {
-			log.Error().Err(err).Msg("Failed to get next result from iterator")
+			r.logger(ctx).Error().Err(err).Msg("Failed to get next result from iterator")
+			return nil, err
+		}
+		if err := budget.consume(len(queryResult.Value)); err != nil {
+			r.logger(ctx).Warn().Err(err).Int("assetCount", assetCount).Msg("Query budget exhausted")
 			return nil, err
 		}
-		var asset Asset
-		err = json.Unmarshal(queryResult.Value, &asset)
+		asset, err := decodeAsset(queryResult.Value)
 		if err != nil {
-			log.Error().Err(err).Str("key", queryResult.Key).Msg("Failed to unmarshal asset from query result")
+			r.logger(ctx).Error().Err(err).Str("key", queryResult.Key).Msg("Failed to decode asset from query result")
 			return nil, err
 		}
-		assets = append(assets, &asset)
+		if asset.Deleted {
+			continue
+		}
+		if !presenter.visible(asset) {
+			r.logger(ctx).Debug().Str("key", queryResult.Key).Str("residency", asset.Residency).Msg("Skipping asset restricted by residency")
+			continue
+		}
+		assets = append(assets, presenter.present(asset))
 		assetCount++
 	}
 
-	log.Debug().Int("assetCount", assetCount).Msg("Query response construction completed")
+	r.logger(ctx).Debug().Int("assetCount", assetCount).Msg("Query response construction completed")
 	return assets, nil
 }
 
@@ -254,27 +504,28 @@ func constructQueryResponseFromIterator(resultsIterator shim.StateQueryIteratorI
 // invalidated by the committing peers if the result set has changed between endorsement
 // time and commit time.
 // Therefore, range queries are a safe option for performing update transactions based on query results.
-func (t *SimpleChaincode) GetAssetsByRange(ctx contractapi.TransactionContextInterface, startKey, endKey string) ([]*Asset, error) {
-	log.Info().
+func (q *QueryContract) GetAssetsByRange(ctx contractapi.TransactionContextInterface, startKey, endKey string) ([]*Asset, error) {
+	q.logger(ctx).Info().
 		Str("function", "GetAssetsByRange").
 		Str("startKey", startKey).
 		Str("endKey", endKey).
 		Msg("Performing range query on assets")
 
-	resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	startAssetKey, endAssetKey := assetKeyRange(startKey, endKey)
+	resultsIterator, err := ctx.GetStub().GetStateByRange(startAssetKey, endAssetKey)
 	if err != nil {
-		log.Error().Err(err).Str("startKey", startKey).Str("endKey", endKey).Msg("Failed to get state by range")
+		q.logger(ctx).Error().Err(err).Str("startKey", startKey).Str("endKey", endKey).Msg("Failed to get state by range")
 		return nil, err
 	}
 	defer resultsIterator.Close()
 
-	assets, err := constructQueryResponseFromIterator(resultsIterator)
+	assets, err := q.constructQueryResponseFromIterator(ctx, resultsIterator)
 	if err != nil {
-		log.Error().Err(err).Str("startKey", startKey).Str("endKey", endKey).Msg("Failed to construct query response")
+		q.logger(ctx).Error().Err(err).Str("startKey", startKey).Str("endKey", endKey).Msg("Failed to construct query response")
 		return nil, err
 	}
 
-	log.Info().Int("count", len(assets)).Str("startKey", startKey).Str("endKey", endKey).Msg("Range query completed successfully")
+	q.logger(ctx).Info().Int("count", len(assets)).Str("startKey", startKey).Str("endKey", endKey).Msg("Range query completed successfully")
 	return assets, nil
 }
 
@@ -285,60 +536,75 @@ func (t *SimpleChaincode) GetAssetsByRange(ctx contractapi.TransactionContextInt
 // committing peers if the result set has changed between endorsement time and commit time.
 // Therefore, range queries are a safe option for performing update transactions based on query results.
 // Example: GetStateByPartialCompositeKey/RangeQuery
-func (t *SimpleChaincode) TransferAssetByColor(ctx contractapi.TransactionContextInterface, color, newOwner string) error {
-	log.Info().
+func (t *AssetContract) TransferAssetByColor(ctx contractapi.TransactionContextInterface, color, newOwner string) error {
+	t.logger(ctx).Info().
 		Str("function", "TransferAssetByColor").
 		Str("color", color).
 		Str("newOwner", newOwner).
 		Msg("Transferring all assets of specified color")
 
+	if _, err := assertOrgCanWrite(ctx); err != nil {
+		return err
+	}
+	if t.skipIndexes {
+		return fmt.Errorf("the color index is disabled in this chaincode")
+	}
+
 	// Execute a key range query on all keys starting with 'color'
 	coloredAssetResultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(index, []string{color})
 	if err != nil {
-		log.Error().Err(err).Str("color", color).Msg("Failed to get state by partial composite key")
+		t.logger(ctx).Error().Err(err).Str("color", color).Msg("Failed to get state by partial composite key")
 		return err
 	}
 	defer coloredAssetResultsIterator.Close()
 
-	transferCount := 0
+	transferred := []string{}
 	for coloredAssetResultsIterator.HasNext() {
 		responseRange, err := coloredAssetResultsIterator.Next()
 		if err != nil {
-			log.Error().Err(err).Str("color", color).Msg("Failed to get next result from iterator")
+			t.logger(ctx).Error().Err(err).Str("color", color).Msg("Failed to get next result from iterator")
 			return err
 		}
 
 		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(responseRange.Key)
 		if err != nil {
-			log.Error().Err(err).Str("color", color).Str("key", responseRange.Key).Msg("Failed to split composite key")
+			t.logger(ctx).Error().Err(err).Str("color", color).Str("key", responseRange.Key).Msg("Failed to split composite key")
 			return err
 		}
 
 		if len(compositeKeyParts) > 1 {
 			returnedAssetID := compositeKeyParts[1]
-			log.Debug().Str("assetID", returnedAssetID).Str("color", color).Msg("Processing asset for color transfer")
+			t.logger(ctx).Debug().Str("assetID", returnedAssetID).Str("color", color).Msg("Processing asset for color transfer")
 
-			asset, err := t.ReadAsset(ctx, returnedAssetID)
+			asset, err := getAsset(ctx, returnedAssetID)
 			if err != nil {
-				log.Error().Err(err).Str("assetID", returnedAssetID).Str("color", color).Msg("Failed to read asset during color transfer")
+				t.logger(ctx).Error().Err(err).Str("assetID", returnedAssetID).Str("color", color).Msg("Failed to read asset during color transfer")
 				return err
 			}
-			asset.Owner = newOwner
-			assetBytes, err := json.Marshal(asset)
-			if err != nil {
-				log.Error().Err(err).Str("assetID", returnedAssetID).Str("color", color).Msg("Failed to marshal asset during color transfer")
+			if asset.Frozen {
+				t.logger(ctx).Warn().Str("assetID", returnedAssetID).Str("color", color).Msg("Skipping frozen asset during color transfer")
+				continue
+			}
+			previousOwner := asset.Owner
+			if err := setAssetOwner(ctx, asset, newOwner); err != nil {
 				return err
 			}
-			err = ctx.GetStub().PutState(returnedAssetID, assetBytes)
+			err = t.saveAsset(ctx, asset)
 			if err != nil {
-				log.Error().Err(err).Str("assetID", returnedAssetID).Str("color", color).Msg("Failed to update asset during color transfer")
+				t.logger(ctx).Error().Err(err).Str("assetID", returnedAssetID).Str("color", color).Msg("Failed to update asset during color transfer")
+				return fmt.Errorf("transfer failed for asset %s: %v", returnedAssetID, err)
+			}
+			if err := t.hooks.runAfterTransfer(ctx, asset, previousOwner); err != nil {
 				return fmt.Errorf("transfer failed for asset %s: %v", returnedAssetID, err)
 			}
-			transferCount++
+			transferred = append(transferred, returnedAssetID)
 		}
 	}
+	if err := t.emitAssetEvent(ctx, EventAssetTransferred, transferred...); err != nil {
+		return err
+	}
 
-	log.Info().Str("color", color).Str("newOwner", newOwner).Int("transferCount", transferCount).Msg("Color-based asset transfer completed successfully")
+	t.logger(ctx).Info().Str("color", color).Str("newOwner", newOwner).Int("transferCount", len(transferred)).Msg("Color-based asset transfer completed successfully")
 	return nil
 }
 
@@ -347,60 +613,91 @@ func (t *SimpleChaincode) TransferAssetByColor(ctx contractapi.TransactionContex
 // and accepting a single query parameter (owner).
 // Only available on state databases that support rich query (e.g. CouchDB)
 // Example: Parameterized rich query
-func (t *SimpleChaincode) QueryAssetsByOwner(ctx contractapi.TransactionContextInterface, owner string) ([]*Asset, error) {
-	log.Info().Str("function", "QueryAssetsByOwner").Str("owner", owner).Msg("Querying assets by owner")
+func (q *QueryContract) QueryAssetsByOwner(ctx contractapi.TransactionContextInterface, owner string) ([]*Asset, error) {
+	q.logger(ctx).Info().Str("function", "QueryAssetsByOwner").Str("owner", owner).Msg("Querying assets by owner")
 
-	queryString := fmt.Sprintf(`{"selector":{"docType":"asset","owner":"%s"}}`, owner)
-	log.Debug().Str("queryString", queryString).Msg("Generated query string for owner")
+	queryString, err := newAssetQuery().equals("owner", owner).build()
+	if err != nil {
+		return nil, err
+	}
+	q.logger(ctx).Debug().Str("queryString", queryString).Msg("Generated query string for owner")
 
-	assets, err := getQueryResultForQueryString(ctx, queryString)
+	assets, err := q.getQueryResultForQueryString(ctx, queryString)
 	if err != nil {
-		log.Error().Err(err).Str("owner", owner).Msg("Failed to query assets by owner")
+		q.logger(ctx).Error().Err(err).Str("owner", owner).Msg("Failed to query assets by owner")
 		return nil, err
 	}
 
-	log.Info().Str("owner", owner).Int("count", len(assets)).Msg("Owner query completed successfully")
+	q.logger(ctx).Info().Str("owner", owner).Int("count", len(assets)).Msg("Owner query completed successfully")
 	return assets, nil
 }
 
+// QueryAssetsByFilter returns a page of assets matching every given constraint. Empty strings and
+// zero bounds leave a field unconstrained. The selector is generated from the parameters, so they
+// cannot inject additional selector clauses.
+// Only available on state databases that support rich query (e.g. CouchDB)
+func (q *QueryContract) QueryAssetsByFilter(ctx contractapi.TransactionContextInterface, owner, color string, minSize, maxSize, minValue, maxValue int, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+	filter := AssetFilter{Owner: owner, Color: color, MinSize: minSize, MaxSize: maxSize, MinValue: minValue, MaxValue: maxValue}
+	q.logger(ctx).Info().
+		Str("function", "QueryAssetsByFilter").
+		Interface("filter", filter).
+		Int("pageSize", pageSize).
+		Str("bookmark", bookmark).
+		Msg("Querying assets by filter")
+
+	queryString, err := buildAssetSelector(filter)
+	if err != nil {
+		return nil, err
+	}
+	return q.getQueryResultForQueryStringWithPagination(ctx, queryString, int32(pageSize), bookmark)
+}
+
 // QueryAssets uses a query string to perform a query for assets.
-// Query string matching state database syntax is passed in and executed as is.
+// Query string matching state database syntax is passed in and executed as is, except that
+// callers who are neither admin nor auditor only match their own assets.
 // Supports ad hoc queries that can be defined at runtime by the client.
 // If this is not desired, follow the QueryAssetsForOwner example for parameterized queries.
 // Only available on state databases that support rich query (e.g. CouchDB)
 // Example: Ad hoc rich query
-func (t *SimpleChaincode) QueryAssets(ctx contractapi.TransactionContextInterface, queryString string) ([]*Asset, error) {
-	log.Info().Str("function", "QueryAssets").Str("queryString", queryString).Msg("Performing ad hoc query on assets")
+func (q *QueryContract) QueryAssets(ctx contractapi.TransactionContextInterface, queryString string) ([]*Asset, error) {
+	q.logger(ctx).Info().Str("function", "QueryAssets").Str("queryString", queryString).Msg("Performing ad hoc query on assets")
 
-	assets, err := getQueryResultForQueryString(ctx, queryString)
+	if err := q.requireRawQueries("QueryAssets"); err != nil {
+		return nil, err
+	}
+	assets, err := q.getQueryResultForQueryString(ctx, queryString)
 	if err != nil {
-		log.Error().Err(err).Str("queryString", queryString).Msg("Failed to perform ad hoc query")
+		q.logger(ctx).Error().Err(err).Str("queryString", queryString).Msg("Failed to perform ad hoc query")
 		return nil, err
 	}
 
-	log.Info().Str("queryString", queryString).Int("count", len(assets)).Msg("Ad hoc query completed successfully")
+	q.logger(ctx).Info().Str("queryString", queryString).Int("count", len(assets)).Msg("Ad hoc query completed successfully")
 	return assets, nil
 }
 
 // getQueryResultForQueryString executes the passed in query string.
 // The result set is built and returned as a byte array containing the JSON results.
-func getQueryResultForQueryString(ctx contractapi.TransactionContextInterface, queryString string) ([]*Asset, error) {
-	log.Debug().Str("queryString", queryString).Msg("Executing query string")
+func (r *contractRuntime) getQueryResultForQueryString(ctx contractapi.TransactionContextInterface, queryString string) ([]*Asset, error) {
+	r.logger(ctx).Debug().Str("queryString", queryString).Msg("Executing query string")
 
+	queryString, err := r.scopeQuery(ctx, queryString)
+	if err != nil {
+		return nil, err
+	}
 	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
 	if err != nil {
-		log.Error().Err(err).Str("queryString", queryString).Msg("Failed to get query result")
+		r.logger(ctx).Error().Err(err).Str("queryString", queryString).Msg("Failed to get query result")
 		return nil, err
 	}
 	defer resultsIterator.Close()
 
-	assets, err := constructQueryResponseFromIterator(resultsIterator)
+	assets, err := r.constructQueryResponseFromIterator(ctx, resultsIterator)
 	if err != nil {
-		log.Error().Err(err).Str("queryString", queryString).Msg("Failed to construct query response from iterator")
+		r.logger(ctx).Error().Err(err).Str("queryString", queryString).Msg("Failed to construct query response from iterator")
 		return nil, err
 	}
 
-	log.Debug().Str("queryString", queryString).Int("count", len(assets)).Msg("Query string execution completed")
+	r.logger(ctx).Debug().Str("queryString", queryString).Int("count", len(assets)).Msg("Query string execution completed")
 	return assets, nil
 }
 
@@ -409,8 +706,8 @@ func getQueryResultForQueryString(ctx contractapi.TransactionContextInterface, q
 // The number of fetched records will be equal to or lesser than the page size.
 // Paginated range queries are only valid for read only transactions.
 // Example: Pagination with Range Query
-func (t *SimpleChaincode) GetAssetsByRangeWithPagination(ctx contractapi.TransactionContextInterface, startKey string, endKey string, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
-	log.Info().
+func (q *QueryContract) GetAssetsByRangeWithPagination(ctx contractapi.TransactionContextInterface, startKey string, endKey string, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+	q.logger(ctx).Info().
 		Str("function", "GetAssetsByRangeWithPagination").
 		Str("startKey", startKey).
 		Str("endKey", endKey).
@@ -418,34 +715,78 @@ func (t *SimpleChaincode) GetAssetsByRangeWithPagination(ctx contractapi.Transac
 		Str("bookmark", bookmark).
 		Msg("Performing paginated range query on assets")
 
-	resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByRangeWithPagination(startKey, endKey, int32(pageSize), bookmark)
+	startAssetKey, endAssetKey := assetKeyRange(startKey, endKey)
+	queryHash := queryIdentity("range", startAssetKey, endAssetKey)
+	rawBookmark, err := decodeCursor(q.configProvider(), bookmark, queryHash, int32(pageSize))
+	if err != nil {
+		q.logger(ctx).Warn().Err(err).Str("startKey", startKey).Str("endKey", endKey).Msg("Rejected pagination cursor")
+		return nil, err
+	}
+
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByRangeWithPagination(startAssetKey, endAssetKey, int32(pageSize), rawBookmark)
 	if err != nil {
-		log.Error().Err(err).Str("startKey", startKey).Str("endKey", endKey).Int("pageSize", pageSize).Msg("Failed to get state by range with pagination")
+		q.logger(ctx).Error().Err(err).Str("startKey", startKey).Str("endKey", endKey).Int("pageSize", pageSize).Msg("Failed to get state by range with pagination")
 		return nil, err
 	}
 	defer resultsIterator.Close()
 
-	assets, err := constructQueryResponseFromIterator(resultsIterator)
+	assets, err := q.constructQueryResponseFromIterator(ctx, resultsIterator)
+	if err != nil {
+		q.logger(ctx).Error().Err(err).Str("startKey", startKey).Str("endKey", endKey).Msg("Failed to construct query response for paginated range query")
+		return nil, err
+	}
+
+	cursor, err := encodeCursor(q.configProvider(), responseMetadata.Bookmark, queryHash, int32(pageSize))
 	if err != nil {
-		log.Error().Err(err).Str("startKey", startKey).Str("endKey", endKey).Msg("Failed to construct query response for paginated range query")
 		return nil, err
 	}
 
 	result := &PaginatedQueryResult{
 		Records:             assets,
 		FetchedRecordsCount: responseMetadata.FetchedRecordsCount,
-		Bookmark:            responseMetadata.Bookmark,
+		Bookmark:            cursor,
 	}
 
-	log.Info().
+	q.logger(ctx).Info().
 		Str("startKey", startKey).
 		Str("endKey", endKey).
 		Int("fetchedCount", int(responseMetadata.FetchedRecordsCount)).
-		Str("bookmark", responseMetadata.Bookmark).
+		Str("bookmark", cursor).
 		Msg("Paginated range query completed successfully")
 	return result, nil
 }
 
+// GetAssetsByIDPrefix performs a paginated range query over all assets whose ID starts with
+// prefix, e.g. "ORG1-2024-" for hierarchical ID schemes. The scan is bounded to the prefix range,
+// so its cost depends on the number of matching assets only.
+func (q *QueryContract) GetAssetsByIDPrefix(ctx contractapi.TransactionContextInterface, prefix string, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+	q.logger(ctx).Info().Str("function", "GetAssetsByIDPrefix").Str("prefix", prefix).Int("pageSize", pageSize).Msg("Querying assets by ID prefix")
+
+	if prefix == "" {
+		return nil, fmt.Errorf("prefix must not be empty")
+	}
+	return q.GetAssetsByRangeWithPagination(ctx, prefix, prefixRangeEnd(prefix), pageSize, bookmark)
+}
+
+// GetAllAssets returns a page of the whole inventory in key order. pageSize is mandatory and
+// capped, so listing every asset never turns into one unbounded range scan. Every asset lives
+// under a simple key with AssetKeyPrefix while indexes and configuration use composite keys, so
+// the range over those keys is exactly the asset namespace.
+func (q *QueryContract) GetAllAssets(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+	q.logger(ctx).Info().Str("function", "GetAllAssets").Int("pageSize", pageSize).Msg("Listing all assets")
+
+	if pageSize <= 0 || pageSize > maxAllAssetsPageSize {
+		return nil, fmt.Errorf("page size must be between 1 and %d", maxAllAssetsPageSize)
+	}
+	return q.GetAssetsByRangeWithPagination(ctx, "", "", pageSize, bookmark)
+}
+
+// prefixRangeEnd returns the exclusive end key of a range covering every key that starts with
+// prefix. Keys must be valid UTF-8, so appending the highest code point sorts after all of them.
+func prefixRangeEnd(prefix string) string {
+	return prefix + string(utf8.MaxRune)
+}
+
 // QueryAssetsWithPagination uses a query string, page size and a bookmark to perform a query
 // for assets. Query string matching state database syntax is passed in and executed as is.
 // The number of fetched records would be equal to or lesser than the specified page size.
@@ -454,138 +795,165 @@ func (t *SimpleChaincode) GetAssetsByRangeWithPagination(ctx contractapi.Transac
 // Only available on state databases that support rich query (e.g. CouchDB)
 // Paginated queries are only valid for read only transactions.
 // Example: Pagination with Ad hoc Rich Query
-func (t *SimpleChaincode) QueryAssetsWithPagination(ctx contractapi.TransactionContextInterface, queryString string, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
-	log.Info().
+func (q *QueryContract) QueryAssetsWithPagination(ctx contractapi.TransactionContextInterface, queryString string, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+	q.logger(ctx).Info().
 		Str("function", "QueryAssetsWithPagination").
 		Str("queryString", queryString).
 		Int("pageSize", pageSize).
 		Str("bookmark", bookmark).
 		Msg("Performing paginated ad hoc query on assets")
 
-	return getQueryResultForQueryStringWithPagination(ctx, queryString, int32(pageSize), bookmark)
+	if err := q.requireRawQueries("QueryAssetsWithPagination"); err != nil {
+		return nil, err
+	}
+	return q.getQueryResultForQueryStringWithPagination(ctx, queryString, int32(pageSize), bookmark)
 }
 
 // getQueryResultForQueryStringWithPagination executes the passed in query string with
 // pagination info. The result set is built and returned as a byte array containing the JSON results.
-func getQueryResultForQueryStringWithPagination(ctx contractapi.TransactionContextInterface, queryString string, pageSize int32, bookmark string) (*PaginatedQueryResult, error) {
-	log.Debug().
+func (r *contractRuntime) getQueryResultForQueryStringWithPagination(ctx contractapi.TransactionContextInterface, queryString string, pageSize int32, bookmark string) (*PaginatedQueryResult, error) {
+	r.logger(ctx).Debug().
 		Str("queryString", queryString).
 		Int32("pageSize", pageSize).
 		Str("bookmark", bookmark).
 		Msg("Executing paginated query string")
 
-	resultsIterator, responseMetadata, err := ctx.GetStub().GetQueryResultWithPagination(queryString, pageSize, bookmark)
+	queryString, err := r.scopeQuery(ctx, queryString)
 	if err != nil {
-		log.Error().Err(err).Str("queryString", queryString).Int32("pageSize", pageSize).Msg("Failed to get query result with pagination")
+		return nil, err
+	}
+	queryHash := queryIdentity("query", queryString)
+	rawBookmark, err := decodeCursor(r.configProvider(), bookmark, queryHash, pageSize)
+	if err != nil {
+		r.logger(ctx).Warn().Err(err).Str("queryString", queryString).Msg("Rejected pagination cursor")
+		return nil, err
+	}
+
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetQueryResultWithPagination(queryString, pageSize, rawBookmark)
+	if err != nil {
+		r.logger(ctx).Error().Err(err).Str("queryString", queryString).Int32("pageSize", pageSize).Msg("Failed to get query result with pagination")
 		return nil, err
 	}
 	defer resultsIterator.Close()
 
-	assets, err := constructQueryResponseFromIterator(resultsIterator)
+	assets, err := r.constructQueryResponseFromIterator(ctx, resultsIterator)
+	if err != nil {
+		r.logger(ctx).Error().Err(err).Str("queryString", queryString).Msg("Failed to construct query response for paginated query")
+		return nil, err
+	}
+
+	cursor, err := encodeCursor(r.configProvider(), responseMetadata.Bookmark, queryHash, pageSize)
 	if err != nil {
-		log.Error().Err(err).Str("queryString", queryString).Msg("Failed to construct query response for paginated query")
 		return nil, err
 	}
 
 	result := &PaginatedQueryResult{
 		Records:             assets,
 		FetchedRecordsCount: responseMetadata.FetchedRecordsCount,
-		Bookmark:            responseMetadata.Bookmark,
+		Bookmark:            cursor,
 	}
 
-	log.Debug().
+	r.logger(ctx).Debug().
 		Str("queryString", queryString).
 		Int("fetchedCount", int(responseMetadata.FetchedRecordsCount)).
-		Str("bookmark", responseMetadata.Bookmark).
+		Str("bookmark", cursor).
 		Msg("Paginated query string execution completed")
 	return result, nil
 }
 
 // GetAssetHistory returns the chain of custody for an asset since issuance.
-func (t *SimpleChaincode) GetAssetHistory(ctx contractapi.TransactionContextInterface, assetID string) ([]HistoryQueryResult, error) {
-	log.Info().Str("function", "GetAssetHistory").Str("assetID", assetID).Msg("Getting asset history")
+func (q *QueryContract) GetAssetHistory(ctx contractapi.TransactionContextInterface, assetID string) ([]HistoryQueryResult, error) {
+	q.logger(ctx).Info().Str("function", "GetAssetHistory").Str("assetID", assetID).Msg("Getting asset history")
 
-	resultsIterator, err := ctx.GetStub().GetHistoryForKey(assetID)
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(assetKey(assetID))
 	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to get history for key")
+		q.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to get history for key")
 		return nil, err
 	}
 	defer resultsIterator.Close()
 
+	presenter, err := q.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	budget := newQueryBudget(q.configProvider())
 	var records []HistoryQueryResult
 	recordCount := 0
 	for resultsIterator.HasNext() {
 		response, err := resultsIterator.Next()
 		if err != nil {
-			log.Error().Err(err).Str("assetID", assetID).Msg("Failed to get next history record")
+			q.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to get next history record")
 			return nil, err
 		}
-
-		var asset Asset
-		if len(response.Value) > 0 {
-			err = json.Unmarshal(response.Value, &asset)
-			if err != nil {
-				log.Error().Err(err).Str("assetID", assetID).Str("txId", response.TxId).Msg("Failed to unmarshal asset from history record")
-				return nil, err
-			}
-		} else {
-			asset = Asset{
-				ID: assetID,
-			}
+		if err := budget.consume(len(response.Value)); err != nil {
+			q.logger(ctx).Warn().Err(err).Str("assetID", assetID).Int("recordCount", recordCount).Msg("History query budget exhausted, use GetAssetHistoryPage")
+			return nil, err
 		}
 
-		timestamp, err := ptypes.Timestamp(response.Timestamp)
+		record, err := newHistoryQueryResult(presenter, assetID, response)
 		if err != nil {
-			log.Error().Err(err).Str("assetID", assetID).Str("txId", response.TxId).Msg("Failed to parse timestamp from history record")
 			return nil, err
 		}
-
-		record := HistoryQueryResult{
-			TxId:      response.TxId,
-			Timestamp: timestamp,
-			Record:    &asset,
-			IsDelete:  response.IsDelete,
+		if !presenter.visible(record.Record) {
+			return nil, denyAccess(ctx, "data residency", "asset %s is restricted to region %s", assetID, record.Record.Residency)
 		}
-		records = append(records, record)
+		records = append(records, *record)
 		recordCount++
 	}
 
-	log.Info().Str("assetID", assetID).Int("recordCount", recordCount).Msg("Asset history retrieved successfully")
+	q.logger(ctx).Info().Str("assetID", assetID).Int("recordCount", recordCount).Msg("Asset history retrieved successfully")
 	return records, nil
 }
 
 // AssetExists returns true when asset with given ID exists in the ledger.
-func (t *SimpleChaincode) AssetExists(ctx contractapi.TransactionContextInterface, assetID string) (bool, error) {
-	log.Debug().Str("function", "AssetExists").Str("assetID", assetID).Msg("Checking if asset exists")
+func (q *QueryContract) AssetExists(ctx contractapi.TransactionContextInterface, assetID string) (bool, error) {
+	return q.assetExists(ctx, assetID)
+}
 
-	assetBytes, err := ctx.GetStub().GetState(assetID)
+// assetExists reports whether an asset is stored under assetID
+func (r *contractRuntime) assetExists(ctx contractapi.TransactionContextInterface, assetID string) (bool, error) {
+	r.logger(ctx).Debug().Str("function", "AssetExists").Str("assetID", assetID).Msg("Checking if asset exists")
+
+	assetBytes, err := ctx.GetStub().GetState(assetKey(assetID))
 	if err != nil {
-		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to read asset from world state")
+		r.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to read asset from world state")
 		return false, fmt.Errorf("failed to read asset %s from world state. %v", assetID, err)
 	}
 
 	exists := assetBytes != nil
-	log.Debug().Str("assetID", assetID).Bool("exists", exists).Msg("Asset existence check completed")
+	r.logger(ctx).Debug().Str("assetID", assetID).Bool("exists", exists).Msg("Asset existence check completed")
 	return exists, nil
 }
 
-// InitLedger creates the initial set of assets in the ledger.
-func (t *SimpleChaincode) InitLedger(ctx contractapi.TransactionContextInterface) error {
-	log.Info().Str("function", "InitLedger").Msg("Initializing ledger with sample assets")
+// InitLedger creates the initial set of assets in the ledger. It runs once and is refused with
+// ALREADY_INITIALIZED after a previous run or once the deployment was initialized with Init.
+func (t *AssetContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+	t.logger(ctx).Info().Str("function", "InitLedger").Msg("Initializing ledger with sample assets")
+
+	if t.skipSampleData {
+		return fmt.Errorf("InitLedger is disabled: sample data seeding is turned off for this deployment")
+	}
+	if err := requireAdminMSP(ctx); err != nil {
+		return err
+	}
+	if err := t.requireLedgerUninitialized(ctx); err != nil {
+		return err
+	}
 
 	assets := []Asset{
-		{DocType: "asset", ID: "asset1", Color: "blue", Size: 5, Owner: "Tomoko", AppraisedValue: 300},
-		{DocType: "asset", ID: "asset2", Color: "red", Size: 5, Owner: "Brad", AppraisedValue: 400},
-		{DocType: "asset", ID: "asset3", Color: "green", Size: 10, Owner: "Jin Soo", AppraisedValue: 500},
-		{DocType: "asset", ID: "asset4", Color: "yellow", Size: 10, Owner: "Max", AppraisedValue: 600},
-		{DocType: "asset", ID: "asset5", Color: "black", Size: 15, Owner: "Adriana", AppraisedValue: 700},
-		{DocType: "asset", ID: "asset6", Color: "white", Size: 15, Owner: "Michel", AppraisedValue: 800},
+		{DocType: AssetDocType, ID: "asset1", Color: "blue", Size: 5, Owner: "Tomoko", AppraisedValue: 300},
+		{DocType: AssetDocType, ID: "asset2", Color: "red", Size: 5, Owner: "Brad", AppraisedValue: 400},
+		{DocType: AssetDocType, ID: "asset3", Color: "green", Size: 10, Owner: "Jin Soo", AppraisedValue: 500},
+		{DocType: AssetDocType, ID: "asset4", Color: "yellow", Size: 10, Owner: "Max", AppraisedValue: 600},
+		{DocType: AssetDocType, ID: "asset5", Color: "black", Size: 15, Owner: "Adriana", AppraisedValue: 700},
+		{DocType: AssetDocType, ID: "asset6", Color: "white", Size: 15, Owner: "Michel", AppraisedValue: 800},
 	}
 
-	log.Info().Int("assetCount", len(assets)).Msg("Creating initial assets in ledger")
+	t.logger(ctx).Info().Int("assetCount", len(assets)).Msg("Creating initial assets in ledger")
 
+	assetIDs := make([]string, 0, len(assets))
 	for i, asset := range assets {
-		log.Debug().
+		t.logger(ctx).Debug().
 			Int("index", i).
 			Str("assetID", asset.ID).
 			Str("color", asset.Color).
@@ -594,11 +962,19 @@ func (t *SimpleChaincode) InitLedger(ctx contractapi.TransactionContextInterface
 
 		err := t.CreateAsset(ctx, asset.ID, asset.Color, asset.Size, asset.Owner, asset.AppraisedValue)
 		if err != nil {
-			log.Error().Err(err).Str("assetID", asset.ID).Msg("Failed to create initial asset")
+			t.logger(ctx).Error().Err(err).Str("assetID", asset.ID).Msg("Failed to create initial asset")
 			return err
 		}
+		assetIDs = append(assetIDs, asset.ID)
+	}
+	if err := t.emitAssetEvent(ctx, EventAssetCreated, assetIDs...); err != nil {
+		return err
+	}
+
+	if err := t.recordLedgerInit(ctx, len(assets)); err != nil {
+		return err
 	}
 
-	log.Info().Int("assetCount", len(assets)).Msg("Ledger initialization completed successfully")
+	t.logger(ctx).Info().Int("assetCount", len(assets)).Msg("Ledger initialization completed successfully")
 	return nil
 }
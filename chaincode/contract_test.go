@@ -4,7 +4,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // TestAssetStruct tests the Asset struct
@@ -85,9 +88,9 @@ func TestPaginatedQueryResult(t *testing.T) {
 	assert.Equal(t, "asset2", result.Records[1].ID)
 }
 
-// TestSimpleChaincode tests that the SimpleChaincode struct can be instantiated
-func TestSimpleChaincode(t *testing.T) {
-	chaincode := &SimpleChaincode{}
+// TestAssetContract tests that the AssetContract struct can be instantiated
+func TestAssetContract(t *testing.T) {
+	chaincode := &AssetContract{}
 	assert.NotNil(t, chaincode)
 }
 
@@ -95,3 +98,146 @@ func TestSimpleChaincode(t *testing.T) {
 func TestIndexConstant(t *testing.T) {
 	assert.Equal(t, "color~name", index)
 }
+
+// TestNewChaincode tests that every exported contract function has a valid signature
+func TestNewChaincode(t *testing.T) {
+	_, err := contractapi.NewChaincode(&AssetContract{}, &QueryContract{}, &AdminContract{}, &ConfigContract{})
+	assert.NoError(t, err)
+}
+
+// TestPrefixRangeEnd tests that the prefix range covers exactly the keys starting with the prefix
+func TestPrefixRangeEnd(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	for _, id := range []string{"ORG1-2024-1", "ORG1-2024-2", "ORG1-2024-é", "ORG1-2025-1", "ORG1-2023-9"} {
+		assert.NoError(t, cc.CreateAsset(ctx, id, "blue", 5, "John", 100))
+	}
+
+	iterator, err := stub.GetStateByRange("ORG1-2024-", prefixRangeEnd("ORG1-2024-"))
+	assert.NoError(t, err)
+	defer iterator.Close()
+	ids := []string{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		assert.NoError(t, err)
+		ids = append(ids, kv.Key)
+	}
+	assert.Equal(t, []string{"ORG1-2024-1", "ORG1-2024-2", "ORG1-2024-é"}, ids)
+
+	_, err = qc.GetAssetsByIDPrefix(ctx, "", 10, "")
+	assert.Error(t, err)
+}
+
+// TestUpdateAsset tests that updates replace the mutable fields and move the color index entry
+func TestUpdateAsset(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+
+	require.NoError(t, cc.UpdateAsset(ctx, "asset1", "red", 6, "Jane", 200, 0))
+	asset, err := qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "red", asset.Color)
+	assert.Equal(t, 6, asset.Size)
+	assert.Equal(t, "Jane", asset.Owner)
+	assert.Equal(t, 200, asset.AppraisedValue)
+
+	oldKey, _ := stub.CreateCompositeKey(index, []string{"blue", "asset1"})
+	newKey, _ := stub.CreateCompositeKey(index, []string{"red", "asset1"})
+	assert.NotContains(t, stub.State, oldKey)
+	assert.Contains(t, stub.State, newKey)
+
+	require.NoError(t, cc.TransferAssetByColor(ctx, "red", "Max"))
+	asset, err = qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "Max", asset.Owner)
+
+	assert.Error(t, cc.UpdateAsset(ctx, "missing", "red", 6, "Jane", 200, 0))
+	assert.Error(t, cc.UpdateAsset(ctx, "asset1", "", 6, "Jane", 200, 0))
+}
+
+// TestVersionConflict tests that writes based on a stale version are rejected with VERSION_CONFLICT
+func TestVersionConflict(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	asset, err := qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, asset.Version)
+
+	require.NoError(t, cc.UpdateAsset(ctx, "asset1", "red", 6, "John", 200, 1))
+	err = cc.TransferAsset(ctx, "asset1", "Jane", 1)
+	assert.True(t, hasErrorCode(err, ErrCodeVersionConflict), err)
+	err = cc.UpdateAsset(ctx, "asset1", "green", 6, "John", 200, 1)
+	assert.True(t, hasErrorCode(err, ErrCodeVersionConflict), err)
+
+	require.NoError(t, cc.TransferAsset(ctx, "asset1", "Jane", 2))
+	asset, err = qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, asset.Version)
+	assert.Equal(t, "Jane", asset.Owner)
+	assert.Equal(t, "red", asset.Color)
+
+	require.NoError(t, cc.TransferAsset(ctx, "asset1", "Max", 0), "0 skips the check")
+}
+
+// TestAssetTimestamps tests that creation and update times come from the transaction timestamps
+func TestAssetTimestamps(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	created := time.Date(2026, 3, 1, 9, 0, 0, 123, time.UTC)
+	stub.TxTimestamp = timestamppb.New(created)
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+
+	updated := created.Add(time.Hour)
+	stub.MockTransactionStart("tx2")
+	stub.TxTimestamp = timestamppb.New(updated)
+	require.NoError(t, cc.TransferAsset(ctx, "asset1", "Jane", 0))
+
+	asset, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, created, asset.CreatedAt)
+	assert.Equal(t, updated, asset.UpdatedAt)
+}
+
+// TestQueryAssetsByFilterRejectsInvalidRange tests that inverted bounds fail before querying
+func TestQueryAssetsByFilterRejectsInvalidRange(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	qc := &QueryContract{}
+	_, err := qc.QueryAssetsByFilter(ctx, "", "", 10, 5, 0, 0, 10, "")
+	assert.ErrorContains(t, err, "invalid size range")
+	_, err = qc.QueryAssetsByFilter(ctx, "", "", 0, 0, 500, 100, 10, "")
+	assert.ErrorContains(t, err, "invalid appraisedValue range")
+}
+
+// TestReadAssets tests that found assets and missing IDs are returned together
+func TestReadAssets(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "red", 5, "Jane", 200))
+
+	batch, err := qc.ReadAssets(ctx, []string{"asset2", "nope", "asset1"})
+	require.NoError(t, err)
+	require.Len(t, batch.Assets, 2)
+	assert.Equal(t, "asset2", batch.Assets[0].ID)
+	assert.Equal(t, "asset1", batch.Assets[1].ID)
+	assert.Equal(t, []string{"nope"}, batch.Missing)
+
+	_, err = qc.ReadAssets(ctx, nil)
+	assert.Error(t, err)
+}
+
+// TestGetAllAssetsRequiresPageSize tests that the inventory can only be listed page by page
+func TestGetAllAssetsRequiresPageSize(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	qc := &QueryContract{}
+	for _, pageSize := range []int{0, -1, maxAllAssetsPageSize + 1} {
+		_, err := qc.GetAllAssets(ctx, pageSize, "")
+		assert.Error(t, err, pageSize)
+	}
+}
@@ -0,0 +1,67 @@
+package chaincode
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShardedCounter tests that increments from many transactions add up across shards
+func TestShardedCounter(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	counter, err := newShardedCounter("orders")
+	require.NoError(t, err)
+
+	shards := map[int]bool{}
+	for i := 0; i < 50; i++ {
+		stub.MockTransactionStart(fmt.Sprintf("tx-%d", i))
+		current, err := counter.current(ctx)
+		require.NoError(t, err)
+		shard, previous, err := counter.add(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, previous, current)
+		shards[shard] = true
+	}
+	total, err := counter.total(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), total)
+	assert.Greater(t, len(shards), 1)
+
+	assert.Equal(t, uint64(1), shardLimit(16))
+	assert.Equal(t, uint64(2), shardLimit(17))
+
+	_, err = newShardedCounter("")
+	assert.Error(t, err)
+}
+
+// TestNextSequence tests that issued numbers are unique and deterministic per transaction
+func TestNextSequence(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+
+	issued := map[uint64]bool{}
+	var firstShard int
+	for i := 0; i < 200; i++ {
+		stub.MockTransactionStart(fmt.Sprintf("tx-%d", i))
+		number, err := cc.NextSequence(ctx, "invoice")
+		require.NoError(t, err)
+		assert.False(t, issued[number.Value], "duplicate sequence number %d", number.Value)
+		issued[number.Value] = true
+		if i == 0 {
+			firstShard = number.Shard
+		}
+	}
+
+	// Another endorser simulating the same transaction picks the same shard
+	replayCtx, replayStub := newTestContext(t, "Org1MSP", "user1", nil)
+	replayStub.MockTransactionStart("tx-0")
+	replayed, err := cc.NextSequence(replayCtx, "invoice")
+	require.NoError(t, err)
+	assert.Equal(t, firstShard, replayed.Shard)
+	assert.Equal(t, uint64(firstShard+1), replayed.Value)
+
+	_, err = cc.NextSequence(ctx, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package chaincode
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// cursorSecretConfig names the setting holding the secret cursors are signed with. There is no
+// default: a secret published with the source would let anyone forge cursors.
+const cursorSecretConfig = "CHAINCODE_CURSOR_SECRET"
+
+// cursorPayload is the content of an opaque pagination cursor
+type cursorPayload struct {
+	Bookmark  string `json:"b"`
+	QueryHash string `json:"q"`
+	PageSize  int32  `json:"p"`
+}
+
+// queryIdentity hashes the parameters that define a query so a cursor can be bound to it
+func queryIdentity(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// encodeCursor wraps a state database bookmark in a signed cursor bound to the query and page size.
+// An empty bookmark yields an empty cursor.
+func encodeCursor(config ConfigProvider, bookmark, queryHash string, pageSize int32) (string, error) {
+	if bookmark == "" {
+		return "", nil
+	}
+
+	payloadBytes, err := json.Marshal(cursorPayload{Bookmark: bookmark, QueryHash: queryHash, PageSize: pageSize})
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(payloadBytes)
+	signature, err := signCursor(config, payload)
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + signature, nil
+}
+
+// decodeCursor validates a cursor against the current query and page size and returns
+// the state database bookmark it wraps. An empty cursor requests the first page.
+func decodeCursor(config ConfigProvider, cursor, queryHash string, pageSize int32) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+
+	payload, signature, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return "", fmt.Errorf("invalid pagination cursor")
+	}
+	expected, err := signCursor(config, payload)
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "", fmt.Errorf("invalid pagination cursor")
+	}
+
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("invalid pagination cursor: %v", err)
+	}
+	var decoded cursorPayload
+	if err := json.Unmarshal(payloadBytes, &decoded); err != nil {
+		return "", fmt.Errorf("invalid pagination cursor: %v", err)
+	}
+
+	if decoded.QueryHash != queryHash {
+		return "", fmt.Errorf("pagination cursor belongs to a different query")
+	}
+	if decoded.PageSize != pageSize {
+		return "", fmt.Errorf("pagination cursor was issued for page size %d, got %d", decoded.PageSize, pageSize)
+	}
+	return decoded.Bookmark, nil
+}
+
+func signCursor(config ConfigProvider, payload string) (string, error) {
+	secret := config.Get(cursorSecretConfig)
+	if secret == "" {
+		return "", fmt.Errorf("%s is not configured", cursorSecretConfig)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,59 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCursorRoundTrip tests that a cursor decodes back to its bookmark for the same query
+func TestCursorRoundTrip(t *testing.T) {
+	queryHash := queryIdentity("range", "asset1", "asset9")
+
+	cursor, err := encodeCursor(envConfig{}, "g1AAAAA", queryHash, 10)
+	require.NoError(t, err)
+	assert.NotContains(t, cursor, "g1AAAAA")
+
+	bookmark, err := decodeCursor(envConfig{}, cursor, queryHash, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "g1AAAAA", bookmark)
+
+	empty, err := encodeCursor(envConfig{}, "", queryHash, 10)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+// TestCursorRejectsMismatch tests that cursors cannot be reused across queries or page sizes
+func TestCursorRejectsMismatch(t *testing.T) {
+	cursor, err := encodeCursor(envConfig{}, "g1AAAAA", queryIdentity("query", `{"selector":{}}`), 10)
+	require.NoError(t, err)
+
+	_, err = decodeCursor(envConfig{}, cursor, queryIdentity("query", `{"selector":{"owner":"Tom"}}`), 10)
+	assert.Error(t, err)
+
+	_, err = decodeCursor(envConfig{}, cursor, queryIdentity("query", `{"selector":{}}`), 20)
+	assert.Error(t, err)
+
+	_, err = decodeCursor(envConfig{}, "g1AAAAA", queryIdentity("query", `{"selector":{}}`), 10)
+	assert.Error(t, err)
+
+	_, err = decodeCursor(envConfig{}, cursor+"x", queryIdentity("query", `{"selector":{}}`), 10)
+	assert.Error(t, err)
+}
+
+// TestCursorRequiresSecret tests that cursors are neither issued nor accepted without a secret
+func TestCursorRequiresSecret(t *testing.T) {
+	queryHash := queryIdentity("range", "asset1", "asset9")
+	cursor, err := encodeCursor(envConfig{}, "g1AAAAA", queryHash, 10)
+	require.NoError(t, err)
+
+	_, err = encodeCursor(mapConfig{}, "g1AAAAA", queryHash, 10)
+	assert.ErrorContains(t, err, "CHAINCODE_CURSOR_SECRET is not configured")
+	_, err = decodeCursor(mapConfig{}, cursor, queryHash, 10)
+	assert.ErrorContains(t, err, "CHAINCODE_CURSOR_SECRET is not configured")
+
+	first, err := decodeCursor(mapConfig{}, "", queryHash, 10)
+	require.NoError(t, err)
+	assert.Empty(t, first)
+}
@@ -0,0 +1,283 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// deadLetterIndex keys failed import items by the submitting identity
+	deadLetterIndex = "deadletter~msp~submitter~id"
+	// maxImportBatchSize caps the number of items accepted by one ImportAssets or CreateAssets call.
+	// Init can lower it per deployment.
+	maxImportBatchSize = 500
+)
+
+// DeadLetter is an import item that failed, kept with its error so it can be fixed and reprocessed
+type DeadLetter struct {
+	ID        string    `json:"id"`
+	Submitter string    `json:"submitter"`
+	MSPID     string    `json:"mspId"`
+	Item      string    `json:"item"`
+	Error     string    `json:"error"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"createdAt"`
+	TxID      string    `json:"txId"`
+	Resolved  bool      `json:"resolved,omitempty" metadata:",optional"`
+}
+
+// ImportResult lists the assets created by ImportAssets and the dead letters written for failed items
+type ImportResult struct {
+	Imported     []string `json:"imported"`
+	DeadLettered []string `json:"deadLettered"`
+}
+
+// ImportAssets creates every asset of a JSON array. By default the first invalid item fails the
+// whole transaction; with deadLetter set, failed items are stored in the caller's dead-letter
+// queue with the error instead, and the remaining items are still imported.
+func (t *AssetContract) ImportAssets(ctx contractapi.TransactionContextInterface, assetsJSON string, deadLetter bool) (*ImportResult, error) {
+	t.logger(ctx).Info().Str("function", "ImportAssets").Bool("deadLetter", deadLetter).Msg("Importing assets")
+
+	items, err := parseAssetBatch(ctx, assetsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := t.now(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Imported: []string{}, DeadLettered: []string{}}
+	seen := map[string]bool{}
+	for i, item := range items {
+		assetID, err := t.importAsset(ctx, item, seen)
+		if err == nil {
+			result.Imported = append(result.Imported, assetID)
+			continue
+		}
+		if !deadLetter {
+			t.logger(ctx).Error().Err(err).Int("item", i).Msg("Failed to import asset")
+			return nil, fmt.Errorf("item %d: %v", i, err)
+		}
+		id, dlqErr := t.newID(ctx, strconv.Itoa(i))
+		if dlqErr != nil {
+			return nil, dlqErr
+		}
+		letter, dlqErr := putDeadLetter(ctx, id, now, item, err)
+		if dlqErr != nil {
+			return nil, dlqErr
+		}
+		t.logger(ctx).Warn().Err(err).Int("item", i).Str("deadLetterID", letter.ID).Msg("Import item moved to dead-letter queue")
+		result.DeadLettered = append(result.DeadLettered, letter.ID)
+	}
+
+	if err := t.emitAssetEvent(ctx, EventAssetCreated, result.Imported...); err != nil {
+		return nil, err
+	}
+
+	t.logger(ctx).Info().Int("imported", len(result.Imported)).Int("deadLettered", len(result.DeadLettered)).Msg("Assets imported")
+	return result, nil
+}
+
+// GetDeadLetters returns the caller's failed import items. Admins see the queue of every identity.
+func (q *QueryContract) GetDeadLetters(ctx contractapi.TransactionContextInterface) ([]*DeadLetter, error) {
+	q.logger(ctx).Info().Str("function", "GetDeadLetters").Msg("Reading dead letters")
+
+	isAdmin, err := callerHasRole(ctx, roleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	attributes := []string{}
+	if !isAdmin {
+		mspID, submitter, err := getCallerSubmitter(ctx)
+		if err != nil {
+			return nil, err
+		}
+		attributes = []string{mspID, submitter}
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(deadLetterIndex, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead letters: %v", err)
+	}
+	defer iterator.Close()
+
+	letters := []*DeadLetter{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var letter DeadLetter
+		if err := json.Unmarshal(response.Value, &letter); err != nil {
+			return nil, err
+		}
+		letters = append(letters, &letter)
+	}
+	return letters, nil
+}
+
+// ReprocessDeadLetter retries a failed import item, optionally replaced by a corrected item.
+// On success the dead letter is removed and returned as resolved; on failure it is kept with
+// the new error.
+func (t *AssetContract) ReprocessDeadLetter(ctx contractapi.TransactionContextInterface, id, correctedItem string) (*DeadLetter, error) {
+	t.logger(ctx).Info().Str("function", "ReprocessDeadLetter").Str("deadLetterID", id).Msg("Reprocessing dead letter")
+
+	key, letter, err := getDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	item := json.RawMessage(letter.Item)
+	if correctedItem != "" {
+		item = json.RawMessage(correctedItem)
+	}
+
+	if _, err := t.importAsset(ctx, item, map[string]bool{}); err != nil {
+		letter.Item = string(item)
+		letter.Error = err.Error()
+		letter.Attempts++
+		letter.TxID = ctx.GetStub().GetTxID()
+		letterBytes, marshalErr := json.Marshal(letter)
+		if marshalErr != nil {
+			return nil, marshalErr
+		}
+		if putErr := ctx.GetStub().PutState(key, letterBytes); putErr != nil {
+			return nil, putErr
+		}
+		t.logger(ctx).Warn().Err(err).Str("deadLetterID", id).Int("attempts", letter.Attempts).Msg("Dead letter failed again")
+		return letter, nil
+	}
+
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return nil, err
+	}
+	letter.Resolved = true
+	t.logger(ctx).Info().Str("deadLetterID", id).Msg("Dead letter reprocessed successfully")
+	return letter, nil
+}
+
+// DiscardDeadLetter removes a failed import item without importing it
+func (t *AssetContract) DiscardDeadLetter(ctx contractapi.TransactionContextInterface, id string) error {
+	t.logger(ctx).Info().Str("function", "DiscardDeadLetter").Str("deadLetterID", id).Msg("Discarding dead letter")
+
+	key, _, err := getDeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().DelState(key)
+}
+
+// importAsset decodes and creates a single import item. seen tracks the IDs of the current
+// batch, since reads do not observe the batch's own pending writes.
+func (t *AssetContract) importAsset(ctx contractapi.TransactionContextInterface, item json.RawMessage, seen map[string]bool) (string, error) {
+	var asset Asset
+	if err := json.Unmarshal(item, &asset); err != nil {
+		return "", fmt.Errorf("invalid asset JSON: %v", err)
+	}
+	if asset.ID == "" {
+		return "", fmt.Errorf("asset ID must not be empty")
+	}
+	if seen[asset.ID] {
+		return "", fmt.Errorf("asset %s appears more than once in the batch", asset.ID)
+	}
+	if err := t.CreateAsset(ctx, asset.ID, asset.Color, asset.Size, asset.Owner, asset.AppraisedValue); err != nil {
+		return "", err
+	}
+	seen[asset.ID] = true
+	return asset.ID, nil
+}
+
+// parseAssetBatch splits a JSON array of assets into its items and checks the batch size
+// against the deployment limit
+func parseAssetBatch(ctx contractapi.TransactionContextInterface, assetsJSON string) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal([]byte(assetsJSON), &items); err != nil {
+		return nil, fmt.Errorf("assets must be a JSON array: %v", err)
+	}
+	maxItems := maxImportBatchSize
+	params, err := getDeploymentParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if params != nil && params.MaxImportBatchSize > 0 {
+		maxItems = params.MaxImportBatchSize
+	}
+	if len(items) == 0 || len(items) > maxItems {
+		return nil, fmt.Errorf("batch must contain between 1 and %d items", maxItems)
+	}
+	return items, nil
+}
+
+func putDeadLetter(ctx contractapi.TransactionContextInterface, id string, createdAt time.Time, item json.RawMessage, cause error) (*DeadLetter, error) {
+	mspID, submitter, err := getCallerSubmitter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	letter := &DeadLetter{
+		ID:        id,
+		Submitter: submitter,
+		MSPID:     mspID,
+		Item:      string(item),
+		Error:     cause.Error(),
+		Attempts:  1,
+		CreatedAt: createdAt,
+		TxID:      ctx.GetStub().GetTxID(),
+	}
+	letterBytes, err := json.Marshal(letter)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(deadLetterIndex, []string{mspID, submitter, id})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(key, letterBytes); err != nil {
+		log.Error().Err(err).Str("deadLetterID", id).Msg("Failed to store dead letter")
+		return nil, err
+	}
+	return letter, nil
+}
+
+// getDeadLetter reads one of the caller's dead letters
+func getDeadLetter(ctx contractapi.TransactionContextInterface, id string) (string, *DeadLetter, error) {
+	mspID, submitter, err := getCallerSubmitter(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(deadLetterIndex, []string{mspID, submitter, id})
+	if err != nil {
+		return "", nil, err
+	}
+	letterBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read dead letter %s: %v", id, err)
+	}
+	if letterBytes == nil {
+		return "", nil, fmt.Errorf("dead letter %s does not exist", id)
+	}
+	var letter DeadLetter
+	if err := json.Unmarshal(letterBytes, &letter); err != nil {
+		return "", nil, err
+	}
+	return key, &letter, nil
+}
+
+// getCallerSubmitter returns the MSP and enrollment ID that own a dead-letter queue
+func getCallerSubmitter(ctx contractapi.TransactionContextInterface) (string, string, error) {
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	submitter, err := getCallerEnrollmentID(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return mspID, submitter, nil
+}
@@ -0,0 +1,74 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImportAssetsAtomic tests that without dead-lettering one bad item fails the batch
+func TestImportAssetsAtomic(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+
+	result, err := cc.ImportAssets(ctx, `[{"ID":"asset1","color":"blue","owner":"John"},{"ID":"asset2","color":"red"}]`, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"asset1", "asset2"}, result.Imported)
+
+	_, err = cc.ImportAssets(ctx, `[{"ID":"asset3","color":"blue"},{"color":"red"}]`, false)
+	assert.Error(t, err)
+	_, err = cc.ImportAssets(ctx, `{"ID":"asset4"}`, false)
+	assert.Error(t, err)
+}
+
+// TestImportAssetsDeadLetter tests that failed items are queued per submitter and can be reprocessed
+func TestImportAssetsDeadLetter(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "existing", "blue", 5, "John", 100))
+
+	result, err := cc.ImportAssets(ctx, `[
+		{"ID":"asset1","color":"blue","owner":"John"},
+		{"ID":"existing","color":"red"},
+		{"ID":"asset1","color":"green"},
+		"not an asset"
+	]`, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"asset1"}, result.Imported)
+	assert.Equal(t, []string{"tx1-1", "tx1-2", "tx1-3"}, result.DeadLettered)
+
+	letters, err := qc.GetDeadLetters(ctx)
+	require.NoError(t, err)
+	require.Len(t, letters, 3)
+	assert.Contains(t, letters[0].Error, "already exists")
+	assert.Equal(t, "user1", letters[0].Submitter)
+
+	letter, err := cc.ReprocessDeadLetter(ctx, "tx1-1", "")
+	require.NoError(t, err)
+	assert.False(t, letter.Resolved)
+	assert.Equal(t, 2, letter.Attempts)
+
+	letter, err = cc.ReprocessDeadLetter(ctx, "tx1-1", `{"ID":"asset2","color":"red","owner":"Jane"}`)
+	require.NoError(t, err)
+	assert.True(t, letter.Resolved)
+	asset, err := qc.ReadAsset(ctx, "asset2")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", asset.Owner)
+
+	require.NoError(t, cc.DiscardDeadLetter(ctx, "tx1-3"))
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "user2", nil)
+	letters, err = qc.GetDeadLetters(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, letters)
+	_, err = cc.ReprocessDeadLetter(ctx, "tx1-2", "")
+	assert.Error(t, err)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "admin", adminAttrs)
+	letters, err = qc.GetDeadLetters(ctx)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, "tx1-2", letters[0].ID)
+}
@@ -0,0 +1,89 @@
+package chaincode
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Clock reports the current time of a transaction. Every endorser must see the same time,
+// so the default reads the transaction timestamp instead of the peer's wall clock.
+type Clock interface {
+	Now(ctx contractapi.TransactionContextInterface) (time.Time, error)
+}
+
+// IDGenerator produces identifiers for records the contract creates on its own, e.g. dead
+// letters. IDs must be identical on every endorser, so the default derives them from the
+// transaction ID and a label that is unique within the transaction.
+type IDGenerator interface {
+	NewID(ctx contractapi.TransactionContextInterface, label string) (string, error)
+}
+
+// ConfigProvider supplies deployment settings such as query limits and the cursor secret.
+// The default reads environment variables.
+type ConfigProvider interface {
+	Get(key string) string
+}
+
+// txClock is the default Clock
+type txClock struct{}
+
+func (txClock) Now(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	if cached, ok := ctx.(TransactionContextInterface); ok {
+		return cached.TxTime()
+	}
+	return readTxTime(ctx)
+}
+
+// txIDGenerator is the default IDGenerator
+type txIDGenerator struct{}
+
+func (txIDGenerator) NewID(ctx contractapi.TransactionContextInterface, label string) (string, error) {
+	txID := ctx.GetStub().GetTxID()
+	if txID == "" {
+		return "", fmt.Errorf("transaction ID is not available")
+	}
+	return txID + "-" + label, nil
+}
+
+// envConfig is the default ConfigProvider
+type envConfig struct{}
+
+func (envConfig) Get(key string) string {
+	return os.Getenv(key)
+}
+
+// now returns the current time of the transaction from the injected clock
+func (r *contractRuntime) now(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	if r.clock == nil {
+		return txClock{}.Now(ctx)
+	}
+	return r.clock.Now(ctx)
+}
+
+// presenter returns the asset presenter of the caller, computing ages with the injected clock
+func (r *contractRuntime) presenter(ctx contractapi.TransactionContextInterface) (*assetPresenter, error) {
+	var clock Clock = txClock{}
+	if r.clock != nil {
+		clock = r.clock
+	}
+	return newAssetPresenter(ctx, clock)
+}
+
+// newID returns a record ID from the injected generator
+func (r *contractRuntime) newID(ctx contractapi.TransactionContextInterface, label string) (string, error) {
+	if r.ids == nil {
+		return txIDGenerator{}.NewID(ctx, label)
+	}
+	return r.ids.NewID(ctx, label)
+}
+
+// configProvider returns the injected configuration, defaulting to the environment
+func (r *contractRuntime) configProvider() ConfigProvider {
+	if r.config == nil {
+		return envConfig{}
+	}
+	return r.config
+}
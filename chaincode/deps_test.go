@@ -0,0 +1,59 @@
+package chaincode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	return time.Time(c), nil
+}
+
+type prefixIDs string
+
+func (p prefixIDs) NewID(ctx contractapi.TransactionContextInterface, label string) (string, error) {
+	return string(p) + label, nil
+}
+
+type mapConfig map[string]string
+
+func (m mapConfig) Get(key string) string {
+	return m[key]
+}
+
+// TestInjectedDependencies tests that the constructor options replace the clock, ID generator and configuration
+func TestInjectedDependencies(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	opts := []Option{
+		WithClock(fixedClock(now)),
+		WithIDGenerator(prefixIDs("dl-")),
+		WithConfigProvider(mapConfig{"CHAINCODE_QUERY_MAX_RECORDS": "1"}),
+	}
+	cc := NewAssetContract(opts...)
+	qc := NewQueryContract(opts...)
+
+	result, err := cc.ImportAssets(ctx, `[{"ID":"asset1","color":"blue"},{"ID":"asset2","color":"blue"},{"color":"red"}]`, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dl-2"}, result.DeadLettered)
+
+	letters, err := qc.GetDeadLetters(ctx)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, now, letters[0].CreatedAt)
+
+	asset, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, now, asset.CreatedAt)
+	assert.Equal(t, now, asset.UpdatedAt)
+
+	_, err = qc.GetAssetsByRange(ctx, "asset1", "asset3")
+	require.Error(t, err)
+	assert.True(t, hasErrorCode(err, ErrCodeRetryWithPagination))
+}
@@ -0,0 +1,143 @@
+package chaincode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// simulatorEnv is the process environment of one simulated endorsing peer. Go randomizes map
+// iteration on every range, so the two simulators also iterate maps in different orders.
+type simulatorEnv struct {
+	name     string
+	location *time.Location
+	lang     string
+}
+
+// divergentEnvs are two peers that should still produce identical endorsements
+var divergentEnvs = [2]simulatorEnv{
+	{name: "utc-posix", location: time.UTC, lang: "C"},
+	{name: "tokyo-turkish", location: time.FixedZone("JST", 9*60*60), lang: "tr_TR.UTF-8"},
+}
+
+// endorsement is what a simulated peer returns for a proposal: the resulting world state and
+// key-level policies, the transaction event and the function's response
+type endorsement struct {
+	state    map[string][]byte
+	policies map[string]map[string][]byte
+	event    *peer.ChaincodeEvent
+	result   interface{}
+	err      error
+}
+
+// assertSameEndorsement runs scenario as one transaction on a simulator per divergentEnvs,
+// sharing the creator, transaction ID and timestamp, and fails if the write sets, events or
+// responses differ, i.e. if real peers would fail to agree on the endorsement. The scenario
+// itself must succeed.
+func assertSameEndorsement(t *testing.T, attrs map[string]string, scenario func(ctx contractapi.TransactionContextInterface) (interface{}, error)) {
+	t.Helper()
+
+	creator := newSerializedIdentity(t, "Org1MSP", "user1", attrs)
+	timestamp := timestamppb.Now()
+	var results [2]*endorsement
+	for i, env := range divergentEnvs {
+		results[i] = simulateEndorsement(t, env, creator, timestamp, scenario)
+	}
+	require.NoError(t, results[0].err)
+
+	assert.Equal(t, results[0].err, results[1].err, "responses differ between %s and %s", divergentEnvs[0].name, divergentEnvs[1].name)
+	assert.Equal(t, results[0].result, results[1].result, "results differ between %s and %s", divergentEnvs[0].name, divergentEnvs[1].name)
+	assert.Equal(t, results[0].state, results[1].state, "write sets differ between %s and %s", divergentEnvs[0].name, divergentEnvs[1].name)
+	assert.Equal(t, results[0].policies, results[1].policies, "key-level policies differ between %s and %s", divergentEnvs[0].name, divergentEnvs[1].name)
+	assert.Equal(t, results[0].event, results[1].event, "events differ between %s and %s", divergentEnvs[0].name, divergentEnvs[1].name)
+}
+
+// simulateEndorsement runs scenario on a fresh MockStub under env
+func simulateEndorsement(t *testing.T, env simulatorEnv, creator []byte, timestamp *timestamppb.Timestamp, scenario func(ctx contractapi.TransactionContextInterface) (interface{}, error)) *endorsement {
+	t.Helper()
+
+	t.Setenv("LANG", env.lang)
+	t.Setenv("LC_ALL", env.lang)
+	local := time.Local
+	time.Local = env.location
+	defer func() { time.Local = local }()
+
+	stub := shimtest.NewMockStub("chaincode", nil)
+	stub.Creator = creator
+	stub.MockTransactionStart("tx1")
+	stub.TxTimestamp = timestamp
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(stub)
+	setTestIdentity(t, ctx, stub)
+
+	result, err := scenario(ctx)
+	outcome := &endorsement{state: stub.State, policies: stub.EndorsementPolicies, result: result, err: err}
+	// Fabric keeps the last event of a transaction
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		outcome.event = <-stub.ChaincodeEventsChannel
+	}
+	return outcome
+}
+
+// TestDeterministicEndorsements runs the functions that iterate maps, ranges or batches on two
+// divergent simulators to catch non-determinism regressions before they fail endorsements
+func TestDeterministicEndorsements(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		attrs    map[string]string
+		scenario func(ctx contractapi.TransactionContextInterface) (interface{}, error)
+	}{
+		{"InitLedger", nil, func(ctx contractapi.TransactionContextInterface) (interface{}, error) {
+			return nil, (&AssetContract{}).InitLedger(ctx)
+		}},
+		{"TransferAssetByColor", nil, func(ctx contractapi.TransactionContextInterface) (interface{}, error) {
+			cc := &AssetContract{}
+			if err := cc.InitLedger(ctx); err != nil {
+				return nil, err
+			}
+			return nil, cc.TransferAssetByColor(ctx, "blue", "Max")
+		}},
+		{"ImportAssets", nil, func(ctx contractapi.TransactionContextInterface) (interface{}, error) {
+			return (&AssetContract{}).ImportAssets(ctx, `[{"ID":"asset1","color":"blue","owner":"John"},{"ID":"asset2","color":"red","owner":"Jane"},{"color":"green"}]`, true)
+		}},
+		{"MoveAssetSubtree", nil, func(ctx contractapi.TransactionContextInterface) (interface{}, error) {
+			cc := &AssetContract{}
+			for _, id := range []string{"org1/site1/line1/a", "org1/site1/line2/b", "org1/site1/line2/c"} {
+				if err := cc.CreateAsset(ctx, id, "blue", 5, "John", 100); err != nil {
+					return nil, err
+				}
+			}
+			return cc.MoveAssetSubtree(ctx, "org1/site1", "org1/site2")
+		}},
+		{"BackfillIndex", adminAttrs, func(ctx contractapi.TransactionContextInterface) (interface{}, error) {
+			if err := (&AssetContract{}).InitLedger(ctx); err != nil {
+				return nil, err
+			}
+			return (&AdminContract{}).BackfillIndex(ctx, "owner~name", 10)
+		}},
+		{"AddEndorsingOrg", adminAttrs, func(ctx contractapi.TransactionContextInterface) (interface{}, error) {
+			if err := NewAssetContract(WithKeyLevelEndorsement()).CreateAsset(ctx, "asset1", "blue", 5, "John", 100); err != nil {
+				return nil, err
+			}
+			ac := &AdminContract{}
+			for _, mspID := range []string{"Org3MSP", "Org2MSP"} {
+				if err := ac.AddEndorsingOrg(ctx, "asset1", mspID); err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			assertSameEndorsement(t, s.attrs, s.scenario)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package chaincode
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/rs/zerolog/log"
+)
+
+// DrainingChaincode counts in-flight invocations so the server can shut down without cutting
+// transactions off mid-endorsement. Once Drain is called new invocations are rejected, which
+// lets the peer retry them on another instance.
+type DrainingChaincode struct {
+	shim.Chaincode
+
+	mu       sync.Mutex
+	inFlight sync.WaitGroup
+	draining bool
+}
+
+// NewDrainingChaincode wraps a chaincode with in-flight tracking
+func NewDrainingChaincode(cc shim.Chaincode) *DrainingChaincode {
+	return &DrainingChaincode{Chaincode: cc}
+}
+
+// Init forwards to the wrapped chaincode unless the chaincode is draining
+func (d *DrainingChaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return d.track(stub, d.Chaincode.Init)
+}
+
+// Invoke forwards to the wrapped chaincode unless the chaincode is draining
+func (d *DrainingChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	return d.track(stub, d.Chaincode.Invoke)
+}
+
+// Drain stops accepting invocations and waits until the in-flight ones have returned or ctx is done
+func (d *DrainingChaincode) Drain(ctx context.Context) error {
+	d.mu.Lock()
+	d.draining = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *DrainingChaincode) track(stub shim.ChaincodeStubInterface, call func(shim.ChaincodeStubInterface) peer.Response) peer.Response {
+	d.mu.Lock()
+	if d.draining {
+		d.mu.Unlock()
+		log.Warn().Str("txId", stub.GetTxID()).Msg("Invocation rejected, chaincode is shutting down")
+		return shim.Error("chaincode is shutting down")
+	}
+	d.inFlight.Add(1)
+	d.mu.Unlock()
+
+	defer d.inFlight.Done()
+	return call(stub)
+}
@@ -0,0 +1,53 @@
+package chaincode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingChaincode holds every invocation until release is closed
+type blockingChaincode struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingChaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (b *blockingChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	close(b.started)
+	<-b.release
+	return shim.Success(nil)
+}
+
+// TestDrainingChaincode tests that Drain waits for in-flight invocations and rejects new ones
+func TestDrainingChaincode(t *testing.T) {
+	inner := &blockingChaincode{started: make(chan struct{}), release: make(chan struct{})}
+	cc := NewDrainingChaincode(inner)
+
+	responses := make(chan peer.Response, 1)
+	go func() {
+		responses <- cc.Invoke(shimtest.NewMockStub("chaincode", nil))
+	}()
+	<-inner.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, cc.Drain(ctx), context.DeadlineExceeded)
+
+	rejected := cc.Invoke(shimtest.NewMockStub("chaincode", nil))
+	assert.Equal(t, int32(shim.ERROR), rejected.Status)
+	assert.Contains(t, rejected.Message, "shutting down")
+
+	close(inner.release)
+	require.NoError(t, cc.Drain(context.Background()))
+	assert.Equal(t, int32(shim.OK), (<-responses).Status)
+}
@@ -0,0 +1,68 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetAssetEndorsementPolicy tests decoding of a key-level endorsement policy
+func TestGetAssetEndorsementPolicy(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+
+	info, err := qc.GetAssetEndorsementPolicy(ctx, "asset1")
+	require.NoError(t, err)
+	assert.False(t, info.HasKeyLevelPolicy)
+	assert.Empty(t, info.Principals)
+
+	ep, err := statebased.NewStateEP(nil)
+	require.NoError(t, err)
+	require.NoError(t, ep.AddOrgs(statebased.RoleTypePeer, "Org1MSP", "Org2MSP"))
+	policy, err := ep.Policy()
+	require.NoError(t, err)
+	require.NoError(t, stub.SetStateValidationParameter("asset1", policy))
+
+	info, err = qc.GetAssetEndorsementPolicy(ctx, "asset1")
+	require.NoError(t, err)
+	assert.True(t, info.HasKeyLevelPolicy)
+	assert.ElementsMatch(t, []EndorsementPrincipal{
+		{MSPID: "Org1MSP", Role: "PEER"},
+		{MSPID: "Org2MSP", Role: "PEER"},
+	}, info.Principals)
+	assert.Contains(t, info.Rule, "OutOf(2, ")
+
+	_, err = qc.GetAssetEndorsementPolicy(ctx, "missing")
+	assert.Error(t, err)
+}
+
+// TestEndorsingOrgs tests that new assets require their creator's org and that admins can change the orgs
+func TestEndorsingOrgs(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", adminAttrs)
+	cc := NewAssetContract(WithKeyLevelEndorsement())
+	qc := &QueryContract{}
+	ac := &AdminContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+
+	info, err := qc.GetAssetEndorsementPolicy(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, []EndorsementPrincipal{{MSPID: "Org1MSP", Role: "PEER"}}, info.Principals)
+
+	require.NoError(t, ac.AddEndorsingOrg(ctx, "asset1", "Org2MSP"))
+	info, err = qc.GetAssetEndorsementPolicy(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Contains(t, info.Rule, "OutOf(2, ")
+
+	require.NoError(t, ac.RemoveEndorsingOrg(ctx, "asset1", "Org1MSP"))
+	info, err = qc.GetAssetEndorsementPolicy(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, []EndorsementPrincipal{{MSPID: "Org2MSP", Role: "PEER"}}, info.Principals)
+
+	assert.Error(t, ac.RemoveEndorsingOrg(ctx, "asset1", "Org3MSP"))
+	assert.Error(t, ac.RemoveEndorsingOrg(ctx, "asset1", "Org2MSP"), "the last org must stay")
+	assert.Error(t, ac.AddEndorsingOrg(ctx, "missing", "Org2MSP"))
+}
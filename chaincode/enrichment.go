@@ -0,0 +1,137 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+// Valuation bands of the computed ValuationBand of an asset
+const (
+	ValuationLow    = "low"
+	ValuationMedium = "medium"
+	ValuationHigh   = "high"
+)
+
+const (
+	// mediumValuationFrom is the lowest appraised value in the medium band
+	mediumValuationFrom = 500
+	// highValuationFrom is the lowest appraised value in the high band
+	highValuationFrom = 5000
+)
+
+// assetEnricher fills the fields of asset responses that are not stored: the defaults the active
+// asset schema declares for fields a record lacks, and the fields computed from others.
+// Ledger records stay minimal, and records written before a default was introduced read as if
+// they had it.
+type assetEnricher struct {
+	now      time.Time
+	defaults map[string]json.RawMessage
+}
+
+// newAssetEnricher reads the time of clock and the schema defaults once per request
+func newAssetEnricher(ctx contractapi.TransactionContextInterface, clock Clock) (*assetEnricher, error) {
+	now, err := clock.Now(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := schemaDefaults(ctx, AssetDocType)
+	if err != nil {
+		return nil, err
+	}
+	return &assetEnricher{now: now, defaults: defaults}, nil
+}
+
+// withDefaults returns a copy of the asset with the schema defaults of the fields its stored
+// record lacks. A stored zero is a value and is kept; fields encoded with omitempty are absent
+// exactly when they are zero, so only the always-encoded fields decodeAssetRecord reports absent
+// take defaults. A default that does not fit its field leaves the asset without defaults.
+func (e *assetEnricher) withDefaults(asset *Asset) *Asset {
+	enriched := *asset
+	if len(e.defaults) == 0 || len(asset.absent) == 0 {
+		return &enriched
+	}
+	assetBytes, err := json.Marshal(asset)
+	if err != nil {
+		return &enriched
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(assetBytes, &fields); err != nil {
+		return &enriched
+	}
+	for _, name := range asset.absent {
+		if value, ok := e.defaults[name]; ok {
+			fields[name] = value
+		}
+	}
+	assetBytes, err = json.Marshal(fields)
+	if err != nil {
+		return &enriched
+	}
+	var defaulted Asset
+	if err := json.Unmarshal(assetBytes, &defaulted); err != nil {
+		log.Warn().Err(err).Str("assetID", asset.ID).Msg("Schema defaults do not fit the asset")
+		return &enriched
+	}
+	return &defaulted
+}
+
+// compute sets the computed fields of an asset response
+func (e *assetEnricher) compute(asset *Asset) {
+	asset.ValuationBand = valuationBand(asset.AppraisedValue)
+	asset.AgeDays = 0
+	if !asset.CreatedAt.IsZero() && e.now.After(asset.CreatedAt) {
+		asset.AgeDays = int(e.now.Sub(asset.CreatedAt) / (24 * time.Hour))
+	}
+}
+
+// clearComputedFields empties the computed fields, which are never stored
+func clearComputedFields(asset *Asset) {
+	asset.ValuationBand = ""
+	asset.AgeDays = 0
+}
+
+// valuationBand returns the band of an appraised value, empty for none or a masked one
+func valuationBand(appraisedValue int) string {
+	switch {
+	case appraisedValue <= 0:
+		return ""
+	case appraisedValue < mediumValuationFrom:
+		return ValuationLow
+	case appraisedValue < highValuationFrom:
+		return ValuationMedium
+	default:
+		return ValuationHigh
+	}
+}
+
+// schemaDefaults returns the default values of the top-level properties of the active schema of
+// a docType, nil without an approved schema
+func schemaDefaults(ctx contractapi.TransactionContextInterface, docType string) (map[string]json.RawMessage, error) {
+	var version int
+	found, err := getConfig(ctx, &version, schemaConfig, docType)
+	if err != nil || !found {
+		return nil, err
+	}
+	schema, err := getSchema(ctx, docType, version)
+	if err != nil {
+		return nil, err
+	}
+	var document struct {
+		Properties map[string]struct {
+			Default json.RawMessage `json:"default"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(schema.Schema), &document); err != nil {
+		return nil, err
+	}
+	defaults := map[string]json.RawMessage{}
+	for name, property := range document.Properties {
+		if len(property.Default) > 0 {
+			defaults[name] = property.Default
+		}
+	}
+	return defaults, nil
+}
@@ -0,0 +1,106 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TestValuationBand tests the band boundaries of appraised values
+func TestValuationBand(t *testing.T) {
+	assert.Equal(t, "", valuationBand(0))
+	assert.Equal(t, ValuationLow, valuationBand(1))
+	assert.Equal(t, ValuationLow, valuationBand(mediumValuationFrom-1))
+	assert.Equal(t, ValuationMedium, valuationBand(mediumValuationFrom))
+	assert.Equal(t, ValuationHigh, valuationBand(highValuationFrom))
+}
+
+// TestComputedFieldsOnRead tests that reads compute the band and age, which are never stored
+func TestComputedFieldsOnRead(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	stub.TxTimestamp = timestamppb.New(created)
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 700))
+
+	stub.TxTimestamp = timestamppb.New(created.Add(10*24*time.Hour + time.Hour))
+	asset, err := qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, ValuationMedium, asset.ValuationBand)
+	assert.Equal(t, 10, asset.AgeDays)
+
+	stored, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Empty(t, stored.ValuationBand)
+	assert.Zero(t, stored.AgeDays)
+	assert.NotContains(t, string(stub.State[assetKey("asset1")]), "valuationBand")
+
+	// The band would reveal the masked value
+	switchIdentity(t, ctx, stub, "Org1MSP", "auditor1", map[string]string{"role": "auditor"})
+	asset, err = qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Empty(t, asset.ValuationBand)
+	assert.Equal(t, 10, asset.AgeDays)
+}
+
+// TestAgeUsesInjectedClock tests that the computed age follows the contract's clock rather than
+// the transaction timestamp
+func TestAgeUsesInjectedClock(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	cc := NewAssetContract(WithClock(fixedClock(created)))
+	qc := NewQueryContract(WithClock(fixedClock(created.Add(3 * 24 * time.Hour))))
+	stub.TxTimestamp = timestamppb.New(created.Add(-time.Hour))
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 700))
+
+	stub.TxTimestamp = timestamppb.New(created.Add(30 * 24 * time.Hour))
+	asset, err := qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, asset.AgeDays)
+}
+
+// TestSchemaDefaultsOnRead tests that reads fill the fields a stored record lacks from the active
+// schema defaults and keep stored zero values
+func TestSchemaDefaultsOnRead(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin1", adminAttrs)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	config := &ConfigContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 0, "John", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "blue", 4, "John", 100))
+
+	// A record written before the asset had a size
+	legacy := &Asset{DocType: AssetDocType, ID: "asset3", Color: "blue", Owner: "John", AppraisedValue: 100}
+	require.NoError(t, sealAsset(legacy))
+	legacyBytes, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	var legacyFields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(legacyBytes, &legacyFields))
+	delete(legacyFields, "size")
+	legacyBytes, err = json.Marshal(legacyFields)
+	require.NoError(t, err)
+	require.NoError(t, stub.PutState(assetKey("asset3"), legacyBytes))
+
+	require.NoError(t, config.RegisterSchema(ctx, "asset", `{"type":"object","properties":{"size":{"type":"integer","default":3}}}`, 1))
+	switchIdentity(t, ctx, stub, "Org1MSP", "admin2", adminAttrs)
+	require.NoError(t, config.ApproveSchema(ctx, "asset", 1))
+
+	asset, err := qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, asset.Size)
+	asset, err = qc.ReadAsset(ctx, "asset2")
+	require.NoError(t, err)
+	assert.Equal(t, 4, asset.Size)
+	asset, err = qc.ReadAsset(ctx, "asset3")
+	require.NoError(t, err)
+	assert.Equal(t, 3, asset.Size)
+
+	stored, err := getAsset(ctx, "asset3")
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.Size)
+}
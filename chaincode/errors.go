@@ -0,0 +1,69 @@
+package chaincode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error codes returned to clients as the prefix of the error message
+const (
+	// ErrCodeRetryWithPagination means a query exceeded its execution budget and should be
+	// retried through a paginated variant or with a smaller page size.
+	ErrCodeRetryWithPagination = "RETRY_WITH_PAGINATION"
+	// ErrCodeChangesPruned means the requested change records were pruned from the outbox
+	// and the consumer has to resync from a snapshot.
+	ErrCodeChangesPruned = "CHANGES_PRUNED"
+	// ErrCodeAlreadyInitialized means an initialization transaction ran a second time.
+	ErrCodeAlreadyInitialized = "ALREADY_INITIALIZED"
+	// ErrCodeNotInitialized means the chaincode requires Init before any other function.
+	ErrCodeNotInitialized = "NOT_INITIALIZED"
+	// ErrCodeCertificateExpiring means the caller's certificate expires within the rejection
+	// window and has to be renewed.
+	ErrCodeCertificateExpiring = "CERTIFICATE_EXPIRING"
+	// ErrCodeCorruptRecord means a stored record does not match its integrity checksum, e.g.
+	// after a manual state database edit, and has to be repaired.
+	ErrCodeCorruptRecord = "CORRUPT_RECORD"
+	// ErrCodeVersionConflict means an asset changed since the client read it; the client has to
+	// read it again and retry its change.
+	ErrCodeVersionConflict = "VERSION_CONFLICT"
+	// ErrCodeAccessDenied means the caller is not authorized for the operation, e.g. it lacks
+	// the admin role or its organization is offboarding.
+	ErrCodeAccessDenied = "ACCESS_DENIED"
+	// ErrCodeBatchInvalid means items of a batch failed validation. The message ends with a JSON
+	// array of BatchItemError listing every failed item; nothing of the batch was written.
+	ErrCodeBatchInvalid = "BATCH_INVALID"
+	// ErrCodeSchemaViolation means a record does not match the approved JSON Schema of its docType.
+	// The message ends with a JSON array of FieldError locating each invalid field.
+	ErrCodeSchemaViolation = "SCHEMA_VIOLATION"
+	// ErrCodeInputTooLarge means an invocation has more arguments, a larger argument or a larger
+	// payload than the chaincode accepts; the message names the limit.
+	ErrCodeInputTooLarge = "INPUT_TOO_LARGE"
+	// ErrCodeInvariantViolated means the writes of a transaction break a registered invariant,
+	// see InvariantRegistry
+	ErrCodeInvariantViolated = "INVARIANT_VIOLATED"
+	// ErrCodeQuotaExceeded means the caller's organization used up its monthly transaction or
+	// storage quota, see ConfigContract:SetTenantQuota
+	ErrCodeQuotaExceeded = "QUOTA_EXCEEDED"
+)
+
+// ChaincodeError is an error carrying a stable code clients can match on.
+// It is rendered as "<CODE>: <message>" since only the message reaches the client.
+type ChaincodeError struct {
+	Code    string
+	Message string
+}
+
+func (e *ChaincodeError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// newChaincodeError builds a coded error with a formatted message
+func newChaincodeError(code, format string, args ...interface{}) error {
+	return &ChaincodeError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// hasErrorCode reports whether err wraps a ChaincodeError with the given code
+func hasErrorCode(err error, code string) bool {
+	var chaincodeErr *ChaincodeError
+	return errors.As(err, &chaincodeErr) && chaincodeErr.Code == code
+}
@@ -0,0 +1,287 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+// escrowIndex keys an escrow by ID
+const escrowIndex = "escrow~id"
+
+// Escrow statuses
+const (
+	EscrowHeld     = "held"
+	EscrowReleased = "released"
+	EscrowRefunded = "refunded"
+)
+
+// EscrowContract holds assets in escrow for conditional exchanges and is invoked as
+// EscrowContract:<Function>. A deposited asset stays owned by the depositor but is frozen, so
+// the asset contract neither transfers, updates nor deletes it until the escrow is closed.
+// Parties are accounts "<MSP ID>:<enrollment ID>" like in TokenContract.
+type EscrowContract struct {
+	contractapi.Contract
+	contractRuntime
+}
+
+// Escrow is an asset held for a counterparty until it is released to them or refunded to the
+// depositor after the deadline
+type Escrow struct {
+	DocType      string    `json:"docType"`
+	ID           string    `json:"id"`
+	AssetID      string    `json:"assetId"`
+	Depositor    string    `json:"depositor"`
+	DepositorMSP string    `json:"depositorMsp"`
+	Counterparty string    `json:"counterparty"`
+	Arbiter      string    `json:"arbiter,omitempty" metadata:",optional"`
+	Deadline     time.Time `json:"deadline"`
+	Status       string    `json:"status"`
+	DepositedAt  time.Time `json:"depositedAt"`
+	ClosedAt     time.Time `json:"closedAt,omitzero" metadata:",optional"`
+	ClosedBy     string    `json:"closedBy,omitempty" metadata:",optional"`
+}
+
+// Deposit puts an asset owned by the caller in escrow for counterparty until deadline
+// (RFC 3339). arbiter, who may release the asset in place of the counterparty and refund it,
+// is optional.
+func (e *EscrowContract) Deposit(ctx contractapi.TransactionContextInterface, escrowID, assetID, counterparty, arbiter, deadline string) (*Escrow, error) {
+	log.Info().Str("function", "Deposit").Str("escrowID", escrowID).Str("assetID", assetID).Str("counterparty", counterparty).Str("arbiter", arbiter).Str("deadline", deadline).Msg("Depositing asset in escrow")
+
+	mspID, err := assertOrgCanWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if escrowID == "" {
+		return nil, fmt.Errorf("escrowID must not be empty")
+	}
+	existing, err := readEscrow(ctx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("escrow %s already exists", escrowID)
+	}
+	depositor, err := getCallerAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccount(counterparty); err != nil {
+		return nil, err
+	}
+	if arbiter != "" {
+		if err := checkAccount(arbiter); err != nil {
+			return nil, err
+		}
+	}
+	if counterparty == depositor || arbiter == depositor {
+		return nil, fmt.Errorf("counterparty must be another identity and the depositor cannot be the arbiter")
+	}
+	now, err := e.now(ctx)
+	if err != nil {
+		return nil, err
+	}
+	due, err := time.Parse(time.RFC3339, deadline)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deadline %q, expected RFC 3339: %v", deadline, err)
+	}
+	if !due.After(now) {
+		return nil, fmt.Errorf("deadline %s has passed", deadline)
+	}
+
+	asset, err := getAsset(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if !heldByAccount(asset, depositor) {
+		return nil, denyAccess(ctx, "asset owner", "asset %s is not owned by %s", assetID, depositor)
+	}
+	if asset.Frozen {
+		return nil, fmt.Errorf("asset %s is frozen", assetID)
+	}
+	asset.Frozen = true
+	if err := e.saveAsset(ctx, asset); err != nil {
+		return nil, err
+	}
+
+	escrow := &Escrow{
+		DocType:      "escrow",
+		ID:           escrowID,
+		AssetID:      assetID,
+		Depositor:    depositor,
+		DepositorMSP: mspID,
+		Counterparty: counterparty,
+		Arbiter:      arbiter,
+		Deadline:     due.UTC(),
+		Status:       EscrowHeld,
+		DepositedAt:  now,
+	}
+	if err := putEscrow(ctx, escrow); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, EventEscrowDeposited, escrowID, escrow); err != nil {
+		return nil, err
+	}
+	return escrow, nil
+}
+
+// Release transfers the escrowed asset to the counterparty. The counterparty or the arbiter
+// approves it by calling Release before the deadline.
+func (e *EscrowContract) Release(ctx contractapi.TransactionContextInterface, escrowID string) (*Escrow, error) {
+	log.Info().Str("function", "Release").Str("escrowID", escrowID).Msg("Releasing escrow")
+
+	escrow, caller, now, err := e.heldEscrowForCaller(ctx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if caller != escrow.Counterparty && caller != escrow.Arbiter {
+		return nil, denyAccess(ctx, "escrow counterparty or arbiter", "%s may not release escrow %s", caller, escrowID)
+	}
+	if !now.Before(escrow.Deadline) {
+		return nil, fmt.Errorf("escrow %s expired at %s and can only be refunded", escrowID, escrow.Deadline.Format(time.RFC3339))
+	}
+	return e.closeEscrow(ctx, escrow, EscrowReleased, escrow.Counterparty, caller, now)
+}
+
+// Refund returns the escrowed asset to the depositor once the deadline passed without a
+// release. The depositor or the arbiter calls it.
+func (e *EscrowContract) Refund(ctx contractapi.TransactionContextInterface, escrowID string) (*Escrow, error) {
+	log.Info().Str("function", "Refund").Str("escrowID", escrowID).Msg("Refunding escrow")
+
+	escrow, caller, now, err := e.heldEscrowForCaller(ctx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if caller != escrow.Depositor && caller != escrow.Arbiter {
+		return nil, denyAccess(ctx, "escrow depositor or arbiter", "%s may not refund escrow %s", caller, escrowID)
+	}
+	if now.Before(escrow.Deadline) {
+		return nil, fmt.Errorf("escrow %s can only be refunded after %s", escrowID, escrow.Deadline.Format(time.RFC3339))
+	}
+	return e.closeEscrow(ctx, escrow, EscrowRefunded, escrow.Depositor, caller, now)
+}
+
+// GetEscrow returns an escrow. Only its parties, admins and auditors may read it.
+func (e *EscrowContract) GetEscrow(ctx contractapi.TransactionContextInterface, escrowID string) (*Escrow, error) {
+	log.Info().Str("function", "GetEscrow").Str("escrowID", escrowID).Msg("Reading escrow")
+
+	escrow, err := getEscrow(ctx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	readsAll, err := callerReadsAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !readsAll {
+		caller, err := getCallerAccount(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if caller != escrow.Depositor && caller != escrow.Counterparty && caller != escrow.Arbiter {
+			return nil, denyAccess(ctx, "escrow party", "%s is not a party of escrow %s", caller, escrowID)
+		}
+	}
+	return escrow, nil
+}
+
+// heldEscrowForCaller reads an escrow that is still held, the caller's account and the time of
+// the contract's clock
+func (e *EscrowContract) heldEscrowForCaller(ctx contractapi.TransactionContextInterface, escrowID string) (*Escrow, string, time.Time, error) {
+	escrow, err := getEscrow(ctx, escrowID)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	if escrow.Status != EscrowHeld {
+		return nil, "", time.Time{}, fmt.Errorf("escrow %s is already %s", escrowID, escrow.Status)
+	}
+	caller, err := getCallerAccount(ctx)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	now, err := e.now(ctx)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	return escrow, caller, now, nil
+}
+
+// closeEscrow unfreezes the escrowed asset with the account owner as its owner and records the
+// outcome
+func (e *EscrowContract) closeEscrow(ctx contractapi.TransactionContextInterface, escrow *Escrow, status, owner, caller string, now time.Time) (*Escrow, error) {
+	asset, err := getAsset(ctx, escrow.AssetID)
+	if err != nil {
+		return nil, err
+	}
+	if !heldByAccount(asset, escrow.Depositor) {
+		return nil, fmt.Errorf("asset %s changed owner while in escrow %s", escrow.AssetID, escrow.ID)
+	}
+	asset.OwnerMSP, asset.Owner = splitAccount(owner)
+	asset.Frozen = false
+	if err := e.saveAsset(ctx, asset); err != nil {
+		return nil, err
+	}
+
+	escrow.Status = status
+	escrow.ClosedAt = now
+	escrow.ClosedBy = caller
+	if err := putEscrow(ctx, escrow); err != nil {
+		return nil, err
+	}
+	event := EventEscrowReleased
+	if status == EscrowRefunded {
+		event = EventEscrowRefunded
+	}
+	if err := emitEvent(ctx, event, escrow.ID, escrow); err != nil {
+		return nil, err
+	}
+	log.Info().Str("escrowID", escrow.ID).Str("assetID", escrow.AssetID).Str("status", status).Str("owner", owner).Msg("Escrow closed")
+	return escrow, nil
+}
+
+// readEscrow reads an escrow, nil when it does not exist
+func readEscrow(ctx contractapi.TransactionContextInterface, escrowID string) (*Escrow, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(escrowIndex, []string{escrowID})
+	if err != nil {
+		return nil, err
+	}
+	escrowBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read escrow %s: %v", escrowID, err)
+	}
+	if escrowBytes == nil {
+		return nil, nil
+	}
+	var escrow Escrow
+	if err := json.Unmarshal(escrowBytes, &escrow); err != nil {
+		return nil, err
+	}
+	return &escrow, nil
+}
+
+// getEscrow reads an escrow, failing when it does not exist
+func getEscrow(ctx contractapi.TransactionContextInterface, escrowID string) (*Escrow, error) {
+	escrow, err := readEscrow(ctx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if escrow == nil {
+		return nil, fmt.Errorf("escrow %s does not exist", escrowID)
+	}
+	return escrow, nil
+}
+
+func putEscrow(ctx contractapi.TransactionContextInterface, escrow *Escrow) error {
+	key, err := ctx.GetStub().CreateCompositeKey(escrowIndex, []string{escrow.ID})
+	if err != nil {
+		return err
+	}
+	escrowBytes, err := json.Marshal(escrow)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, escrowBytes)
+}
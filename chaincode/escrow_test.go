@@ -0,0 +1,126 @@
+package chaincode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TestEscrowRelease tests that an escrowed asset is frozen until the counterparty releases it
+func TestEscrowRelease(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "alice", nil)
+	deposited := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	ac := NewAssetContract()
+	ec := NewEscrowContract(WithClock(fixedClock(deposited)))
+	require.NoError(t, ac.CreateAsset(ctx, "asset1", "blue", 5, "alice", 100))
+
+	deadline := deposited.Add(time.Hour).Format(time.RFC3339)
+	_, err := ec.Deposit(ctx, "escrow1", "asset1", "Org1MSP:alice", "", deadline)
+	assert.Error(t, err)
+	_, err = ec.Deposit(ctx, "escrow1", "asset1", "bob", "", deadline)
+	assert.ErrorContains(t, err, "must be of the form")
+	_, err = ec.Deposit(ctx, "escrow1", "asset1", "Org2MSP:bob", "", "2000-01-01T00:00:00Z")
+	assert.ErrorContains(t, err, "has passed")
+
+	escrow, err := ec.Deposit(ctx, "escrow1", "asset1", "Org2MSP:bob", "Org1MSP:carol", deadline)
+	require.NoError(t, err)
+	assert.Equal(t, EscrowHeld, escrow.Status)
+	assert.Equal(t, "Org1MSP:alice", escrow.Depositor)
+	assert.Equal(t, deposited, escrow.DepositedAt)
+	assert.Equal(t, EventEscrowDeposited, lastEvent(t, stub).EventName)
+	_, err = ec.Deposit(ctx, "escrow1", "asset1", "Org2MSP:bob", "", deadline)
+	assert.ErrorContains(t, err, "already exists")
+
+	stub.MockTransactionStart("tx2")
+	assert.ErrorContains(t, ac.TransferAsset(ctx, "asset1", "mallory", 0), "frozen")
+	_, err = ec.Refund(ctx, "escrow1")
+	assert.ErrorContains(t, err, "only be refunded after")
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "mallory", nil)
+	_, err = ec.Release(ctx, "escrow1")
+	assert.Error(t, err)
+	_, err = ec.GetEscrow(ctx, "escrow1")
+	assert.Error(t, err)
+
+	// The same enrollment ID in another organization is another party
+	switchIdentity(t, ctx, stub, "Org1MSP", "bob", nil)
+	_, err = ec.Release(ctx, "escrow1")
+	assert.Error(t, err)
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "bob", nil)
+	escrow, err = ec.Release(ctx, "escrow1")
+	require.NoError(t, err)
+	assert.Equal(t, EscrowReleased, escrow.Status)
+	assert.Equal(t, "Org2MSP:bob", escrow.ClosedBy)
+	assert.Equal(t, deposited, escrow.ClosedAt)
+	assert.Equal(t, EventEscrowReleased, lastEvent(t, stub).EventName)
+
+	asset, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", asset.Owner)
+	assert.Equal(t, "Org2MSP", asset.OwnerMSP)
+	assert.Equal(t, deposited, asset.UpdatedAt)
+	assert.False(t, asset.Frozen)
+
+	_, err = ec.Release(ctx, "escrow1")
+	assert.ErrorContains(t, err, "already released")
+}
+
+// TestEscrowRefund tests that the depositor gets the asset back only after the deadline
+func TestEscrowRefund(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "alice", nil)
+	ac := NewAssetContract()
+	ec := &EscrowContract{}
+	require.NoError(t, ac.CreateAsset(ctx, "asset1", "blue", 5, "alice", 100))
+
+	due := time.Now().Add(time.Hour).UTC()
+	_, err := ec.Deposit(ctx, "escrow1", "asset1", "Org2MSP:bob", "", due.Format(time.RFC3339))
+	require.NoError(t, err)
+
+	stub.MockTransactionStart("tx2")
+	stub.TxTimestamp = timestamppb.New(due.Add(time.Minute))
+	switchIdentity(t, ctx, stub, "Org2MSP", "bob", nil)
+	_, err = ec.Release(ctx, "escrow1")
+	assert.ErrorContains(t, err, "can only be refunded")
+	_, err = ec.Refund(ctx, "escrow1")
+	assert.Error(t, err)
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "alice", nil)
+	_, err = ec.Refund(ctx, "escrow1")
+	assert.Error(t, err)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "alice", nil)
+	escrow, err := ec.Refund(ctx, "escrow1")
+	require.NoError(t, err)
+	assert.Equal(t, EscrowRefunded, escrow.Status)
+	assert.Equal(t, EventEscrowRefunded, lastEvent(t, stub).EventName)
+
+	asset, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", asset.Owner)
+	assert.Equal(t, "Org1MSP", asset.OwnerMSP)
+	assert.False(t, asset.Frozen)
+}
+
+// TestEscrowOfTransferredAsset tests that the holder of an asset received from another
+// organization may escrow it, and a same-named identity of the creating organization may not
+func TestEscrowOfTransferredAsset(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "alice", nil)
+	ac := NewAssetContract()
+	ec := &EscrowContract{}
+	require.NoError(t, ac.CreateAsset(ctx, "asset1", "blue", 5, "alice", 100))
+	require.NoError(t, ac.TransferAsset(ctx, "asset1", "Org2MSP:dave", 0))
+
+	deadline := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	switchIdentity(t, ctx, stub, "Org1MSP", "dave", nil)
+	_, err := ec.Deposit(ctx, "escrow1", "asset1", "Org1MSP:alice", "", deadline)
+	assert.True(t, hasErrorCode(err, ErrCodeAccessDenied))
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "dave", nil)
+	escrow, err := ec.Deposit(ctx, "escrow1", "asset1", "Org1MSP:alice", "", deadline)
+	require.NoError(t, err)
+	assert.Equal(t, "Org2MSP:dave", escrow.Depositor)
+}
@@ -0,0 +1,129 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// eventFormatConfig is the configuration key of the chaincode event payload format
+	eventFormatConfig = "eventformat"
+	// EventFormatPlain emits the event data as the payload, the default
+	EventFormatPlain = "plain"
+	// EventFormatCloudEvents wraps the event data in a CloudEvents 1.0 JSON envelope
+	EventFormatCloudEvents = "cloudevents"
+	// EventFormatAssetTransfer sets single-asset events with the names and payloads of the
+	// Fabric asset-transfer-events sample; other events stay plain
+	EventFormatAssetTransfer = "asset-transfer-events"
+	// cloudEventTypePrefix namespaces event names in the CloudEvents type attribute
+	cloudEventTypePrefix = "io.chainlaunch.chaincode."
+)
+
+// EventFormat selects how chaincode event payloads are encoded
+type EventFormat struct {
+	Format string `json:"format"`
+	Source string `json:"source,omitempty" metadata:",optional"`
+}
+
+// CloudEvent is a CloudEvents 1.0 envelope in the JSON event format
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty" metadata:",optional"`
+	Time            string          `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// SetEventFormat switches chaincode event payloads between plain JSON, CloudEvents envelopes and
+// the asset-transfer-events sample. source overrides the CloudEvents source attribute, which
+// defaults to /channels/<channel>.
+func (c *ConfigContract) SetEventFormat(ctx contractapi.TransactionContextInterface, format, source string) error {
+	log.Info().Str("function", "SetEventFormat").Str("format", format).Str("source", source).Msg("Setting event format")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if format != EventFormatPlain && format != EventFormatCloudEvents && format != EventFormatAssetTransfer {
+		return fmt.Errorf("event format must be %s, %s or %s", EventFormatPlain, EventFormatCloudEvents, EventFormatAssetTransfer)
+	}
+	if err := putConfig(ctx, &EventFormat{Format: format, Source: source}, eventFormatConfig); err != nil {
+		return err
+	}
+	return recordAudit(ctx, "SetEventFormat", format, source)
+}
+
+// GetEventFormat returns the configured chaincode event payload format
+func (c *ConfigContract) GetEventFormat(ctx contractapi.TransactionContextInterface) (*EventFormat, error) {
+	log.Info().Str("function", "GetEventFormat").Msg("Reading event format")
+	return getEventFormat(ctx)
+}
+
+func getEventFormat(ctx contractapi.TransactionContextInterface) (*EventFormat, error) {
+	format := &EventFormat{}
+	found, err := getConfig(ctx, format, eventFormatConfig)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &EventFormat{Format: EventFormatPlain}, nil
+	}
+	return format, nil
+}
+
+// emitEvent sets the chaincode event of the transaction in the configured format.
+// subject identifies the record the event is about, e.g. an asset ID or MSP ID.
+func emitEvent(ctx contractapi.TransactionContextInterface, name, subject string, data interface{}) error {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	format, err := getEventFormat(ctx)
+	if err != nil {
+		return err
+	}
+	payload := dataBytes
+	if format.Format == EventFormatCloudEvents {
+		payload, err = newCloudEvent(ctx, format, name, subject, dataBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.GetStub().SetEvent(name, payload); err != nil {
+		log.Error().Err(err).Str("event", name).Str("subject", subject).Msg("Failed to set chaincode event")
+		return err
+	}
+	return nil
+}
+
+// newCloudEvent wraps event data in a CloudEvents envelope. The transaction ID and timestamp
+// are used for id and time so every endorser produces the same payload.
+func newCloudEvent(ctx contractapi.TransactionContextInterface, format *EventFormat, name, subject string, data []byte) ([]byte, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	source := format.Source
+	if source == "" {
+		source = "/channels/" + ctx.GetStub().GetChannelID()
+	}
+
+	return json.Marshal(&CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              ctx.GetStub().GetTxID(),
+		Source:          source,
+		Type:            cloudEventTypePrefix + name,
+		Subject:         subject,
+		Time:            timestamp.AsTime().UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		Data:            data,
+	})
+}
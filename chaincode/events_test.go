@@ -0,0 +1,41 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEmitEventCloudEvents tests that events switch from plain payloads to CloudEvents envelopes
+func TestEmitEventCloudEvents(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	config := &ConfigContract{}
+
+	require.NoError(t, emitEvent(ctx, "AssetCreated", "asset1", map[string]string{"id": "asset1"}))
+	event := <-stub.ChaincodeEventsChannel
+	assert.JSONEq(t, `{"id":"asset1"}`, string(event.Payload))
+
+	assert.Error(t, config.SetEventFormat(ctx, "xml", ""))
+	require.NoError(t, config.SetEventFormat(ctx, EventFormatCloudEvents, ""))
+	require.NoError(t, emitEvent(ctx, "AssetCreated", "asset1", map[string]string{"id": "asset1"}))
+	event = <-stub.ChaincodeEventsChannel
+	assert.Equal(t, "AssetCreated", event.EventName)
+
+	var envelope CloudEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &envelope))
+	assert.Equal(t, "1.0", envelope.SpecVersion)
+	assert.Equal(t, "tx1", envelope.ID)
+	assert.Equal(t, "io.chainlaunch.chaincode.AssetCreated", envelope.Type)
+	assert.Equal(t, "/channels/"+stub.GetChannelID(), envelope.Source)
+	assert.Equal(t, "asset1", envelope.Subject)
+	assert.Equal(t, "application/json", envelope.DataContentType)
+	assert.JSONEq(t, `{"id":"asset1"}`, string(envelope.Data))
+
+	require.NoError(t, config.SetEventFormat(ctx, EventFormatCloudEvents, "urn:example:assets"))
+	require.NoError(t, emitEvent(ctx, "AssetCreated", "asset1", nil))
+	event = <-stub.ChaincodeEventsChannel
+	require.NoError(t, json.Unmarshal(event.Payload, &envelope))
+	assert.Equal(t, "urn:example:assets", envelope.Source)
+}
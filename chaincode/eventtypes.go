@@ -0,0 +1,196 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Chaincode event names. Fabric keeps only the last event set in a transaction, so functions
+// that change several assets set one event listing all of them.
+const (
+	EventAssetCreated          = "AssetCreated"
+	EventAssetUpdated          = "AssetUpdated"
+	EventAssetTransferred      = "AssetTransferred"
+	EventAssetDeleted          = "AssetDeleted"
+	EventAssetMoved            = "AssetMoved"
+	EventAssetRestored         = "AssetRestored"
+	EventAssetPurged           = "AssetPurged"
+	EventTransferProposed      = "TransferProposed"
+	EventTransferAccepted      = "TransferAccepted"
+	EventTransferRejected      = "TransferRejected"
+	EventAssetPurchased        = "AssetPurchased"
+	EventSLABreached           = "SLABreached"
+	EventOrgRegistered         = "OrgRegistered"
+	EventOrgOffboardingStarted = "OrgOffboardingStarted"
+	EventOrgOffboardingBatch   = "OrgOffboardingBatch"
+	EventOrgOffboarded         = "OrgOffboarded"
+	EventTokenTransfer         = "TokenTransfer"
+	EventTokenApproval         = "TokenApproval"
+	EventNFTTransfer           = "NFTTransfer"
+	EventNFTApproval           = "NFTApproval"
+	EventNFTApprovalForAll     = "NFTApprovalForAll"
+	EventEscrowDeposited       = "EscrowDeposited"
+	EventEscrowReleased        = "EscrowReleased"
+	EventEscrowRefunded        = "EscrowRefunded"
+)
+
+// EventType documents a chaincode event and the payload it carries
+type EventType struct {
+	Name        string `json:"name"`
+	Payload     string `json:"payload"`
+	Description string `json:"description"`
+}
+
+// EventTypes is the registry of the events set by the contracts. Payloads are JSON, wrapped in a
+// CloudEvents envelope when ConfigContract:SetEventFormat selects it; the protobuf function
+// variants set AssetCreated and AssetTransferred with an assetpb.AssetEvent payload instead.
+// The asset-transfer-events format renames the single-asset events, see assetTransferEventNames.
+var EventTypes = []EventType{
+	{Name: EventAssetCreated, Payload: "AssetEvent", Description: "assets were created by CreateAsset, ImportAssets or InitLedger"},
+	{Name: EventAssetUpdated, Payload: "AssetEvent", Description: "an asset's fields were replaced by UpdateAsset"},
+	{Name: EventAssetTransferred, Payload: "AssetEvent", Description: "assets changed owner by TransferAsset, TransferAssetByColor, TransferAssetSecured, SwapAssets or SwapAssetPair"},
+	{Name: EventAssetDeleted, Payload: "AssetEvent", Description: "an asset was deleted by DeleteAsset, or soft-deleted with WithSoftDelete"},
+	{Name: EventAssetMoved, Payload: "AssetEvent", Description: "assets were re-keyed by MoveAssetSubtree, assetIds lists the old IDs"},
+	{Name: EventAssetRestored, Payload: "AssetEvent", Description: "a soft-deleted asset was restored by RestoreAsset"},
+	{Name: EventAssetPurged, Payload: "AssetEvent", Description: "a soft-deleted asset was removed for good by PurgeAsset"},
+	{Name: EventTransferProposed, Payload: "PendingTransfer", Description: "an owner proposed a two-phase transfer with ProposeTransfer"},
+	{Name: EventTransferAccepted, Payload: "PendingTransfer", Description: "the recipient accepted a transfer and now owns the asset"},
+	{Name: EventTransferRejected, Payload: "PendingTransfer", Description: "the recipient rejected a transfer, the owner is unchanged"},
+	{Name: EventAssetPurchased, Payload: "OperationReceipt", Description: "an asset was transferred against a token payment by TransferAssetWithToken, replacing AssetTransferred"},
+	{Name: EventSLABreached, Payload: "SLABreachReport", Description: "CheckSLABreaches flagged overdue SLA timers"},
+	{Name: EventOrgRegistered, Payload: "organization status", Description: "an organization was registered"},
+	{Name: EventOrgOffboardingStarted, Payload: "organization status", Description: "offboarding of an organization started"},
+	{Name: EventOrgOffboardingBatch, Payload: "organization status", Description: "a batch of an organization's assets was offboarded"},
+	{Name: EventOrgOffboarded, Payload: "organization status", Description: "every asset of an organization was offboarded"},
+	{Name: EventTokenTransfer, Payload: "TokenTransferEvent", Description: "tokens were minted, burned or transferred by TokenContract"},
+	{Name: EventTokenApproval, Payload: "TokenApprovalEvent", Description: "an owner approved a spender with TokenContract:Approve"},
+	{Name: EventNFTTransfer, Payload: "NFTTransferEvent", Description: "a non-fungible token was minted, burned or transferred by NFTContract"},
+	{Name: EventNFTApproval, Payload: "NFTApprovalEvent", Description: "an account was approved for a token with NFTContract:Approve"},
+	{Name: EventNFTApprovalForAll, Payload: "NFTApprovalForAllEvent", Description: "an owner set an operator with NFTContract:SetApprovalForAll"},
+	{Name: EventEscrowDeposited, Payload: "Escrow", Description: "an asset was frozen in escrow by EscrowContract:Deposit"},
+	{Name: EventEscrowReleased, Payload: "Escrow", Description: "an escrowed asset was transferred to the counterparty by EscrowContract:Release"},
+	{Name: EventEscrowRefunded, Payload: "Escrow", Description: "an escrowed asset was returned to the depositor by EscrowContract:Refund"},
+	{Name: "CreateAsset", Payload: "sample asset", Description: "AssetCreated of CreateAsset in the asset-transfer-events format"},
+	{Name: "UpdateAsset", Payload: "sample asset", Description: "AssetUpdated in the asset-transfer-events format"},
+	{Name: "TransferAsset", Payload: "sample asset", Description: "AssetTransferred of TransferAsset in the asset-transfer-events format"},
+	{Name: "DeleteAsset", Payload: "sample asset", Description: "AssetDeleted in the asset-transfer-events format, with the asset before deletion"},
+}
+
+// assetEventActions maps the asset event names to the action in their payload
+var assetEventActions = map[string]string{
+	EventAssetCreated:     "create",
+	EventAssetUpdated:     "update",
+	EventAssetTransferred: "transfer",
+	EventAssetDeleted:     "delete",
+	EventAssetMoved:       "move",
+	EventAssetRestored:    "restore",
+	EventAssetPurged:      "purge",
+}
+
+// assetTransferEventNames maps the single-asset events to their names in the Fabric
+// asset-transfer-events sample
+var assetTransferEventNames = map[string]string{
+	EventAssetCreated:     "CreateAsset",
+	EventAssetUpdated:     "UpdateAsset",
+	EventAssetTransferred: "TransferAsset",
+	EventAssetDeleted:     "DeleteAsset",
+}
+
+// sampleAsset is the asset payload of the asset-transfer-events sample, whose listeners read
+// the capitalized field names
+type sampleAsset struct {
+	ID             string `json:"ID"`
+	Color          string `json:"Color"`
+	Size           int    `json:"Size"`
+	Owner          string `json:"Owner"`
+	AppraisedValue int    `json:"AppraisedValue"`
+}
+
+// AssetEvent is the payload of the asset events. AssetID is set when one asset changed and
+// AssetIDs when a function changed several. Subscribers lists the identities watching the
+// assets, up to maxEventSubscribers of SubscriberCount.
+type AssetEvent struct {
+	Action          string    `json:"action"`
+	AssetID         string    `json:"assetId,omitempty" metadata:",optional"`
+	AssetIDs        []string  `json:"assetIds,omitempty" metadata:",optional"`
+	Actor           string    `json:"actor"`
+	ActorMSP        string    `json:"actorMsp"`
+	TxID            string    `json:"txId"`
+	Timestamp       time.Time `json:"timestamp"`
+	Subscribers     []string  `json:"subscribers,omitempty" metadata:",optional"`
+	SubscriberCount int       `json:"subscriberCount,omitempty" metadata:",optional"`
+}
+
+// GetEventTypes returns the registry of chaincode events, so clients can discover what to listen for
+func (q *QueryContract) GetEventTypes(ctx contractapi.TransactionContextInterface) []EventType {
+	q.logger(ctx).Info().Str("function", "GetEventTypes").Msg("Reading event types")
+	return EventTypes
+}
+
+// emitAssetChange sets the event of a function that changed one asset. With the
+// asset-transfer-events format the event is named after the function, e.g. TransferAsset, and
+// carries the asset as the sample does; deletions carry the asset as it was before.
+func (r *contractRuntime) emitAssetChange(ctx contractapi.TransactionContextInterface, name string, asset *Asset) error {
+	if r.skipEvents {
+		return nil
+	}
+	format, err := getEventFormat(ctx)
+	if err != nil {
+		return err
+	}
+	sampleName, ok := assetTransferEventNames[name]
+	if format.Format != EventFormatAssetTransfer || !ok {
+		return r.emitAssetEvent(ctx, name, asset.ID)
+	}
+	payload, err := json.Marshal(&sampleAsset{
+		ID:             asset.ID,
+		Color:          asset.Color,
+		Size:           asset.Size,
+		Owner:          asset.Owner,
+		AppraisedValue: asset.AppraisedValue,
+	})
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().SetEvent(sampleName, payload); err != nil {
+		r.logger(ctx).Error().Err(err).Str("event", sampleName).Msg("Failed to set chaincode event")
+		return err
+	}
+	return nil
+}
+
+// emitAssetEvent sets an asset event naming the changed assets, unless events are disabled
+func (r *contractRuntime) emitAssetEvent(ctx contractapi.TransactionContextInterface, name string, assetIDs ...string) error {
+	if r.skipEvents || len(assetIDs) == 0 {
+		return nil
+	}
+	mspID, actor, err := getCallerSubmitter(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := r.now(ctx)
+	if err != nil {
+		return err
+	}
+	event := &AssetEvent{
+		Action:    assetEventActions[name],
+		Actor:     actor,
+		ActorMSP:  mspID,
+		TxID:      ctx.GetStub().GetTxID(),
+		Timestamp: now,
+	}
+	event.Subscribers, event.SubscriberCount, err = eventSubscribers(ctx, assetIDs)
+	if err != nil {
+		return err
+	}
+	subject := ""
+	if len(assetIDs) == 1 {
+		event.AssetID = assetIDs[0]
+		subject = assetIDs[0]
+	} else {
+		event.AssetIDs = assetIDs
+	}
+	return emitEvent(ctx, name, subject, event)
+}
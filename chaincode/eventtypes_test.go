@@ -0,0 +1,91 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAssetEvents tests that asset mutations set events with the standardized payload
+func TestAssetEvents(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+
+	assertEvent := func(name, action, assetID string) *AssetEvent {
+		t.Helper()
+		event := lastEvent(t, stub)
+		assert.Equal(t, name, event.EventName)
+		var payload AssetEvent
+		require.NoError(t, json.Unmarshal(event.Payload, &payload))
+		assert.Equal(t, action, payload.Action)
+		assert.Equal(t, assetID, payload.AssetID)
+		assert.Equal(t, "user1", payload.Actor)
+		assert.Equal(t, "Org1MSP", payload.ActorMSP)
+		assert.Equal(t, "tx1", payload.TxID)
+		assert.False(t, payload.Timestamp.IsZero())
+		return &payload
+	}
+
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	assertEvent(EventAssetCreated, "create", "asset1")
+	require.NoError(t, cc.UpdateAsset(ctx, "asset1", "red", 6, "John", 200, 0))
+	assertEvent(EventAssetUpdated, "update", "asset1")
+	require.NoError(t, cc.TransferAsset(ctx, "asset1", "Jane", 0))
+	assertEvent(EventAssetTransferred, "transfer", "asset1")
+
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "red", 5, "John", 100))
+	require.NoError(t, cc.TransferAssetByColor(ctx, "red", "Max"))
+	payload := assertEvent(EventAssetTransferred, "transfer", "")
+	assert.Equal(t, []string{"asset1", "asset2"}, payload.AssetIDs)
+
+	require.NoError(t, cc.DeleteAsset(ctx, "asset1"))
+	assertEvent(EventAssetDeleted, "delete", "asset1")
+
+	cc = NewAssetContract(WithEvents(false))
+	require.NoError(t, cc.CreateAsset(ctx, "asset3", "blue", 5, "John", 100))
+	assert.Empty(t, stub.ChaincodeEventsChannel)
+}
+
+// TestEventTypes tests that the registry lists each event once and covers every asset event
+func TestEventTypes(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	qc := &QueryContract{}
+
+	names := map[string]bool{}
+	for _, eventType := range qc.GetEventTypes(ctx) {
+		assert.False(t, names[eventType.Name], "event %s is registered twice", eventType.Name)
+		names[eventType.Name] = true
+		assert.NotEmpty(t, eventType.Description)
+	}
+	for name := range assetEventActions {
+		assert.True(t, names[name], "asset event %s is not registered", name)
+	}
+}
+
+// TestAssetTransferEventFormat tests that single-asset events match the asset-transfer-events sample
+func TestAssetTransferEventFormat(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	cc := &AssetContract{}
+	config := &ConfigContract{}
+	require.NoError(t, config.SetEventFormat(ctx, EventFormatAssetTransfer, ""))
+
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "Tom", 100))
+	event := lastEvent(t, stub)
+	assert.Equal(t, "CreateAsset", event.EventName)
+	assert.JSONEq(t, `{"ID":"asset1","Color":"blue","Size":5,"Owner":"Tom","AppraisedValue":100}`, string(event.Payload))
+
+	require.NoError(t, cc.UpdateAsset(ctx, "asset1", "red", 6, "Tom", 200, 0))
+	assert.Equal(t, "UpdateAsset", lastEvent(t, stub).EventName)
+
+	require.NoError(t, cc.TransferAsset(ctx, "asset1", "Ann", 0))
+	event = lastEvent(t, stub)
+	assert.Equal(t, "TransferAsset", event.EventName)
+	assert.JSONEq(t, `{"ID":"asset1","Color":"red","Size":6,"Owner":"Ann","AppraisedValue":200}`, string(event.Payload))
+
+	require.NoError(t, cc.DeleteAsset(ctx, "asset1"))
+	event = lastEvent(t, stub)
+	assert.Equal(t, "DeleteAsset", event.EventName)
+	assert.Contains(t, string(event.Payload), `"Owner":"Ann"`)
+}
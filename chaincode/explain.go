@@ -0,0 +1,309 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CouchDBIndexDir is where Fabric reads the CouchDB indexes of a chaincode package from
+const CouchDBIndexDir = "META-INF/statedb/couchdb/indexes"
+
+// CouchDBIndex is a CouchDB index definition in the layout of the index files Fabric installs
+type CouchDBIndex struct {
+	Index     CouchDBIndexFields `json:"index"`
+	DesignDoc string             `json:"ddoc"`
+	Name      string             `json:"name"`
+	Type      string             `json:"type"`
+}
+
+// CouchDBIndexFields are the fields a CouchDB index is built on, in order
+type CouchDBIndexFields struct {
+	Fields []string `json:"fields"`
+}
+
+// couchDBIndexes are the indexes cmd/package installs with the chaincode and ExplainQuery
+// matches queries against. Every query the chaincode builds itself has one.
+var couchDBIndexes = []CouchDBIndex{
+	{Index: CouchDBIndexFields{Fields: []string{"docType", "owner"}}, DesignDoc: "indexOwnerDoc", Name: "indexOwner", Type: "json"},
+	{Index: CouchDBIndexFields{Fields: []string{"docType", "color"}}, DesignDoc: "indexColorDoc", Name: "indexColor", Type: "json"},
+}
+
+// CouchDBIndexes returns the CouchDB indexes of the chaincode
+func CouchDBIndexes() []CouchDBIndex {
+	return slices.Clone(couchDBIndexes)
+}
+
+// FileName returns the name of the index file under CouchDBIndexDir
+func (i CouchDBIndex) FileName() string {
+	return i.Name + ".json"
+}
+
+// rangeOperators narrow the key range a CouchDB index scans. Other operators on an indexed field
+// still select the index but filter every document it holds.
+var rangeOperators = map[string]bool{"$eq": true, "$gt": true, "$gte": true, "$lt": true, "$lte": true}
+
+// QueryExplanation tells which CouchDB index would serve a rich query
+type QueryExplanation struct {
+	Query       string   `json:"query"`
+	Scoped      bool     `json:"scoped"`
+	Fields      []string `json:"fields"`
+	Index       string   `json:"index,omitempty" metadata:",optional"`
+	IndexFields []string `json:"indexFields,omitempty" metadata:",optional"`
+	Warnings    []string `json:"warnings,omitempty" metadata:",optional"`
+}
+
+// ExplainQuery returns the normalized form of a rich query, or of a bare selector, as it would
+// run for the caller, and the chaincode's CouchDB index that would serve it. Queries served by
+// no index scan every document and carry a warning. Nothing is read from the ledger, so it also
+// works on LevelDB.
+func (q *QueryContract) ExplainQuery(ctx contractapi.TransactionContextInterface, queryString string) (*QueryExplanation, error) {
+	q.logger(ctx).Info().Str("function", "ExplainQuery").Str("queryString", queryString).Msg("Explaining query")
+
+	var query map[string]interface{}
+	if err := json.Unmarshal([]byte(queryString), &query); err != nil {
+		return nil, fmt.Errorf("invalid query string: %v", err)
+	}
+	if _, ok := query["selector"]; !ok {
+		query = map[string]interface{}{"selector": query}
+	}
+	wrapped, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	scoped, err := q.scopeQuery(ctx, string(wrapped))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scoped), &query); err != nil {
+		return nil, err
+	}
+	selector, ok := query["selector"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("selector must be a JSON object")
+	}
+	query["selector"] = normalizeSelector(selector)
+	normalized, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := explainSelector(query["selector"].(map[string]interface{}), query)
+	explanation.Query = string(normalized)
+	explanation.Scoped = scoped != string(wrapped)
+	q.logger(ctx).Info().Str("index", explanation.Index).Int("warnings", len(explanation.Warnings)).Msg("Query explained")
+	return explanation, nil
+}
+
+// normalizeSelector rewrites a selector the way CouchDB does before planning: nested field
+// objects become dotted paths, implicit equality becomes $eq and nested $and clauses are merged
+// into the top level where their fields don't clash
+func normalizeSelector(selector map[string]interface{}) map[string]interface{} {
+	normalized := map[string]interface{}{}
+	var leftover []interface{}
+	merge := func(field string, condition interface{}) {
+		existing, ok := normalized[field]
+		if !ok {
+			normalized[field] = condition
+			return
+		}
+		existingOps, okExisting := existing.(map[string]interface{})
+		ops, okOps := condition.(map[string]interface{})
+		if okExisting && okOps {
+			for op := range ops {
+				if _, clash := existingOps[op]; clash {
+					leftover = append(leftover, map[string]interface{}{field: condition})
+					return
+				}
+			}
+			for op, value := range ops {
+				existingOps[op] = value
+			}
+			return
+		}
+		leftover = append(leftover, map[string]interface{}{field: condition})
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(selector)) {
+		value := selector[key]
+		switch {
+		case key == "$and":
+			clauses, _ := value.([]interface{})
+			for _, clause := range clauses {
+				clauseSelector, ok := clause.(map[string]interface{})
+				if !ok {
+					leftover = append(leftover, clause)
+					continue
+				}
+				clauseConditions := normalizeSelector(clauseSelector)
+				for _, field := range slices.Sorted(maps.Keys(clauseConditions)) {
+					condition := clauseConditions[field]
+					if field == "$and" {
+						leftover = append(leftover, condition.([]interface{})...)
+						continue
+					}
+					merge(field, condition)
+				}
+			}
+		case strings.HasPrefix(key, "$"):
+			normalized[key] = normalizeOperand(value)
+		default:
+			conditions := fieldConditions(key, value)
+			for _, field := range slices.Sorted(maps.Keys(conditions)) {
+				merge(field, conditions[field])
+			}
+		}
+	}
+	if len(leftover) > 0 {
+		normalized["$and"] = leftover
+	}
+	return normalized
+}
+
+// normalizeOperand normalizes the selectors inside a combination operator such as $or
+func normalizeOperand(value interface{}) interface{} {
+	switch operand := value.(type) {
+	case []interface{}:
+		normalized := make([]interface{}, len(operand))
+		for i, clause := range operand {
+			normalized[i] = normalizeOperand(clause)
+		}
+		return normalized
+	case map[string]interface{}:
+		return normalizeSelector(operand)
+	}
+	return value
+}
+
+// fieldConditions returns the operator conditions of a field, flattening subfield objects
+func fieldConditions(field string, value interface{}) map[string]interface{} {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{field: map[string]interface{}{"$eq": value}}
+	}
+	conditions := map[string]interface{}{}
+	operators := map[string]interface{}{}
+	for key, nested := range object {
+		if strings.HasPrefix(key, "$") {
+			operators[key] = nested
+			continue
+		}
+		for subfield, condition := range fieldConditions(field+"."+key, nested) {
+			conditions[subfield] = condition
+		}
+	}
+	if len(operators) > 0 || len(object) == 0 {
+		conditions[field] = operators
+	}
+	return conditions
+}
+
+// explainSelector picks the index CouchDB would use for a normalized selector: a usable index
+// has every one of its fields required by the selector, use_index is honored when usable, and
+// otherwise the index covering the most fields wins
+func explainSelector(selector map[string]interface{}, query map[string]interface{}) *QueryExplanation {
+	explanation := &QueryExplanation{Fields: []string{}}
+	required := map[string]bool{}
+	for _, field := range slices.Sorted(maps.Keys(selector)) {
+		if strings.HasPrefix(field, "$") {
+			if field != "$and" {
+				explanation.Warnings = append(explanation.Warnings, fmt.Sprintf("%s is evaluated on every document the index returns", field))
+			}
+			continue
+		}
+		ops, _ := selector[field].(map[string]interface{})
+		if exists, ok := ops["$exists"]; ok && exists == false {
+			continue
+		}
+		required[field] = true
+		explanation.Fields = append(explanation.Fields, field)
+	}
+
+	var candidates []CouchDBIndex
+	for _, index := range couchDBIndexes {
+		usable := true
+		for _, field := range index.Index.Fields {
+			usable = usable && required[field]
+		}
+		if usable {
+			candidates = append(candidates, index)
+		}
+	}
+
+	var chosen *CouchDBIndex
+	if designDoc, name := useIndex(query["use_index"]); designDoc != "" {
+		for i, index := range candidates {
+			if index.DesignDoc == designDoc && (name == "" || index.Name == name) {
+				chosen = &candidates[i]
+			}
+		}
+		if chosen == nil {
+			explanation.Warnings = append(explanation.Warnings, fmt.Sprintf("use_index %s cannot serve the query and is ignored", designDoc))
+		}
+	}
+	if chosen == nil {
+		for i, index := range candidates {
+			if chosen == nil || len(index.Index.Fields) > len(chosen.Index.Fields) {
+				chosen = &candidates[i]
+			}
+		}
+	}
+	if chosen == nil {
+		explanation.Warnings = append(explanation.Warnings, "no index serves the query, CouchDB scans every document")
+		return explanation
+	}
+
+	explanation.Index = chosen.DesignDoc + "/" + chosen.Name
+	explanation.IndexFields = chosen.Index.Fields
+	for _, field := range chosen.Index.Fields {
+		ops, _ := selector[field].(map[string]interface{})
+		for _, op := range slices.Sorted(maps.Keys(ops)) {
+			if !rangeOperators[op] {
+				explanation.Warnings = append(explanation.Warnings, fmt.Sprintf("%s on %s does not narrow the index range", op, field))
+			}
+		}
+	}
+	if sortFields := sortFields(query["sort"]); len(sortFields) > 0 && !slices.Equal(sortFields, chosen.Index.Fields[:min(len(sortFields), len(chosen.Index.Fields))]) {
+		explanation.Warnings = append(explanation.Warnings, fmt.Sprintf("sort on %s is not a prefix of the fields of %s, CouchDB rejects it", strings.Join(sortFields, ", "), explanation.Index))
+	}
+	return explanation
+}
+
+// useIndex returns the design document and optional index name of a use_index value
+func useIndex(value interface{}) (string, string) {
+	switch index := value.(type) {
+	case string:
+		return strings.TrimPrefix(index, "_design/"), ""
+	case []interface{}:
+		designDoc, name := "", ""
+		if len(index) > 0 {
+			designDoc, _ = index[0].(string)
+		}
+		if len(index) > 1 {
+			name, _ = index[1].(string)
+		}
+		return strings.TrimPrefix(designDoc, "_design/"), name
+	}
+	return "", ""
+}
+
+// sortFields returns the fields of a sort value, given as names or {"field": "asc"} objects
+func sortFields(value interface{}) []string {
+	entries, _ := value.([]interface{})
+	var fields []string
+	for _, entry := range entries {
+		switch field := entry.(type) {
+		case string:
+			fields = append(fields, field)
+		case map[string]interface{}:
+			for name := range field {
+				fields = append(fields, name)
+			}
+		}
+	}
+	return fields
+}
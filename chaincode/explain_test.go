@@ -0,0 +1,51 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExplainQuery tests that queries are normalized and matched to the index CouchDB would use
+func TestExplainQuery(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	qc := NewQueryContract()
+
+	explanation, err := qc.ExplainQuery(ctx, `{"docType":"asset","owner":"alice"}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"selector":{"docType":{"$eq":"asset"},"owner":{"$eq":"alice"}}}`, explanation.Query)
+	assert.Equal(t, "indexOwnerDoc/indexOwner", explanation.Index)
+	assert.Equal(t, []string{"docType", "owner"}, explanation.Fields)
+	assert.False(t, explanation.Scoped)
+	assert.Empty(t, explanation.Warnings)
+
+	explanation, err = qc.ExplainQuery(ctx, `{"selector":{"$and":[{"docType":"asset"},{"color":{"$ne":"red"}}]},"sort":["owner"]}`)
+	require.NoError(t, err)
+	assert.Equal(t, "indexColorDoc/indexColor", explanation.Index)
+	assert.Equal(t, []string{
+		"$ne on color does not narrow the index range",
+		"sort on owner is not a prefix of the fields of indexColorDoc/indexColor, CouchDB rejects it",
+	}, explanation.Warnings)
+
+	explanation, err = qc.ExplainQuery(ctx, `{"selector":{"docType":"asset","owner":"alice","color":"blue"},"use_index":"_design/indexColorDoc"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "indexColorDoc/indexColor", explanation.Index)
+
+	explanation, err = qc.ExplainQuery(ctx, `{"size":{"$gt":3},"details":{"weight":2}}`)
+	require.NoError(t, err)
+	assert.Empty(t, explanation.Index)
+	assert.Equal(t, []string{"details.weight", "size"}, explanation.Fields)
+	assert.Equal(t, []string{"no index serves the query, CouchDB scans every document"}, explanation.Warnings)
+
+	_, err = qc.ExplainQuery(ctx, `not json`)
+	assert.Error(t, err)
+
+	// Other callers are scoped to their own assets, which the owner index serves
+	switchIdentity(t, ctx, stub, "Org1MSP", "alice", nil)
+	explanation, err = qc.ExplainQuery(ctx, `{"docType":"asset","size":5}`)
+	require.NoError(t, err)
+	assert.True(t, explanation.Scoped)
+	assert.Equal(t, "indexOwnerDoc/indexOwner", explanation.Index)
+	assert.Contains(t, explanation.Query, `"owner":{"$eq":"alice"}`)
+}
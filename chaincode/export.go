@@ -0,0 +1,186 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// reportIndex keys the registry of export pages by report ID
+	reportIndex = "report~id"
+	// maxExportPageSize caps the assets exported by one ExportAssets call
+	maxExportPageSize = 1000
+)
+
+// ExportReport is the on-chain registry entry of an exported page. Consumers verify a page by
+// recomputing its digest and comparing it with the entry; the block holding TxID is found with
+// the qscc GetBlockByTxID query.
+type ExportReport struct {
+	ID          string    `json:"id"`
+	Digest      string    `json:"digest"`
+	RecordCount int       `json:"recordCount"`
+	StartKey    string    `json:"startKey"`
+	EndKey      string    `json:"endKey"`
+	NextKey     string    `json:"nextKey,omitempty" metadata:",optional"`
+	Exporter    string    `json:"exporter"`
+	MSPID       string    `json:"mspId"`
+	ChannelID   string    `json:"channelId"`
+	TxID        string    `json:"txId"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// ExportPage is one page of exported assets with the registry entry that attests to it
+type ExportPage struct {
+	Records  []*Asset      `json:"records"`
+	Bookmark string        `json:"bookmark"`
+	Report   *ExportReport `json:"report"`
+}
+
+// ExportAssets exports a page of the assets in [startKey, endKey) and records the page digest,
+// the SHA-256 of the compact JSON encoding of records, in the report registry. It must be
+// submitted, not evaluated, for the registry entry to be committed. Pass the returned bookmark
+// back unchanged to export the next page.
+func (t *AssetContract) ExportAssets(ctx contractapi.TransactionContextInterface, startKey, endKey string, pageSize int, bookmark string) (*ExportPage, error) {
+	t.logger(ctx).Info().
+		Str("function", "ExportAssets").
+		Str("startKey", startKey).
+		Str("endKey", endKey).
+		Int("pageSize", pageSize).
+		Str("bookmark", bookmark).
+		Msg("Exporting assets")
+
+	if pageSize <= 0 || pageSize > maxExportPageSize {
+		return nil, fmt.Errorf("page size must be between 1 and %d", maxExportPageSize)
+	}
+	queryHash := queryIdentity("export", startKey, endKey)
+	resumeKey, err := decodeCursor(t.configProvider(), bookmark, queryHash, int32(pageSize))
+	if err != nil {
+		t.logger(ctx).Warn().Err(err).Msg("Rejected export cursor")
+		return nil, err
+	}
+	startAssetKey, endAssetKey := assetKeyRange(startKey, endKey)
+	if resumeKey == "" {
+		resumeKey = startAssetKey
+	}
+
+	// Paginated range queries are only allowed in read-only transactions, so the page is cut
+	// by hand and the key after it becomes the bookmark
+	iterator, err := ctx.GetStub().GetStateByRange(resumeKey, endAssetKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read assets: %v", err)
+	}
+	defer iterator.Close()
+
+	presenter, err := t.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records := []*Asset{}
+	nextKey := ""
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == pageSize {
+			nextKey = response.Key
+			break
+		}
+		asset, err := decodeAsset(response.Value)
+		if err != nil {
+			return nil, err
+		}
+		if !asset.Deleted && presenter.visible(asset) {
+			records = append(records, presenter.present(asset))
+		}
+	}
+
+	digest, err := exportDigest(records)
+	if err != nil {
+		return nil, err
+	}
+	mspID, exporter, err := getCallerSubmitter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := t.now(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reportID, err := t.newID(ctx, "export")
+	if err != nil {
+		return nil, err
+	}
+	report := &ExportReport{
+		ID:          reportID,
+		Digest:      digest,
+		RecordCount: len(records),
+		StartKey:    resumeKey,
+		EndKey:      endKey,
+		NextKey:     nextKey,
+		Exporter:    exporter,
+		MSPID:       mspID,
+		ChannelID:   ctx.GetStub().GetChannelID(),
+		TxID:        ctx.GetStub().GetTxID(),
+		Timestamp:   now,
+	}
+	if err := putExportReport(ctx, report); err != nil {
+		return nil, err
+	}
+	cursor, err := encodeCursor(t.configProvider(), nextKey, queryHash, int32(pageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	t.logger(ctx).Info().Str("reportID", reportID).Str("digest", digest).Int("recordCount", len(records)).Msg("Export page recorded")
+	return &ExportPage{Records: records, Bookmark: cursor, Report: report}, nil
+}
+
+// GetExportReport returns the registry entry of an exported page
+func (q *QueryContract) GetExportReport(ctx contractapi.TransactionContextInterface, reportID string) (*ExportReport, error) {
+	q.logger(ctx).Info().Str("function", "GetExportReport").Str("reportID", reportID).Msg("Reading export report")
+
+	key, err := ctx.GetStub().CreateCompositeKey(reportIndex, []string{reportID})
+	if err != nil {
+		return nil, err
+	}
+	reportBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export report %s: %v", reportID, err)
+	}
+	if reportBytes == nil {
+		return nil, fmt.Errorf("export report %s does not exist", reportID)
+	}
+	var report ExportReport
+	if err := json.Unmarshal(reportBytes, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// exportDigest returns the hex SHA-256 of the compact JSON encoding of the exported records
+func exportDigest(records []*Asset) (string, error) {
+	recordsBytes, err := json.Marshal(records)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(recordsBytes)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func putExportReport(ctx contractapi.TransactionContextInterface, report *ExportReport) error {
+	key, err := ctx.GetStub().CreateCompositeKey(reportIndex, []string{report.ID})
+	if err != nil {
+		return err
+	}
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, reportBytes)
+}
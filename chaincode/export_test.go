@@ -0,0 +1,42 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExportAssets tests that exported pages are chained by bookmark and attested in the report registry
+func TestExportAssets(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "red", 5, "Jane", 200))
+	require.NoError(t, cc.CreateAsset(ctx, "asset3", "green", 5, "Jin", 300))
+
+	first, err := cc.ExportAssets(ctx, "asset0", "asset9", 2, "")
+	require.NoError(t, err)
+	require.Len(t, first.Records, 2)
+	assert.NotEmpty(t, first.Bookmark)
+	assert.Equal(t, "asset3", first.Report.NextKey)
+	assert.Equal(t, "tx1", first.Report.TxID)
+
+	report, err := qc.GetExportReport(ctx, first.Report.ID)
+	require.NoError(t, err)
+	digest, err := exportDigest(first.Records)
+	require.NoError(t, err)
+	assert.Equal(t, digest, report.Digest)
+
+	stub.MockTransactionStart("tx2")
+	second, err := cc.ExportAssets(ctx, "asset0", "asset9", 2, first.Bookmark)
+	require.NoError(t, err)
+	require.Len(t, second.Records, 1)
+	assert.Equal(t, "asset3", second.Records[0].ID)
+	assert.Empty(t, second.Bookmark)
+	assert.NotEqual(t, first.Report.ID, second.Report.ID)
+
+	_, err = cc.ExportAssets(ctx, "asset0", "asset5", 2, first.Bookmark)
+	assert.Error(t, err)
+}
@@ -0,0 +1,217 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// genesisPublicKeyConfig names the setting holding the PEM public key that signs genesis documents
+	genesisPublicKeyConfig = "CHAINCODE_GENESIS_PUBLIC_KEY"
+	// adminConfig is the configuration key prefix of the admins registered by a genesis document
+	adminConfig = "admin"
+)
+
+// GenesisDocument is the approved bootstrap configuration of a production deployment
+type GenesisDocument struct {
+	Deployment    DeploymentParams      `json:"deployment"`
+	AssetClasses  []GenesisAssetClass   `json:"assetClasses"`
+	Organizations []GenesisOrganization `json:"organizations"`
+	Admins        []GenesisAdmin        `json:"admins"`
+}
+
+// GenesisAssetClass declares a docType and the state codec its records are written with
+type GenesisAssetClass struct {
+	DocType string `json:"docType"`
+	Codec   string `json:"codec,omitempty"`
+}
+
+// GenesisOrganization is a channel member registered at bootstrap
+type GenesisOrganization struct {
+	MSPID       string   `json:"mspId"`
+	Roles       []string `json:"roles"`
+	Collections []string `json:"collections"`
+	Region      string   `json:"region,omitempty"`
+}
+
+// GenesisAdmin is an identity granted admin rights without the admin certificate attribute
+type GenesisAdmin struct {
+	MSPID        string `json:"mspId"`
+	EnrollmentID string `json:"enrollmentId"`
+}
+
+// InitFromGenesis bootstraps all configuration state from a genesis document in one transaction.
+// signature is the base64 ECDSA (ASN.1, over the SHA-256 digest) or Ed25519 signature of the exact
+// genesisJSON bytes by the key configured in CHAINCODE_GENESIS_PUBLIC_KEY; the signature, not the
+// caller's role, authorizes the call. Use it instead of InitLedger for production deployments.
+func (t *AssetContract) InitFromGenesis(ctx contractapi.TransactionContextInterface, genesisJSON, signature string) error {
+	t.logger(ctx).Info().Str("function", "InitFromGenesis").Msg("Bootstrapping chaincode from genesis document")
+
+	if err := verifyGenesisSignature(t.configProvider(), []byte(genesisJSON), signature); err != nil {
+		t.logger(ctx).Warn().Err(err).Msg("Genesis document rejected")
+		return err
+	}
+	if err := t.requireUninitialized(ctx); err != nil {
+		return err
+	}
+
+	var genesis GenesisDocument
+	if err := json.Unmarshal([]byte(genesisJSON), &genesis); err != nil {
+		return fmt.Errorf("invalid genesis document: %v", err)
+	}
+	if err := validateGenesis(&genesis); err != nil {
+		return err
+	}
+
+	for _, class := range genesis.AssetClasses {
+		codec := class.Codec
+		if codec == "" {
+			codec = CodecJSON
+		}
+		if err := putConfig(ctx, codec, codecConfig, class.DocType); err != nil {
+			return err
+		}
+	}
+	for _, member := range genesis.Organizations {
+		org := &Organization{
+			DocType:     "org",
+			MSPID:       member.MSPID,
+			Roles:       member.Roles,
+			Collections: member.Collections,
+			Status:      orgStatusActive,
+		}
+		if org.Roles == nil {
+			org.Roles = []string{}
+		}
+		if org.Collections == nil {
+			org.Collections = []string{}
+		}
+		if err := putOrganization(ctx, org); err != nil {
+			return err
+		}
+		if member.Region != "" {
+			if err := putConfig(ctx, member.Region, regionConfig, member.MSPID); err != nil {
+				return err
+			}
+		}
+	}
+	for _, admin := range genesis.Admins {
+		if err := putConfig(ctx, true, adminConfig, admin.MSPID, admin.EnrollmentID); err != nil {
+			return err
+		}
+	}
+
+	digest := sha256.Sum256([]byte(genesisJSON))
+	genesis.Deployment.GenesisHash = hex.EncodeToString(digest[:])
+	if err := t.recordDeployment(ctx, &genesis.Deployment, initGenesisFunction); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().
+		Str("genesisHash", genesis.Deployment.GenesisHash).
+		Int("assetClasses", len(genesis.AssetClasses)).
+		Int("organizations", len(genesis.Organizations)).
+		Int("admins", len(genesis.Admins)).
+		Msg("Chaincode bootstrapped from genesis document")
+	return nil
+}
+
+// validateGenesis checks the document for consistency before anything is written
+func validateGenesis(genesis *GenesisDocument) error {
+	docTypes := make(map[string]bool)
+	for _, class := range genesis.AssetClasses {
+		if class.DocType == "" {
+			return fmt.Errorf("asset class docType must not be empty")
+		}
+		if docTypes[class.DocType] {
+			return fmt.Errorf("asset class %s is declared twice", class.DocType)
+		}
+		docTypes[class.DocType] = true
+		if _, ok := stateCodecs[class.Codec]; class.Codec != "" && !ok {
+			return fmt.Errorf("asset class %s: state codec must be %s or %s", class.DocType, CodecJSON, CodecCBOR)
+		}
+	}
+
+	members := make(map[string]bool)
+	for _, org := range genesis.Organizations {
+		if org.MSPID == "" {
+			return fmt.Errorf("organization mspId must not be empty")
+		}
+		if members[org.MSPID] {
+			return fmt.Errorf("organization %s is declared twice", org.MSPID)
+		}
+		members[org.MSPID] = true
+	}
+
+	if len(genesis.Admins) == 0 {
+		return fmt.Errorf("genesis document must name at least one admin")
+	}
+	for _, admin := range genesis.Admins {
+		if admin.MSPID == "" || admin.EnrollmentID == "" {
+			return fmt.Errorf("admin mspId and enrollmentId must not be empty")
+		}
+		if !members[admin.MSPID] {
+			return fmt.Errorf("admin %s belongs to unknown organization %s", admin.EnrollmentID, admin.MSPID)
+		}
+	}
+	return nil
+}
+
+// verifyGenesisSignature checks signature over document with the configured genesis public key
+func verifyGenesisSignature(config ConfigProvider, document []byte, signature string) error {
+	keyPEM := config.Get(genesisPublicKeyConfig)
+	if keyPEM == "" {
+		return fmt.Errorf("%s is not configured", genesisPublicKeyConfig)
+	}
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return fmt.Errorf("%s is not a PEM encoded key", genesisPublicKeyConfig)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid genesis public key: %v", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid genesis signature encoding: %v", err)
+	}
+
+	valid := false
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(document)
+		valid = ecdsa.VerifyASN1(key, digest[:], sig)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, document, sig)
+	default:
+		return fmt.Errorf("unsupported genesis public key type %T", publicKey)
+	}
+	if !valid {
+		return fmt.Errorf("genesis document signature is invalid")
+	}
+	return nil
+}
+
+// isGenesisAdmin reports whether the caller was named an admin by the genesis document
+func isGenesisAdmin(ctx contractapi.TransactionContextInterface) (bool, error) {
+	mspID, enrollmentID, err := getCallerSubmitter(ctx)
+	if err != nil {
+		return false, err
+	}
+	var admin bool
+	if _, err := getConfig(ctx, &admin, adminConfig, mspID, enrollmentID); err != nil {
+		log.Error().Err(err).Str("mspID", mspID).Msg("Failed to read admin registry")
+		return false, err
+	}
+	return admin, nil
+}
@@ -0,0 +1,108 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGenesis = `{
+	"deployment": {"issuerMSP": "Org1MSP", "schemaVersion": 1},
+	"assetClasses": [{"docType": "asset", "codec": "cbor"}],
+	"organizations": [{"mspId": "Org1MSP", "roles": ["issuer"], "region": "EU"}, {"mspId": "Org2MSP"}],
+	"admins": [{"mspId": "Org2MSP", "enrollmentId": "ops"}]
+}`
+
+// newGenesisKey returns a signing key and its PEM encoded public key
+func newGenesisKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func signGenesis(t *testing.T, key *ecdsa.PrivateKey, document string) string {
+	t.Helper()
+	digest := sha256.Sum256([]byte(document))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+// TestInitFromGenesis tests that a signed genesis document bootstraps the configuration once
+func TestInitFromGenesis(t *testing.T) {
+	key, publicKey := newGenesisKey(t)
+	cc := NewAssetContract(WithConfigProvider(mapConfig{genesisPublicKeyConfig: publicKey}))
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+
+	otherKey, _ := newGenesisKey(t)
+	err := cc.InitFromGenesis(ctx, testGenesis, signGenesis(t, otherKey, testGenesis))
+	assert.ErrorContains(t, err, "signature is invalid")
+
+	require.NoError(t, cc.InitFromGenesis(ctx, testGenesis, signGenesis(t, key, testGenesis)))
+
+	params, err := getDeploymentParams(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, params)
+	assert.Equal(t, "Org1MSP", params.IssuerMSP)
+	assert.Len(t, params.GenesisHash, 64)
+	codec, err := getCodec(ctx, "asset")
+	require.NoError(t, err)
+	assert.Equal(t, CodecCBOR, codec.name())
+	region, err := getMSPRegion(ctx, "Org1MSP")
+	require.NoError(t, err)
+	assert.Equal(t, "EU", region)
+	org, err := getOrganization(ctx, "Org2MSP")
+	require.NoError(t, err)
+	require.NotNil(t, org)
+	assert.Equal(t, orgStatusActive, org.Status)
+
+	err = cc.InitFromGenesis(ctx, testGenesis, signGenesis(t, key, testGenesis))
+	assert.ErrorContains(t, err, ErrCodeAlreadyInitialized)
+
+	opsCtx, opsStub := newTestContext(t, "Org2MSP", "ops", nil)
+	opsStub.State = stub.State
+	assert.NoError(t, requireAdmin(opsCtx))
+	assert.Error(t, requireAdmin(ctx))
+}
+
+// TestInitFromGenesisValidation tests that inconsistent genesis documents are rejected
+func TestInitFromGenesisValidation(t *testing.T) {
+	assert.ErrorContains(t, validateGenesis(&GenesisDocument{}), "at least one admin")
+	assert.ErrorContains(t, validateGenesis(&GenesisDocument{
+		Admins: []GenesisAdmin{{MSPID: "Org9MSP", EnrollmentID: "ops"}},
+	}), "unknown organization")
+	assert.ErrorContains(t, validateGenesis(&GenesisDocument{
+		AssetClasses: []GenesisAssetClass{{DocType: "asset", Codec: "xml"}},
+	}), "state codec")
+	assert.ErrorContains(t, verifyGenesisSignature(mapConfig{}, []byte(testGenesis), ""), "is not configured")
+}
+
+// TestInitFromGenesisBeforeInit tests that InitFromGenesis is allowed while initialization is required
+func TestInitFromGenesisBeforeInit(t *testing.T) {
+	key, publicKey := newGenesisKey(t)
+	opts := []Option{WithInitRequired(), WithConfigProvider(mapConfig{genesisPublicKeyConfig: publicKey})}
+	cc, err := contractapi.NewChaincode(NewAssetContract(opts...), NewQueryContract(opts...))
+	require.NoError(t, err)
+	stub := shimtest.NewMockStub("chaincode", cc)
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "user1", nil)
+
+	status, message := invoke(stub, "tx1", "InitFromGenesis", testGenesis, signGenesis(t, key, testGenesis))
+	require.Equal(t, int32(shim.OK), status, message)
+	response := stub.MockInvoke("tx2", [][]byte{[]byte("QueryContract:GetDeploymentParams")})
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	assert.Contains(t, string(response.Payload), `"genesisHash"`)
+}
@@ -0,0 +1,237 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// graphQLName matches the names GraphQL allows for types, fields and arguments
+var graphQLName = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// contractMetadata is the part of the contract metadata returned by
+// org.hyperledger.fabric:GetMetadata that the GraphQL schema is generated from
+type contractMetadata struct {
+	Contracts map[string]struct {
+		Transactions []struct {
+			Name       string   `json:"name"`
+			Tag        []string `json:"tag"`
+			Parameters []struct {
+				Name   string      `json:"name"`
+				Schema *jsonSchema `json:"schema"`
+			} `json:"parameters"`
+			Returns *jsonSchema `json:"returns"`
+		} `json:"transactions"`
+	} `json:"contracts"`
+	Components struct {
+		Schemas map[string]*jsonSchema `json:"schemas"`
+	} `json:"components"`
+}
+
+// jsonSchema is the subset of JSON Schema contractapi uses to describe parameters and returns
+type jsonSchema struct {
+	Ref        string                 `json:"$ref"`
+	Type       string                 `json:"type"`
+	Format     string                 `json:"format"`
+	Items      *jsonSchema            `json:"items"`
+	Properties map[string]*jsonSchema `json:"properties"`
+	Required   []string               `json:"required"`
+}
+
+// graphQLSchemaBuilder collects the object and input types the query fields refer to
+type graphQLSchemaBuilder struct {
+	schemas map[string]*jsonSchema
+	objects map[string]bool
+	inputs  map[string]bool
+}
+
+// GraphQLSchema generates a GraphQL schema (SDL) from contract metadata. Every evaluate function
+// becomes a field of Query with an @chaincode directive naming the function a gateway resolver
+// invokes, and the types it returns or takes become object and input types. Arguments are named
+// after namedParameters where the function is listed there, and after the metadata otherwise.
+func GraphQLSchema(metadataJSON []byte) (string, error) {
+	var metadata contractMetadata
+	if err := json.Unmarshal(metadataJSON, &metadata); err != nil {
+		return "", fmt.Errorf("invalid contract metadata: %v", err)
+	}
+	builder := &graphQLSchemaBuilder{schemas: metadata.Components.Schemas, objects: map[string]bool{}, inputs: map[string]bool{}}
+
+	// QueryContract functions keep their plain names, other contracts' only when not taken
+	contracts := make([]string, 0, len(metadata.Contracts))
+	for name := range metadata.Contracts {
+		if name != "org.hyperledger.fabric" {
+			contracts = append(contracts, name)
+		}
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		if (contracts[i] == "QueryContract") != (contracts[j] == "QueryContract") {
+			return contracts[i] == "QueryContract"
+		}
+		return contracts[i] < contracts[j]
+	})
+
+	var fields []string
+	taken := map[string]bool{}
+	for _, contract := range contracts {
+		for _, transaction := range metadata.Contracts[contract].Transactions {
+			if !slices.Contains(transaction.Tag, "evaluate") {
+				continue
+			}
+			function := contract + ":" + transaction.Name
+			field := lowerFirst(transaction.Name)
+			if taken[field] {
+				field = lowerFirst(contract) + transaction.Name
+			}
+			taken[field] = true
+
+			names := namedParameters[function]
+			args := make([]string, 0, len(transaction.Parameters))
+			for i, parameter := range transaction.Parameters {
+				name := parameter.Name
+				if i < len(names) {
+					name = names[i]
+				}
+				argType, err := builder.typeOf(parameter.Schema, true, true)
+				if err != nil {
+					return "", fmt.Errorf("%s parameter %s: %v", function, name, err)
+				}
+				args = append(args, fmt.Sprintf("%s: %s", name, argType))
+			}
+			returnType := "Boolean"
+			if transaction.Returns != nil {
+				var err error
+				if returnType, err = builder.typeOf(transaction.Returns, false, false); err != nil {
+					return "", fmt.Errorf("%s returns: %v", function, err)
+				}
+			}
+
+			signature := field
+			if len(args) > 0 {
+				signature += "(" + strings.Join(args, ", ") + ")"
+			}
+			fields = append(fields, fmt.Sprintf("  %s: %s @chaincode(function: %q)", signature, returnType, function))
+		}
+	}
+
+	var sdl strings.Builder
+	sdl.WriteString("# Generated from the contract metadata, do not edit\n\n")
+	sdl.WriteString("\"The chaincode function, contract:function, that resolves the field\"\n")
+	sdl.WriteString("directive @chaincode(function: String!) on FIELD_DEFINITION\n\n")
+	sdl.WriteString("\"RFC3339 timestamp\"\nscalar DateTime\n\n")
+	sdl.WriteString("\"JSON object with arbitrary keys\"\nscalar JSON\n\n")
+	sdl.WriteString("type Query {\n" + strings.Join(fields, "\n") + "\n}\n")
+
+	for _, kind := range []struct {
+		keyword string
+		names   map[string]bool
+		suffix  string
+		input   bool
+	}{{"type", builder.objects, "", false}, {"input", builder.inputs, "Input", true}} {
+		names := make([]string, 0, len(kind.names))
+		for name := range kind.names {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			schema := builder.schemas[name]
+			properties := make([]string, 0, len(schema.Properties))
+			for property := range schema.Properties {
+				properties = append(properties, property)
+			}
+			sort.Strings(properties)
+
+			fmt.Fprintf(&sdl, "\n%s %s%s {\n", kind.keyword, name, kind.suffix)
+			for _, property := range properties {
+				if !graphQLName.MatchString(property) {
+					return "", fmt.Errorf("%s.%s is not a valid GraphQL field name", name, property)
+				}
+				propertyType, err := builder.typeOf(schema.Properties[property], slices.Contains(schema.Required, property), kind.input)
+				if err != nil {
+					return "", fmt.Errorf("%s.%s: %v", name, property, err)
+				}
+				fmt.Fprintf(&sdl, "  %s: %s\n", property, propertyType)
+			}
+			sdl.WriteString("}\n")
+		}
+	}
+	return sdl.String(), nil
+}
+
+// typeOf returns the GraphQL type of a schema and registers the component types it refers to,
+// as input types when input is set
+func (b *graphQLSchemaBuilder) typeOf(schema *jsonSchema, required, input bool) (string, error) {
+	if schema == nil {
+		return "", fmt.Errorf("missing schema")
+	}
+	var name string
+	switch {
+	case schema.Ref != "":
+		component := schema.Ref[strings.LastIndex(schema.Ref, "/")+1:]
+		if err := b.register(component, input); err != nil {
+			return "", err
+		}
+		name = component
+		if input {
+			name += "Input"
+		}
+	case schema.Type == "array":
+		items, err := b.typeOf(schema.Items, true, input)
+		if err != nil {
+			return "", err
+		}
+		name = "[" + items + "]"
+	case schema.Type == "string" && schema.Format == "date-time":
+		name = "DateTime"
+	case schema.Type == "string":
+		name = "String"
+	case schema.Type == "integer":
+		name = "Int"
+	case schema.Type == "number":
+		name = "Float"
+	case schema.Type == "boolean":
+		name = "Boolean"
+	case schema.Type == "object":
+		name = "JSON"
+	default:
+		return "", fmt.Errorf("unsupported schema type %q", schema.Type)
+	}
+	if required {
+		name += "!"
+	}
+	return name, nil
+}
+
+// register adds a component and the components its properties refer to
+func (b *graphQLSchemaBuilder) register(component string, input bool) error {
+	registered := b.objects
+	if input {
+		registered = b.inputs
+	}
+	if registered[component] {
+		return nil
+	}
+	schema, ok := b.schemas[component]
+	if !ok {
+		return fmt.Errorf("unknown component %s", component)
+	}
+	if !graphQLName.MatchString(component) {
+		return fmt.Errorf("%s is not a valid GraphQL type name", component)
+	}
+	registered[component] = true
+	for _, property := range schema.Properties {
+		if _, err := b.typeOf(property, false, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lowerFirst(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
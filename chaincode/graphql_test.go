@@ -0,0 +1,40 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGraphQLSchema tests that evaluate functions become Query fields over the types they use
+func TestGraphQLSchema(t *testing.T) {
+	cc, err := contractapi.NewChaincode(NewAssetContract(), NewQueryContract(), &AdminContract{}, &ConfigContract{})
+	require.NoError(t, err)
+	response := shimtest.NewMockStub("chaincode", cc).MockInvoke("tx1", [][]byte{[]byte("org.hyperledger.fabric:GetMetadata")})
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+
+	schema, err := GraphQLSchema(response.Payload)
+	require.NoError(t, err)
+	assert.Contains(t, schema, `readAsset(param0: String!): Asset @chaincode(function: "QueryContract:ReadAsset")`)
+	assert.Contains(t, schema, `getAllAssets(param0: Int!, param1: String!): PaginatedQueryResult @chaincode(function: "QueryContract:GetAllAssets")`)
+	assert.Contains(t, schema, `getAssetsByRangeWithPagination(startKey: String!, endKey: String!, pageSize: Int!, bookmark: String!): PaginatedQueryResult`)
+	assert.Contains(t, schema, `@chaincode(function: "AssetContract:PreviewTransfer")`)
+	assert.Contains(t, schema, "type Asset {\n  ID: String!\n  ageDays: Int\n  appraisedValue: Int!\n")
+	assert.Contains(t, schema, "  createdAt: DateTime\n")
+	assert.Contains(t, schema, "type PaginatedQueryResult {\n  bookmark: String!\n  fetchedRecordsCount: Int!\n  records: [Asset!]!\n}")
+	assert.Contains(t, schema, "type HistoryQueryResult {")
+	assert.Contains(t, schema, "input OwnershipClaimInput {")
+
+	// Submit-only functions and the types only they use stay out of the schema
+	assert.NotContains(t, schema, "createAsset")
+	assert.NotContains(t, schema, "type ImportResult")
+
+	_, err = GraphQLSchema([]byte(`{"contracts":{"C":{"transactions":[{"name":"F","tag":["evaluate"],"returns":{"$ref":"#/components/schemas/Missing"}}]}}}`))
+	assert.ErrorContains(t, err, "unknown component Missing")
+	_, err = GraphQLSchema([]byte("not json"))
+	assert.Error(t, err)
+}
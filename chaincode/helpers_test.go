@@ -0,0 +1,311 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain configures the settings the chaincode refuses to run without
+func TestMain(m *testing.M) {
+	os.Setenv(cursorSecretConfig, "test-cursor-secret")
+	os.Exit(m.Run())
+}
+
+// attrOID is the X.509 extension used by Fabric CA to embed identity attributes
+var attrOID = []int{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+// newTestContext returns a transaction context backed by a MockStub whose creator
+// is a freshly generated certificate for the given MSP, common name and attributes.
+func newTestContext(t *testing.T, mspID, commonName string, attrs map[string]string) (*contractapi.TransactionContext, *shimtest.MockStub) {
+	t.Helper()
+
+	stub := shimtest.NewMockStub("chaincode", nil)
+	stub.Creator = newSerializedIdentity(t, mspID, commonName, attrs)
+	stub.MockTransactionStart("tx1")
+
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(stub)
+	setTestIdentity(t, ctx, stub)
+	return ctx, stub
+}
+
+// switchIdentity replaces the creator of the stub, keeping the world state
+func switchIdentity(t *testing.T, ctx *contractapi.TransactionContext, stub *shimtest.MockStub, mspID, commonName string, attrs map[string]string) {
+	t.Helper()
+	stub.Creator = newSerializedIdentity(t, mspID, commonName, attrs)
+	setTestIdentity(t, ctx, stub)
+}
+
+// lastEvent drains the events set so far and returns the last one, which is the event Fabric
+// keeps for a transaction
+func lastEvent(t *testing.T, stub *shimtest.MockStub) *peer.ChaincodeEvent {
+	t.Helper()
+	var event *peer.ChaincodeEvent
+	for {
+		select {
+		case event = <-stub.ChaincodeEventsChannel:
+		default:
+			require.NotNil(t, event, "no chaincode event was set")
+			return event
+		}
+	}
+}
+
+func setTestIdentity(t *testing.T, ctx *contractapi.TransactionContext, stub *shimtest.MockStub) {
+	t.Helper()
+	identity, err := cid.New(stub)
+	require.NoError(t, err)
+	ctx.SetClientIdentity(identity)
+}
+
+func newSerializedIdentity(t *testing.T, mspID, commonName string, attrs map[string]string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{mspID}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	if len(attrs) > 0 {
+		attrBytes, err := json.Marshal(map[string]interface{}{"attrs": attrs})
+		require.NoError(t, err)
+		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{Id: attrOID, Value: attrBytes})
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	identity, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	require.NoError(t, err)
+	return identity
+}
+
+// privateDataStub extends MockStub, which does not implement private data hashes, deletion or
+// purging, with implementations over its private state
+type privateDataStub struct {
+	*shimtest.MockStub
+}
+
+// newPrivateDataContext returns a transaction context over a privateDataStub
+func newPrivateDataContext(t *testing.T, mspID, commonName string, attrs map[string]string) (*contractapi.TransactionContext, *privateDataStub) {
+	t.Helper()
+	ctx, mockStub := newTestContext(t, mspID, commonName, attrs)
+	stub := &privateDataStub{MockStub: mockStub}
+	ctx.SetStub(stub)
+	return ctx, stub
+}
+
+func (s *privateDataStub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	value := s.PvtState[collection][key]
+	if value == nil {
+		return nil, nil
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+func (s *privateDataStub) DelPrivateData(collection, key string) error {
+	delete(s.PvtState[collection], key)
+	return nil
+}
+
+func (s *privateDataStub) PurgePrivateData(collection, key string) error {
+	delete(s.PvtState[collection], key)
+	return nil
+}
+
+// historyStub extends MockStub, which does not implement GetHistoryForKey, with a
+// recorded history per key returned newest first like the peer does.
+type historyStub struct {
+	*shimtest.MockStub
+	history map[string][]*queryresult.KeyModification
+}
+
+// newHistoryContext returns a transaction context over a historyStub
+func newHistoryContext(t *testing.T, mspID, commonName string) (*contractapi.TransactionContext, *historyStub) {
+	t.Helper()
+	ctx, mockStub := newTestContext(t, mspID, commonName, nil)
+	stub := &historyStub{MockStub: mockStub, history: map[string][]*queryresult.KeyModification{}}
+	ctx.SetStub(stub)
+	return ctx, stub
+}
+
+// record appends a modification of key at the given time
+func (s *historyStub) record(t *testing.T, key, txID string, at time.Time, value []byte) {
+	t.Helper()
+	ts, err := ptypes.TimestampProto(at)
+	require.NoError(t, err)
+	modification := &queryresult.KeyModification{TxId: txID, Value: value, Timestamp: ts, IsDelete: value == nil}
+	s.history[key] = append([]*queryresult.KeyModification{modification}, s.history[key]...)
+}
+
+func (s *historyStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &historyIterator{modifications: s.history[key]}, nil
+}
+
+type historyIterator struct {
+	modifications []*queryresult.KeyModification
+	position      int
+}
+
+func (i *historyIterator) HasNext() bool {
+	return i.position < len(i.modifications)
+}
+
+func (i *historyIterator) Next() (*queryresult.KeyModification, error) {
+	modification := i.modifications[i.position]
+	i.position++
+	return modification, nil
+}
+
+func (i *historyIterator) Close() error {
+	return nil
+}
+
+// isolatedStub extends MockStub, which shows a transaction its own writes, with the peer's
+// behaviour: writes and the event are buffered until commit and reads return committed state only
+type isolatedStub struct {
+	*shimtest.MockStub
+	writes map[string][]byte
+	event  *peer.ChaincodeEvent
+}
+
+// newIsolatedContext returns a transaction context over an isolatedStub
+func newIsolatedContext(t *testing.T, mspID, commonName string, attrs map[string]string) (*contractapi.TransactionContext, *isolatedStub) {
+	t.Helper()
+	ctx, mockStub := newTestContext(t, mspID, commonName, attrs)
+	stub := &isolatedStub{MockStub: mockStub, writes: map[string][]byte{}}
+	ctx.SetStub(stub)
+	return ctx, stub
+}
+
+func (s *isolatedStub) PutState(key string, value []byte) error {
+	s.writes[key] = value
+	return nil
+}
+
+func (s *isolatedStub) DelState(key string) error {
+	s.writes[key] = nil
+	return nil
+}
+
+func (s *isolatedStub) SetEvent(name string, payload []byte) error {
+	s.event = &peer.ChaincodeEvent{EventName: name, Payload: payload}
+	return nil
+}
+
+// commit applies the buffered writes and starts the transaction txID
+func (s *isolatedStub) commit(t *testing.T, txID string) {
+	t.Helper()
+	for key, value := range s.writes {
+		if value == nil {
+			require.NoError(t, s.MockStub.DelState(key))
+		} else {
+			require.NoError(t, s.MockStub.PutState(key, value))
+		}
+	}
+	if s.event != nil {
+		require.NoError(t, s.MockStub.SetEvent(s.event.EventName, s.event.Payload))
+	}
+	s.writes = map[string][]byte{}
+	s.event = nil
+	s.MockTransactionStart(txID)
+}
+
+// abort discards the buffered writes and event of a failed transaction and starts the
+// transaction txID
+func (s *isolatedStub) abort(txID string) {
+	s.writes = map[string][]byte{}
+	s.event = nil
+	s.MockTransactionStart(txID)
+}
+
+// paginationStub extends MockStub, whose paginated queries return nothing, with paginated
+// partial composite key queries. Like the peer's, the bookmark is the key the next page
+// starts at and is empty after the last page.
+type paginationStub struct {
+	*shimtest.MockStub
+}
+
+// newPaginationContext returns a transaction context over a paginationStub
+func newPaginationContext(t *testing.T, mspID, commonName string, attrs map[string]string) (*contractapi.TransactionContext, *paginationStub) {
+	t.Helper()
+	ctx, mockStub := newTestContext(t, mspID, commonName, attrs)
+	stub := &paginationStub{MockStub: mockStub}
+	ctx.SetStub(stub)
+	return ctx, stub
+}
+
+func (s *paginationStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	iterator, err := s.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iterator.Close()
+
+	page := &kvIterator{}
+	metadata := &peer.QueryResponseMetadata{}
+	for iterator.HasNext() {
+		result, err := iterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if result.Key < bookmark {
+			continue
+		}
+		if len(page.results) == int(pageSize) {
+			metadata.Bookmark = result.Key
+			break
+		}
+		page.results = append(page.results, result)
+	}
+	metadata.FetchedRecordsCount = int32(len(page.results))
+	return page, metadata, nil
+}
+
+type kvIterator struct {
+	results  []*queryresult.KV
+	position int
+}
+
+func (i *kvIterator) HasNext() bool {
+	return i.position < len(i.results)
+}
+
+func (i *kvIterator) Next() (*queryresult.KV, error) {
+	result := i.results[i.position]
+	i.position++
+	return result, nil
+}
+
+func (i *kvIterator) Close() error {
+	return nil
+}
@@ -0,0 +1,496 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxHistoryPageSize caps the number of records returned by a single history page
+	maxHistoryPageSize = 100
+	// maxHistorySummaryAssets caps the assets summarized by one GetMultipleAssetHistoriesSummary call
+	maxHistorySummaryAssets = 100
+)
+
+// HistoryPage is a window of an asset's history. Pass LastTxID as afterTxID to get the next window,
+// or Bookmark to GetAssetHistoryPaginated.
+type HistoryPage struct {
+	Records  []HistoryQueryResult `json:"records"`
+	LastTxID string               `json:"lastTxId"`
+	HasMore  bool                 `json:"hasMore"`
+	Bookmark string               `json:"bookmark,omitempty" metadata:",optional"`
+}
+
+// GetAssetHistoryPage returns at most limit history records of an asset, starting after the
+// record written by afterTxID (or from the most recent record when afterTxID is empty).
+// Fabric has no pagination for GetHistoryForKey, so earlier records are skipped within the
+// iterator; this bounds the response size and the work spent unmarshalling records.
+func (q *QueryContract) GetAssetHistoryPage(ctx contractapi.TransactionContextInterface, assetID, afterTxID string, limit int) (*HistoryPage, error) {
+	q.logger(ctx).Info().
+		Str("function", "GetAssetHistoryPage").
+		Str("assetID", assetID).
+		Str("afterTxID", afterTxID).
+		Int("limit", limit).
+		Msg("Getting asset history page")
+
+	return q.historyPage(ctx, assetID, afterTxID, limit)
+}
+
+// GetAssetHistoryPaginated returns at most pageSize history records of an asset, newest first,
+// and a bookmark for the next page while there are more. The bookmark is an opaque, signed
+// cursor bound to the asset and page size; it anchors on the last returned transaction rather
+// than an offset, so writes made between two calls do not shift the pages.
+func (q *QueryContract) GetAssetHistoryPaginated(ctx contractapi.TransactionContextInterface, assetID string, pageSize int, bookmark string) (*HistoryPage, error) {
+	q.logger(ctx).Info().
+		Str("function", "GetAssetHistoryPaginated").
+		Str("assetID", assetID).
+		Int("pageSize", pageSize).
+		Str("bookmark", bookmark).
+		Msg("Getting paginated asset history")
+
+	if pageSize <= 0 || pageSize > maxHistoryPageSize {
+		return nil, fmt.Errorf("pageSize must be between 1 and %d", maxHistoryPageSize)
+	}
+	queryHash := queryIdentity("history", assetID)
+	afterTxID, err := decodeCursor(q.configProvider(), bookmark, queryHash, int32(pageSize))
+	if err != nil {
+		return nil, err
+	}
+	page, err := q.historyPage(ctx, assetID, afterTxID, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if page.HasMore {
+		if page.Bookmark, err = encodeCursor(q.configProvider(), page.LastTxID, queryHash, int32(pageSize)); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// GetAssetHistoryBetween returns the history records of an asset, newest first, whose transaction
+// timestamp lies from fromTime (inclusive) to toTime (exclusive), both RFC3339. Transaction
+// timestamps are set by clients and need not follow commit order, so the whole history is
+// walked, but only records in the window are decoded and count against the query budget.
+func (q *QueryContract) GetAssetHistoryBetween(ctx contractapi.TransactionContextInterface, assetID, fromTime, toTime string) ([]HistoryQueryResult, error) {
+	q.logger(ctx).Info().
+		Str("function", "GetAssetHistoryBetween").
+		Str("assetID", assetID).
+		Str("from", fromTime).
+		Str("to", toTime).
+		Msg("Getting asset history between times")
+
+	from, err := time.Parse(time.RFC3339, fromTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from time %q: %v", fromTime, err)
+	}
+	to, err := time.Parse(time.RFC3339, toTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to time %q: %v", toTime, err)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("to time must be after from time")
+	}
+
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(assetKey(assetID))
+	if err != nil {
+		q.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to get history for key")
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	presenter, err := q.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	budget := newQueryBudget(q.configProvider())
+	records := []HistoryQueryResult{}
+	scanned := 0
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			q.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to get next history record")
+			return nil, err
+		}
+		scanned++
+		timestamp, err := ptypes.Timestamp(response.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp in history record %s: %v", response.TxId, err)
+		}
+		if timestamp.Before(from) || !timestamp.Before(to) {
+			continue
+		}
+		if err := budget.consume(len(response.Value)); err != nil {
+			q.logger(ctx).Warn().Err(err).Str("assetID", assetID).Int("recordCount", len(records)).Msg("History query budget exhausted, narrow the time window")
+			return nil, err
+		}
+
+		record, err := newHistoryQueryResult(presenter, assetID, response)
+		if err != nil {
+			return nil, err
+		}
+		if !presenter.visible(record.Record) {
+			return nil, denyAccess(ctx, "data residency", "asset %s is restricted to region %s", assetID, record.Record.Residency)
+		}
+		records = append(records, *record)
+	}
+
+	q.logger(ctx).Info().Str("assetID", assetID).Int("scanned", scanned).Int("recordCount", len(records)).Msg("Asset history between times retrieved successfully")
+	return records, nil
+}
+
+// FieldChange is a field whose value differs between two versions of an asset. Values are the
+// field's JSON text with strings unquoted, and empty when the field was not set.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// AssetChange lists the fields a transaction changed. Deletions have IsDelete set and no changes;
+// the first write after a deletion or of a new asset compares against an empty asset.
+type AssetChange struct {
+	TxID      string        `json:"txId"`
+	Timestamp time.Time     `json:"timestamp"`
+	Version   int           `json:"version"`
+	IsDelete  bool          `json:"isDelete"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+// changeBookkeepingFields change on every write and are reported by AssetChange itself
+var changeBookkeepingFields = map[string]bool{"checksum": true, "updatedAt": true, "version": true}
+
+// GetAssetChanges compares successive versions of an asset and returns the fields each
+// transaction changed, newest first, as the caller is allowed to see them
+func (q *QueryContract) GetAssetChanges(ctx contractapi.TransactionContextInterface, assetID string) ([]*AssetChange, error) {
+	q.logger(ctx).Info().Str("function", "GetAssetChanges").Str("assetID", assetID).Msg("Getting asset changes")
+
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(assetKey(assetID))
+	if err != nil {
+		q.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to get history for key")
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	presenter, err := q.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	budget := newQueryBudget(q.configProvider())
+	records := []*HistoryQueryResult{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			q.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to get next history record")
+			return nil, err
+		}
+		if err := budget.consume(len(response.Value)); err != nil {
+			q.logger(ctx).Warn().Err(err).Str("assetID", assetID).Int("recordCount", len(records)).Msg("History query budget exhausted, use GetAssetHistoryPage")
+			return nil, err
+		}
+		// Changes are those of the stored records, masked for restricted roles
+		record, err := decodeHistoryRecord(assetID, response)
+		if err != nil {
+			return nil, err
+		}
+		if !presenter.visible(record.Record) {
+			return nil, denyAccess(ctx, "data residency", "asset %s is restricted to region %s", assetID, record.Record.Residency)
+		}
+		record.Record = presenter.mask(record.Record)
+		records = append(records, record)
+	}
+
+	// History comes newest first, so each record is compared with the one after it
+	changes := make([]*AssetChange, 0, len(records))
+	for i, record := range records {
+		change := &AssetChange{TxID: record.TxId, Timestamp: record.Timestamp, IsDelete: record.IsDelete, Changes: []FieldChange{}}
+		if !record.IsDelete {
+			change.Version = record.Record.Version
+			var previous *Asset
+			if i+1 < len(records) && !records[i+1].IsDelete {
+				previous = records[i+1].Record
+			}
+			if change.Changes, err = diffAssets(previous, record.Record); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	q.logger(ctx).Info().Str("assetID", assetID).Int("changeCount", len(changes)).Msg("Asset changes retrieved successfully")
+	return changes, nil
+}
+
+// diffAssets returns the fields that differ between two versions of an asset in field name order,
+// comparing against an empty asset when previous is nil
+func diffAssets(previous, current *Asset) ([]FieldChange, error) {
+	oldFields, err := assetFields(previous)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := assetFields(current)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(newFields))
+	for name := range newFields {
+		names = append(names, name)
+	}
+	for name := range oldFields {
+		if _, ok := newFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []FieldChange{}
+	for _, name := range names {
+		if changeBookkeepingFields[name] || oldFields[name] == newFields[name] {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: name, Old: oldFields[name], New: newFields[name]})
+	}
+	return changes, nil
+}
+
+// assetFields returns the fields set in the JSON encoding of an asset
+func assetFields(asset *Asset) (map[string]string, error) {
+	fields := map[string]string{}
+	if asset == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(asset)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	for name, value := range raw {
+		var text string
+		if err := json.Unmarshal(value, &text); err != nil {
+			text = string(value)
+		}
+		fields[name] = text
+	}
+	return fields, nil
+}
+
+// historyPage reads the window of an asset's history after afterTxID
+func (q *QueryContract) historyPage(ctx contractapi.TransactionContextInterface, assetID, afterTxID string, limit int) (*HistoryPage, error) {
+	if limit <= 0 || limit > maxHistoryPageSize {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxHistoryPageSize)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(assetKey(assetID))
+	if err != nil {
+		q.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to get history for key")
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	presenter, err := q.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page := &HistoryPage{Records: []HistoryQueryResult{}}
+	skipping := afterTxID != ""
+	skipped := 0
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			q.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to get next history record")
+			return nil, err
+		}
+
+		if skipping {
+			skipped++
+			if response.TxId == afterTxID {
+				skipping = false
+			}
+			continue
+		}
+
+		if len(page.Records) == limit {
+			page.HasMore = true
+			break
+		}
+
+		record, err := newHistoryQueryResult(presenter, assetID, response)
+		if err != nil {
+			return nil, err
+		}
+		if !presenter.visible(record.Record) {
+			return nil, denyAccess(ctx, "data residency", "asset %s is restricted to region %s", assetID, record.Record.Residency)
+		}
+		page.Records = append(page.Records, *record)
+		page.LastTxID = response.TxId
+	}
+
+	if skipping {
+		q.logger(ctx).Warn().Str("assetID", assetID).Str("afterTxID", afterTxID).Msg("Transaction not found in asset history")
+		return nil, fmt.Errorf("transaction %s not found in history of asset %s", afterTxID, assetID)
+	}
+
+	q.logger(ctx).Info().
+		Str("assetID", assetID).
+		Int("skipped", skipped).
+		Int("recordCount", len(page.Records)).
+		Bool("hasMore", page.HasMore).
+		Msg("Asset history page retrieved successfully")
+	return page, nil
+}
+
+// AssetHistorySummary condenses the history of an asset: the transaction that first wrote it,
+// its most recent changes, newest first, and how many changes it has seen in total.
+// CurrentOwner is empty once the asset is deleted.
+type AssetHistorySummary struct {
+	AssetID      string               `json:"assetId"`
+	CreationTxID string               `json:"creationTxId"`
+	CreatedAt    time.Time            `json:"createdAt"`
+	LastChanges  []HistoryQueryResult `json:"lastChanges"`
+	ChangeCount  int                  `json:"changeCount"`
+	CurrentOwner string               `json:"currentOwner"`
+	Deleted      bool                 `json:"deleted"`
+}
+
+// GetMultipleAssetHistoriesSummary summarizes the histories of several assets in one call, with
+// the lastN most recent changes of each. Assets that were never written have a ChangeCount of 0.
+// All histories share one query budget.
+func (q *QueryContract) GetMultipleAssetHistoriesSummary(ctx contractapi.TransactionContextInterface, assetIDs []string, lastN int) ([]*AssetHistorySummary, error) {
+	q.logger(ctx).Info().
+		Str("function", "GetMultipleAssetHistoriesSummary").
+		Strs("assetIDs", assetIDs).
+		Int("lastN", lastN).
+		Msg("Summarizing asset histories")
+
+	if len(assetIDs) == 0 || len(assetIDs) > maxHistorySummaryAssets {
+		return nil, fmt.Errorf("between 1 and %d asset IDs must be given", maxHistorySummaryAssets)
+	}
+	if lastN < 0 || lastN > maxHistoryPageSize {
+		return nil, fmt.Errorf("lastN must be between 0 and %d", maxHistoryPageSize)
+	}
+
+	presenter, err := q.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	budget := newQueryBudget(q.configProvider())
+	summaries := make([]*AssetHistorySummary, 0, len(assetIDs))
+	for _, assetID := range assetIDs {
+		summary, err := summarizeAssetHistory(ctx, presenter, budget, assetID, lastN)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+
+	q.logger(ctx).Info().Int("assetCount", len(summaries)).Msg("Asset histories summarized successfully")
+	return summaries, nil
+}
+
+// summarizeAssetHistory walks the history of an asset once. Only the lastN newest records are
+// decoded; older ones are counted, and the oldest gives the creation transaction.
+func summarizeAssetHistory(ctx contractapi.TransactionContextInterface, presenter *assetPresenter, budget *queryBudget, assetID string, lastN int) (*AssetHistorySummary, error) {
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(assetKey(assetID))
+	if err != nil {
+		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to get history for key")
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	summary := &AssetHistorySummary{AssetID: assetID, LastChanges: []HistoryQueryResult{}}
+	var oldest *queryresult.KeyModification
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			log.Error().Err(err).Str("assetID", assetID).Msg("Failed to get next history record")
+			return nil, err
+		}
+		if err := budget.consume(len(response.Value)); err != nil {
+			return nil, err
+		}
+		newest := summary.ChangeCount == 0
+		if newest {
+			summary.Deleted = response.IsDelete
+		}
+		if summary.ChangeCount < lastN || (newest && !response.IsDelete) {
+			record, err := newHistoryQueryResult(presenter, assetID, response)
+			if err != nil {
+				return nil, err
+			}
+			if !presenter.visible(record.Record) {
+				return nil, denyAccess(ctx, "data residency", "asset %s is restricted to region %s", assetID, record.Record.Residency)
+			}
+			if newest && !response.IsDelete {
+				summary.CurrentOwner = record.Record.Owner
+			}
+			if summary.ChangeCount < lastN {
+				summary.LastChanges = append(summary.LastChanges, *record)
+			}
+		}
+		summary.ChangeCount++
+		oldest = response
+	}
+
+	if oldest != nil {
+		createdAt, err := ptypes.Timestamp(oldest.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		summary.CreationTxID = oldest.TxId
+		summary.CreatedAt = createdAt
+	}
+	return summary, nil
+}
+
+// newHistoryQueryResult converts a key modification returned by the history iterator into a
+// HistoryQueryResult presented for the caller
+func newHistoryQueryResult(presenter *assetPresenter, assetID string, response *queryresult.KeyModification) (*HistoryQueryResult, error) {
+	result, err := decodeHistoryRecord(assetID, response)
+	if err != nil {
+		return nil, err
+	}
+	result.Record = presenter.present(result.Record)
+	return result, nil
+}
+
+// decodeHistoryRecord returns a history entry with the record as it was stored. Deletions carry
+// no value, so only the asset ID is set on their record. Versions come from committed blocks and
+// may predate checksums, so they are not verified.
+func decodeHistoryRecord(assetID string, response *queryresult.KeyModification) (*HistoryQueryResult, error) {
+	var asset Asset
+	if len(response.Value) > 0 {
+		decoded, err := decodeAssetRecord(response.Value)
+		if err != nil {
+			log.Error().Err(err).Str("assetID", assetID).Str("txId", response.TxId).Msg("Failed to decode asset from history record")
+			return nil, err
+		}
+		asset = *decoded
+	} else {
+		asset = Asset{
+			ID: assetID,
+		}
+	}
+
+	timestamp, err := ptypes.Timestamp(response.Timestamp)
+	if err != nil {
+		log.Error().Err(err).Str("assetID", assetID).Str("txId", response.TxId).Msg("Failed to parse timestamp from history record")
+		return nil, err
+	}
+
+	return &HistoryQueryResult{
+		TxId:      response.TxId,
+		Timestamp: timestamp,
+		Record:    &asset,
+		IsDelete:  response.IsDelete,
+	}, nil
+}
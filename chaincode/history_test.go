@@ -0,0 +1,197 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordAssetHistory(t *testing.T, stub *historyStub, assetID string, versions int) {
+	t.Helper()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= versions; i++ {
+		value, err := json.Marshal(Asset{DocType: "asset", ID: assetID, Color: "blue", Size: i, Owner: "John"})
+		require.NoError(t, err)
+		stub.record(t, assetID, fmt.Sprintf("tx%d", i), start.Add(time.Duration(i)*time.Hour), value)
+	}
+}
+
+// TestGetAssetHistoryPage tests windowed history reads with afterTxID continuation
+func TestGetAssetHistoryPage(t *testing.T) {
+	ctx, stub := newHistoryContext(t, "Org1MSP", "user1")
+	recordAssetHistory(t, stub, "asset1", 5)
+	qc := &QueryContract{}
+
+	page, err := qc.GetAssetHistoryPage(ctx, "asset1", "", 2)
+	require.NoError(t, err)
+	require.Len(t, page.Records, 2)
+	assert.Equal(t, "tx5", page.Records[0].TxId)
+	assert.Equal(t, "tx4", page.LastTxID)
+	assert.True(t, page.HasMore)
+
+	page, err = qc.GetAssetHistoryPage(ctx, "asset1", page.LastTxID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "tx3", page.Records[0].TxId)
+	assert.True(t, page.HasMore)
+
+	page, err = qc.GetAssetHistoryPage(ctx, "asset1", page.LastTxID, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	assert.Equal(t, "tx1", page.LastTxID)
+	assert.False(t, page.HasMore)
+
+	_, err = qc.GetAssetHistoryPage(ctx, "asset1", "unknown", 2)
+	assert.Error(t, err)
+
+	_, err = qc.GetAssetHistoryPage(ctx, "asset1", "", maxHistoryPageSize+1)
+	assert.Error(t, err)
+}
+
+// TestGetAssetHistoryPaginated tests that bookmarks walk the history and are bound to the query
+func TestGetAssetHistoryPaginated(t *testing.T) {
+	ctx, stub := newHistoryContext(t, "Org1MSP", "user1")
+	recordAssetHistory(t, stub, "asset1", 5)
+	recordAssetHistory(t, stub, "asset2", 5)
+	qc := &QueryContract{}
+
+	txIDs := []string{}
+	bookmark := ""
+	for {
+		page, err := qc.GetAssetHistoryPaginated(ctx, "asset1", 2, bookmark)
+		require.NoError(t, err)
+		for _, record := range page.Records {
+			txIDs = append(txIDs, record.TxId)
+		}
+		if !page.HasMore {
+			assert.Empty(t, page.Bookmark)
+			break
+		}
+		require.NotEmpty(t, page.Bookmark)
+		bookmark = page.Bookmark
+	}
+	assert.Equal(t, []string{"tx5", "tx4", "tx3", "tx2", "tx1"}, txIDs)
+
+	page, err := qc.GetAssetHistoryPaginated(ctx, "asset1", 2, "")
+	require.NoError(t, err)
+	_, err = qc.GetAssetHistoryPaginated(ctx, "asset2", 2, page.Bookmark)
+	assert.ErrorContains(t, err, "different query")
+	_, err = qc.GetAssetHistoryPaginated(ctx, "asset1", 3, page.Bookmark)
+	assert.Error(t, err)
+	_, err = qc.GetAssetHistoryPaginated(ctx, "asset1", 2, "forged")
+	assert.Error(t, err)
+	_, err = qc.GetAssetHistoryPaginated(ctx, "asset1", 0, "")
+	assert.Error(t, err)
+}
+
+// TestGetAssetHistoryBetween tests that only records in the half-open time window are returned
+func TestGetAssetHistoryBetween(t *testing.T) {
+	ctx, stub := newHistoryContext(t, "Org1MSP", "user1")
+	recordAssetHistory(t, stub, "asset1", 5)
+	qc := &QueryContract{}
+
+	// Versions are recorded hourly from 2024-01-01T01:00:00Z
+	records, err := qc.GetAssetHistoryBetween(ctx, "asset1", "2024-01-01T02:00:00Z", "2024-01-01T04:00:00Z")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "tx3", records[0].TxId)
+	assert.Equal(t, "tx2", records[1].TxId)
+
+	records, err = qc.GetAssetHistoryBetween(ctx, "asset1", "2025-01-01T00:00:00Z", "2025-02-01T00:00:00Z")
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	_, err = qc.GetAssetHistoryBetween(ctx, "asset1", "yesterday", "2024-01-01T04:00:00Z")
+	assert.Error(t, err)
+	_, err = qc.GetAssetHistoryBetween(ctx, "asset1", "2024-01-01T04:00:00Z", "2024-01-01T04:00:00Z")
+	assert.Error(t, err)
+}
+
+// TestGetAssetChanges tests that each transaction lists the fields it changed with both values
+func TestGetAssetChanges(t *testing.T) {
+	ctx, stub := newHistoryContext(t, "Org1MSP", "user1")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	versions := []Asset{
+		{DocType: "asset", ID: "asset1", Color: "blue", Size: 5, Owner: "John", AppraisedValue: 100, Version: 1},
+		{DocType: "asset", ID: "asset1", Color: "red", Size: 5, Owner: "John", AppraisedValue: 100, Version: 2},
+		{DocType: "asset", ID: "asset1", Color: "red", Size: 5, Owner: "Jane", AppraisedValue: 150, Version: 3},
+	}
+	for i, asset := range versions {
+		value, err := json.Marshal(asset)
+		require.NoError(t, err)
+		stub.record(t, "asset1", fmt.Sprintf("tx%d", i+1), start.Add(time.Duration(i)*time.Hour), value)
+	}
+	stub.record(t, "asset1", "tx4", start.Add(4*time.Hour), nil)
+	qc := &QueryContract{}
+
+	changes, err := qc.GetAssetChanges(ctx, "asset1")
+	require.NoError(t, err)
+	require.Len(t, changes, 4)
+
+	assert.Equal(t, "tx4", changes[0].TxID)
+	assert.True(t, changes[0].IsDelete)
+	assert.Empty(t, changes[0].Changes)
+
+	assert.Equal(t, 3, changes[1].Version)
+	assert.Equal(t, []FieldChange{
+		{Field: "appraisedValue", Old: "100", New: "150"},
+		{Field: "owner", Old: "John", New: "Jane"},
+	}, changes[1].Changes)
+
+	assert.Equal(t, []FieldChange{{Field: "color", Old: "blue", New: "red"}}, changes[2].Changes)
+
+	// The creation compares against an empty asset
+	assert.Equal(t, "tx1", changes[3].TxID)
+	assert.Contains(t, changes[3].Changes, FieldChange{Field: "color", Old: "", New: "blue"})
+	assert.Contains(t, changes[3].Changes, FieldChange{Field: "size", Old: "", New: "5"})
+	// Changes are those of the stored records, without computed fields
+	for _, change := range changes[3].Changes {
+		assert.NotContains(t, []string{"valuationBand", "ageDays"}, change.Field)
+	}
+
+	changes, err = qc.GetAssetChanges(ctx, "missing")
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+// TestGetMultipleAssetHistoriesSummary tests condensed histories of several assets in one call
+func TestGetMultipleAssetHistoriesSummary(t *testing.T) {
+	ctx, stub := newHistoryContext(t, "Org1MSP", "user1")
+	recordAssetHistory(t, stub, "asset1", 5)
+	recordAssetHistory(t, stub, "asset2", 2)
+	stub.record(t, "asset2", "tx-delete", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), nil)
+	qc := &QueryContract{}
+
+	summaries, err := qc.GetMultipleAssetHistoriesSummary(ctx, []string{"asset1", "asset2", "missing"}, 2)
+	require.NoError(t, err)
+	require.Len(t, summaries, 3)
+
+	assert.Equal(t, "tx1", summaries[0].CreationTxID)
+	assert.Equal(t, time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC), summaries[0].CreatedAt)
+	assert.Equal(t, 5, summaries[0].ChangeCount)
+	assert.Equal(t, "John", summaries[0].CurrentOwner)
+	require.Len(t, summaries[0].LastChanges, 2)
+	assert.Equal(t, "tx5", summaries[0].LastChanges[0].TxId)
+	assert.Equal(t, "tx4", summaries[0].LastChanges[1].TxId)
+
+	assert.True(t, summaries[1].Deleted)
+	assert.Empty(t, summaries[1].CurrentOwner)
+	assert.Equal(t, 3, summaries[1].ChangeCount)
+	assert.True(t, summaries[1].LastChanges[0].IsDelete)
+
+	assert.Zero(t, summaries[2].ChangeCount)
+	assert.Empty(t, summaries[2].LastChanges)
+
+	summaries, err = qc.GetMultipleAssetHistoriesSummary(ctx, []string{"asset1"}, 0)
+	require.NoError(t, err)
+	assert.Empty(t, summaries[0].LastChanges)
+	assert.Equal(t, "John", summaries[0].CurrentOwner)
+
+	_, err = qc.GetMultipleAssetHistoriesSummary(ctx, nil, 2)
+	assert.Error(t, err)
+	_, err = qc.GetMultipleAssetHistoriesSummary(ctx, []string{"asset1"}, maxHistoryPageSize+1)
+	assert.Error(t, err)
+}
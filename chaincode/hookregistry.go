@@ -0,0 +1,89 @@
+package chaincode
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// BeforeCreateHook runs before a new asset is stored. It may adjust the asset, e.g. to fill
+// in domain defaults, or return an error to reject the creation.
+type BeforeCreateHook func(ctx contractapi.TransactionContextInterface, asset *Asset) error
+
+// AfterTransferHook runs after an asset has been stored with its new owner. Returning an
+// error fails the whole transaction, so nothing of the transfer is committed.
+type AfterTransferHook func(ctx contractapi.TransactionContextInterface, asset *Asset, previousOwner string) error
+
+// ValidateHook checks an asset as it is about to be stored by a create or update. It runs
+// in addition to the built-in field checks and also when those are disabled with WithValidation.
+type ValidateHook func(ctx contractapi.TransactionContextInterface, asset *Asset) error
+
+// HookRegistry holds the domain callbacks that embedding projects register to extend the
+// create, update and transfer flows without forking them. Hooks run in registration order
+// and the first error aborts the transaction. Register hooks before the chaincode starts;
+// the registry is not safe for concurrent registration.
+type HookRegistry struct {
+	beforeCreate  []BeforeCreateHook
+	afterTransfer []AfterTransferHook
+	validate      []ValidateHook
+}
+
+// NewHookRegistry returns an empty registry
+func NewHookRegistry() *HookRegistry {
+	return &HookRegistry{}
+}
+
+// OnBeforeCreate registers a hook run by CreateAsset before the asset is stored
+func (r *HookRegistry) OnBeforeCreate(hook BeforeCreateHook) *HookRegistry {
+	r.beforeCreate = append(r.beforeCreate, hook)
+	return r
+}
+
+// OnAfterTransfer registers a hook run after TransferAsset and TransferAssetByColor change an owner
+func (r *HookRegistry) OnAfterTransfer(hook AfterTransferHook) *HookRegistry {
+	r.afterTransfer = append(r.afterTransfer, hook)
+	return r
+}
+
+// OnValidate registers a hook run before an asset is stored by a create or update
+func (r *HookRegistry) OnValidate(hook ValidateHook) *HookRegistry {
+	r.validate = append(r.validate, hook)
+	return r
+}
+
+// runBeforeCreate runs the before-create hooks; a nil registry has no hooks
+func (r *HookRegistry) runBeforeCreate(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	if r == nil {
+		return nil
+	}
+	for _, hook := range r.beforeCreate {
+		if err := hook(ctx, asset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runAfterTransfer runs the after-transfer hooks; a nil registry has no hooks
+func (r *HookRegistry) runAfterTransfer(ctx contractapi.TransactionContextInterface, asset *Asset, previousOwner string) error {
+	if r == nil {
+		return nil
+	}
+	for _, hook := range r.afterTransfer {
+		if err := hook(ctx, asset, previousOwner); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runValidate runs the validation hooks; a nil registry has no hooks
+func (r *HookRegistry) runValidate(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	if r == nil {
+		return nil
+	}
+	for _, hook := range r.validate {
+		if err := hook(ctx, asset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
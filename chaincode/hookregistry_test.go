@@ -0,0 +1,45 @@
+package chaincode
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHookRegistry tests that registered hooks adjust, veto and observe the core flows
+func TestHookRegistry(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	var transfers []string
+	hooks := NewHookRegistry().
+		OnBeforeCreate(func(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+			if asset.Owner == "" {
+				asset.Owner = "treasury"
+			}
+			return nil
+		}).
+		OnValidate(func(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+			if asset.AppraisedValue > 1000 {
+				return fmt.Errorf("appraised value of %s exceeds the limit", asset.ID)
+			}
+			return nil
+		}).
+		OnAfterTransfer(func(ctx contractapi.TransactionContextInterface, asset *Asset, previousOwner string) error {
+			transfers = append(transfers, previousOwner+"->"+asset.Owner)
+			return nil
+		})
+	cc := NewAssetContract(WithHooks(hooks))
+
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "", 100))
+	asset, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "treasury", asset.Owner)
+
+	assert.Error(t, cc.CreateAsset(ctx, "asset2", "blue", 5, "John", 5000))
+	assert.Error(t, cc.UpdateAsset(ctx, "asset1", "blue", 5, "treasury", 5000, 0))
+
+	require.NoError(t, cc.TransferAsset(ctx, "asset1", "Jane", 0))
+	assert.Equal(t, []string{"treasury->Jane"}, transfers)
+}
@@ -0,0 +1,244 @@
+package chaincode
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// GetBeforeTransaction returns the handler run by contractapi before every AssetContract transaction
+func (t *AssetContract) GetBeforeTransaction() interface{} {
+	return t.beforeAssetTransaction
+}
+
+// beforeAssetTransaction adds invariant tracking and the attribute-based access checks to the
+// shared checks
+func (t *AssetContract) beforeAssetTransaction(ctx contractapi.TransactionContextInterface) error {
+	if err := t.beforeTransaction(ctx); err != nil {
+		return err
+	}
+	if t.invariants != nil {
+		if err := trackAssetWrites(ctx); err != nil {
+			return err
+		}
+	}
+	if t.attributeAccessControl {
+		return authorizeAssetFunction(ctx)
+	}
+	return nil
+}
+
+// GetAfterTransaction returns the handler run by contractapi after every successful
+// AssetContract transaction
+func (t *AssetContract) GetAfterTransaction() interface{} {
+	return t.afterAssetTransaction
+}
+
+// afterAssetTransaction checks the registered invariants and counts the bytes written against
+// the caller's organization
+func (t *AssetContract) afterAssetTransaction(ctx contractapi.TransactionContextInterface) error {
+	if err := t.checkInvariants(ctx); err != nil {
+		return err
+	}
+	return t.recordTenantBytes(ctx)
+}
+
+// beforeTransaction runs the checks shared by every asset and query transaction
+func (r *contractRuntime) beforeTransaction(ctx contractapi.TransactionContextInterface) error {
+	logInvocation(ctx, r.logger(ctx))
+	if err := r.checkInitialized(ctx); err != nil {
+		return err
+	}
+	if err := r.checkCertificateExpiry(ctx); err != nil {
+		return err
+	}
+	if err := checkCircuitBreaker(ctx); err != nil {
+		return err
+	}
+	if r.usageStats {
+		if err := r.recordUsage(ctx); err != nil {
+			return err
+		}
+	}
+	if r.tenantUsage {
+		return r.recordTenantTransaction(ctx)
+	}
+	return nil
+}
+
+// GetBeforeTransaction returns the handler run by contractapi before every AdminContract transaction
+func (a *AdminContract) GetBeforeTransaction() interface{} {
+	return beforeAdminTransaction
+}
+
+// GetBeforeTransaction returns the handler run by contractapi before every ConfigContract transaction
+func (c *ConfigContract) GetBeforeTransaction() interface{} {
+	return beforeAdminTransaction
+}
+
+// GetBeforeTransaction returns the handler run by contractapi before every TokenContract transaction
+func (c *TokenContract) GetBeforeTransaction() interface{} {
+	return beforeTokenTransaction
+}
+
+// GetBeforeTransaction returns the handler run by contractapi before every NFTContract transaction
+func (c *NFTContract) GetBeforeTransaction() interface{} {
+	return beforeTokenTransaction
+}
+
+// GetEvaluateTransactions returns the NFTContract functions that only read tokens
+func (c *NFTContract) GetEvaluateTransactions() []string {
+	return []string{
+		"BalanceOf",
+		"GetApproved",
+		"IsApprovedForAll",
+		"OwnerOf",
+		"TokenURI",
+		"TokensOfOwner",
+	}
+}
+
+// GetBeforeTransaction returns the handler run by contractapi before every EscrowContract transaction
+func (e *EscrowContract) GetBeforeTransaction() interface{} {
+	return beforeTokenTransaction
+}
+
+// GetEvaluateTransactions returns the EscrowContract functions that only read escrows
+func (e *EscrowContract) GetEvaluateTransactions() []string {
+	return []string{
+		"GetEscrow",
+	}
+}
+
+// beforeTokenTransaction logs the invocation and applies the circuit breaker; the functions
+// check the caller themselves, since most act on the caller's own account
+func beforeTokenTransaction(ctx contractapi.TransactionContextInterface) error {
+	logger := log.With().Str("txId", ctx.GetStub().GetTxID()).Logger()
+	logInvocation(ctx, &logger)
+	return checkCircuitBreaker(ctx)
+}
+
+// GetEvaluateTransactions returns the TokenContract functions that only read balances
+func (c *TokenContract) GetEvaluateTransactions() []string {
+	return []string{
+		"Allowance",
+		"BalanceOf",
+		"GetTokenInfo",
+	}
+}
+
+// adminReadFunctions are the AdminContract and ConfigContract functions any member may call
+var adminReadFunctions = map[string]bool{
+	"GetAdminMSPs":         true,
+	"GetAuditEntries":      true,
+	"GetCalendar":          true,
+	"GetCircuitBreaker":    true,
+	"GetEventFormat":       true,
+	"GetIDRanges":          true,
+	"GetMSPRegion":         true,
+	"GetOrg":               true,
+	"GetOutboxCheckpoints": true,
+	"GetSchemaHistory":     true,
+}
+
+// beforeAdminTransaction logs the invocation and rejects callers who are not admins before any
+// administrative function that is not in adminReadFunctions runs. The functions keep their own
+// requireAdmin checks for callers that bypass the contract router.
+func beforeAdminTransaction(ctx contractapi.TransactionContextInterface) error {
+	logger := log.With().Str("txId", ctx.GetStub().GetTxID()).Logger()
+	function := logInvocation(ctx, &logger)
+	if adminReadFunctions[function] {
+		return nil
+	}
+	return requireAdmin(ctx)
+}
+
+// logInvocation logs who invoked which function on which channel, once per transaction, and
+// returns the function name without its contract prefix
+func logInvocation(ctx contractapi.TransactionContextInterface, logger *zerolog.Logger) string {
+	stub := ctx.GetStub()
+	function, _ := stub.GetFunctionAndParameters()
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		mspID = "unknown"
+	}
+	logger.Info().Str("channel", stub.GetChannelID()).Str("function", function).Str("mspId", mspID).Msg("Transaction invoked")
+	if i := strings.LastIndex(function, ":"); i >= 0 {
+		function = function[i+1:]
+	}
+	return function
+}
+
+// GetEvaluateTransactions returns the asset functions that only simulate writes, which clients
+// should evaluate rather than submit
+func (t *AssetContract) GetEvaluateTransactions() []string {
+	return []string{
+		"PreviewTransfer",
+		"PreviewUpdate",
+	}
+}
+
+// GetBeforeTransaction returns the handler run by contractapi before every QueryContract transaction
+func (q *QueryContract) GetBeforeTransaction() interface{} {
+	return q.beforeTransaction
+}
+
+// GetEvaluateTransactions returns every QueryContract function, since none of them writes state
+func (q *QueryContract) GetEvaluateTransactions() []string {
+	return []string{
+		"AssetExists",
+		"CheckConsent",
+		"CompareDigests",
+		"ExplainQuery",
+		"GetAllAssets",
+		"GetAssetChanges",
+		"GetAssetEndorsementPolicy",
+		"GetAssetHistory",
+		"GetAssetHistoryBetween",
+		"GetAssetHistoryPage",
+		"GetAssetHistoryPaginated",
+		"GetAssetsByIndex",
+		"GetAssetsByIDPrefix",
+		"GetAssetsByOwnerRange",
+		"GetAssetsByPath",
+		"GetAssetsByRange",
+		"GetAssetsByRangeWithPagination",
+		"GetAssetsByRangeWithPaginationEncoded",
+		"GetCertificateStatus",
+		"GetChangesSince",
+		"GetClientIdentity",
+		"GetConsentsByGrantor",
+		"GetConsentsBySubject",
+		"GetDeadLetters",
+		"GetDeploymentParams",
+		"GetEventTypes",
+		"GetExportReport",
+		"GetIndexStatus",
+		"GetMultipleAssetHistoriesSummary",
+		"GetOperationReceipt",
+		"GetPendingTransfer",
+		"GetSLATimer",
+		"GetSLATimersByStatus",
+		"GetSavedQueries",
+		"GetSubscriptions",
+		"GetTenantUsage",
+		"GetUsageStats",
+		"IsBusinessDay",
+		"QueryAssets",
+		"QueryAssetsByFilter",
+		"QueryAssetsByOwner",
+		"QueryAssetsWithPagination",
+		"QueryAssetsWithPaginationEncoded",
+		"ReadAsset",
+		"ReadAssetPrivateDetails",
+		"ReadAssetProto",
+		"ReadAssets",
+		"ReadDeletedAsset",
+		"ReadSnapshot",
+		"RunSavedQuery",
+		"VerifyOwnership",
+		"VerifyTokenOwnership",
+	}
+}
@@ -0,0 +1,48 @@
+package chaincode
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBeforeTransactionLogging tests that every invocation is logged once with its channel,
+// function and caller MSP
+func TestBeforeTransactionLogging(t *testing.T) {
+	var buf bytes.Buffer
+	cc, err := contractapi.NewChaincode(NewAssetContract(WithLogger(zerolog.New(&buf))))
+	require.NoError(t, err)
+	stub := shimtest.NewMockStub("chaincode", cc)
+	stub.ChannelID = "mychannel"
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "user1", nil)
+
+	status, message := invoke(stub, "tx1", "CreateAsset", "asset1", "blue", "5", "user1", "100")
+	require.Equal(t, int32(shim.OK), status, message)
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("Transaction invoked")))
+	assert.Contains(t, buf.String(), `"txId":"tx1","channel":"mychannel","function":"CreateAsset","mspId":"Org1MSP"`)
+}
+
+// TestAdminTransactionGuard tests that non-admins are turned away before administrative
+// functions run, while the read-only ones stay open to every member
+func TestAdminTransactionGuard(t *testing.T) {
+	cc, err := contractapi.NewChaincode(&AssetContract{}, &ConfigContract{})
+	require.NoError(t, err)
+	stub := shimtest.NewMockStub("chaincode", cc)
+
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "user1", nil)
+	status, message := invoke(stub, "tx1", "ConfigContract:SetEventFormat", "cloudevents", "urn:test")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "ACCESS_DENIED")
+	status, message = invoke(stub, "tx2", "ConfigContract:GetEventFormat")
+	assert.Equal(t, int32(shim.OK), status, message)
+
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "admin", adminAttrs)
+	status, message = invoke(stub, "tx3", "ConfigContract:SetEventFormat", "cloudevents", "urn:test")
+	assert.Equal(t, int32(shim.OK), status, message)
+}
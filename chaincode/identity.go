@@ -0,0 +1,283 @@
+package chaincode
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+// Roles carried in the "role" certificate attribute, comma separated when an identity has several
+const (
+	roleAdmin   = "admin"
+	roleAuditor = "auditor"
+)
+
+const (
+	// certExpiryConfig is the configuration key of the certificate expiry policy
+	certExpiryConfig = "certexpiry"
+	// defaultCertWarnDays is the warning window used until a policy is configured
+	defaultCertWarnDays = 30
+)
+
+// Certificate expiry statuses reported by GetCertificateStatus
+const (
+	CertStatusValid    = "valid"
+	CertStatusExpiring = "expiring"
+	CertStatusRejected = "rejected"
+)
+
+// CertExpiryPolicy sets how close to NotAfter a caller certificate is warned about or rejected.
+// A RejectWithinDays of 0 never rejects.
+type CertExpiryPolicy struct {
+	WarnWithinDays   int `json:"warnWithinDays"`
+	RejectWithinDays int `json:"rejectWithinDays"`
+}
+
+// CertificateStatus reports the expiry of the caller's certificate against the policy
+type CertificateStatus struct {
+	Subject       string    `json:"subject"`
+	NotAfter      time.Time `json:"notAfter"`
+	DaysRemaining int       `json:"daysRemaining"`
+	Status        string    `json:"status"`
+	Warning       string    `json:"warning,omitempty" metadata:",optional"`
+}
+
+// SetCertExpiryPolicy sets the windows, in days before expiry, in which caller certificates are
+// warned about and rejected
+func (c *ConfigContract) SetCertExpiryPolicy(ctx contractapi.TransactionContextInterface, warnWithinDays, rejectWithinDays int) error {
+	log.Info().Str("function", "SetCertExpiryPolicy").Int("warnWithinDays", warnWithinDays).Int("rejectWithinDays", rejectWithinDays).Msg("Setting certificate expiry policy")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if warnWithinDays < 0 || rejectWithinDays < 0 {
+		return fmt.Errorf("expiry windows must not be negative")
+	}
+	if rejectWithinDays > warnWithinDays {
+		return fmt.Errorf("rejection window must not exceed the warning window")
+	}
+	policy := &CertExpiryPolicy{WarnWithinDays: warnWithinDays, RejectWithinDays: rejectWithinDays}
+	if err := putConfig(ctx, policy, certExpiryConfig); err != nil {
+		return err
+	}
+	return recordAudit(ctx, "SetCertExpiryPolicy", "", fmt.Sprintf("warn=%d reject=%d", warnWithinDays, rejectWithinDays))
+}
+
+// GetCertificateStatus reports how soon the caller's certificate expires, with a warning when it
+// falls within the warning window, so clients can rotate identities ahead of rejection
+func (q *QueryContract) GetCertificateStatus(ctx contractapi.TransactionContextInterface) (*CertificateStatus, error) {
+	q.logger(ctx).Info().Str("function", "GetCertificateStatus").Msg("Checking caller certificate expiry")
+	return q.certificateStatus(ctx)
+}
+
+// checkCertificateExpiry rejects callers whose certificate expires within the rejection window
+// and logs a warning for those within the warning window
+func (r *contractRuntime) checkCertificateExpiry(ctx contractapi.TransactionContextInterface) error {
+	status, err := r.certificateStatus(ctx)
+	if err != nil {
+		return err
+	}
+	switch status.Status {
+	case CertStatusRejected:
+		r.logger(ctx).Warn().Str("subject", status.Subject).Time("notAfter", status.NotAfter).Msg("Invocation rejected, certificate expiring")
+		return newChaincodeError(ErrCodeCertificateExpiring, "%s", status.Warning)
+	case CertStatusExpiring:
+		r.logger(ctx).Warn().Str("subject", status.Subject).Int("daysRemaining", status.DaysRemaining).Msg(status.Warning)
+	}
+	return nil
+}
+
+// certificateStatus evaluates the caller's certificate at the transaction time
+func (r *contractRuntime) certificateStatus(ctx contractapi.TransactionContextInterface) (*CertificateStatus, error) {
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client certificate: %v", err)
+	}
+	policy := &CertExpiryPolicy{WarnWithinDays: defaultCertWarnDays}
+	if _, err := getConfig(ctx, policy, certExpiryConfig); err != nil {
+		return nil, err
+	}
+	now, err := r.now(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	day := 24 * time.Hour
+	remaining := cert.NotAfter.Sub(now)
+	status := &CertificateStatus{
+		Subject:       cert.Subject.CommonName,
+		NotAfter:      cert.NotAfter.UTC(),
+		DaysRemaining: int(remaining / day),
+		Status:        CertStatusValid,
+	}
+	switch {
+	case policy.RejectWithinDays > 0 && remaining < time.Duration(policy.RejectWithinDays)*day:
+		status.Status = CertStatusRejected
+		status.Warning = fmt.Sprintf("certificate of %s expires at %s, within the %d day rejection window", status.Subject, status.NotAfter.Format(time.RFC3339), policy.RejectWithinDays)
+	case remaining < time.Duration(policy.WarnWithinDays)*day:
+		status.Status = CertStatusExpiring
+		status.Warning = fmt.Sprintf("certificate of %s expires at %s, renew it within %d days", status.Subject, status.NotAfter.Format(time.RFC3339), status.DaysRemaining)
+	}
+	return status, nil
+}
+
+// getCallerEnrollmentID returns the enrollment ID of the caller, which is the name asset owners
+// are recorded under. Fabric CA embeds it as the hf.EnrollmentID attribute; certificates issued
+// without attributes fall back to the subject common name.
+func getCallerEnrollmentID(ctx contractapi.TransactionContextInterface) (string, error) {
+	if cached, ok := ctx.(TransactionContextInterface); ok {
+		return cached.CallerID()
+	}
+	return readCallerEnrollmentID(ctx)
+}
+
+// getCallerAccount returns the caller's account in the token, NFT and escrow ledgers. Enrollment
+// IDs are only unique within the CA of one organization, so the account qualifies the
+// enrollment ID with the MSP ID: another organization enrolling the same name gets another
+// account.
+func getCallerAccount(ctx contractapi.TransactionContextInterface) (string, error) {
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		return "", err
+	}
+	enrollmentID, err := getCallerEnrollmentID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return accountID(mspID, enrollmentID), nil
+}
+
+// accountID returns the account "<MSP ID>:<enrollment ID>" of an identity
+func accountID(mspID, enrollmentID string) string {
+	return mspID + ":" + enrollmentID
+}
+
+// splitAccount returns the MSP ID and enrollment ID of an account
+func splitAccount(account string) (string, string) {
+	mspID, enrollmentID, _ := strings.Cut(account, ":")
+	return mspID, enrollmentID
+}
+
+// holderAccount returns the account of the owner of an asset
+func holderAccount(asset *Asset) string {
+	return accountID(asset.OwnerMSP, asset.Owner)
+}
+
+// heldByAccount reports whether account owns asset. Assets written before OwnerMSP was recorded
+// are held by no account until a transfer records their organization.
+func heldByAccount(asset *Asset, account string) bool {
+	return asset.OwnerMSP != "" && holderAccount(asset) == account
+}
+
+// resolveAccount returns the account named by name: name itself when it is an account
+// "<MSP ID>:<enrollment ID>" of any organization, otherwise the enrollment ID name in the caller's
+// organization
+func resolveAccount(ctx contractapi.TransactionContextInterface, name string) (string, error) {
+	if strings.Contains(name, ":") {
+		return name, checkAccount(name)
+	}
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return accountID(mspID, name), nil
+}
+
+// setAssetOwner makes the account owner names, see resolveAccount, the owner of asset. A bare
+// owner naming the current owner leaves the asset with its organization, e.g. on updates of
+// other fields.
+func setAssetOwner(ctx contractapi.TransactionContextInterface, asset *Asset, owner string) error {
+	if owner == asset.Owner && asset.OwnerMSP != "" {
+		return nil
+	}
+	account, err := resolveAccount(ctx, owner)
+	if err != nil {
+		return err
+	}
+	asset.OwnerMSP, asset.Owner = splitAccount(account)
+	return nil
+}
+
+// checkAccount rejects accounts that are not of the form "<MSP ID>:<enrollment ID>"
+func checkAccount(account string) error {
+	mspID, enrollmentID, found := strings.Cut(account, ":")
+	if !found || mspID == "" || enrollmentID == "" {
+		return fmt.Errorf("account %q must be of the form <MSP ID>:<enrollment ID>", account)
+	}
+	return nil
+}
+
+// getCallerMSPID returns the MSP ID of the caller
+func getCallerMSPID(ctx contractapi.TransactionContextInterface) (string, error) {
+	if cached, ok := ctx.(TransactionContextInterface); ok {
+		return cached.CallerMSP()
+	}
+	return readCallerMSPID(ctx)
+}
+
+// readCallerMSPID reads the MSP ID from the client identity
+func readCallerMSPID(ctx contractapi.TransactionContextInterface) (string, error) {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	return mspID, nil
+}
+
+// readCallerEnrollmentID reads the enrollment ID from the client certificate
+func readCallerEnrollmentID(ctx contractapi.TransactionContextInterface) (string, error) {
+	enrollmentID, found, err := ctx.GetClientIdentity().GetAttributeValue("hf.EnrollmentID")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read enrollment ID attribute")
+		return "", fmt.Errorf("failed to read enrollment ID: %v", err)
+	}
+	if found && enrollmentID != "" {
+		return enrollmentID, nil
+	}
+
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read client certificate")
+		return "", fmt.Errorf("failed to read client certificate: %v", err)
+	}
+	if cert.Subject.CommonName == "" {
+		return "", fmt.Errorf("client certificate has no common name")
+	}
+	return cert.Subject.CommonName, nil
+}
+
+// getCallerRoles returns the comma separated values of the caller's role attribute
+func getCallerRoles(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	value, found, err := ctx.GetClientIdentity().GetAttributeValue("role")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read role attribute: %v", err)
+	}
+	if !found || value == "" {
+		return []string{}, nil
+	}
+
+	var roles []string
+	for _, role := range strings.Split(value, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+// callerHasRole reports whether the caller's role attribute includes role
+func callerHasRole(ctx contractapi.TransactionContextInterface, role string) (bool, error) {
+	roles, err := getCallerRoles(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range roles {
+		if r == role {
+			return true, nil
+		}
+	}
+	return false, nil
+}
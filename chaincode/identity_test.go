@@ -0,0 +1,53 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCertificateExpiry tests that certificates close to expiry are warned about and then rejected.
+// Test certificates expire 24 hours after they are generated.
+func TestCertificateExpiry(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	qc := &QueryContract{}
+	config := &ConfigContract{}
+
+	status, err := qc.GetCertificateStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, CertStatusExpiring, status.Status)
+	assert.Equal(t, 0, status.DaysRemaining)
+	assert.Contains(t, status.Warning, "admin")
+
+	assert.Error(t, config.SetCertExpiryPolicy(ctx, 1, 2))
+	require.NoError(t, config.SetCertExpiryPolicy(ctx, 0, 0))
+	status, err = qc.GetCertificateStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, CertStatusValid, status.Status)
+	assert.Empty(t, status.Warning)
+
+	require.NoError(t, config.SetCertExpiryPolicy(ctx, 30, 7))
+	err = qc.checkCertificateExpiry(ctx)
+	assert.True(t, hasErrorCode(err, ErrCodeCertificateExpiring))
+}
+
+// TestCertificateExpiryRejection tests that invocations are refused within the rejection window
+func TestCertificateExpiryRejection(t *testing.T) {
+	cc, err := contractapi.NewChaincode(NewAssetContract(), NewQueryContract(), &ConfigContract{})
+	require.NoError(t, err)
+	stub := shimtest.NewMockStub("chaincode", cc)
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "admin", adminAttrs)
+
+	status, message := invoke(stub, "tx1", "CreateAsset", "asset1", "blue", "5", "John", "100")
+	require.Equal(t, int32(shim.OK), status, message)
+	status, message = invoke(stub, "tx2", "ConfigContract:SetCertExpiryPolicy", "30", "7")
+	require.Equal(t, int32(shim.OK), status, message)
+
+	status, message = invoke(stub, "tx3", "CreateAsset", "asset2", "blue", "5", "John", "100")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, ErrCodeCertificateExpiring)
+}
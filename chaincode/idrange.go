@@ -0,0 +1,177 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// idRangeIndex keys reserved ID ranges by prefix and zero-padded start
+	idRangeIndex = "idrange~prefix~start"
+	// idPrefixIndex holds the next unreserved number of each managed prefix
+	idPrefixIndex = "idprefix~prefix"
+	// idPrefixesConfig is the configuration key listing every managed prefix
+	idPrefixesConfig = "idprefixes"
+)
+
+// IDRange reserves the asset IDs prefix+Start through prefix+End for one organization
+type IDRange struct {
+	Prefix string `json:"prefix"`
+	MSPID  string `json:"mspId"`
+	Start  uint64 `json:"start"`
+	End    uint64 `json:"end"`
+	TxID   string `json:"txId"`
+}
+
+// AllocateIDRange reserves the next size numbers under prefix for an organization. Once a prefix
+// has a range, CreateAsset only accepts IDs of the form <prefix><number> from organizations
+// holding a range that contains the number, so organizations cannot mint colliding IDs.
+func (a *AdminContract) AllocateIDRange(ctx contractapi.TransactionContextInterface, mspID, prefix string, size int) (*IDRange, error) {
+	log.Info().Str("function", "AllocateIDRange").Str("mspID", mspID).Str("prefix", prefix).Int("size", size).Msg("Allocating asset ID range")
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if mspID == "" || prefix == "" {
+		return nil, fmt.Errorf("mspID and prefix must not be empty")
+	}
+	if unicode.IsDigit(rune(prefix[len(prefix)-1])) {
+		return nil, fmt.Errorf("prefix %s must not end with a digit", prefix)
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("size must be positive")
+	}
+
+	prefixKey, err := ctx.GetStub().CreateCompositeKey(idPrefixIndex, []string{prefix})
+	if err != nil {
+		return nil, err
+	}
+	nextBytes, err := ctx.GetStub().GetState(prefixKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ID prefix %s: %v", prefix, err)
+	}
+	next := uint64(1)
+	if nextBytes == nil {
+		if err := addManagedIDPrefix(ctx, prefix); err != nil {
+			return nil, err
+		}
+	} else {
+		next, err = strconv.ParseUint(string(nextBytes), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	idRange := &IDRange{
+		Prefix: prefix,
+		MSPID:  mspID,
+		Start:  next,
+		End:    next + uint64(size) - 1,
+		TxID:   ctx.GetStub().GetTxID(),
+	}
+	rangeBytes, err := json.Marshal(idRange)
+	if err != nil {
+		return nil, err
+	}
+	rangeKey, err := ctx.GetStub().CreateCompositeKey(idRangeIndex, []string{prefix, fmt.Sprintf("%020d", idRange.Start)})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(rangeKey, rangeBytes); err != nil {
+		log.Error().Err(err).Str("prefix", prefix).Msg("Failed to store ID range")
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(prefixKey, []byte(strconv.FormatUint(idRange.End+1, 10))); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, "AllocateIDRange", mspID, fmt.Sprintf("%s%d-%s%d", prefix, idRange.Start, prefix, idRange.End)); err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("mspID", mspID).Str("prefix", prefix).Uint64("start", idRange.Start).Uint64("end", idRange.End).Msg("Asset ID range allocated")
+	return idRange, nil
+}
+
+// GetIDRanges returns the ranges reserved under a prefix in allocation order
+func (a *AdminContract) GetIDRanges(ctx contractapi.TransactionContextInterface, prefix string) ([]*IDRange, error) {
+	log.Info().Str("function", "GetIDRanges").Str("prefix", prefix).Msg("Reading asset ID ranges")
+	return getIDRanges(ctx, prefix)
+}
+
+func getIDRanges(ctx contractapi.TransactionContextInterface, prefix string) ([]*IDRange, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(idRangeIndex, []string{prefix})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ID ranges of %s: %v", prefix, err)
+	}
+	defer iterator.Close()
+
+	ranges := []*IDRange{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var idRange IDRange
+		if err := json.Unmarshal(response.Value, &idRange); err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, &idRange)
+	}
+	return ranges, nil
+}
+
+// addManagedIDPrefix adds a prefix to the list checked by CreateAsset
+func addManagedIDPrefix(ctx contractapi.TransactionContextInterface, prefix string) error {
+	prefixes := []string{}
+	if _, err := getConfig(ctx, &prefixes, idPrefixesConfig); err != nil {
+		return err
+	}
+	prefixes = append(prefixes, prefix)
+	return putConfig(ctx, prefixes, idPrefixesConfig)
+}
+
+// checkIDRange rejects asset IDs starting with a managed prefix unless the rest of the ID is a
+// number without leading zeros inside a range reserved for mspID. When prefixes nest, the longest match applies.
+func checkIDRange(ctx contractapi.TransactionContextInterface, mspID, assetID string) error {
+	prefixes := []string{}
+	if _, err := getConfig(ctx, &prefixes, idPrefixesConfig); err != nil {
+		return err
+	}
+	prefix := ""
+	for _, managed := range prefixes {
+		if strings.HasPrefix(assetID, managed) && len(managed) > len(prefix) {
+			prefix = managed
+		}
+	}
+	if prefix == "" {
+		return nil
+	}
+
+	// Only the canonical form is accepted, otherwise SN-7 and SN-007 would be distinct assets
+	// with the same reserved number
+	suffix := assetID[len(prefix):]
+	number, err := strconv.ParseUint(suffix, 10, 64)
+	if err != nil || strconv.FormatUint(number, 10) != suffix {
+		return fmt.Errorf("asset ID %s must be %s followed by a reserved number without leading zeros", assetID, prefix)
+	}
+	ranges, err := getIDRanges(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, idRange := range ranges {
+		if number >= idRange.Start && number <= idRange.End {
+			if idRange.MSPID != mspID {
+				log.Warn().Str("assetID", assetID).Str("mspID", mspID).Str("owner", idRange.MSPID).Msg("Asset ID reserved for another organization")
+				return fmt.Errorf("asset ID %s is reserved for %s", assetID, idRange.MSPID)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("asset ID %s is outside the reserved ranges of prefix %s", assetID, prefix)
+}
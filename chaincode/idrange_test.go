@@ -0,0 +1,44 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAllocateIDRange tests that ranges are handed out consecutively and enforced on create
+func TestAllocateIDRange(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	admin := &AdminContract{}
+	cc := &AssetContract{}
+
+	_, err := admin.AllocateIDRange(ctx, "Org1MSP", "SN-2024", 10)
+	assert.Error(t, err)
+
+	first, err := admin.AllocateIDRange(ctx, "Org1MSP", "SN-", 10)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), first.Start)
+	assert.Equal(t, uint64(10), first.End)
+	second, err := admin.AllocateIDRange(ctx, "Org2MSP", "SN-", 5)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(11), second.Start)
+	assert.Equal(t, uint64(15), second.End)
+
+	ranges, err := admin.GetIDRanges(ctx, "SN-")
+	require.NoError(t, err)
+	assert.Len(t, ranges, 2)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "user1", nil)
+	require.NoError(t, cc.CreateAsset(ctx, "SN-7", "blue", 5, "John", 100))
+	assert.Error(t, cc.CreateAsset(ctx, "SN-07", "blue", 5, "John", 100))
+	assert.Error(t, cc.CreateAsset(ctx, "SN-12", "blue", 5, "John", 100))
+	assert.Error(t, cc.CreateAsset(ctx, "SN-16", "blue", 5, "John", 100))
+	assert.Error(t, cc.CreateAsset(ctx, "SN-x", "blue", 5, "John", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "user2", nil)
+	require.NoError(t, cc.CreateAsset(ctx, "SN-12", "blue", 5, "Jane", 100))
+	_, err = admin.AllocateIDRange(ctx, "Org2MSP", "SN-", 5)
+	assert.Error(t, err)
+}
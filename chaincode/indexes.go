@@ -0,0 +1,539 @@
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// indexConfig is the configuration key prefix of declarative index build status
+	indexConfig = "index"
+	// indexRebuildConfig is the configuration key prefix of RebuildIndexes progress
+	indexRebuildConfig = "indexRebuild"
+	// maxIndexBackfillBatch caps the assets BackfillIndex and RebuildIndexes read in one transaction
+	maxIndexBackfillBatch = 500
+
+	// ownerIndex keys assets by owner, for owner lookups that need no rich queries
+	ownerIndex = "owner~name"
+
+	// Declarative index statuses
+	IndexStatusBuilding = "building"
+	IndexStatusReady    = "ready"
+
+	// RebuildIndexes phases
+	IndexRebuildEntries = "entries"
+	IndexRebuildAssets  = "assets"
+	IndexRebuildDone    = "done"
+)
+
+// assetIndex declares a secondary index over assets. Its entries are composite keys of the
+// attributes followed by the asset ID, kept up to date by putAsset and deleteAsset. Assets for
+// which attributes returns nil have no entry.
+type assetIndex struct {
+	name       string
+	attributes func(asset *Asset) []string
+	// ready indexes have been written since the first release and never need a backfill
+	ready bool
+	// optional indexes are skipped by contracts running WithIndexes(false)
+	optional bool
+}
+
+// assetIndexes lists the declarative indexes. An index added in an upgrade starts out
+// building: every asset written from then on gets its entries, and AdminContract:BackfillIndex
+// indexes the assets nobody touched, so the upgrade needs no downtime for a full reindex.
+var assetIndexes = []assetIndex{
+	{name: index, ready: true, optional: true, attributes: func(asset *Asset) []string { return []string{asset.Color} }},
+	{name: mspAssetIndex, ready: true, attributes: func(asset *Asset) []string {
+		if asset.OwnerMSP == "" {
+			return nil
+		}
+		return []string{asset.OwnerMSP}
+	}},
+	{name: ownerIndex, attributes: func(asset *Asset) []string { return []string{asset.Owner} }},
+}
+
+// indexes returns the registered indexes the contract maintains
+func (r *contractRuntime) indexes() []assetIndex {
+	if !r.skipIndexes {
+		return assetIndexes
+	}
+	indexes := []assetIndex{}
+	for _, index := range assetIndexes {
+		if !index.optional {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
+
+// saveAsset is putAsset for the indexes the contract maintains, stamped by the contract's clock
+func (r *contractRuntime) saveAsset(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	now, err := r.now(ctx)
+	if err != nil {
+		return err
+	}
+	return putAssetIndexed(ctx, asset, r.indexes(), now)
+}
+
+// removeAsset is deleteAsset for the indexes the contract maintains
+func (r *contractRuntime) removeAsset(ctx contractapi.TransactionContextInterface, assetID string) error {
+	return deleteAssetIndexed(ctx, assetID, r.indexes())
+}
+
+// IndexStatus tracks the backfill of a declarative index. Cursor is the first asset key the
+// next backfill batch reads.
+type IndexStatus struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Cursor     string `json:"cursor,omitempty" metadata:",optional"`
+	Backfilled int    `json:"backfilled"`
+	TxID       string `json:"txId,omitempty" metadata:",optional"` // transaction that completed the backfill
+}
+
+// BackfillIndex indexes up to limit assets that a building index has not reached yet and marks
+// the index ready once every asset is covered. Call it repeatedly until Status is ready; on a
+// new deployment the first call completes the index.
+func (a *AdminContract) BackfillIndex(ctx contractapi.TransactionContextInterface, indexName string, limit int) (*IndexStatus, error) {
+	log.Info().Str("function", "BackfillIndex").Str("index", indexName).Int("limit", limit).Msg("Backfilling index")
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxIndexBackfillBatch {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxIndexBackfillBatch)
+	}
+	index, status, err := getIndexStatus(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+	if status.Status == IndexStatusReady {
+		return status, nil
+	}
+
+	startKey, endKey := assetKeyRange("", "")
+	if status.Cursor != "" {
+		startKey = status.Cursor
+	}
+	iterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read assets: %v", err)
+	}
+	defer iterator.Close()
+
+	processed := 0
+	nextKey := ""
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if processed == limit {
+			nextKey = response.Key
+			break
+		}
+		processed++
+		asset, err := decodeAsset(response.Value)
+		if err != nil {
+			// A corrupt record gets its entries once AdminContract:RepairAsset rewrites it
+			log.Warn().Err(err).Str("assetID", response.Key).Str("index", indexName).Msg("Skipping unreadable asset during backfill")
+			continue
+		}
+		if asset.Deleted {
+			continue
+		}
+		key, err := index.key(ctx, asset)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			continue
+		}
+		if err := ctx.GetStub().PutState(key, []byte{0x00}); err != nil {
+			return nil, err
+		}
+	}
+
+	status.Backfilled += processed
+	status.Cursor = nextKey
+	if nextKey == "" {
+		status.Status = IndexStatusReady
+		status.TxID = ctx.GetStub().GetTxID()
+	}
+	if err := putConfig(ctx, status, indexConfig, indexName); err != nil {
+		return nil, err
+	}
+	if status.Status == IndexStatusReady {
+		if err := recordAudit(ctx, "BackfillIndex", indexName, fmt.Sprintf("ready after %d assets", status.Backfilled)); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info().Str("index", indexName).Int("processed", processed).Str("status", status.Status).Msg("Index backfill batch completed")
+	return status, nil
+}
+
+// IndexRebuild tracks a RebuildIndexes run. The entries phase removes entries whose asset no
+// longer exists or has other attributes, the assets phase adds the entries assets are missing.
+// Cursor is the first key the next batch of the phase reads.
+type IndexRebuild struct {
+	Name    string `json:"name"`
+	Phase   string `json:"phase"`
+	Cursor  string `json:"cursor,omitempty" metadata:",optional"`
+	Checked int    `json:"checked"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	TxID    string `json:"txId,omitempty" metadata:",optional"` // transaction that completed the rebuild
+}
+
+// RebuildIndexes repairs drift between an index and the assets, e.g. after records were written
+// by an older chaincode version, in batches of up to limit keys. Call it repeatedly until Phase
+// is done; a call after that starts a new rebuild.
+func (a *AdminContract) RebuildIndexes(ctx contractapi.TransactionContextInterface, indexName string, limit int) (*IndexRebuild, error) {
+	log.Info().Str("function", "RebuildIndexes").Str("index", indexName).Int("limit", limit).Msg("Rebuilding index")
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxIndexBackfillBatch {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxIndexBackfillBatch)
+	}
+	index, _, err := getIndexStatus(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+	rebuild := &IndexRebuild{}
+	found, err := getConfig(ctx, rebuild, indexRebuildConfig, indexName)
+	if err != nil {
+		return nil, err
+	}
+	if !found || rebuild.Phase == IndexRebuildDone {
+		rebuild = &IndexRebuild{Name: indexName, Phase: IndexRebuildEntries}
+	}
+
+	if rebuild.Phase == IndexRebuildEntries {
+		err = rebuildIndexEntries(ctx, index, rebuild, limit)
+	} else {
+		err = rebuildAssetEntries(ctx, index, rebuild, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rebuild.Phase == IndexRebuildDone {
+		rebuild.TxID = ctx.GetStub().GetTxID()
+		details := fmt.Sprintf("checked %d keys, added %d entries, removed %d entries", rebuild.Checked, rebuild.Added, rebuild.Removed)
+		if err := recordAudit(ctx, "RebuildIndexes", indexName, details); err != nil {
+			return nil, err
+		}
+	}
+	if err := putConfig(ctx, rebuild, indexRebuildConfig, indexName); err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("index", indexName).Str("phase", rebuild.Phase).Int("added", rebuild.Added).Int("removed", rebuild.Removed).Msg("Index rebuild batch completed")
+	return rebuild, nil
+}
+
+// rebuildIndexEntries removes stale entries among the next limit entries of the index. Fabric
+// does not allow range reads that start inside a composite key, so the entries before the
+// cursor are walked again but not checked.
+func rebuildIndexEntries(ctx contractapi.TransactionContextInterface, index assetIndex, rebuild *IndexRebuild, limit int) error {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(index.name, []string{})
+	if err != nil {
+		return fmt.Errorf("failed to read index %s: %v", index.name, err)
+	}
+	defer iterator.Close()
+
+	checked := 0
+	nextKey := ""
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return err
+		}
+		if response.Key < rebuild.Cursor {
+			continue
+		}
+		if checked == limit {
+			nextKey = response.Key
+			break
+		}
+		checked++
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil {
+			return err
+		}
+		assetBytes, err := ctx.GetStub().GetState(assetKey(attributes[len(attributes)-1]))
+		if err != nil {
+			return err
+		}
+		stale := assetBytes == nil
+		if !stale {
+			asset, err := decodeAsset(assetBytes)
+			if err != nil {
+				// Entries of a corrupt record are checked again once AdminContract:RepairAsset
+				// rewrites it
+				continue
+			}
+			key := ""
+			if !asset.Deleted {
+				if key, err = index.key(ctx, asset); err != nil {
+					return err
+				}
+			}
+			stale = key != response.Key
+		}
+		if stale {
+			if err := ctx.GetStub().DelState(response.Key); err != nil {
+				return err
+			}
+			rebuild.Removed++
+		}
+	}
+
+	rebuild.Checked += checked
+	rebuild.Cursor = nextKey
+	if nextKey == "" {
+		rebuild.Phase = IndexRebuildAssets
+	}
+	return nil
+}
+
+// rebuildAssetEntries adds the missing entries of the next limit assets
+func rebuildAssetEntries(ctx contractapi.TransactionContextInterface, index assetIndex, rebuild *IndexRebuild, limit int) error {
+	startKey, endKey := assetKeyRange("", "")
+	if rebuild.Cursor != "" {
+		startKey = rebuild.Cursor
+	}
+	iterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		return fmt.Errorf("failed to read assets: %v", err)
+	}
+	defer iterator.Close()
+
+	checked := 0
+	nextKey := ""
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return err
+		}
+		if checked == limit {
+			nextKey = response.Key
+			break
+		}
+		checked++
+		asset, err := decodeAsset(response.Value)
+		if err != nil || asset.Deleted {
+			continue
+		}
+		key, err := index.key(ctx, asset)
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			continue
+		}
+		entry, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			if err := ctx.GetStub().PutState(key, []byte{0x00}); err != nil {
+				return err
+			}
+			rebuild.Added++
+		}
+	}
+
+	rebuild.Checked += checked
+	rebuild.Cursor = nextKey
+	if nextKey == "" {
+		rebuild.Phase = IndexRebuildDone
+	}
+	return nil
+}
+
+// GetIndexStatus returns the backfill progress of a declarative index
+func (q *QueryContract) GetIndexStatus(ctx contractapi.TransactionContextInterface, indexName string) (*IndexStatus, error) {
+	q.logger(ctx).Info().Str("function", "GetIndexStatus").Str("index", indexName).Msg("Reading index status")
+
+	_, status, err := getIndexStatus(ctx, indexName)
+	return status, err
+}
+
+// GetAssetsByIndex returns the assets whose leading index attributes equal values, e.g. the
+// assets of owner "John" with index "owner~name" and values ["John"]. Building indexes are
+// rejected since they may still miss assets. Callers whose rich queries are scoped to their
+// own assets only receive those here too.
+func (q *QueryContract) GetAssetsByIndex(ctx contractapi.TransactionContextInterface, indexName string, values []string) ([]*Asset, error) {
+	q.logger(ctx).Info().Str("function", "GetAssetsByIndex").Str("index", indexName).Strs("values", values).Msg("Querying assets by index")
+
+	return q.readIndex(ctx, indexName, values, "", "")
+}
+
+// GetAssetsByOwnerRange returns the assets whose owner sorts from startOwner up to but excluding
+// endOwner, in owner order; an empty endOwner leaves the range open. It reads the owner~name
+// index, so it works on LevelDB state databases without rich queries. Owners before startOwner
+// are still walked, since Fabric does not allow range reads that start inside a composite key.
+func (q *QueryContract) GetAssetsByOwnerRange(ctx contractapi.TransactionContextInterface, startOwner, endOwner string) ([]*Asset, error) {
+	q.logger(ctx).Info().Str("function", "GetAssetsByOwnerRange").Str("startOwner", startOwner).Str("endOwner", endOwner).Msg("Querying assets by owner range")
+
+	if endOwner != "" && endOwner <= startOwner {
+		return nil, fmt.Errorf("endOwner must sort after startOwner")
+	}
+	return q.readIndex(ctx, ownerIndex, nil, startOwner, endOwner)
+}
+
+// readIndex returns the assets of the index entries that start with values and whose next
+// attribute lies within [from, to); empty bounds are open. Entries are read in key order, so the
+// walk stops at the first attribute past to.
+func (q *QueryContract) readIndex(ctx contractapi.TransactionContextInterface, indexName string, values []string, from, to string) ([]*Asset, error) {
+	index, status, err := getIndexStatus(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+	if status.Status != IndexStatusReady {
+		return nil, fmt.Errorf("index %s is still building (%d assets backfilled), run AdminContract:BackfillIndex", indexName, status.Backfilled)
+	}
+	owner := ""
+	if !q.skipQueryScoping {
+		unrestricted, err := callerReadsAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !unrestricted {
+			if owner, err = getCallerEnrollmentID(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(indexName, values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index %s: %v", indexName, err)
+	}
+	defer iterator.Close()
+
+	presenter, err := q.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	budget := newQueryBudget(q.configProvider())
+	assets := []*Asset{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if err := budget.consume(len(response.Key)); err != nil {
+			return nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil {
+			return nil, err
+		}
+		if from != "" || to != "" {
+			if bound := attributes[len(values)]; bound < from {
+				continue
+			} else if to != "" && bound >= to {
+				break
+			}
+		}
+		asset, err := getAsset(ctx, attributes[len(attributes)-1])
+		if err != nil {
+			return nil, err
+		}
+		// An asset written twice in one transaction can leave an entry of its intermediate
+		// version behind, so entries that no longer match the asset are skipped
+		key, err := index.key(ctx, asset)
+		if err != nil {
+			return nil, err
+		}
+		if key != response.Key || (owner != "" && asset.Owner != owner) || !presenter.visible(asset) {
+			continue
+		}
+		assets = append(assets, presenter.present(asset))
+	}
+	return assets, nil
+}
+
+// key returns the index entry of an asset, or "" if the asset is not indexed
+func (i assetIndex) key(ctx contractapi.TransactionContextInterface, asset *Asset) (string, error) {
+	attributes := i.attributes(asset)
+	if attributes == nil {
+		return "", nil
+	}
+	return ctx.GetStub().CreateCompositeKey(i.name, append(attributes, asset.ID))
+}
+
+// getIndexStatus looks up a declared index and its status. Indexes without a status record
+// were added by an upgrade and are building, unless they are ready from the start.
+func getIndexStatus(ctx contractapi.TransactionContextInterface, indexName string) (assetIndex, *IndexStatus, error) {
+	for _, index := range assetIndexes {
+		if index.name != indexName {
+			continue
+		}
+		status := &IndexStatus{Name: indexName, Status: IndexStatusBuilding}
+		if index.ready {
+			status.Status = IndexStatusReady
+		}
+		if _, err := getConfig(ctx, status, indexConfig, indexName); err != nil {
+			return index, nil, err
+		}
+		return index, status, nil
+	}
+	return assetIndex{}, nil, fmt.Errorf("index %s is not declared", indexName)
+}
+
+// updateIndexEntries moves the entries of indexes for an asset from its committed version to
+// asset, or removes them when asset is nil. Entries are written whatever the index
+// status, which is how building indexes pick up touched assets; the status record is only
+// written by backfills, so concurrent writes do not conflict on it.
+func updateIndexEntries(ctx contractapi.TransactionContextInterface, assetID string, asset *Asset, indexes []assetIndex) error {
+	previousBytes, err := ctx.GetStub().GetState(assetKey(assetID))
+	if err != nil {
+		return fmt.Errorf("failed to get asset %s: %v", assetID, err)
+	}
+	var previous *Asset
+	if previousBytes != nil {
+		// The entries of a record that fails to decode, e.g. one being repaired, are left
+		// for GetAssetsByIndex to skip
+		previous, _ = decodeAsset(previousBytes)
+	}
+	// Tombstones have no index entries
+	if previous != nil && previous.Deleted {
+		previous = nil
+	}
+	if asset != nil && asset.Deleted {
+		asset = nil
+	}
+
+	for _, index := range indexes {
+		previousKey, currentKey := "", ""
+		if previous != nil {
+			if previousKey, err = index.key(ctx, previous); err != nil {
+				return err
+			}
+		}
+		if asset != nil {
+			if currentKey, err = index.key(ctx, asset); err != nil {
+				return err
+			}
+		}
+		if previousKey != "" && previousKey != currentKey {
+			if err := ctx.GetStub().DelState(previousKey); err != nil {
+				return err
+			}
+		}
+		// Entries of ready indexes are only written when they move, which keeps them out of the
+		// write set of most updates
+		if currentKey != "" && (currentKey != previousKey || !index.ready) {
+			if err := ctx.GetStub().PutState(currentKey, []byte{0x00}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeclarativeIndexBackfill tests that a new index is filled on touch and by bounded backfills
+func TestDeclarativeIndexBackfill(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	admin := &AdminContract{}
+	for _, id := range []string{"asset1", "asset2", "asset3"} {
+		require.NoError(t, cc.CreateAsset(ctx, id, "blue", 5, "John", 100))
+	}
+	// Simulate assets written before the index was declared
+	for _, id := range []string{"asset1", "asset2", "asset3"} {
+		key, err := stub.CreateCompositeKey("owner~name", []string{"John", id})
+		require.NoError(t, err)
+		delete(stub.State, key)
+	}
+
+	status, err := qc.GetIndexStatus(ctx, "owner~name")
+	require.NoError(t, err)
+	assert.Equal(t, IndexStatusBuilding, status.Status)
+	_, err = qc.GetAssetsByIndex(ctx, "owner~name", []string{"John"})
+	assert.ErrorContains(t, err, "still building")
+
+	// Touching an asset indexes it, the transfer moves its entry
+	require.NoError(t, cc.TransferAsset(ctx, "asset2", "Jane", 0))
+
+	status, err = admin.BackfillIndex(ctx, "owner~name", 2)
+	require.NoError(t, err)
+	assert.Equal(t, IndexStatusBuilding, status.Status)
+	assert.Equal(t, "asset3", status.Cursor)
+	status, err = admin.BackfillIndex(ctx, "owner~name", 2)
+	require.NoError(t, err)
+	assert.Equal(t, IndexStatusReady, status.Status)
+	assert.Equal(t, 3, status.Backfilled)
+
+	assets, err := qc.GetAssetsByIndex(ctx, "owner~name", []string{"John"})
+	require.NoError(t, err)
+	require.Len(t, assets, 2)
+	assert.Equal(t, "asset1", assets[0].ID)
+	assert.Equal(t, "asset3", assets[1].ID)
+
+	require.NoError(t, cc.DeleteAsset(ctx, "asset1"))
+	assets, err = qc.GetAssetsByIndex(ctx, "owner~name", []string{"John"})
+	require.NoError(t, err)
+	assert.Len(t, assets, 1)
+
+	_, err = admin.BackfillIndex(ctx, "missing~name", 2)
+	assert.ErrorContains(t, err, "not declared")
+	switchIdentity(t, ctx, stub, "Org1MSP", "user1", nil)
+	_, err = admin.BackfillIndex(ctx, "owner~name", 2)
+	assert.Error(t, err)
+}
+
+// TestIndexQueryScoping tests that index queries only return the caller's assets unless they read everything
+func TestIndexQueryScoping(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	cc := &AssetContract{}
+	admin := &AdminContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "user1", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "blue", 5, "John", 100))
+	_, err := admin.BackfillIndex(ctx, "owner~name", 10)
+	require.NoError(t, err)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "user1", nil)
+	qc := &QueryContract{}
+	assets, err := qc.GetAssetsByIndex(ctx, "owner~name", []string{"John"})
+	require.NoError(t, err)
+	assert.Empty(t, assets)
+	assets, err = qc.GetAssetsByIndex(ctx, "owner~name", nil)
+	require.NoError(t, err)
+	require.Len(t, assets, 1)
+	assert.Equal(t, "asset1", assets[0].ID)
+}
+
+// TestGetAssetsByOwnerRange tests that owner ranges are read from the owner index in owner order
+func TestGetAssetsByOwnerRange(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "Max", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "blue", 5, "Adriana", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "asset3", "blue", 5, "Jane", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "asset4", "blue", 5, "John", 100))
+	_, err := (&AdminContract{}).BackfillIndex(ctx, ownerIndex, 10)
+	require.NoError(t, err)
+
+	assets, err := qc.GetAssetsByOwnerRange(ctx, "B", "Max")
+	require.NoError(t, err)
+	require.Len(t, assets, 2)
+	assert.Equal(t, "Jane", assets[0].Owner)
+	assert.Equal(t, "John", assets[1].Owner)
+
+	require.NoError(t, cc.TransferAsset(ctx, "asset4", "Zoe", 0))
+	require.NoError(t, cc.DeleteAsset(ctx, "asset3"))
+	assets, err = qc.GetAssetsByOwnerRange(ctx, "J", "")
+	require.NoError(t, err)
+	require.Len(t, assets, 2)
+	assert.Equal(t, "asset1", assets[0].ID)
+	assert.Equal(t, "asset4", assets[1].ID)
+
+	_, err = qc.GetAssetsByOwnerRange(ctx, "M", "A")
+	assert.Error(t, err)
+}
+
+// TestRebuildIndexes tests that a rebuild removes stale entries and adds missing ones in batches
+func TestRebuildIndexes(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	admin := &AdminContract{}
+	for _, id := range []string{"asset1", "asset2", "asset3"} {
+		require.NoError(t, cc.CreateAsset(ctx, id, "blue", 5, "John", 100))
+	}
+	status, err := qc.GetIndexStatus(ctx, index)
+	require.NoError(t, err)
+	assert.Equal(t, IndexStatusReady, status.Status)
+
+	// Drift: a lost entry, an entry with an old color and one of an asset that is gone
+	missingKey, _ := stub.CreateCompositeKey(index, []string{"blue", "asset1"})
+	delete(stub.State, missingKey)
+	staleKey, _ := stub.CreateCompositeKey(index, []string{"green", "asset2"})
+	require.NoError(t, stub.PutState(staleKey, []byte{0x00}))
+	orphanKey, _ := stub.CreateCompositeKey(index, []string{"red", "ghost"})
+	require.NoError(t, stub.PutState(orphanKey, []byte{0x00}))
+
+	var rebuild *IndexRebuild
+	for i := 0; i < 10; i++ {
+		rebuild, err = admin.RebuildIndexes(ctx, index, 2)
+		require.NoError(t, err)
+		if rebuild.Phase == IndexRebuildDone {
+			break
+		}
+	}
+	require.Equal(t, IndexRebuildDone, rebuild.Phase)
+	assert.Equal(t, 1, rebuild.Added)
+	assert.Equal(t, 2, rebuild.Removed)
+	assert.NotNil(t, stub.State[missingKey])
+	assert.Nil(t, stub.State[staleKey])
+	assert.Nil(t, stub.State[orphanKey])
+
+	assets, err := qc.GetAssetsByIndex(ctx, index, []string{"blue"})
+	require.NoError(t, err)
+	assert.Len(t, assets, 3)
+
+	_, err = admin.RebuildIndexes(ctx, index, 0)
+	assert.Error(t, err)
+	switchIdentity(t, ctx, stub, "Org1MSP", "user1", nil)
+	_, err = admin.RebuildIndexes(ctx, index, 2)
+	assert.Error(t, err)
+}
+
+// TestRegisteredIndexesFollowWrites tests that updates and deletes move the color and MSP entries
+func TestRegisteredIndexesFollowWrites(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	require.NoError(t, cc.UpdateAsset(ctx, "asset1", "red", 5, "John", 100, 0))
+
+	blueKey, _ := stub.CreateCompositeKey(index, []string{"blue", "asset1"})
+	redKey, _ := stub.CreateCompositeKey(index, []string{"red", "asset1"})
+	mspKey, _ := stub.CreateCompositeKey(mspAssetIndex, []string{"Org1MSP", "asset1"})
+	assert.Nil(t, stub.State[blueKey])
+	assert.NotNil(t, stub.State[redKey])
+	assert.NotNil(t, stub.State[mspKey])
+
+	require.NoError(t, cc.DeleteAsset(ctx, "asset1"))
+	assert.Nil(t, stub.State[redKey])
+	assert.Nil(t, stub.State[mspKey])
+}
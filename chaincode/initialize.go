@@ -0,0 +1,177 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// deploymentConfig is the configuration key of the parameters recorded by Init
+	deploymentConfig = "deployment"
+	// ledgerInitConfig is the configuration key of the marker written by InitLedger
+	ledgerInitConfig = "ledgerinit"
+)
+
+// Names of the initialization transactions, exempt from the initialized check
+const (
+	initFunction        = "Init"
+	initGenesisFunction = "InitFromGenesis"
+)
+
+// DeploymentParams are the deployment parameters passed to Init when the chaincode definition
+// is approved with --init-required and the first transaction is submitted with --isInit
+type DeploymentParams struct {
+	IssuerMSP          string    `json:"issuerMSP"`
+	SchemaVersion      int       `json:"schemaVersion"`
+	MaxImportBatchSize int       `json:"maxImportBatchSize,omitempty" metadata:",optional"`
+	GenesisHash        string    `json:"genesisHash,omitempty" metadata:",optional"`
+	InitializedAt      time.Time `json:"initializedAt"`
+	TxID               string    `json:"txId"`
+}
+
+// Init records the deployment parameters, a JSON DeploymentParams document, e.g.
+// {"issuerMSP":"Org1MSP","schemaVersion":1,"maxImportBatchSize":100}. It runs once; unlike
+// InitLedger it creates no assets. When initialization is required, every other asset and
+// query function is rejected until Init has committed.
+func (t *AssetContract) Init(ctx contractapi.TransactionContextInterface, paramsJSON string) error {
+	t.logger(ctx).Info().Str("function", "Init").Str("params", paramsJSON).Msg("Initializing chaincode deployment")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if err := t.requireUninitialized(ctx); err != nil {
+		return err
+	}
+
+	var params DeploymentParams
+	if err := json.Unmarshal([]byte(paramsJSON), &params); err != nil {
+		return fmt.Errorf("invalid deployment parameters: %v", err)
+	}
+	if err := t.recordDeployment(ctx, &params, initFunction); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("issuerMSP", params.IssuerMSP).Int("schemaVersion", params.SchemaVersion).Msg("Chaincode initialized")
+	return nil
+}
+
+// requireUninitialized fails with ALREADY_INITIALIZED once deployment parameters are recorded
+func (r *contractRuntime) requireUninitialized(ctx contractapi.TransactionContextInterface) error {
+	existing, err := getDeploymentParams(ctx)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		r.logger(ctx).Warn().Str("initTxId", existing.TxID).Msg("Chaincode already initialized")
+		return newChaincodeError(ErrCodeAlreadyInitialized, "chaincode was initialized in transaction %s", existing.TxID)
+	}
+	return nil
+}
+
+// recordDeployment validates and stores the deployment parameters, which marks the chaincode initialized
+func (r *contractRuntime) recordDeployment(ctx contractapi.TransactionContextInterface, params *DeploymentParams, action string) error {
+	if params.IssuerMSP == "" {
+		return fmt.Errorf("issuerMSP must not be empty")
+	}
+	if params.SchemaVersion <= 0 {
+		return fmt.Errorf("schemaVersion must be positive")
+	}
+	if params.MaxImportBatchSize < 0 || params.MaxImportBatchSize > maxImportBatchSize {
+		return fmt.Errorf("maxImportBatchSize must be between 0 and %d", maxImportBatchSize)
+	}
+	now, err := r.now(ctx)
+	if err != nil {
+		return err
+	}
+	params.InitializedAt = now
+	params.TxID = ctx.GetStub().GetTxID()
+
+	if err := putConfig(ctx, params, deploymentConfig); err != nil {
+		return err
+	}
+	return recordAudit(ctx, action, params.IssuerMSP, fmt.Sprintf("schemaVersion=%d", params.SchemaVersion))
+}
+
+// LedgerInitMarker records that InitLedger loaded the sample assets
+type LedgerInitMarker struct {
+	TxID          string    `json:"txId"`
+	AssetCount    int       `json:"assetCount"`
+	InitializedAt time.Time `json:"initializedAt"`
+}
+
+// requireLedgerUninitialized refuses to load sample assets twice or on top of an initialized deployment
+func (r *contractRuntime) requireLedgerUninitialized(ctx contractapi.TransactionContextInterface) error {
+	var marker LedgerInitMarker
+	found, err := getConfig(ctx, &marker, ledgerInitConfig)
+	if err != nil {
+		return err
+	}
+	if found {
+		r.logger(ctx).Warn().Str("initTxId", marker.TxID).Msg("Ledger already initialized")
+		return newChaincodeError(ErrCodeAlreadyInitialized, "ledger was initialized in transaction %s", marker.TxID)
+	}
+	return r.requireUninitialized(ctx)
+}
+
+// recordLedgerInit writes the InitLedger marker
+func (r *contractRuntime) recordLedgerInit(ctx contractapi.TransactionContextInterface, assetCount int) error {
+	now, err := r.now(ctx)
+	if err != nil {
+		return err
+	}
+	marker := &LedgerInitMarker{TxID: ctx.GetStub().GetTxID(), AssetCount: assetCount, InitializedAt: now}
+	return putConfig(ctx, marker, ledgerInitConfig)
+}
+
+// GetDeploymentParams returns the parameters recorded by Init
+func (q *QueryContract) GetDeploymentParams(ctx contractapi.TransactionContextInterface) (*DeploymentParams, error) {
+	q.logger(ctx).Info().Str("function", "GetDeploymentParams").Msg("Reading deployment parameters")
+
+	params, err := getDeploymentParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if params == nil {
+		return nil, newChaincodeError(ErrCodeNotInitialized, "chaincode has not been initialized")
+	}
+	return params, nil
+}
+
+// getDeploymentParams reads the parameters recorded by Init, returning nil before initialization
+func getDeploymentParams(ctx contractapi.TransactionContextInterface) (*DeploymentParams, error) {
+	params := &DeploymentParams{}
+	found, err := getConfig(ctx, params, deploymentConfig)
+	if err != nil || !found {
+		return nil, err
+	}
+	return params, nil
+}
+
+// checkInitialized rejects every function but Init and InitFromGenesis until the chaincode has been initialized,
+// when the contract was built with WithInitRequired
+func (r *contractRuntime) checkInitialized(ctx contractapi.TransactionContextInterface) error {
+	if !r.initRequired {
+		return nil
+	}
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	if idx := strings.LastIndex(function, ":"); idx >= 0 {
+		function = function[idx+1:]
+	}
+	if function == initFunction || function == initGenesisFunction {
+		return nil
+	}
+
+	params, err := getDeploymentParams(ctx)
+	if err != nil {
+		return err
+	}
+	if params == nil {
+		r.logger(ctx).Warn().Str("target", function).Msg("Invocation rejected before initialization")
+		return newChaincodeError(ErrCodeNotInitialized, "chaincode must be initialized with %s before calling %s", initFunction, function)
+	}
+	return nil
+}
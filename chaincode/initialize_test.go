@@ -0,0 +1,63 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInitRequired tests that functions are blocked until Init records the deployment parameters
+func TestInitRequired(t *testing.T) {
+	cc, err := contractapi.NewChaincode(NewAssetContract(WithInitRequired()), NewQueryContract(WithInitRequired()), &AdminContract{})
+	require.NoError(t, err)
+	stub := shimtest.NewMockStub("chaincode", cc)
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "admin", adminAttrs)
+
+	status, message := invoke(stub, "tx1", "CreateAsset", "asset1", "blue", "5", "John", "100")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, ErrCodeNotInitialized)
+	status, _ = invoke(stub, "tx2", "Init", `{"issuerMSP":""}`)
+	assert.Equal(t, int32(shim.ERROR), status)
+
+	status, message = invoke(stub, "tx3", "Init", `{"issuerMSP":"Org1MSP","schemaVersion":1,"maxImportBatchSize":1}`)
+	require.Equal(t, int32(shim.OK), status, message)
+	status, message = invoke(stub, "tx4", "Init", `{"issuerMSP":"Org1MSP","schemaVersion":2}`)
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, ErrCodeAlreadyInitialized)
+
+	status, message = invoke(stub, "tx5", "CreateAsset", "asset1", "blue", "5", "John", "100")
+	require.Equal(t, int32(shim.OK), status, message)
+	status, message = invoke(stub, "tx6", "ImportAssets", `[{"ID":"asset2","color":"red"},{"ID":"asset3","color":"red"}]`, "false")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "between 1 and 1 items")
+
+	response := stub.MockInvoke("tx7", [][]byte{[]byte("QueryContract:GetDeploymentParams")})
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	assert.Contains(t, string(response.Payload), `"txId":"tx3"`)
+}
+
+// TestInitLedgerOnce tests that InitLedger refuses to run twice or after Init
+func TestInitLedgerOnce(t *testing.T) {
+	cc := &AssetContract{}
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+
+	require.NoError(t, cc.InitLedger(ctx))
+	var marker LedgerInitMarker
+	found, err := getConfig(ctx, &marker, ledgerInitConfig)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, 6, marker.AssetCount)
+
+	stub.MockTransactionStart("tx2")
+	err = cc.InitLedger(ctx)
+	assert.ErrorContains(t, err, ErrCodeAlreadyInitialized)
+
+	initCtx, _ := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	require.NoError(t, cc.Init(initCtx, `{"issuerMSP":"Org1MSP","schemaVersion":1}`))
+	err = cc.InitLedger(initCtx)
+	assert.ErrorContains(t, err, ErrCodeAlreadyInitialized)
+}
@@ -0,0 +1,99 @@
+package chaincode
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/rs/zerolog/log"
+)
+
+// Default input limits, sized for the largest legitimate inputs (an import batch of 500 assets or
+// a genesis document) while staying below the default 4MB gRPC message size
+const (
+	defaultMaxArgs         = 32
+	defaultMaxArgBytes     = 1024 * 1024
+	defaultMaxPayloadBytes = 3 * 1024 * 1024
+)
+
+// InputLimits caps what a single invocation may send. MaxArgs counts the function name too, and
+// MaxPayloadBytes applies to the arguments and transient data together. Zero keeps the default.
+type InputLimits struct {
+	MaxArgs         int
+	MaxArgBytes     int
+	MaxPayloadBytes int
+}
+
+// InputLimitsChaincode rejects invocations exceeding its limits with an INPUT_TOO_LARGE error
+// before they are decoded, instead of letting them fail deep in argument parsing or the peer
+type InputLimitsChaincode struct {
+	shim.Chaincode
+	limits InputLimits
+}
+
+// NewInputLimitsChaincode wraps a chaincode with input limits
+func NewInputLimitsChaincode(cc shim.Chaincode, limits InputLimits) shim.Chaincode {
+	if limits.MaxArgs <= 0 {
+		limits.MaxArgs = defaultMaxArgs
+	}
+	if limits.MaxArgBytes <= 0 {
+		limits.MaxArgBytes = defaultMaxArgBytes
+	}
+	if limits.MaxPayloadBytes <= 0 {
+		limits.MaxPayloadBytes = defaultMaxPayloadBytes
+	}
+	return &InputLimitsChaincode{Chaincode: cc, limits: limits}
+}
+
+// Init checks the input and forwards to the wrapped chaincode
+func (l *InputLimitsChaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	if err := l.check(stub); err != nil {
+		return l.reject(stub, err)
+	}
+	return l.Chaincode.Init(stub)
+}
+
+// Invoke checks the input and forwards to the wrapped chaincode
+func (l *InputLimitsChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	if err := l.check(stub); err != nil {
+		return l.reject(stub, err)
+	}
+	return l.Chaincode.Invoke(stub)
+}
+
+// check returns an INPUT_TOO_LARGE error naming the first limit the invocation exceeds
+func (l *InputLimitsChaincode) check(stub shim.ChaincodeStubInterface) error {
+	args := stub.GetArgs()
+	function := invokedFunction(args)
+	if len(args) > l.limits.MaxArgs {
+		return newChaincodeError(ErrCodeInputTooLarge, "%s got %d arguments, at most %d are accepted", function, len(args)-1, l.limits.MaxArgs-1)
+	}
+	total := 0
+	for i, arg := range args {
+		if len(arg) > l.limits.MaxArgBytes {
+			return newChaincodeError(ErrCodeInputTooLarge, "argument %d of %s is %d bytes, at most %d are accepted", i, function, len(arg), l.limits.MaxArgBytes)
+		}
+		total += len(arg)
+	}
+	// Transient data is not part of the arguments but travels in the same proposal
+	if transient, err := stub.GetTransient(); err == nil {
+		for key, value := range transient {
+			total += len(key) + len(value)
+		}
+	}
+	if total > l.limits.MaxPayloadBytes {
+		return newChaincodeError(ErrCodeInputTooLarge, "%s payload is %d bytes, at most %d are accepted", function, total, l.limits.MaxPayloadBytes)
+	}
+	return nil
+}
+
+func (l *InputLimitsChaincode) reject(stub shim.ChaincodeStubInterface, err error) peer.Response {
+	log.Warn().Err(err).Str("function", invokedFunction(stub.GetArgs())).Str("txId", stub.GetTxID()).Msg("Rejected oversized input")
+	return shim.Error(err.Error())
+}
+
+// invokedFunction returns the function named by the first argument
+func invokedFunction(args [][]byte) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return string(args[0])
+}
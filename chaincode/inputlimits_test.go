@@ -0,0 +1,41 @@
+package chaincode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInputLimits tests that oversized invocations are rejected with the limit they exceed
+func TestInputLimits(t *testing.T) {
+	cc, err := contractapi.NewChaincode(NewAssetContract(), NewQueryContract())
+	require.NoError(t, err)
+	limited := NewInputLimitsChaincode(cc, InputLimits{MaxArgs: 7, MaxArgBytes: 64, MaxPayloadBytes: 128})
+	stub := shimtest.NewMockStub("chaincode", limited)
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "user1", nil)
+
+	status, message := invoke(stub, "tx1", "CreateAsset", "asset1", "blue", "5", "John", "100")
+	require.Equal(t, int32(shim.OK), status, message)
+
+	status, message = invoke(stub, "tx2", "CreateAsset", "asset2", "blue", "5", "John", "100", "1", "2")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, ErrCodeInputTooLarge)
+	assert.Contains(t, message, "got 7 arguments, at most 6")
+
+	status, message = invoke(stub, "tx3", "CreateAsset", "asset2", strings.Repeat("b", 65), "5", "John", "100")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "argument 2 of CreateAsset is 65 bytes")
+
+	status, message = invoke(stub, "tx4", "CreateAsset", "asset2", strings.Repeat("b", 60), "5", strings.Repeat("J", 60), "100")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "payload is")
+
+	// Defaults apply to unset limits
+	defaults := NewInputLimitsChaincode(cc, InputLimits{}).(*InputLimitsChaincode)
+	assert.Equal(t, InputLimits{MaxArgs: defaultMaxArgs, MaxArgBytes: defaultMaxArgBytes, MaxPayloadBytes: defaultMaxPayloadBytes}, defaults.limits)
+}
@@ -0,0 +1,189 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// assetSchemaVersion is the version of the Asset record layout covered by new checksums. Bump
+	// it when fields change meaning so records sealed under the old layout stay verifiable.
+	assetSchemaVersion = 1
+	// maxChecksumBackfillBatch caps the number of keys BackfillChecksums scans per call
+	maxChecksumBackfillBatch = 500
+)
+
+// assetChecksum returns "v<version>:<hex SHA-256>" over the schema version and the JSON encoding
+// of the asset without its checksum. The JSON form is hashed whatever codec stores the record,
+// so a codec migration keeps checksums valid.
+//
+// The hash is unkeyed: it detects corruption and edits made without recomputing it, not a
+// deliberate edit by someone who can write the state database and recompute the checksum.
+// The ledger history, which RepairAsset restores from, stays the authority for such edits.
+func assetChecksum(asset *Asset, version int) (string, error) {
+	unsealed := *asset
+	unsealed.Checksum = ""
+	content, err := json.Marshal(&unsealed)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(strconv.Itoa(version)+"\x00"), content...))
+	return fmt.Sprintf("v%d:%s", version, hex.EncodeToString(sum[:])), nil
+}
+
+// sealAsset sets the checksum of an asset about to be stored
+func sealAsset(asset *Asset) error {
+	checksum, err := assetChecksum(asset, assetSchemaVersion)
+	if err != nil {
+		return err
+	}
+	asset.Checksum = checksum
+	return nil
+}
+
+// verifyAsset checks the checksum of a stored asset with the schema version it was sealed
+// under. Records written before checksums were introduced are sealed by BackfillChecksums;
+// until then they are rejected like any other record without a valid checksum.
+func verifyAsset(asset *Asset) error {
+	if asset.Checksum == "" {
+		return newChaincodeError(ErrCodeCorruptRecord, "asset %s has no checksum; seal records written before checksums with AdminContract:BackfillChecksums, otherwise %s", asset.ID, repairGuidance(asset.ID))
+	}
+	versionPart, _, found := strings.Cut(asset.Checksum, ":")
+	version, err := strconv.Atoi(strings.TrimPrefix(versionPart, "v"))
+	if !found || err != nil || !strings.HasPrefix(versionPart, "v") {
+		return newChaincodeError(ErrCodeCorruptRecord, "asset %s has a malformed checksum; %s", asset.ID, repairGuidance(asset.ID))
+	}
+	expected, err := assetChecksum(asset, version)
+	if err != nil {
+		return err
+	}
+	if expected != asset.Checksum {
+		log.Error().Str("assetID", asset.ID).Str("checksum", asset.Checksum).Msg("Asset failed its integrity check")
+		return newChaincodeError(ErrCodeCorruptRecord, "asset %s does not match its checksum; %s", asset.ID, repairGuidance(asset.ID))
+	}
+	return nil
+}
+
+func repairGuidance(assetID string) string {
+	return fmt.Sprintf("compare it with QueryContract:GetAssetHistory and restore the last committed version with AdminContract:RepairAsset %s", assetID)
+}
+
+// RepairAsset restores an asset that fails its integrity check from the last version committed
+// by a transaction. Direct state database edits bypass the history database, so the newest
+// history entry is the version the ledger agreed on; a deletion removes the asset.
+func (a *AdminContract) RepairAsset(ctx contractapi.TransactionContextInterface, assetID string) (*Asset, error) {
+	log.Info().Str("function", "RepairAsset").Str("assetID", assetID).Msg("Repairing asset from history")
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	iterator, err := ctx.GetStub().GetHistoryForKey(assetKey(assetID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of asset %s: %v", assetID, err)
+	}
+	defer iterator.Close()
+	if !iterator.HasNext() {
+		return nil, fmt.Errorf("asset %s has no history", assetID)
+	}
+	latest, err := iterator.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	if latest.IsDelete {
+		if err := deleteAsset(ctx, assetID); err != nil {
+			return nil, err
+		}
+		return nil, recordAudit(ctx, "RepairAsset", assetID, "deleted in "+latest.TxId)
+	}
+	// The committed version is trusted as is; it may predate checksums
+	asset, err := decodeAssetRecord(latest.Value)
+	if err != nil {
+		return nil, fmt.Errorf("history of asset %s in transaction %s is unreadable: %v", assetID, latest.TxId, err)
+	}
+	if err := a.saveAsset(ctx, asset); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, "RepairAsset", assetID, "restored from "+latest.TxId); err != nil {
+		return nil, err
+	}
+	log.Info().Str("assetID", assetID).Str("txId", latest.TxId).Msg("Asset restored from history")
+	return asset, nil
+}
+
+// ChecksumBackfill reports the progress of a BackfillChecksums batch
+type ChecksumBackfill struct {
+	Scanned int    `json:"scanned"`
+	Sealed  int    `json:"sealed"`
+	NextKey string `json:"nextKey"`
+}
+
+// BackfillChecksums seals up to limit asset records written before checksums were introduced,
+// starting at startKey. Call it again with NextKey until NextKey is empty; reads reject unsealed
+// records until then. Only the checksum is added, so versions, indexes and the outbox are kept.
+func (a *AdminContract) BackfillChecksums(ctx contractapi.TransactionContextInterface, startKey string, limit int) (*ChecksumBackfill, error) {
+	log.Info().Str("function", "BackfillChecksums").Str("startKey", startKey).Int("limit", limit).Msg("Backfilling asset checksums")
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxChecksumBackfillBatch {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxChecksumBackfillBatch)
+	}
+
+	firstKey, endKey := assetKeyRange("", "")
+	if startKey == "" {
+		startKey = firstKey
+	}
+	iterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan assets: %v", err)
+	}
+	defer iterator.Close()
+
+	backfill := &ChecksumBackfill{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if backfill.Scanned == limit {
+			backfill.NextKey = response.Key
+			break
+		}
+		backfill.Scanned++
+
+		// Composite keys hold indexes and configuration, never assets
+		if strings.HasPrefix(response.Key, "\x00") {
+			continue
+		}
+		asset, err := decodeAssetRecord(response.Value)
+		if err != nil {
+			log.Error().Err(err).Str("key", response.Key).Msg("Failed to decode record during checksum backfill")
+			return nil, fmt.Errorf("failed to decode %s: %v", response.Key, err)
+		}
+		if asset.DocType != AssetDocType || asset.Checksum != "" || response.Key != assetKey(asset.ID) {
+			continue
+		}
+		if err := sealAsset(asset); err != nil {
+			return nil, err
+		}
+		if err := storeAssetRecord(ctx, asset); err != nil {
+			return nil, err
+		}
+		backfill.Sealed++
+	}
+
+	if err := recordAudit(ctx, "BackfillChecksums", startKey, fmt.Sprintf("sealed=%d", backfill.Sealed)); err != nil {
+		return nil, err
+	}
+	log.Info().Int("scanned", backfill.Scanned).Int("sealed", backfill.Sealed).Str("nextKey", backfill.NextKey).Msg("Checksum backfill batch completed")
+	return backfill, nil
+}
@@ -0,0 +1,160 @@
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AssetWrite is an asset as committed before the transaction and as the transaction leaves it
+type AssetWrite struct {
+	ID     string
+	Before *Asset // nil for assets the transaction created
+	After  *Asset // nil for assets the transaction deleted
+}
+
+// AssetWrites are the assets written by a transaction, in the order they were first written
+type AssetWrites struct {
+	ids    []string
+	writes map[string]*AssetWrite
+}
+
+// All returns every write of the transaction
+func (w *AssetWrites) All() []AssetWrite {
+	all := make([]AssetWrite, 0, len(w.ids))
+	for _, id := range w.ids {
+		all = append(all, *w.writes[id])
+	}
+	return all
+}
+
+// Asset returns an asset as the transaction leaves it: as written, or as committed when the
+// transaction did not write it. It is nil when the asset does not exist. Invariants use it to
+// read related records, since Fabric reads do not see the transaction's own writes.
+func (w *AssetWrites) Asset(ctx contractapi.TransactionContextInterface, id string) (*Asset, error) {
+	if write, ok := w.writes[id]; ok {
+		return write.After, nil
+	}
+	return readCommittedAsset(ctx, id)
+}
+
+// Invariant checks a consistency rule over the assets written by a transaction and returns an
+// error describing the first violation
+type Invariant func(ctx contractapi.TransactionContextInterface, writes *AssetWrites) error
+
+// InvariantRegistry holds the cross-record consistency rules of the model. They are evaluated
+// after an AssetContract function succeeded and before the transaction returns, so a violation
+// fails the transaction whichever function caused it. Register invariants before the chaincode
+// starts; the registry is not safe for concurrent registration.
+type InvariantRegistry struct {
+	names  []string
+	checks []Invariant
+}
+
+// NewInvariantRegistry returns an empty registry
+func NewInvariantRegistry() *InvariantRegistry {
+	return &InvariantRegistry{}
+}
+
+// Register adds an invariant under a name used in violation errors
+func (r *InvariantRegistry) Register(name string, check Invariant) *InvariantRegistry {
+	r.names = append(r.names, name)
+	r.checks = append(r.checks, check)
+	return r
+}
+
+// check runs the invariants in registration order and reports the first violation
+func (r *InvariantRegistry) check(ctx contractapi.TransactionContextInterface, writes *AssetWrites) error {
+	for i, check := range r.checks {
+		if err := check(ctx, writes); err != nil {
+			return newChaincodeError(ErrCodeInvariantViolated, "invariant %s violated: %v", r.names[i], err)
+		}
+	}
+	return nil
+}
+
+// FrozenAssetsKeepOwner is an invariant keeping frozen assets, e.g. assets held in escrow, with
+// their owner: a transaction may neither transfer nor delete them
+func FrozenAssetsKeepOwner(ctx contractapi.TransactionContextInterface, writes *AssetWrites) error {
+	for _, write := range writes.All() {
+		if write.Before == nil || !write.Before.Frozen {
+			continue
+		}
+		if write.After == nil || write.After.Deleted {
+			return fmt.Errorf("frozen asset %s was deleted", write.ID)
+		}
+		if write.After.Owner != write.Before.Owner {
+			return fmt.Errorf("frozen asset %s changed owner from %s to %s", write.ID, write.Before.Owner, write.After.Owner)
+		}
+	}
+	return nil
+}
+
+// assetWriteTracker is implemented by transaction contexts that can record asset writes
+type assetWriteTracker interface {
+	trackAssetWrites()
+	trackedAssetWrites() *AssetWrites
+}
+
+// trackAssetWrites makes the context record the asset writes of the transaction for the
+// invariants, or fails when the context cannot
+func trackAssetWrites(ctx contractapi.TransactionContextInterface) error {
+	tracker, ok := ctx.(assetWriteTracker)
+	if !ok {
+		return fmt.Errorf("invariants require the transaction context to be a chaincode.TransactionContext, got %T", ctx)
+	}
+	tracker.trackAssetWrites()
+	return nil
+}
+
+// recordAssetWrite remembers an asset about to be written, nil for deletions, when the context
+// tracks writes. The committed asset is read on the first write, before it is overwritten.
+func recordAssetWrite(ctx contractapi.TransactionContextInterface, id string, after *Asset) error {
+	tracker, ok := ctx.(assetWriteTracker)
+	if !ok || tracker.trackedAssetWrites() == nil {
+		return nil
+	}
+	writes := tracker.trackedAssetWrites()
+	write, ok := writes.writes[id]
+	if !ok {
+		before, err := readCommittedAsset(ctx, id)
+		if err != nil {
+			return err
+		}
+		write = &AssetWrite{ID: id, Before: before}
+		writes.writes[id] = write
+		writes.ids = append(writes.ids, id)
+	}
+	if after != nil {
+		copied := *after
+		after = &copied
+	}
+	write.After = after
+	return nil
+}
+
+// checkInvariants runs the registered invariants over the writes the context tracked
+func (r *contractRuntime) checkInvariants(ctx contractapi.TransactionContextInterface) error {
+	tracker, ok := ctx.(assetWriteTracker)
+	if r.invariants == nil || !ok || tracker.trackedAssetWrites() == nil {
+		return nil
+	}
+	if err := r.invariants.check(ctx, tracker.trackedAssetWrites()); err != nil {
+		r.logger(ctx).Warn().Err(err).Msg("Transaction rejected by invariant")
+		return err
+	}
+	return nil
+}
+
+// readCommittedAsset reads an asset from world state, nil when it does not exist. Tombstones
+// are returned as they are, so invariants can tell them from missing assets.
+func readCommittedAsset(ctx contractapi.TransactionContextInterface, id string) (*Asset, error) {
+	assetBytes, err := ctx.GetStub().GetState(assetKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset %s: %v", id, err)
+	}
+	if assetBytes == nil {
+		return nil, nil
+	}
+	return decodeAsset(assetBytes)
+}
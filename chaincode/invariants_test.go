@@ -0,0 +1,105 @@
+package chaincode
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// childrenFitParent is a cross-record invariant: a path-style child may not be larger than its parent
+func childrenFitParent(ctx contractapi.TransactionContextInterface, writes *AssetWrites) error {
+	for _, write := range writes.All() {
+		i := strings.LastIndex(write.ID, pathSeparator)
+		if write.After == nil || i < 0 {
+			continue
+		}
+		parent, err := writes.Asset(ctx, write.ID[:i])
+		if err != nil {
+			return err
+		}
+		if parent != nil && write.After.Size > parent.Size {
+			return fmt.Errorf("%s is larger than its parent %s", write.ID, parent.ID)
+		}
+	}
+	return nil
+}
+
+// TestInvariants tests that registered invariants reject transactions after the function ran
+func TestInvariants(t *testing.T) {
+	var checked []string
+	registry := NewInvariantRegistry().
+		Register("audit", func(ctx contractapi.TransactionContextInterface, writes *AssetWrites) error {
+			for _, write := range writes.All() {
+				checked = append(checked, write.ID)
+			}
+			return nil
+		}).
+		Register("children fit parent", childrenFitParent)
+	cc, err := contractapi.NewChaincode(NewAssetContract(WithInvariants(registry)))
+	require.NoError(t, err)
+	stub := shimtest.NewMockStub("chaincode", cc)
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "admin", adminAttrs)
+
+	status, message := invoke(stub, "tx1", "CreateAsset", "org1", "blue", "10", "admin", "100")
+	require.Equal(t, int32(shim.OK), status, message)
+	status, message = invoke(stub, "tx2", "CreateAsset", "org1/a", "blue", "5", "admin", "100")
+	require.Equal(t, int32(shim.OK), status, message)
+	assert.Equal(t, []string{"org1", "org1/a"}, checked)
+
+	status, message = invoke(stub, "tx3", "CreateAsset", "org1/b", "blue", "11", "admin", "100")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "INVARIANT_VIOLATED")
+	assert.Contains(t, message, "invariant children fit parent violated: org1/b is larger than its parent org1")
+
+	// Failed functions are not checked
+	status, _ = invoke(stub, "tx4", "CreateAsset", "org1", "blue", "10", "admin", "100")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Equal(t, []string{"org1", "org1/a", "org1/b"}, checked)
+}
+
+// TestFrozenAssetsKeepOwner tests the escrow invariant over the writes recorded by the context
+func TestFrozenAssetsKeepOwner(t *testing.T) {
+	stub := shimtest.NewMockStub("chaincode", nil)
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "admin", adminAttrs)
+	stub.MockTransactionStart("tx1")
+	ctx := &TransactionContext{}
+	ctx.SetStub(stub)
+	identity, err := cid.New(stub)
+	require.NoError(t, err)
+	ctx.SetClientIdentity(identity)
+	require.NoError(t, putAsset(ctx, &Asset{DocType: "asset", ID: "escrow1", Color: "blue", Size: 5, Owner: "alice", Frozen: true}))
+	require.NoError(t, putAsset(ctx, &Asset{DocType: "asset", ID: "asset2", Color: "blue", Size: 5, Owner: "alice"}))
+
+	require.NoError(t, trackAssetWrites(ctx))
+	escrow, err := getAsset(ctx, "escrow1")
+	require.NoError(t, err)
+	escrow.AppraisedValue = 200
+	require.NoError(t, putAsset(ctx, escrow))
+	require.NoError(t, deleteAsset(ctx, "asset2"))
+	assert.NoError(t, FrozenAssetsKeepOwner(ctx, ctx.trackedAssetWrites()))
+
+	writes := ctx.trackedAssetWrites().All()
+	require.Len(t, writes, 2)
+	assert.Equal(t, 0, writes[0].Before.AppraisedValue)
+	assert.Equal(t, 200, writes[0].After.AppraisedValue)
+	assert.Nil(t, writes[1].After)
+	current, err := ctx.trackedAssetWrites().Asset(ctx, "asset2")
+	require.NoError(t, err)
+	assert.Nil(t, current)
+
+	escrow.Owner = "bob"
+	require.NoError(t, putAsset(ctx, escrow))
+	assert.EqualError(t, FrozenAssetsKeepOwner(ctx, ctx.trackedAssetWrites()), "frozen asset escrow1 changed owner from alice to bob")
+	require.NoError(t, deleteAsset(ctx, "escrow1"))
+	assert.EqualError(t, FrozenAssetsKeepOwner(ctx, ctx.trackedAssetWrites()), "frozen asset escrow1 was deleted")
+
+	plain, _ := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	assert.Error(t, trackAssetWrites(plain))
+}
@@ -0,0 +1,172 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// receiptIndex keys the receipt of a composite operation by transaction ID
+const receiptIndex = "receipt~tx"
+
+// ReceiptLeg is one part of a composite operation, e.g. the token payment or the asset transfer
+type ReceiptLeg struct {
+	Module string `json:"module"`
+	Action string `json:"action"`
+	Ref    string `json:"ref"`
+	Detail string `json:"detail,omitempty" metadata:",optional"`
+}
+
+// OperationReceipt records a composite operation whose legs all completed in one transaction
+type OperationReceipt struct {
+	DocType   string       `json:"docType"`
+	TxID      string       `json:"txId"`
+	Operation string       `json:"operation"`
+	Actor     string       `json:"actor"`
+	ActorMSP  string       `json:"actorMsp"`
+	Parties   []string     `json:"parties"`
+	Timestamp time.Time    `json:"timestamp"`
+	Legs      []ReceiptLeg `json:"legs"`
+}
+
+// operationJournal collects the legs of an operation that touches both the token module and
+// assets. Legs stage their bookkeeping writes instead of applying them, and commitJournal
+// applies them together with the receipt and a single event once every leg is recorded. An
+// operation abandoned after a failed leg therefore leaves no token marked as spent, no receipt
+// and no event, even when the caller handles the error instead of failing the transaction.
+type operationJournal struct {
+	operation string
+	parties   []string
+	legs      []ReceiptLeg
+	writes    []stagedWrite
+}
+
+// stagedWrite is a state write applied at commit, a deletion when value is nil
+type stagedWrite struct {
+	key   string
+	value []byte
+}
+
+// journalMarker is implemented by transaction contexts that remember whether the transaction
+// committed a composite operation
+type journalMarker interface {
+	markJournaled() bool
+}
+
+func newOperationJournal(operation string, parties ...string) *operationJournal {
+	return &operationJournal{operation: operation, parties: parties}
+}
+
+// record adds a completed leg
+func (j *operationJournal) record(module, action, ref, detail string) {
+	j.legs = append(j.legs, ReceiptLeg{Module: module, Action: action, Ref: ref, Detail: detail})
+}
+
+// stage defers a state write to the commit
+func (j *operationJournal) stage(key string, value []byte) {
+	j.writes = append(j.writes, stagedWrite{key: key, value: value})
+}
+
+// commitJournal checks that a leg of every module in modules was recorded, applies the staged
+// writes and stores the receipt. The event is set last, so it replaces the events the legs set.
+// Receipts are keyed by transaction ID, so a transaction commits at most one operation. A peer
+// does not show a transaction its own writes, so the context remembers the commit instead of
+// the receipt being read back.
+func (r *contractRuntime) commitJournal(ctx contractapi.TransactionContextInterface, j *operationJournal, event string, modules ...string) (*OperationReceipt, error) {
+	for _, module := range modules {
+		if !slices.ContainsFunc(j.legs, func(leg ReceiptLeg) bool { return leg.Module == module }) {
+			return nil, fmt.Errorf("%s is missing its %s leg", j.operation, module)
+		}
+	}
+
+	mspID, actor, err := getCallerSubmitter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := r.now(ctx)
+	if err != nil {
+		return nil, err
+	}
+	receipt := &OperationReceipt{
+		DocType:   "receipt",
+		TxID:      ctx.GetStub().GetTxID(),
+		Operation: j.operation,
+		Actor:     actor,
+		ActorMSP:  mspID,
+		Parties:   j.parties,
+		Timestamp: now,
+		Legs:      j.legs,
+	}
+	receiptKey, err := ctx.GetStub().CreateCompositeKey(receiptIndex, []string{receipt.TxID})
+	if err != nil {
+		return nil, err
+	}
+	if marker, ok := ctx.(journalMarker); ok && marker.markJournaled() {
+		return nil, fmt.Errorf("transaction %s already committed an operation", receipt.TxID)
+	}
+
+	for _, write := range j.writes {
+		if write.value == nil {
+			err = ctx.GetStub().DelState(write.key)
+		} else {
+			err = ctx.GetStub().PutState(write.key, write.value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply %s: %v", j.operation, err)
+		}
+	}
+	receiptBytes, err := json.Marshal(receipt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(receiptKey, receiptBytes); err != nil {
+		return nil, fmt.Errorf("failed to store receipt of %s: %v", j.operation, err)
+	}
+	if !r.skipEvents {
+		if err := emitEvent(ctx, event, receipt.TxID, receipt); err != nil {
+			return nil, err
+		}
+	}
+	r.logger(ctx).Info().Str("operation", j.operation).Int("legs", len(j.legs)).Msg("Composite operation committed")
+	return receipt, nil
+}
+
+// GetOperationReceipt returns the receipt of a composite operation, e.g. TransferAssetWithToken,
+// by transaction ID. Only its parties, admins and auditors may read it.
+func (q *QueryContract) GetOperationReceipt(ctx contractapi.TransactionContextInterface, txID string) (*OperationReceipt, error) {
+	q.logger(ctx).Info().Str("function", "GetOperationReceipt").Str("txID", txID).Msg("Reading operation receipt")
+
+	receiptKey, err := ctx.GetStub().CreateCompositeKey(receiptIndex, []string{txID})
+	if err != nil {
+		return nil, err
+	}
+	receiptBytes, err := ctx.GetStub().GetState(receiptKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt of transaction %s: %v", txID, err)
+	}
+	if receiptBytes == nil {
+		return nil, fmt.Errorf("transaction %s has no receipt", txID)
+	}
+	var receipt OperationReceipt
+	if err := json.Unmarshal(receiptBytes, &receipt); err != nil {
+		return nil, err
+	}
+
+	readsAll, err := callerReadsAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !readsAll {
+		caller, err := getCallerEnrollmentID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(receipt.Parties, caller) {
+			return nil, denyAccess(ctx, "receipt party", "%s is not a party of transaction %s", caller, txID)
+		}
+	}
+	return &receipt, nil
+}
@@ -0,0 +1,138 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTokenSale sets up asset1 owned by alice, priced at 50 USD, and token tok1 bob paid to alice
+// for it, and continues as bob
+func newTokenSale(t *testing.T) (*contractapi.TransactionContext, *shimtest.MockStub) {
+	t.Helper()
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	tokenStub := shimtest.NewMockStub("tokens", &fakeTokenChaincode{tokens: map[string]TokenReference{
+		"tok1": {TokenID: "tok1", Owner: "alice", Type: "USD", Quantity: "0x64", Sender: "bob", Reference: "asset1"},
+	}})
+	stub.MockPeerChaincode("tokens", tokenStub, "mychannel")
+	require.NoError(t, (&ConfigContract{}).SetTokenInterop(ctx, "tokens", "mychannel", "queryToken"))
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "alice", nil)
+	cc := &AssetContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "alice", 100))
+	require.NoError(t, cc.SetAssetTokenPrice(ctx, "asset1", "USD", 50))
+	lastEvent(t, stub)
+	switchIdentity(t, ctx, stub, "Org1MSP", "bob", nil)
+	return ctx, stub
+}
+
+// TestTransferAssetWithTokenReceipt tests that a token purchase commits both legs with one
+// receipt and one event, readable by its parties only
+func TestTransferAssetWithTokenReceipt(t *testing.T) {
+	ctx, stub := newTokenSale(t)
+	qc := &QueryContract{}
+
+	require.NoError(t, (&AssetContract{}).TransferAssetWithToken(ctx, "asset1", "bob", "tok1"))
+	event := lastEvent(t, stub)
+	require.NotNil(t, event)
+	assert.Equal(t, EventAssetPurchased, event.EventName)
+	var emitted OperationReceipt
+	require.NoError(t, json.Unmarshal(event.Payload, &emitted))
+
+	receipt, err := qc.GetOperationReceipt(ctx, "tx1")
+	require.NoError(t, err)
+	assert.Equal(t, emitted, *receipt)
+	assert.Equal(t, "TransferAssetWithToken", receipt.Operation)
+	assert.Equal(t, "bob", receipt.Actor)
+	assert.Equal(t, []string{"alice", "bob"}, receipt.Parties)
+	require.Len(t, receipt.Legs, 2)
+	assert.Equal(t, ReceiptLeg{Module: "token", Action: "pay", Ref: "tok1", Detail: "100 USD held by alice"}, receipt.Legs[0])
+	assert.Equal(t, ReceiptLeg{Module: "asset", Action: "transfer", Ref: "asset1", Detail: "alice to bob"}, receipt.Legs[1])
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "bob", nil)
+	_, err = qc.GetOperationReceipt(ctx, "tx1")
+	assert.NoError(t, err)
+	switchIdentity(t, ctx, stub, "Org1MSP", "carol", nil)
+	_, err = qc.GetOperationReceipt(ctx, "tx1")
+	assert.ErrorContains(t, err, "ACCESS_DENIED")
+	switchIdentity(t, ctx, stub, "Org1MSP", "auditor", map[string]string{"role": roleAuditor})
+	_, err = qc.GetOperationReceipt(ctx, "tx1")
+	assert.NoError(t, err)
+	_, err = qc.GetOperationReceipt(ctx, "tx2")
+	assert.Error(t, err)
+}
+
+// TestTransferAssetWithTokenPartialFailure tests that a failed asset leg leaves the token unused
+// and commits neither the asset write, the receipt nor an event
+func TestTransferAssetWithTokenPartialFailure(t *testing.T) {
+	ctx, mockStub := newTokenSale(t)
+	stub := &isolatedStub{MockStub: mockStub, writes: map[string][]byte{}}
+	ctx.SetStub(stub)
+	hooks := NewHookRegistry().OnAfterTransfer(func(ctx contractapi.TransactionContextInterface, asset *Asset, previousOwner string) error {
+		return fmt.Errorf("transfers to %s are blocked", asset.Owner)
+	})
+
+	assert.ErrorContains(t, NewAssetContract(WithHooks(hooks)).TransferAssetWithToken(ctx, "asset1", "bob", "tok1"), "blocked")
+	// Fabric commits nothing of a failed transaction
+	stub.abort("tx2")
+	usedKey, _ := stub.CreateCompositeKey(tokenUsedIndex, []string{"tok1"})
+	assert.Nil(t, stub.State[usedKey])
+	priceKey, _ := stub.CreateCompositeKey(tokenPriceIndex, []string{"asset1"})
+	assert.NotNil(t, stub.State[priceKey])
+	receiptKey, _ := stub.CreateCompositeKey(receiptIndex, []string{"tx1"})
+	assert.Nil(t, stub.State[receiptKey])
+	asset, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", asset.Owner)
+	assert.Empty(t, stub.ChaincodeEventsChannel)
+
+	// Once the failing leg is retried in a new transaction, the token pays for the asset
+	require.NoError(t, (&AssetContract{}).TransferAssetWithToken(ctx, "asset1", "bob", "tok1"))
+	stub.commit(t, "tx3")
+	assert.NotNil(t, stub.State[usedKey])
+	assert.Nil(t, stub.State[priceKey])
+	asset, err = getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", asset.Owner)
+	assert.Equal(t, EventAssetPurchased, lastEvent(t, stub.MockStub).EventName)
+}
+
+// TestCommitJournalMissingLeg tests that an operation missing a leg applies none of its writes,
+// and that a transaction commits one operation although it cannot read its own receipt
+func TestCommitJournalMissingLeg(t *testing.T) {
+	_, mockStub := newTestContext(t, "Org1MSP", "alice", nil)
+	stub := &isolatedStub{MockStub: mockStub, writes: map[string][]byte{}}
+	ctx := &TransactionContext{}
+	ctx.SetStub(stub)
+	identity, err := cid.New(stub)
+	require.NoError(t, err)
+	ctx.SetClientIdentity(identity)
+	cc := &AssetContract{}
+
+	journal := newOperationJournal("TransferAssetWithToken", "alice", "bob")
+	journal.record("token", "pay", "tok1", "")
+	journal.stage("staged", []byte("value"))
+	_, err = cc.commitJournal(ctx, journal, EventAssetPurchased, "token", "asset")
+	assert.ErrorContains(t, err, "missing its asset leg")
+	assert.Empty(t, stub.writes)
+	assert.Nil(t, stub.event)
+
+	journal.record("asset", "transfer", "asset1", "")
+	_, err = cc.commitJournal(ctx, journal, EventAssetPurchased, "token", "asset")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), stub.writes["staged"])
+	_, err = cc.commitJournal(ctx, journal, EventAssetPurchased, "token", "asset")
+	assert.ErrorContains(t, err, "already committed")
+
+	// The next transaction commits an operation of its own
+	stub.commit(t, "tx2")
+	ctx.SetStub(stub)
+	_, err = cc.commitJournal(ctx, journal, EventAssetPurchased, "token", "asset")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,122 @@
+package chaincode
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// AssetDocType is the docType of asset records, which rich queries select on
+	AssetDocType = "asset"
+	// AssetKeyPrefix prefixes the world state key of every asset, e.g. "ASSET_" to keep assets
+	// apart from other records under simple keys. Empty keeps assets under their bare IDs. It is
+	// part of the ledger layout, so it is a constant: every peer must run the same prefix. After
+	// changing it, AdminContract:MigrateAssetKeys moves the assets stored under the previous one.
+	AssetKeyPrefix = ""
+
+	// firstSimpleKey sorts after every composite key, which start with a null character
+	firstSimpleKey = "\x01"
+	// maxKeyMigrationBatch caps the keys one MigrateAssetKeys call scans
+	maxKeyMigrationBatch = 500
+)
+
+// assetKey returns the world state key of an asset
+func assetKey(assetID string) string {
+	return AssetKeyPrefix + assetID
+}
+
+// assetIDFromKey returns the ID of the asset stored under key
+func assetIDFromKey(key string) string {
+	return strings.TrimPrefix(key, AssetKeyPrefix)
+}
+
+// assetKeyRange returns the keys bounding a range query over the assets from startID up to,
+// but excluding, endID. Empty IDs leave that end open, bounded by the asset key space only.
+func assetKeyRange(startID, endID string) (string, string) {
+	startKey := assetKey(startID)
+	if startKey == "" {
+		startKey = firstSimpleKey
+	}
+	endKey := prefixRangeEnd(AssetKeyPrefix)
+	if endID != "" {
+		endKey = assetKey(endID)
+	}
+	return startKey, endKey
+}
+
+// KeyMigration reports the progress of a MigrateAssetKeys batch
+type KeyMigration struct {
+	Scanned  int    `json:"scanned"`
+	Migrated int    `json:"migrated"`
+	NextKey  string `json:"nextKey"`
+}
+
+// MigrateAssetKeys moves up to limit assets stored under fromPrefix, "" for bare IDs, to the
+// keys of the current AssetKeyPrefix, starting at startKey. Call it again with NextKey until
+// NextKey is empty. Records are moved as they are, with their key-level endorsement policy;
+// their history before the move stays under the old key.
+func (a *AdminContract) MigrateAssetKeys(ctx contractapi.TransactionContextInterface, fromPrefix, startKey string, limit int) (*KeyMigration, error) {
+	log.Info().Str("function", "MigrateAssetKeys").Str("fromPrefix", fromPrefix).Str("startKey", startKey).Int("limit", limit).Msg("Migrating asset keys")
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if fromPrefix == AssetKeyPrefix {
+		return nil, fmt.Errorf("assets are already stored under prefix %q", AssetKeyPrefix)
+	}
+	if limit <= 0 || limit > maxKeyMigrationBatch {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxKeyMigrationBatch)
+	}
+	if startKey == "" {
+		startKey = fromPrefix
+		if startKey == "" {
+			startKey = firstSimpleKey
+		}
+	}
+
+	iterator, err := ctx.GetStub().GetStateByRange(startKey, prefixRangeEnd(fromPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan assets: %v", err)
+	}
+	defer iterator.Close()
+
+	migration := &KeyMigration{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if migration.Scanned == limit {
+			migration.NextKey = response.Key
+			break
+		}
+		migration.Scanned++
+
+		// Bare IDs share the key space with the new prefix, whose keys are already migrated
+		if strings.HasPrefix(response.Key, "\x00") || (AssetKeyPrefix != "" && strings.HasPrefix(response.Key, AssetKeyPrefix)) {
+			continue
+		}
+		// Records are moved byte for byte; BackfillChecksums seals those that predate checksums
+		asset, err := decodeAssetRecord(response.Value)
+		if err != nil {
+			log.Error().Err(err).Str("key", response.Key).Msg("Failed to decode record during key migration")
+			return nil, fmt.Errorf("failed to decode %s: %v", response.Key, err)
+		}
+		if asset.DocType != AssetDocType || response.Key != fromPrefix+asset.ID {
+			continue
+		}
+		if err := moveAssetKey(ctx, response.Key, assetKey(asset.ID), response.Value); err != nil {
+			return nil, err
+		}
+		migration.Migrated++
+	}
+
+	if err := recordAudit(ctx, "MigrateAssetKeys", fromPrefix, fmt.Sprintf("%q migrated=%d", AssetKeyPrefix, migration.Migrated)); err != nil {
+		return nil, err
+	}
+	log.Info().Str("fromPrefix", fromPrefix).Int("scanned", migration.Scanned).Int("migrated", migration.Migrated).Str("nextKey", migration.NextKey).Msg("Asset key migration batch completed")
+	return migration, nil
+}
@@ -0,0 +1,70 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAssetKeyRange tests that open range ends stay within the asset key space
+func TestAssetKeyRange(t *testing.T) {
+	startKey, endKey := assetKeyRange("", "")
+	assert.Equal(t, firstSimpleKey, startKey)
+	assert.Equal(t, prefixRangeEnd(AssetKeyPrefix), endKey)
+
+	startKey, endKey = assetKeyRange("asset1", "asset5")
+	assert.Equal(t, assetKey("asset1"), startKey)
+	assert.Equal(t, assetKey("asset5"), endKey)
+	assert.Equal(t, "asset1", assetIDFromKey(assetKey("asset1")))
+}
+
+// TestMigrateAssetKeys tests that assets stored under a previous prefix are moved in batches
+func TestMigrateAssetKeys(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	ac := &AdminContract{}
+
+	for _, id := range []string{"asset1", "asset2"} {
+		assetBytes, err := json.Marshal(&Asset{DocType: AssetDocType, ID: id, Color: "blue", Size: 5, Owner: "Tomoko", AppraisedValue: 300})
+		require.NoError(t, err)
+		require.NoError(t, stub.PutState("OLD_"+id, assetBytes))
+	}
+	require.NoError(t, stub.SetStateValidationParameter("OLD_asset1", []byte("policy")))
+	// A record whose ID does not match its key was not written under the old prefix
+	require.NoError(t, stub.PutState("OLD_other", []byte(`{"docType":"asset","ID":"asset9"}`)))
+	stub.MockTransactionEnd("tx1")
+
+	_, err := ac.MigrateAssetKeys(ctx, AssetKeyPrefix, "", 10)
+	assert.ErrorContains(t, err, "already stored")
+	_, err = ac.MigrateAssetKeys(ctx, "OLD_", "", 0)
+	assert.Error(t, err)
+
+	stub.MockTransactionStart("tx2")
+	migration, err := ac.MigrateAssetKeys(ctx, "OLD_", "", 1)
+	require.NoError(t, err)
+	assert.Equal(t, KeyMigration{Scanned: 1, Migrated: 1, NextKey: "OLD_asset2"}, *migration)
+	stub.MockTransactionEnd("tx2")
+
+	stub.MockTransactionStart("tx3")
+	migration, err = ac.MigrateAssetKeys(ctx, "OLD_", migration.NextKey, 10)
+	require.NoError(t, err)
+	assert.Equal(t, KeyMigration{Scanned: 2, Migrated: 1}, *migration)
+	stub.MockTransactionEnd("tx3")
+
+	stub.MockTransactionStart("tx4")
+	_, err = ac.BackfillChecksums(ctx, "", 10)
+	require.NoError(t, err)
+	stub.MockTransactionEnd("tx4")
+
+	for _, id := range []string{"asset1", "asset2"} {
+		asset, err := getAsset(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "Tomoko", asset.Owner)
+		assert.Nil(t, stub.State["OLD_"+id])
+	}
+	assert.NotNil(t, stub.State["OLD_other"])
+	policy, err := stub.GetStateValidationParameter(assetKey("asset1"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("policy"), policy)
+}
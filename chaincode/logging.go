@@ -0,0 +1,27 @@
+package chaincode
+
+import (
+	"io"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Configure sets up the package logger. Importing the package leaves the global zerolog state
+// untouched, so main calls this once before starting the server and tests or embedders can
+// keep their own logging setup.
+func Configure(w io.Writer, level zerolog.Level) {
+	log.Logger = zerolog.New(w).Level(level).With().Timestamp().Logger()
+}
+
+// logger returns the logger of the contract, or the package logger when none was injected,
+// with the transaction ID attached so concurrent invocations can be told apart
+func (r *contractRuntime) logger(ctx contractapi.TransactionContextInterface) *zerolog.Logger {
+	base := log.Logger
+	if r.baseLogger != nil {
+		base = *r.baseLogger
+	}
+	logger := base.With().Str("txId", ctx.GetStub().GetTxID()).Logger()
+	return &logger
+}
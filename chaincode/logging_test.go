@@ -0,0 +1,22 @@
+package chaincode
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInjectedLogger tests that contract logs go to the injected logger with the transaction ID
+func TestInjectedLogger(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	cc := NewAssetContract(WithLogger(logger))
+
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	assert.Contains(t, buf.String(), `"txId":"tx1"`)
+	assert.Contains(t, buf.String(), `"assetID":"asset1"`)
+}
@@ -0,0 +1,131 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/rs/zerolog/log"
+)
+
+// namedParameters lists the parameter names, in positional order, of the functions that accept
+// a single JSON object instead of positional arguments. Go reflection does not expose parameter
+// names, so they are declared here; TestNamedParameters checks them against the signatures.
+var namedParameters = map[string][]string{
+	"AdminContract:StartOffboarding":                      {"mspID", "mode", "successorOwner", "successorMSP"},
+	"AssetContract:CreateAsset":                           {"assetID", "color", "size", "owner", "appraisedValue"},
+	"AssetContract:PreviewUpdate":                         {"assetID", "color", "size", "owner", "appraisedValue"},
+	"AssetContract:SwapAssets":                            {"swapID", "assetIDsA", "ownerA", "assetIDsB", "ownerB"},
+	"AssetContract:UpdateAsset":                           {"assetID", "color", "size", "owner", "appraisedValue", "expectedVersion"},
+	"QueryContract:GetAssetsByRangeWithPagination":        {"startKey", "endKey", "pageSize", "bookmark"},
+	"QueryContract:GetAssetsByRangeWithPaginationEncoded": {"startKey", "endKey", "pageSize", "bookmark", "acceptEncoding"},
+	"QueryContract:QueryAssetsByFilter":                   {"owner", "color", "minSize", "maxSize", "minValue", "maxValue", "pageSize", "bookmark"},
+}
+
+// defaultNamespace is the contract invoked when a function name has no namespace
+const defaultNamespace = "AssetContract"
+
+// NewNamedArgsChaincode wraps a chaincode so the functions in namedParameters can also be
+// invoked with a single JSON object argument, e.g.
+//
+//	{"Args":["CreateAsset","{\"assetID\":\"asset1\",\"color\":\"blue\",\"size\":5,\"owner\":\"Tom\",\"appraisedValue\":100}"]}
+//
+// The object is expanded into positional arguments before contractapi sees the call. This cannot
+// be done by a contractapi TransactionSerializer, which converts each argument on its own after
+// contractapi has already checked the argument count.
+func NewNamedArgsChaincode(cc shim.Chaincode) shim.Chaincode {
+	return &namedArgsChaincode{Chaincode: cc}
+}
+
+type namedArgsChaincode struct {
+	shim.Chaincode
+}
+
+// Invoke expands named arguments and forwards the call to the wrapped chaincode
+func (n *namedArgsChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	args := stub.GetArgs()
+	if len(args) != 2 {
+		return n.Chaincode.Invoke(stub)
+	}
+	function := string(args[0])
+	names, ok := namedParameters[qualifyFunction(function)]
+	if !ok || !bytes.HasPrefix(bytes.TrimSpace(args[1]), []byte("{")) {
+		return n.Chaincode.Invoke(stub)
+	}
+
+	positional, err := expandNamedArgs(names, args[1])
+	if err != nil {
+		log.Warn().Err(err).Str("function", function).Msg("Rejected named arguments")
+		return shim.Error(fmt.Sprintf("invalid named arguments for %s: %v", function, err))
+	}
+	return n.Chaincode.Invoke(&namedArgsStub{ChaincodeStubInterface: stub, args: append([][]byte{args[0]}, positional...)})
+}
+
+// qualifyFunction prefixes functions of the default contract with its namespace
+func qualifyFunction(function string) string {
+	if strings.Contains(function, ":") {
+		return function
+	}
+	return defaultNamespace + ":" + function
+}
+
+// expandNamedArgs orders the values of a JSON object by names. String values are passed
+// unquoted like positional string arguments, every other value as its JSON text.
+func expandNamedArgs(names []string, object []byte) ([][]byte, error) {
+	values := map[string]json.RawMessage{}
+	if err := json.Unmarshal(object, &values); err != nil {
+		return nil, err
+	}
+
+	positional := make([][]byte, 0, len(names))
+	for _, name := range names {
+		value, ok := values[name]
+		if !ok {
+			return nil, fmt.Errorf("missing parameter %s", name)
+		}
+		delete(values, name)
+
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			positional = append(positional, []byte(text))
+			continue
+		}
+		positional = append(positional, value)
+	}
+	if len(values) > 0 {
+		unknown := slices.Sorted(maps.Keys(values))
+		if len(unknown) == 1 {
+			return nil, fmt.Errorf("unknown parameter %s", unknown[0])
+		}
+		return nil, fmt.Errorf("unknown parameters %s", strings.Join(unknown, ", "))
+	}
+	return positional, nil
+}
+
+// namedArgsStub replaces the arguments of the invocation with the expanded positional ones
+type namedArgsStub struct {
+	shim.ChaincodeStubInterface
+	args [][]byte
+}
+
+func (s *namedArgsStub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s *namedArgsStub) GetStringArgs() []string {
+	strs := make([]string, 0, len(s.args))
+	for _, arg := range s.args {
+		strs = append(strs, string(arg))
+	}
+	return strs
+}
+
+func (s *namedArgsStub) GetFunctionAndParameters() (string, []string) {
+	strs := s.GetStringArgs()
+	return strs[0], strs[1:]
+}
@@ -0,0 +1,60 @@
+package chaincode
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNamedParameters tests that every named parameter list matches the function's arity
+func TestNamedParameters(t *testing.T) {
+	contracts := map[string]interface{}{
+		"AssetContract":  &AssetContract{},
+		"QueryContract":  &QueryContract{},
+		"AdminContract":  &AdminContract{},
+		"ConfigContract": &ConfigContract{},
+	}
+	for function, names := range namedParameters {
+		parts := strings.SplitN(function, ":", 2)
+		contract, ok := contracts[parts[0]]
+		require.True(t, ok, function)
+		method, ok := reflect.TypeOf(contract).MethodByName(parts[1])
+		require.True(t, ok, function)
+		// receiver and transaction context precede the named parameters
+		assert.Equal(t, method.Type.NumIn()-2, len(names), function)
+	}
+}
+
+// TestNamedArgsChaincode tests that a JSON object argument is expanded into positional arguments
+func TestNamedArgsChaincode(t *testing.T) {
+	cc, err := contractapi.NewChaincode(&AssetContract{}, &QueryContract{}, &AdminContract{})
+	require.NoError(t, err)
+	stub := shimtest.NewMockStub("chaincode", NewNamedArgsChaincode(cc))
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "user1", nil)
+
+	status, message := invoke(stub, "tx1", "CreateAsset", `{"assetID":"asset1","color":"blue","size":5,"owner":"Tom","appraisedValue":100}`)
+	require.Equal(t, int32(shim.OK), status, message)
+	status, message = invoke(stub, "tx2", "AssetContract:CreateAsset", "asset2", "red", "6", "Ann", "200")
+	require.Equal(t, int32(shim.OK), status, message)
+
+	response := stub.MockInvoke("tx3", [][]byte{[]byte("QueryContract:ReadAsset"), []byte("asset1")})
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	assert.Contains(t, string(response.Payload), `"size":5`)
+	assert.Contains(t, string(response.Payload), `"owner":"Tom"`)
+
+	status, message = invoke(stub, "tx4", "CreateAsset", `{"assetID":"asset3","color":"blue","size":5,"owner":"Tom"}`)
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "missing parameter appraisedValue")
+	status, message = invoke(stub, "tx5", "CreateAsset", `{"assetID":"asset3","color":"blue","size":5,"owner":"Tom","appraisedValue":1,"extra":1}`)
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "unknown parameter extra")
+	status, message = invoke(stub, "tx6", "CreateAsset", `{"assetID":"asset3","color":"blue","size":5,"owner":"Tom","appraisedValue":1,"zeta":1,"alpha":2,"mid":3}`)
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "unknown parameters alpha, mid, zeta")
+}
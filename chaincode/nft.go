@@ -0,0 +1,385 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// nftIndex keys a non-fungible token by ID
+	nftIndex = "nft~tokenId"
+	// nftOwnerIndex lists the tokens of an owner, for BalanceOf and TokensOfOwner
+	nftOwnerIndex = "nftowner~owner~tokenId"
+	// nftOperatorIndex keys the operators an owner approved for all their tokens
+	nftOperatorIndex = "nftoperator~owner~operator"
+)
+
+// NFTContract is a non-fungible token in the style of ERC-721, e.g. for digital collectibles or
+// certificates. Like TokenContract, accounts are "<MSP ID>:<enrollment ID>". Admins mint; owners,
+// the account approved for a token and the operators of its owner transfer it.
+type NFTContract struct {
+	contractapi.Contract
+}
+
+// NFT is a non-fungible token. Approved is the account that may transfer it besides its owner
+// and the owner's operators; a transfer clears it.
+type NFT struct {
+	TokenID  string `json:"tokenId"`
+	Owner    string `json:"owner"`
+	TokenURI string `json:"tokenURI"`
+	Approved string `json:"approved,omitempty" metadata:",optional"`
+}
+
+// NFTTransferEvent is the payload of the NFTTransfer event. From is empty for mints and To for
+// burns.
+type NFTTransferEvent struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	TokenID string `json:"tokenId"`
+}
+
+// NFTApprovalEvent is the payload of the NFTApproval event
+type NFTApprovalEvent struct {
+	Owner    string `json:"owner"`
+	Approved string `json:"approved"`
+	TokenID  string `json:"tokenId"`
+}
+
+// NFTApprovalForAllEvent is the payload of the NFTApprovalForAll event
+type NFTApprovalForAllEvent struct {
+	Owner    string `json:"owner"`
+	Operator string `json:"operator"`
+	Approved bool   `json:"approved"`
+}
+
+// MintWithTokenURI creates a token owned by the caller whose metadata, e.g. a JSON document
+// with the name and image of a collectible, is found at tokenURI
+func (c *NFTContract) MintWithTokenURI(ctx contractapi.TransactionContextInterface, tokenID, tokenURI string) (*NFT, error) {
+	log.Info().Str("function", "MintWithTokenURI").Str("tokenID", tokenID).Str("tokenURI", tokenURI).Msg("Minting non-fungible token")
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if tokenID == "" || tokenURI == "" {
+		return nil, fmt.Errorf("tokenID and tokenURI must not be empty")
+	}
+	existing, err := readNFT(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("token %s already exists", tokenID)
+	}
+	minter, err := getCallerAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nft := &NFT{TokenID: tokenID, Owner: minter, TokenURI: tokenURI}
+	if err := putNFT(ctx, nft); err != nil {
+		return nil, err
+	}
+	if err := setNFTOwnerIndex(ctx, minter, tokenID, true); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, EventNFTTransfer, tokenID, &NFTTransferEvent{To: minter, TokenID: tokenID}); err != nil {
+		return nil, err
+	}
+	return nft, nil
+}
+
+// Burn destroys a token of the caller
+func (c *NFTContract) Burn(ctx contractapi.TransactionContextInterface, tokenID string) error {
+	log.Info().Str("function", "Burn").Str("tokenID", tokenID).Msg("Burning non-fungible token")
+
+	nft, err := getNFT(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	caller, err := getCallerAccount(ctx)
+	if err != nil {
+		return err
+	}
+	if nft.Owner != caller {
+		return denyAccess(ctx, "token owner", "token %s is not owned by %s", tokenID, caller)
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(nftIndex, []string{tokenID})
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return fmt.Errorf("failed to delete token %s: %v", tokenID, err)
+	}
+	if err := setNFTOwnerIndex(ctx, nft.Owner, tokenID, false); err != nil {
+		return err
+	}
+	return emitEvent(ctx, EventNFTTransfer, tokenID, &NFTTransferEvent{From: nft.Owner, TokenID: tokenID})
+}
+
+// TransferFrom moves a token from its owner to recipient. The caller must be the owner, the
+// account approved for the token or an operator of the owner.
+func (c *NFTContract) TransferFrom(ctx contractapi.TransactionContextInterface, from, to, tokenID string) error {
+	log.Info().Str("function", "TransferFrom").Str("from", from).Str("to", to).Str("tokenID", tokenID).Msg("Transferring non-fungible token")
+
+	nft, err := getNFT(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if nft.Owner != from {
+		return fmt.Errorf("token %s is not owned by %s", tokenID, from)
+	}
+	if err := checkAccount(to); err != nil {
+		return err
+	}
+	if to == from {
+		return fmt.Errorf("recipient must not be the owner")
+	}
+	caller, err := getCallerAccount(ctx)
+	if err != nil {
+		return err
+	}
+	if caller != nft.Owner && caller != nft.Approved {
+		operator, err := isNFTOperator(ctx, nft.Owner, caller)
+		if err != nil {
+			return err
+		}
+		if !operator {
+			return denyAccess(ctx, "token owner or approved", "%s may not transfer token %s of %s", caller, tokenID, nft.Owner)
+		}
+	}
+
+	nft.Owner = to
+	nft.Approved = ""
+	if err := putNFT(ctx, nft); err != nil {
+		return err
+	}
+	if err := setNFTOwnerIndex(ctx, from, tokenID, false); err != nil {
+		return err
+	}
+	if err := setNFTOwnerIndex(ctx, to, tokenID, true); err != nil {
+		return err
+	}
+	return emitEvent(ctx, EventNFTTransfer, tokenID, &NFTTransferEvent{From: from, To: to, TokenID: tokenID})
+}
+
+// Approve lets approved transfer a token, replacing the previous approval. An empty approved
+// revokes it. The caller must own the token or be an operator of its owner.
+func (c *NFTContract) Approve(ctx contractapi.TransactionContextInterface, approved, tokenID string) error {
+	log.Info().Str("function", "Approve").Str("approved", approved).Str("tokenID", tokenID).Msg("Approving non-fungible token transfer")
+
+	nft, err := getNFT(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	caller, err := getCallerAccount(ctx)
+	if err != nil {
+		return err
+	}
+	if caller != nft.Owner {
+		operator, err := isNFTOperator(ctx, nft.Owner, caller)
+		if err != nil {
+			return err
+		}
+		if !operator {
+			return denyAccess(ctx, "token owner or operator", "%s may not approve transfers of token %s", caller, tokenID)
+		}
+	}
+	if approved != "" {
+		if err := checkAccount(approved); err != nil {
+			return err
+		}
+	}
+	if approved == nft.Owner {
+		return fmt.Errorf("the owner of token %s cannot be approved for it", tokenID)
+	}
+	nft.Approved = approved
+	if err := putNFT(ctx, nft); err != nil {
+		return err
+	}
+	return emitEvent(ctx, EventNFTApproval, tokenID, &NFTApprovalEvent{Owner: nft.Owner, Approved: approved, TokenID: tokenID})
+}
+
+// SetApprovalForAll makes operator an operator of the caller, who may transfer and approve
+// every token of the caller, or revokes it
+func (c *NFTContract) SetApprovalForAll(ctx contractapi.TransactionContextInterface, operator string, approved bool) error {
+	log.Info().Str("function", "SetApprovalForAll").Str("operator", operator).Bool("approved", approved).Msg("Setting non-fungible token operator")
+
+	owner, err := getCallerAccount(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkAccount(operator); err != nil {
+		return err
+	}
+	if operator == owner {
+		return fmt.Errorf("operator must not be the caller")
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(nftOperatorIndex, []string{owner, operator})
+	if err != nil {
+		return err
+	}
+	if approved {
+		err = ctx.GetStub().PutState(key, []byte{0x00})
+	} else {
+		err = ctx.GetStub().DelState(key)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update operator %s of %s: %v", operator, owner, err)
+	}
+	return emitEvent(ctx, EventNFTApprovalForAll, owner, &NFTApprovalForAllEvent{Owner: owner, Operator: operator, Approved: approved})
+}
+
+// OwnerOf returns the owner of a token
+func (c *NFTContract) OwnerOf(ctx contractapi.TransactionContextInterface, tokenID string) (string, error) {
+	log.Info().Str("function", "OwnerOf").Str("tokenID", tokenID).Msg("Reading non-fungible token owner")
+
+	nft, err := getNFT(ctx, tokenID)
+	if err != nil {
+		return "", err
+	}
+	return nft.Owner, nil
+}
+
+// BalanceOf returns the number of tokens owner holds
+func (c *NFTContract) BalanceOf(ctx contractapi.TransactionContextInterface, owner string) (int, error) {
+	log.Info().Str("function", "BalanceOf").Str("owner", owner).Msg("Counting non-fungible tokens")
+
+	tokenIDs, err := nftsOfOwner(ctx, owner)
+	if err != nil {
+		return 0, err
+	}
+	return len(tokenIDs), nil
+}
+
+// TokensOfOwner returns the IDs of the tokens owner holds, in key order
+func (c *NFTContract) TokensOfOwner(ctx contractapi.TransactionContextInterface, owner string) ([]string, error) {
+	log.Info().Str("function", "TokensOfOwner").Str("owner", owner).Msg("Listing non-fungible tokens")
+	return nftsOfOwner(ctx, owner)
+}
+
+// GetApproved returns the account approved for a token, or an empty string
+func (c *NFTContract) GetApproved(ctx contractapi.TransactionContextInterface, tokenID string) (string, error) {
+	log.Info().Str("function", "GetApproved").Str("tokenID", tokenID).Msg("Reading non-fungible token approval")
+
+	nft, err := getNFT(ctx, tokenID)
+	if err != nil {
+		return "", err
+	}
+	return nft.Approved, nil
+}
+
+// IsApprovedForAll reports whether operator is an operator of owner
+func (c *NFTContract) IsApprovedForAll(ctx contractapi.TransactionContextInterface, owner, operator string) (bool, error) {
+	log.Info().Str("function", "IsApprovedForAll").Str("owner", owner).Str("operator", operator).Msg("Reading non-fungible token operator")
+	return isNFTOperator(ctx, owner, operator)
+}
+
+// TokenURI returns the metadata URI of a token
+func (c *NFTContract) TokenURI(ctx contractapi.TransactionContextInterface, tokenID string) (string, error) {
+	log.Info().Str("function", "TokenURI").Str("tokenID", tokenID).Msg("Reading non-fungible token URI")
+
+	nft, err := getNFT(ctx, tokenID)
+	if err != nil {
+		return "", err
+	}
+	return nft.TokenURI, nil
+}
+
+// readNFT reads a token, nil when it does not exist
+func readNFT(ctx contractapi.TransactionContextInterface, tokenID string) (*NFT, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(nftIndex, []string{tokenID})
+	if err != nil {
+		return nil, err
+	}
+	nftBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token %s: %v", tokenID, err)
+	}
+	if nftBytes == nil {
+		return nil, nil
+	}
+	var nft NFT
+	if err := json.Unmarshal(nftBytes, &nft); err != nil {
+		return nil, err
+	}
+	return &nft, nil
+}
+
+// getNFT reads a token, failing when it does not exist
+func getNFT(ctx contractapi.TransactionContextInterface, tokenID string) (*NFT, error) {
+	nft, err := readNFT(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if nft == nil {
+		return nil, fmt.Errorf("token %s does not exist", tokenID)
+	}
+	return nft, nil
+}
+
+func putNFT(ctx contractapi.TransactionContextInterface, nft *NFT) error {
+	key, err := ctx.GetStub().CreateCompositeKey(nftIndex, []string{nft.TokenID})
+	if err != nil {
+		return err
+	}
+	nftBytes, err := json.Marshal(nft)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, nftBytes)
+}
+
+// setNFTOwnerIndex adds a token to the owner index of owner, or removes it
+func setNFTOwnerIndex(ctx contractapi.TransactionContextInterface, owner, tokenID string, owned bool) error {
+	key, err := ctx.GetStub().CreateCompositeKey(nftOwnerIndex, []string{owner, tokenID})
+	if err != nil {
+		return err
+	}
+	if owned {
+		return ctx.GetStub().PutState(key, []byte{0x00})
+	}
+	return ctx.GetStub().DelState(key)
+}
+
+// nftsOfOwner lists the tokens of owner from the owner index
+func nftsOfOwner(ctx contractapi.TransactionContextInterface, owner string) ([]string, error) {
+	if owner == "" {
+		return nil, fmt.Errorf("owner must not be empty")
+	}
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(nftOwnerIndex, []string{owner})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens of %s: %v", owner, err)
+	}
+	defer iterator.Close()
+
+	tokenIDs := []string{}
+	for iterator.HasNext() {
+		result, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(result.Key)
+		if err != nil {
+			return nil, err
+		}
+		if len(attributes) != 2 {
+			return nil, fmt.Errorf("malformed token owner key %q", result.Key)
+		}
+		tokenIDs = append(tokenIDs, attributes[1])
+	}
+	return tokenIDs, nil
+}
+
+func isNFTOperator(ctx contractapi.TransactionContextInterface, owner, operator string) (bool, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(nftOperatorIndex, []string{owner, operator})
+	if err != nil {
+		return false, err
+	}
+	value, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read operator %s of %s: %v", operator, owner, err)
+	}
+	return value != nil, nil
+}
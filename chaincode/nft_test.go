@@ -0,0 +1,96 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNFTContract tests minting, approvals, operators, transfers and burns with the owner index
+func TestNFTContract(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	nc := &NFTContract{}
+
+	nft, err := nc.MintWithTokenURI(ctx, "cert1", "https://example.com/cert1.json")
+	require.NoError(t, err)
+	assert.Equal(t, NFT{TokenID: "cert1", Owner: "Org1MSP:admin", TokenURI: "https://example.com/cert1.json"}, *nft)
+	_, err = nc.MintWithTokenURI(ctx, "cert1", "https://example.com/other.json")
+	assert.ErrorContains(t, err, "already exists")
+	_, err = nc.MintWithTokenURI(ctx, "cert2", "https://example.com/cert2.json")
+	require.NoError(t, err)
+
+	var transfer NFTTransferEvent
+	require.NoError(t, json.Unmarshal(lastEvent(t, stub).Payload, &transfer))
+	assert.Equal(t, NFTTransferEvent{To: "Org1MSP:admin", TokenID: "cert2"}, transfer)
+
+	require.NoError(t, nc.TransferFrom(ctx, "Org1MSP:admin", "Org1MSP:alice", "cert1"))
+	require.NoError(t, nc.TransferFrom(ctx, "Org1MSP:admin", "Org1MSP:alice", "cert2"))
+	assert.Error(t, nc.TransferFrom(ctx, "Org1MSP:admin", "Org1MSP:bob", "cert1"))
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "alice", nil)
+	_, err = nc.MintWithTokenURI(ctx, "cert3", "https://example.com/cert3.json")
+	assert.ErrorContains(t, err, "ACCESS_DENIED")
+	require.NoError(t, nc.Approve(ctx, "Org1MSP:bob", "cert1"))
+	require.NoError(t, nc.SetApprovalForAll(ctx, "Org1MSP:carol", true))
+	var approval NFTApprovalForAllEvent
+	require.NoError(t, json.Unmarshal(lastEvent(t, stub).Payload, &approval))
+	assert.Equal(t, NFTApprovalForAllEvent{Owner: "Org1MSP:alice", Operator: "Org1MSP:carol", Approved: true}, approval)
+
+	// The same enrollment ID in another organization is another account
+	switchIdentity(t, ctx, stub, "Org2MSP", "alice", nil)
+	assert.ErrorContains(t, nc.TransferFrom(ctx, "Org1MSP:alice", "Org2MSP:alice", "cert2"), "ACCESS_DENIED")
+	assert.ErrorContains(t, nc.Approve(ctx, "Org2MSP:alice", "cert2"), "ACCESS_DENIED")
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "bob", nil)
+	assert.ErrorContains(t, nc.TransferFrom(ctx, "Org1MSP:alice", "Org1MSP:bob", "cert2"), "ACCESS_DENIED")
+	require.NoError(t, nc.TransferFrom(ctx, "Org1MSP:alice", "Org1MSP:bob", "cert1"))
+	approved, err := nc.GetApproved(ctx, "cert1")
+	require.NoError(t, err)
+	assert.Empty(t, approved)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "carol", nil)
+	assert.ErrorContains(t, nc.Approve(ctx, "Org1MSP:dave", "cert1"), "ACCESS_DENIED")
+	require.NoError(t, nc.Approve(ctx, "Org1MSP:dave", "cert2"))
+	require.NoError(t, nc.TransferFrom(ctx, "Org1MSP:alice", "Org1MSP:dave", "cert2"))
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "bob", nil)
+	assert.ErrorContains(t, nc.Burn(ctx, "cert2"), "ACCESS_DENIED")
+	require.NoError(t, nc.Burn(ctx, "cert1"))
+	_, err = nc.OwnerOf(ctx, "cert1")
+	assert.ErrorContains(t, err, "does not exist")
+
+	for owner, expected := range map[string][]string{"Org1MSP:alice": {}, "Org1MSP:bob": {}, "Org1MSP:dave": {"cert2"}} {
+		tokens, err := nc.TokensOfOwner(ctx, owner)
+		require.NoError(t, err)
+		assert.Equal(t, expected, tokens, owner)
+		balance, err := nc.BalanceOf(ctx, owner)
+		require.NoError(t, err)
+		assert.Equal(t, len(expected), balance, owner)
+	}
+	operator, err := nc.IsApprovedForAll(ctx, "Org1MSP:alice", "Org1MSP:carol")
+	require.NoError(t, err)
+	assert.True(t, operator)
+	uri, err := nc.TokenURI(ctx, "cert2")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/cert2.json", uri)
+}
+
+// TestNFTContractRegistration tests that the NFT contract is served under its own namespace
+func TestNFTContractRegistration(t *testing.T) {
+	cc, err := contractapi.NewChaincode(NewAssetContract(), &NFTContract{})
+	require.NoError(t, err)
+	stub := shimtest.NewMockStub("chaincode", cc)
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "admin", adminAttrs)
+
+	status, message := invoke(stub, "tx1", "NFTContract:MintWithTokenURI", "cert1", "https://example.com/cert1.json")
+	require.Equal(t, int32(shim.OK), status, message)
+
+	response := stub.MockInvoke("tx2", [][]byte{[]byte("NFTContract:OwnerOf"), []byte("cert1")})
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	assert.Equal(t, "Org1MSP:admin", string(response.Payload))
+}
@@ -0,0 +1,219 @@
+package chaincode
+
+import "github.com/rs/zerolog"
+
+// Option customizes a contract built by NewAssetContract, NewQueryContract, NewAdminContract,
+// NewConfigContract or NewEscrowContract
+type Option func(*contractRuntime)
+
+// NewAssetContract returns a contract using the transaction clock, transaction-derived IDs,
+// environment configuration and the package logger, with the color index, input validation
+// and events enabled, unless overridden by opts.
+// The zero value of AssetContract behaves the same as NewAssetContract().
+func NewAssetContract(opts ...Option) *AssetContract {
+	t := &AssetContract{}
+	t.apply(opts)
+	t.Name = t.namespace
+	return t
+}
+
+// NewQueryContract returns a read-only contract configured like NewAssetContract.
+// Pass it the same options as the asset contract so both share one configuration.
+func NewQueryContract(opts ...Option) *QueryContract {
+	q := &QueryContract{}
+	q.apply(opts)
+	q.Name = q.namespace
+	return q
+}
+
+// NewConfigContract returns a configuration contract using the clock of opts; the other options
+// only apply to the asset contracts. The zero value of ConfigContract uses the transaction clock.
+func NewConfigContract(opts ...Option) *ConfigContract {
+	c := &ConfigContract{}
+	c.apply(opts)
+	return c
+}
+
+// NewAdminContract returns an administrative contract stamping the assets it rewrites, e.g. when
+// offboarding an organization, with the clock of opts and maintaining the indexes they select.
+// The zero value of AdminContract uses the transaction clock and every index.
+func NewAdminContract(opts ...Option) *AdminContract {
+	a := &AdminContract{}
+	a.apply(opts)
+	return a
+}
+
+// NewEscrowContract returns an escrow contract using the clock of opts for deadlines and asset
+// timestamps. The zero value of EscrowContract uses the transaction clock.
+func NewEscrowContract(opts ...Option) *EscrowContract {
+	e := &EscrowContract{}
+	e.apply(opts)
+	return e
+}
+
+func (r *contractRuntime) apply(opts []Option) {
+	for _, opt := range opts {
+		opt(r)
+	}
+}
+
+// WithClock replaces the transaction clock, e.g. with a fixed time in tests
+func WithClock(clock Clock) Option {
+	return func(r *contractRuntime) {
+		r.clock = clock
+	}
+}
+
+// WithIDGenerator replaces the generator of record IDs
+func WithIDGenerator(ids IDGenerator) Option {
+	return func(r *contractRuntime) {
+		r.ids = ids
+	}
+}
+
+// WithConfigProvider replaces the source of deployment settings
+func WithConfigProvider(config ConfigProvider) Option {
+	return func(r *contractRuntime) {
+		r.config = config
+	}
+}
+
+// WithLogger replaces the package logger for this contract's transactions
+func WithLogger(logger zerolog.Logger) Option {
+	return func(r *contractRuntime) {
+		r.baseLogger = &logger
+	}
+}
+
+// WithIndexes toggles the color~name index. Without it, writes skip the index entries and
+// TransferAssetByColor is rejected; the organization index used by offboarding is always kept.
+func WithIndexes(enabled bool) Option {
+	return func(r *contractRuntime) {
+		r.skipIndexes = !enabled
+	}
+}
+
+// WithValidation toggles the field checks applied to assets on create and update
+func WithValidation(enabled bool) Option {
+	return func(r *contractRuntime) {
+		r.skipValidation = !enabled
+	}
+}
+
+// WithEvents toggles the chaincode events set by the asset contract
+func WithEvents(enabled bool) Option {
+	return func(r *contractRuntime) {
+		r.skipEvents = !enabled
+	}
+}
+
+// WithQueryScoping toggles the owner constraint added to rich queries of callers who are neither
+// admin nor auditor. Disable it only when another layer restricts reads.
+func WithQueryScoping(enabled bool) Option {
+	return func(r *contractRuntime) {
+		r.skipQueryScoping = !enabled
+	}
+}
+
+// WithRawQueries toggles QueryAssets, QueryAssetsWithPagination and QueryAssetsWithPaginationEncoded,
+// which take CouchDB selectors written by the client. Without them clients use
+// QueryAssetsByFilter and saved queries, whose selectors the chaincode builds.
+func WithRawQueries(enabled bool) Option {
+	return func(r *contractRuntime) {
+		r.skipRawQueries = !enabled
+	}
+}
+
+// WithSampleData toggles InitLedger, which seeds the sample assets
+func WithSampleData(enabled bool) Option {
+	return func(r *contractRuntime) {
+		r.skipSampleData = !enabled
+	}
+}
+
+// WithHooks registers the domain callbacks of hooks with the contract
+func WithHooks(hooks *HookRegistry) Option {
+	return func(r *contractRuntime) {
+		r.hooks = hooks
+	}
+}
+
+// WithInvariants evaluates the invariants of registry over the assets each AssetContract
+// transaction writes, after the function succeeded and before the transaction returns
+func WithInvariants(registry *InvariantRegistry) Option {
+	return func(r *contractRuntime) {
+		r.invariants = registry
+	}
+}
+
+// WithInitRequired rejects every asset and query function but Init until Init has recorded
+// the deployment parameters, for chaincode definitions approved with --init-required
+func WithInitRequired() Option {
+	return func(r *contractRuntime) {
+		r.initRequired = true
+	}
+}
+
+// WithNamespace sets the contract name used to invoke functions as <namespace>:<Function>.
+// Pass it to one constructor only, since contract names must be unique within a chaincode.
+// Functions invoked without a namespace still reach the first contract of the chaincode.
+func WithNamespace(name string) Option {
+	return func(r *contractRuntime) {
+		r.namespace = name
+	}
+}
+
+// WithKeyLevelEndorsement makes CreateAsset set a key-level endorsement policy on each new asset
+// that requires a peer of the creating organization; Fabric validates writes of the key against
+// it instead of the chaincode-level policy.
+// AdminContract:AddEndorsingOrg and RemoveEndorsingOrg change the orgs afterwards.
+func WithKeyLevelEndorsement() Option {
+	return func(r *contractRuntime) {
+		r.keyLevelEndorsement = true
+	}
+}
+
+// WithSoftDelete makes DeleteAsset leave a tombstone instead of removing the asset. Tombstones
+// are hidden from reads and queries until RestoreAsset brings them back or PurgeAsset removes
+// them for good.
+func WithSoftDelete() Option {
+	return func(r *contractRuntime) {
+		r.softDelete = true
+	}
+}
+
+// WithUsageStats counts the committed invocations of each asset and query function per day for
+// QueryContract:GetUsageStats. Every transaction then also writes one counter shard.
+func WithUsageStats() Option {
+	return func(r *contractRuntime) {
+		r.usageStats = true
+	}
+}
+
+// WithTenantUsage counts the transactions each organization submits to the asset and query
+// contracts and the bytes they write per month for QueryContract:GetTenantUsage. Every
+// transaction then also writes one or two counter shards.
+func WithTenantUsage() Option {
+	return func(r *contractRuntime) {
+		r.tenantUsage = true
+	}
+}
+
+// WithTenantQuotas counts tenant usage like WithTenantUsage and rejects transactions of
+// organizations past the quota set with ConfigContract:SetTenantQuota. A transaction is checked
+// against the counter shard it increments only, so enforcement is approximate.
+func WithTenantQuotas() Option {
+	return func(r *contractRuntime) {
+		r.tenantUsage = true
+		r.tenantQuotas = true
+	}
+}
+
+// WithAttributeAccessControl enforces the caller's role attribute on asset functions: only admins
+// may delete assets, run InitLedger and other bulk changes, auditors are read-only, and everyone
+// else may only create, update and transfer assets they own
+func WithAttributeAccessControl() Option {
+	return func(r *contractRuntime) {
+		r.attributeAccessControl = true
+	}
+}
@@ -0,0 +1,73 @@
+package chaincode
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/chainlaunch/chaincode-fabric-go-tmpl/chaincode/assetpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestWithIndexesDisabled tests that assets are stored without color index entries
+func TestWithIndexesDisabled(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := NewAssetContract(WithIndexes(false))
+
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	indexKey, _ := stub.CreateCompositeKey(index, []string{"blue", "asset1"})
+	assert.Nil(t, stub.State[indexKey])
+	assert.Error(t, cc.TransferAssetByColor(ctx, "blue", "Jane"))
+	require.NoError(t, cc.DeleteAsset(ctx, "asset1"))
+}
+
+// TestWithValidation tests that invalid fields are rejected unless validation is disabled
+func TestWithValidation(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+
+	assert.Error(t, NewAssetContract().CreateAsset(ctx, "asset1", "blue", -1, "John", 100))
+	assert.Error(t, NewAssetContract().CreateAsset(ctx, "asset1", "", 5, "John", 100))
+	assert.NoError(t, NewAssetContract(WithValidation(false)).CreateAsset(ctx, "asset1", "blue", -1, "John", 100))
+}
+
+// TestWithEventsDisabled tests that no chaincode event is set
+func TestWithEventsDisabled(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := NewAssetContract(WithEvents(false))
+
+	reqBytes, err := proto.Marshal(&assetpb.CreateAssetRequest{Id: "asset1", Color: "blue", Size: 5, Owner: "John"})
+	require.NoError(t, err)
+	require.NoError(t, cc.CreateAssetProto(ctx, base64.StdEncoding.EncodeToString(reqBytes)))
+	select {
+	case event := <-stub.ChaincodeEventsChannel:
+		t.Fatalf("unexpected event %s", event.EventName)
+	default:
+	}
+}
+
+// TestWithNamespace tests that the namespace becomes the contract name
+func TestWithNamespace(t *testing.T) {
+	assert.Equal(t, "assets", NewAssetContract(WithNamespace("assets")).GetName())
+}
+
+// TestWithRawQueriesDisabled tests that functions taking client-written selectors are refused
+func TestWithRawQueriesDisabled(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	qc := NewQueryContract(WithRawQueries(false))
+
+	_, err := qc.QueryAssets(ctx, `{"selector":{"docType":"asset"}}`)
+	assert.ErrorContains(t, err, "raw CouchDB queries are turned off")
+	_, err = qc.QueryAssetsWithPaginationEncoded(ctx, `{"selector":{"docType":"asset"}}`, 10, "", "")
+	assert.ErrorContains(t, err, "raw CouchDB queries are turned off")
+}
+
+// TestWithSampleDataDisabled tests that InitLedger seeds nothing when sample data is off
+func TestWithSampleDataDisabled(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+
+	assert.ErrorContains(t, NewAssetContract(WithSampleData(false)).InitLedger(ctx), "sample data seeding is turned off")
+	assert.Nil(t, stub.State["asset1"])
+	require.NoError(t, NewAssetContract().InitLedger(ctx))
+	assert.NotNil(t, stub.State["asset1"])
+}
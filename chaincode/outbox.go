@@ -0,0 +1,593 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// outboxIndex keys change records by partition, bucket, batch number and asset ID
+	outboxIndex = "outbox"
+	// outboxHeadIndex keys the last batch number of each partition and the transaction that
+	// assigned it
+	outboxHeadIndex = "outbox~head"
+	// outboxTailIndex keys the last pruned batch number of each partition
+	outboxTailIndex = "outbox~tail"
+	// outboxConsumerIndex keys the acknowledged position of each consumer
+	outboxConsumerIndex = "outbox~consumer"
+	// outboxPartitions is the number of partitions the outbox is spread over by asset ID. Every
+	// partition has its own head, so concurrent transactions writing assets of different
+	// partitions do not invalidate each other's read sets.
+	outboxPartitions = 16
+	// outboxBucketSize groups change records so GetChangesSince can start near a batch number
+	// without scanning the whole partition
+	outboxBucketSize = 1000
+	// maxChangesPageSize caps the number of change records returned per call
+	maxChangesPageSize = 1000
+
+	changeOperationPut    = "put"
+	changeOperationDelete = "delete"
+)
+
+// ChangeRecord is a compact description of one asset mutation. Seq numbers the transactions that
+// changed assets of a partition in commit order; the changes of one transaction share it, and
+// several changes of the transaction to one asset collapse into its final state.
+type ChangeRecord struct {
+	Partition int       `json:"partition"`
+	Seq       uint64    `json:"seq"`
+	TxID      string    `json:"txId"`
+	Timestamp time.Time `json:"timestamp"`
+	Operation string    `json:"operation"`
+	AssetID   string    `json:"assetId"`
+	Asset     *Asset    `json:"asset,omitempty" metadata:",optional"`
+}
+
+// ChangesPage is a batch of change records and the position to resume from
+type ChangesPage struct {
+	Changes  []*ChangeRecord `json:"changes"`
+	Position string          `json:"position"`
+	HasMore  bool            `json:"hasMore"`
+}
+
+// OutboxCheckpoint is the last position a consumer has acknowledged
+type OutboxCheckpoint struct {
+	Consumer string `json:"consumer"`
+	Position string `json:"position"`
+	TxID     string `json:"txId"`
+}
+
+// PruneResult reports the outcome of a PruneChanges batch
+type PruneResult struct {
+	Pruned        int    `json:"pruned"`
+	PrunedThrough string `json:"prunedThrough"`
+	HasMore       bool   `json:"hasMore"`
+}
+
+// outboxHead is the last batch number of a partition
+type outboxHead struct {
+	Seq  uint64 `json:"seq"`
+	TxID string `json:"txId"`
+}
+
+// outboxPosition holds a batch number per partition. It is passed to clients as the
+// comma-separated numbers, empty for the start of the outbox.
+type outboxPosition []uint64
+
+func parseOutboxPosition(position string) (outboxPosition, error) {
+	parsed := make(outboxPosition, outboxPartitions)
+	if position == "" {
+		return parsed, nil
+	}
+	parts := strings.Split(position, ",")
+	if len(parts) != outboxPartitions {
+		return nil, fmt.Errorf("outbox position must have %d batch numbers, got %d", outboxPartitions, len(parts))
+	}
+	for i, part := range parts {
+		seq, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid outbox position %q: %v", position, err)
+		}
+		parsed[i] = seq
+	}
+	return parsed, nil
+}
+
+func (p outboxPosition) String() string {
+	parts := make([]string, len(p))
+	for i, seq := range p {
+		parts[i] = strconv.FormatUint(seq, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// GetChangesSince returns about limit change records after a position, so off-chain databases
+// can sync incrementally by passing back the Position of the previous page, "" to start. Changes
+// of one asset are returned in commit order; a page ends after a whole transaction's changes of a
+// partition, so it may hold slightly more than limit records. The feed spans every asset, so it
+// is limited to identities that read all records; assets are presented like ReadAsset does and
+// records of assets outside the caller's region are left out.
+func (q *QueryContract) GetChangesSince(ctx contractapi.TransactionContextInterface, position string, limit int) (*ChangesPage, error) {
+	q.logger(ctx).Info().Str("function", "GetChangesSince").Str("position", position).Int("limit", limit).Msg("Reading change records")
+
+	readsAll, err := callerReadsAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !readsAll {
+		return nil, denyAccess(ctx, "role=admin or role=auditor", "the change feed requires the admin or auditor role")
+	}
+	presenter, err := q.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := parseOutboxPosition(position)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxChangesPageSize {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxChangesPageSize)
+	}
+
+	page := &ChangesPage{Changes: []*ChangeRecord{}}
+	next := append(outboxPosition(nil), from...)
+	budget := newQueryBudget(q.configProvider())
+	for partition := 0; partition < outboxPartitions; partition++ {
+		head, err := getOutboxHead(ctx, partition)
+		if err != nil {
+			return nil, err
+		}
+		tail, err := getOutboxTail(ctx, partition)
+		if err != nil {
+			return nil, err
+		}
+		if from[partition] < tail {
+			return nil, newChaincodeError(ErrCodeChangesPruned, "changes up to %d of partition %d have been pruned, resync from a snapshot", tail, partition)
+		}
+		if len(page.Changes) < limit {
+			if err := readOutboxPartition(ctx, partition, head.Seq, next, page, budget, limit); err != nil {
+				return nil, err
+			}
+		}
+		if next[partition] < head.Seq {
+			page.HasMore = true
+		}
+	}
+	page.Position = next.String()
+
+	// The position has already moved past hidden records, so the next page does not return them
+	visible := make([]*ChangeRecord, 0, len(page.Changes))
+	for _, change := range page.Changes {
+		if change.Asset != nil {
+			if !presenter.visible(change.Asset) {
+				continue
+			}
+			change.Asset = presenter.present(change.Asset)
+		}
+		visible = append(visible, change)
+	}
+	page.Changes = visible
+
+	q.logger(ctx).Info().Int("count", len(page.Changes)).Str("position", page.Position).Bool("hasMore", page.HasMore).Msg("Change records read successfully")
+	return page, nil
+}
+
+// readOutboxPartition appends the records of a partition after next[partition] up to head,
+// until the page is full at the end of a batch, and advances next past them
+func readOutboxPartition(ctx contractapi.TransactionContextInterface, partition int, head uint64, next outboxPosition, page *ChangesPage, budget *queryBudget, limit int) error {
+	after := next[partition]
+	for bucket := (after + 1) / outboxBucketSize; bucket <= head/outboxBucketSize; bucket++ {
+		full, err := readOutboxBucket(ctx, partition, bucket, after, next, page, budget, limit)
+		if err != nil || full {
+			return err
+		}
+	}
+	return nil
+}
+
+// readOutboxBucket appends the records of one bucket with a batch number greater than after and
+// reports whether the page is full
+func readOutboxBucket(ctx contractapi.TransactionContextInterface, partition int, bucket, after uint64, next outboxPosition, page *ChangesPage, budget *queryBudget, limit int) (bool, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(outboxIndex, []string{formatOutboxPartition(partition), formatOutboxSeq(bucket)})
+	if err != nil {
+		log.Error().Err(err).Int("partition", partition).Uint64("bucket", bucket).Msg("Failed to read outbox bucket")
+		return false, fmt.Errorf("failed to read outbox: %v", err)
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return false, err
+		}
+		var change ChangeRecord
+		if err := json.Unmarshal(response.Value, &change); err != nil {
+			return false, err
+		}
+		if change.Seq <= after {
+			continue
+		}
+		// Pages end between batches, so a position never splits a transaction's changes
+		if change.Seq > next[partition] && len(page.Changes) >= limit {
+			return true, nil
+		}
+		if err := budget.consume(len(response.Value)); err != nil {
+			return false, err
+		}
+		page.Changes = append(page.Changes, &change)
+		next[partition] = change.Seq
+	}
+	return false, nil
+}
+
+// appendChange records an asset mutation in the outbox partition of the asset, under the batch
+// number of the current transaction. asset is nil for deletions.
+func appendChange(ctx contractapi.TransactionContextInterface, operation, assetID string, asset *Asset) error {
+	partition := outboxPartition(assetID)
+	head, err := getOutboxHead(ctx, partition)
+	if err != nil {
+		return err
+	}
+	// A peer does not show a transaction its own writes, while test stubs do. The head names
+	// the transaction that wrote it, so every change of this transaction in the partition gets
+	// the same batch number either way.
+	txID := ctx.GetStub().GetTxID()
+	seq := head.Seq
+	if head.TxID != txID {
+		seq++
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	change := &ChangeRecord{
+		Partition: partition,
+		Seq:       seq,
+		TxID:      txID,
+		Timestamp: timestamp.AsTime().UTC(),
+		Operation: operation,
+		AssetID:   assetID,
+		Asset:     asset,
+	}
+	changeBytes, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	key, err := outboxRecordKey(ctx, partition, seq, assetID)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, changeBytes); err != nil {
+		log.Error().Err(err).Int("partition", partition).Uint64("seq", seq).Str("assetID", assetID).Msg("Failed to append change record")
+		return fmt.Errorf("failed to append change record: %v", err)
+	}
+	return putOutboxHead(ctx, partition, &outboxHead{Seq: seq, TxID: txID})
+}
+
+// AcknowledgeChanges records that consumer has applied every change up to a position returned by
+// GetChangesSince. Checkpoints only move forward; PruneChanges deletes records acknowledged by
+// every registered consumer.
+func (a *AdminContract) AcknowledgeChanges(ctx contractapi.TransactionContextInterface, consumer, position string) error {
+	log.Info().Str("function", "AcknowledgeChanges").Str("consumer", consumer).Str("position", position).Msg("Acknowledging change records")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if consumer == "" {
+		return fmt.Errorf("consumer must not be empty")
+	}
+	acknowledged, err := parseOutboxPosition(position)
+	if err != nil {
+		return err
+	}
+	for partition, seq := range acknowledged {
+		head, err := getOutboxHead(ctx, partition)
+		if err != nil {
+			return err
+		}
+		if seq > head.Seq {
+			return fmt.Errorf("batch number %d is outside the range 0-%d of partition %d", seq, head.Seq, partition)
+		}
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(outboxConsumerIndex, []string{consumer})
+	if err != nil {
+		return err
+	}
+	checkpointBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint of %s: %v", consumer, err)
+	}
+	if checkpointBytes != nil {
+		var previous OutboxCheckpoint
+		if err := json.Unmarshal(checkpointBytes, &previous); err != nil {
+			return err
+		}
+		previousPosition, err := parseOutboxPosition(previous.Position)
+		if err != nil {
+			return err
+		}
+		for partition, seq := range acknowledged {
+			if seq < previousPosition[partition] {
+				return fmt.Errorf("consumer %s already acknowledged changes up to %d in partition %d", consumer, previousPosition[partition], partition)
+			}
+		}
+	}
+
+	checkpoint := &OutboxCheckpoint{Consumer: consumer, Position: acknowledged.String(), TxID: ctx.GetStub().GetTxID()}
+	checkpointBytes, err = json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, checkpointBytes); err != nil {
+		log.Error().Err(err).Str("consumer", consumer).Msg("Failed to store outbox checkpoint")
+		return err
+	}
+	return recordAudit(ctx, "AcknowledgeChanges", consumer, checkpoint.Position)
+}
+
+// RemoveOutboxConsumer drops the checkpoint of a consumer that no longer syncs, so it does
+// not hold back pruning
+func (a *AdminContract) RemoveOutboxConsumer(ctx contractapi.TransactionContextInterface, consumer string) error {
+	log.Info().Str("function", "RemoveOutboxConsumer").Str("consumer", consumer).Msg("Removing outbox consumer")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(outboxConsumerIndex, []string{consumer})
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return err
+	}
+	return recordAudit(ctx, "RemoveOutboxConsumer", consumer, "")
+}
+
+// GetOutboxCheckpoints returns the acknowledged position of every consumer
+func (a *AdminContract) GetOutboxCheckpoints(ctx contractapi.TransactionContextInterface) ([]*OutboxCheckpoint, error) {
+	log.Info().Str("function", "GetOutboxCheckpoints").Msg("Reading outbox checkpoints")
+
+	readsAll, err := callerReadsAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !readsAll {
+		return nil, denyAccess(ctx, "role=admin or role=auditor", "outbox checkpoints require the admin or auditor role")
+	}
+	return getOutboxCheckpoints(ctx)
+}
+
+// PruneChanges deletes about limit change records acknowledged by every consumer, whole batches
+// at a time. Call it repeatedly while HasMore is set to keep the outbox bounded.
+func (a *AdminContract) PruneChanges(ctx contractapi.TransactionContextInterface, limit int) (*PruneResult, error) {
+	log.Info().Str("function", "PruneChanges").Int("limit", limit).Msg("Pruning acknowledged change records")
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxChangesPageSize {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxChangesPageSize)
+	}
+
+	checkpoints, err := getOutboxCheckpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tails := make(outboxPosition, outboxPartitions)
+	for partition := range tails {
+		if tails[partition], err = getOutboxTail(ctx, partition); err != nil {
+			return nil, err
+		}
+	}
+	result := &PruneResult{PrunedThrough: tails.String()}
+	if len(checkpoints) == 0 {
+		log.Info().Msg("No outbox consumers registered, nothing to prune")
+		return result, nil
+	}
+
+	// Every partition can be pruned up to the slowest consumer's position in it
+	acknowledged, err := parseOutboxPosition(checkpoints[0].Position)
+	if err != nil {
+		return nil, err
+	}
+	for _, checkpoint := range checkpoints[1:] {
+		position, err := parseOutboxPosition(checkpoint.Position)
+		if err != nil {
+			return nil, err
+		}
+		for partition, seq := range position {
+			acknowledged[partition] = min(acknowledged[partition], seq)
+		}
+	}
+
+	for partition := 0; partition < outboxPartitions; partition++ {
+		if result.Pruned >= limit {
+			break
+		}
+		previous := tails[partition]
+		if err := pruneOutboxPartition(ctx, partition, acknowledged[partition], tails, result, limit); err != nil {
+			return nil, err
+		}
+		if tails[partition] > previous {
+			if err := putOutboxTail(ctx, partition, tails[partition]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for partition, tail := range tails {
+		if tail < acknowledged[partition] {
+			result.HasMore = true
+		}
+	}
+	result.PrunedThrough = tails.String()
+	if result.Pruned > 0 {
+		if err := recordAudit(ctx, "PruneChanges", "outbox", result.PrunedThrough); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info().Int("pruned", result.Pruned).Str("prunedThrough", result.PrunedThrough).Bool("hasMore", result.HasMore).Msg("Change records pruned")
+	return result, nil
+}
+
+// pruneOutboxPartition deletes the records of a partition after tails[partition] up to
+// acknowledged, until limit is reached at the end of a batch, and advances tails past them
+func pruneOutboxPartition(ctx contractapi.TransactionContextInterface, partition int, acknowledged uint64, tails outboxPosition, result *PruneResult, limit int) error {
+	after := tails[partition]
+	if acknowledged <= after {
+		return nil
+	}
+	for bucket := (after + 1) / outboxBucketSize; bucket <= acknowledged/outboxBucketSize; bucket++ {
+		iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(outboxIndex, []string{formatOutboxPartition(partition), formatOutboxSeq(bucket)})
+		if err != nil {
+			return fmt.Errorf("failed to read outbox: %v", err)
+		}
+		var keys []string
+		full := false
+		for iterator.HasNext() {
+			response, err := iterator.Next()
+			if err != nil {
+				iterator.Close()
+				return err
+			}
+			var change ChangeRecord
+			if err := json.Unmarshal(response.Value, &change); err != nil {
+				iterator.Close()
+				return err
+			}
+			if change.Seq <= after {
+				continue
+			}
+			if change.Seq > acknowledged || (change.Seq > tails[partition] && result.Pruned+len(keys) >= limit) {
+				full = true
+				break
+			}
+			keys = append(keys, response.Key)
+			tails[partition] = change.Seq
+		}
+		iterator.Close()
+
+		for _, key := range keys {
+			if err := ctx.GetStub().DelState(key); err != nil {
+				log.Error().Err(err).Str("key", key).Msg("Failed to prune change record")
+				return err
+			}
+		}
+		result.Pruned += len(keys)
+		if full {
+			return nil
+		}
+	}
+	// Batches without a record left, e.g. of aborted reads, are covered as well
+	tails[partition] = acknowledged
+	return nil
+}
+
+func getOutboxCheckpoints(ctx contractapi.TransactionContextInterface) ([]*OutboxCheckpoint, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(outboxConsumerIndex, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox checkpoints: %v", err)
+	}
+	defer iterator.Close()
+
+	checkpoints := []*OutboxCheckpoint{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var checkpoint OutboxCheckpoint
+		if err := json.Unmarshal(response.Value, &checkpoint); err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, &checkpoint)
+	}
+	return checkpoints, nil
+}
+
+// outboxPartition returns the partition of an asset's change records
+func outboxPartition(assetID string) int {
+	sum := sha256.Sum256([]byte(assetID))
+	return int(binary.BigEndian.Uint64(sum[:8]) % outboxPartitions)
+}
+
+// getOutboxHead returns the last batch number of a partition, zero when unset
+func getOutboxHead(ctx contractapi.TransactionContextInterface, partition int) (*outboxHead, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(outboxHeadIndex, []string{formatOutboxPartition(partition)})
+	if err != nil {
+		return nil, err
+	}
+	headBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox head of partition %d: %v", partition, err)
+	}
+	head := &outboxHead{}
+	if headBytes == nil {
+		return head, nil
+	}
+	if err := json.Unmarshal(headBytes, head); err != nil {
+		return nil, err
+	}
+	return head, nil
+}
+
+func putOutboxHead(ctx contractapi.TransactionContextInterface, partition int, head *outboxHead) error {
+	key, err := ctx.GetStub().CreateCompositeKey(outboxHeadIndex, []string{formatOutboxPartition(partition)})
+	if err != nil {
+		return err
+	}
+	headBytes, err := json.Marshal(head)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, headBytes)
+}
+
+// getOutboxTail returns the last pruned batch number of a partition, zero when unset
+func getOutboxTail(ctx contractapi.TransactionContextInterface, partition int) (uint64, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(outboxTailIndex, []string{formatOutboxPartition(partition)})
+	if err != nil {
+		return 0, err
+	}
+	tailBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read outbox tail of partition %d: %v", partition, err)
+	}
+	if tailBytes == nil {
+		return 0, nil
+	}
+	return strconv.ParseUint(string(tailBytes), 10, 64)
+}
+
+func putOutboxTail(ctx contractapi.TransactionContextInterface, partition int, seq uint64) error {
+	key, err := ctx.GetStub().CreateCompositeKey(outboxTailIndex, []string{formatOutboxPartition(partition)})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, []byte(strconv.FormatUint(seq, 10)))
+}
+
+// outboxRecordKey returns the key of a change record
+func outboxRecordKey(ctx contractapi.TransactionContextInterface, partition int, seq uint64, assetID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(outboxIndex, []string{formatOutboxPartition(partition), formatOutboxSeq(seq / outboxBucketSize), formatOutboxSeq(seq), assetID})
+}
+
+// formatOutboxPartition zero-pads partitions so composite keys sort in numeric order
+func formatOutboxPartition(partition int) string {
+	return fmt.Sprintf("%02d", partition)
+}
+
+// formatOutboxSeq zero-pads numbers so composite keys sort in numeric order
+func formatOutboxSeq(n uint64) string {
+	return fmt.Sprintf("%020d", n)
+}
@@ -0,0 +1,194 @@
+package chaincode
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// positionAt returns an outbox position at seq in one partition and the start in all others
+func positionAt(partition int, seq uint64) string {
+	position := make(outboxPosition, outboxPartitions)
+	position[partition] = seq
+	return position.String()
+}
+
+// TestGetChangesSince tests that the changes of an asset are returned in commit order across pages
+func TestGetChangesSince(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	stub.MockTransactionStart("tx2")
+	require.NoError(t, cc.TransferAsset(ctx, "asset1", "Jane", 0))
+	stub.MockTransactionStart("tx3")
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "red", 6, "John", 200))
+	stub.MockTransactionStart("tx4")
+	require.NoError(t, cc.DeleteAsset(ctx, "asset2"))
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "admin", adminAttrs)
+	changes := map[string][]*ChangeRecord{}
+	position := ""
+	for {
+		page, err := qc.GetChangesSince(ctx, position, 1)
+		require.NoError(t, err)
+		require.Len(t, page.Changes, 1)
+		changes[page.Changes[0].AssetID] = append(changes[page.Changes[0].AssetID], page.Changes[0])
+		position = page.Position
+		if !page.HasMore {
+			break
+		}
+	}
+	require.Len(t, changes["asset1"], 2)
+	assert.Equal(t, "John", changes["asset1"][0].Asset.Owner)
+	assert.Equal(t, "Jane", changes["asset1"][1].Asset.Owner)
+	assert.Equal(t, "tx2", changes["asset1"][1].TxID)
+	require.Len(t, changes["asset2"], 2)
+	assert.Equal(t, changeOperationPut, changes["asset2"][0].Operation)
+	assert.Equal(t, changeOperationDelete, changes["asset2"][1].Operation)
+	assert.Nil(t, changes["asset2"][1].Asset)
+
+	page, err := qc.GetChangesSince(ctx, position, 10)
+	require.NoError(t, err)
+	assert.Empty(t, page.Changes)
+	assert.Equal(t, position, page.Position)
+
+	_, err = qc.GetChangesSince(ctx, "", 0)
+	assert.Error(t, err)
+	_, err = qc.GetChangesSince(ctx, "1,2", 10)
+	assert.Error(t, err)
+}
+
+// TestChangesOfOneTransaction tests that a transaction writing several assets of a partition
+// keeps every record when it cannot read its own writes, like on a peer
+func TestChangesOfOneTransaction(t *testing.T) {
+	ctx, stub := newIsolatedContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	partition := outboxPartition("asset0")
+	sibling := ""
+	for i := 1; sibling == ""; i++ {
+		if id := fmt.Sprintf("asset%d", i); outboxPartition(id) == partition {
+			sibling = id
+		}
+	}
+
+	_, err := cc.CreateAssets(ctx, fmt.Sprintf(`[{"ID":"asset0","color":"blue","owner":"John"},{"ID":"%s","color":"red","owner":"John"},{"ID":"other","color":"red","owner":"John"}]`, sibling))
+	require.NoError(t, err)
+	stub.commit(t, "tx2")
+	require.NoError(t, cc.TransferAsset(ctx, "asset0", "Jane", 0))
+	stub.commit(t, "tx3")
+
+	switchIdentity(t, ctx, stub.MockStub, "Org1MSP", "admin", adminAttrs)
+	page, err := qc.GetChangesSince(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Changes, 4)
+	var batches []uint64
+	for _, change := range page.Changes {
+		if change.Partition == partition {
+			batches = append(batches, change.Seq)
+		}
+	}
+	assert.Equal(t, []uint64{1, 1, 2}, batches)
+
+	// A page never splits the changes of one transaction
+	page, err = qc.GetChangesSince(ctx, positionAt(partition, 0), 1)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(page.Changes), 2)
+	assert.True(t, page.HasMore)
+}
+
+// TestChangeFeedAccess tests that only identities reading every record get the feed, presented
+// like ReadAsset and without assets of other regions
+func TestChangeFeedAccess(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	config := &ConfigContract{}
+	require.NoError(t, config.SetMSPRegion(ctx, "Org1MSP", "EU"))
+	require.NoError(t, config.SetMSPRegion(ctx, "Org2MSP", "US"))
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	switchIdentity(t, ctx, stub, "Org2MSP", "admin", adminAttrs)
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "red", 6, "Jane", 200))
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "user2", nil)
+	_, err := qc.GetChangesSince(ctx, "", 10)
+	assert.ErrorContains(t, err, ErrCodeAccessDenied)
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "auditor2", map[string]string{"role": "auditor"})
+	page, err := qc.GetChangesSince(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Changes, 1)
+	assert.Equal(t, "asset2", page.Changes[0].AssetID)
+	assert.Equal(t, redactedValue, page.Changes[0].Asset.Owner)
+	assert.Zero(t, page.Changes[0].Asset.AppraisedValue)
+	assert.False(t, page.HasMore)
+
+	stored, err := getAsset(ctx, "asset2")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", stored.Owner)
+}
+
+// TestGetChangesSinceAcrossBuckets tests that reads continue into the next outbox bucket
+func TestGetChangesSinceAcrossBuckets(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	qc := &QueryContract{}
+	for i := 0; i < outboxBucketSize+5; i++ {
+		stub.MockTransactionStart(fmt.Sprintf("tx%d", i))
+		require.NoError(t, appendChange(ctx, changeOperationPut, "asset1", nil))
+	}
+	partition := outboxPartition("asset1")
+
+	page, err := qc.GetChangesSince(ctx, positionAt(partition, outboxBucketSize-2), 4)
+	require.NoError(t, err)
+	require.Len(t, page.Changes, 4)
+	assert.Equal(t, uint64(outboxBucketSize-1), page.Changes[0].Seq)
+	assert.Equal(t, positionAt(partition, outboxBucketSize+2), page.Position)
+	assert.True(t, page.HasMore)
+}
+
+// TestPruneChanges tests that pruning stops at the slowest consumer and pruned ranges are rejected
+func TestPruneChanges(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	qc := &QueryContract{}
+	admin := &AdminContract{}
+	for i := 0; i < 6; i++ {
+		stub.MockTransactionStart(fmt.Sprintf("tx%d", i))
+		require.NoError(t, appendChange(ctx, changeOperationPut, "asset1", nil))
+	}
+	partition := outboxPartition("asset1")
+
+	result, err := admin.PruneChanges(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Pruned)
+
+	require.NoError(t, admin.AcknowledgeChanges(ctx, "warehouse", positionAt(partition, 5)))
+	require.NoError(t, admin.AcknowledgeChanges(ctx, "search", positionAt(partition, 3)))
+	assert.Error(t, admin.AcknowledgeChanges(ctx, "search", positionAt(partition, 2)))
+	assert.Error(t, admin.AcknowledgeChanges(ctx, "search", positionAt(partition, 7)))
+	assert.Error(t, admin.AcknowledgeChanges(ctx, "search", "3"))
+
+	result, err = admin.PruneChanges(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, PruneResult{Pruned: 2, PrunedThrough: positionAt(partition, 2), HasMore: true}, *result)
+	result, err = admin.PruneChanges(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, PruneResult{Pruned: 1, PrunedThrough: positionAt(partition, 3), HasMore: false}, *result)
+
+	_, err = qc.GetChangesSince(ctx, positionAt(partition, 1), 10)
+	assert.True(t, hasErrorCode(err, ErrCodeChangesPruned))
+	page, err := qc.GetChangesSince(ctx, positionAt(partition, 3), 10)
+	require.NoError(t, err)
+	require.Len(t, page.Changes, 3)
+	assert.Equal(t, uint64(4), page.Changes[0].Seq)
+
+	require.NoError(t, admin.RemoveOutboxConsumer(ctx, "search"))
+	result, err = admin.PruneChanges(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, positionAt(partition, 5), result.PrunedThrough)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "user1", nil)
+	assert.Error(t, admin.AcknowledgeChanges(ctx, "warehouse", positionAt(partition, 6)))
+}
@@ -0,0 +1,78 @@
+package chaincode
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxOwnershipClaims caps the claims of one VerifyOwnership call
+const maxOwnershipClaims = 500
+
+// OwnershipClaim is a partner's assertion that an asset belongs to an owner
+type OwnershipClaim struct {
+	AssetID      string `json:"assetId"`
+	ClaimedOwner string `json:"claimedOwner"`
+}
+
+// OwnershipVerdict answers one OwnershipClaim. Version, UpdatedAt and Checksum identify the
+// asset record the verdict was taken against, so a partner can later show what it relied on.
+type OwnershipVerdict struct {
+	AssetID      string    `json:"assetId"`
+	ClaimedOwner string    `json:"claimedOwner"`
+	Owned        bool      `json:"owned"`
+	Exists       bool      `json:"exists"`
+	Version      int       `json:"version,omitempty" metadata:",optional"`
+	UpdatedAt    time.Time `json:"updatedAt,omitzero" metadata:",optional"`
+	Checksum     string    `json:"checksum,omitempty" metadata:",optional"`
+	TxID         string    `json:"txId"`
+}
+
+// VerifyOwnership checks many asset/owner claims in one evaluation, in request order. Assets that
+// do not exist or that the caller may not read are reported with Exists false and never owned.
+func (q *QueryContract) VerifyOwnership(ctx contractapi.TransactionContextInterface, claims []OwnershipClaim) ([]*OwnershipVerdict, error) {
+	q.logger(ctx).Info().Str("function", "VerifyOwnership").Int("claims", len(claims)).Msg("Verifying ownership claims")
+
+	if len(claims) == 0 || len(claims) > maxOwnershipClaims {
+		return nil, fmt.Errorf("between 1 and %d claims must be given", maxOwnershipClaims)
+	}
+	presenter, err := q.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	verdicts := make([]*OwnershipVerdict, 0, len(claims))
+	owned := 0
+	for _, claim := range claims {
+		verdict := &OwnershipVerdict{AssetID: claim.AssetID, ClaimedOwner: claim.ClaimedOwner, TxID: txID}
+		verdicts = append(verdicts, verdict)
+
+		assetBytes, err := ctx.GetStub().GetState(assetKey(claim.AssetID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get asset %s: %v", claim.AssetID, err)
+		}
+		if assetBytes == nil {
+			continue
+		}
+		asset, err := decodeAsset(assetBytes)
+		if err != nil {
+			return nil, err
+		}
+		if asset.Deleted || !presenter.visible(asset) {
+			continue
+		}
+		verdict.Exists = true
+		verdict.Owned = claim.ClaimedOwner != "" && asset.Owner == claim.ClaimedOwner
+		verdict.Version = asset.Version
+		verdict.UpdatedAt = asset.UpdatedAt
+		verdict.Checksum = asset.Checksum
+		if verdict.Owned {
+			owned++
+		}
+	}
+
+	q.logger(ctx).Info().Int("claims", len(claims)).Int("owned", owned).Msg("Ownership claims verified")
+	return verdicts, nil
+}
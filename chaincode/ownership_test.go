@@ -0,0 +1,49 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVerifyOwnership tests that claims are answered in order with the record they were checked against
+func TestVerifyOwnership(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "red", 5, "Jane", 200))
+
+	verdicts, err := qc.VerifyOwnership(ctx, []OwnershipClaim{
+		{AssetID: "asset2", ClaimedOwner: "Jane"},
+		{AssetID: "asset1", ClaimedOwner: "Jane"},
+		{AssetID: "nope", ClaimedOwner: "John"},
+	})
+	require.NoError(t, err)
+	require.Len(t, verdicts, 3)
+
+	assert.True(t, verdicts[0].Owned)
+	assert.True(t, verdicts[0].Exists)
+	assert.NotEmpty(t, verdicts[0].Checksum)
+	assert.Equal(t, stub.TxID, verdicts[0].TxID)
+	asset2, err := qc.ReadAsset(ctx, "asset2")
+	require.NoError(t, err)
+	assert.Equal(t, asset2.Version, verdicts[0].Version)
+
+	assert.False(t, verdicts[1].Owned)
+	assert.True(t, verdicts[1].Exists)
+	assert.False(t, verdicts[2].Owned)
+	assert.False(t, verdicts[2].Exists)
+	assert.Zero(t, verdicts[2].Version)
+}
+
+// TestVerifyOwnershipRejectsInvalidBatch tests the bounds on the number of claims
+func TestVerifyOwnershipRejectsInvalidBatch(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	qc := &QueryContract{}
+	_, err := qc.VerifyOwnership(ctx, nil)
+	assert.Error(t, err)
+	_, err = qc.VerifyOwnership(ctx, make([]OwnershipClaim, maxOwnershipClaims+1))
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package chaincode
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// pathSeparator separates the segments of path-style asset IDs, e.g. org/site/line/serial
+	pathSeparator = "/"
+	// maxSubtreeMove caps the number of assets MoveAssetSubtree rewrites in one transaction
+	maxSubtreeMove = 500
+)
+
+// MoveResult maps the old IDs of moved assets to their new IDs
+type MoveResult struct {
+	Moved map[string]string `json:"moved"`
+}
+
+// GetAssetsByPath returns the assets whose path-style ID lies under path, e.g. "org1/site2"
+// matches "org1/site2/line1/serial9" but not "org1/site20/line1/serial1".
+func (q *QueryContract) GetAssetsByPath(ctx contractapi.TransactionContextInterface, path string, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+	q.logger(ctx).Info().Str("function", "GetAssetsByPath").Str("path", path).Int("pageSize", pageSize).Msg("Querying assets by path")
+
+	path, err := normalizePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return q.GetAssetsByIDPrefix(ctx, path+pathSeparator, pageSize, bookmark)
+}
+
+// MoveAssetSubtree re-keys every asset under fromPath to the same relative position under toPath,
+// e.g. moving "org1/site1" to "org1/site2" turns "org1/site1/line1/a" into "org1/site2/line1/a".
+// Indexes are moved with the assets. The move fails as a whole if any asset is frozen or a
+// target ID is taken.
+func (t *AssetContract) MoveAssetSubtree(ctx contractapi.TransactionContextInterface, fromPath, toPath string) (*MoveResult, error) {
+	t.logger(ctx).Info().Str("function", "MoveAssetSubtree").Str("fromPath", fromPath).Str("toPath", toPath).Msg("Moving asset subtree")
+
+	if _, err := assertOrgCanWrite(ctx); err != nil {
+		return nil, err
+	}
+	fromPath, err := normalizePath(fromPath)
+	if err != nil {
+		return nil, err
+	}
+	toPath, err = normalizePath(toPath)
+	if err != nil {
+		return nil, err
+	}
+	if fromPath == toPath || strings.HasPrefix(toPath+pathSeparator, fromPath+pathSeparator) {
+		return nil, fmt.Errorf("cannot move %s into itself", fromPath)
+	}
+
+	prefix := fromPath + pathSeparator
+	iterator, err := ctx.GetStub().GetStateByRange(assetKey(prefix), prefixRangeEnd(assetKey(prefix)))
+	if err != nil {
+		t.logger(ctx).Error().Err(err).Str("fromPath", fromPath).Msg("Failed to scan subtree")
+		return nil, fmt.Errorf("failed to scan %s: %v", fromPath, err)
+	}
+	defer iterator.Close()
+
+	assetIDs := []string{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if len(assetIDs) == maxSubtreeMove {
+			return nil, fmt.Errorf("subtree %s holds more than %d assets, move smaller subtrees", fromPath, maxSubtreeMove)
+		}
+		assetIDs = append(assetIDs, assetIDFromKey(response.Key))
+	}
+
+	result := &MoveResult{Moved: map[string]string{}}
+	for _, assetID := range assetIDs {
+		newID := toPath + pathSeparator + strings.TrimPrefix(assetID, prefix)
+		if err := t.renameAsset(ctx, assetID, newID); err != nil {
+			return nil, err
+		}
+		result.Moved[assetID] = newID
+	}
+
+	if err := t.emitAssetEvent(ctx, EventAssetMoved, assetIDs...); err != nil {
+		return nil, err
+	}
+
+	t.logger(ctx).Info().Str("fromPath", fromPath).Str("toPath", toPath).Int("moved", len(result.Moved)).Msg("Asset subtree moved")
+	return result, nil
+}
+
+// renameAsset stores an asset under a new ID and moves its index entries and endorsement policy
+func (t *AssetContract) renameAsset(ctx contractapi.TransactionContextInterface, assetID, newID string) error {
+	asset, err := getAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	if asset.Frozen {
+		return fmt.Errorf("asset %s is frozen", assetID)
+	}
+	if err := checkIDRange(ctx, asset.OwnerMSP, newID); err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(assetKey(newID))
+	if err != nil {
+		return fmt.Errorf("failed to read asset %s: %v", newID, err)
+	}
+	if existing != nil {
+		return fmt.Errorf("asset already exists: %s", newID)
+	}
+
+	// Deleting the key drops its endorsement policy, so it is carried over like moveAssetKey does
+	policy, err := ctx.GetStub().GetStateValidationParameter(assetKey(assetID))
+	if err != nil {
+		return fmt.Errorf("failed to read endorsement policy of %s: %v", assetID, err)
+	}
+
+	if err := t.removeAsset(ctx, assetID); err != nil {
+		return err
+	}
+	asset.ID = newID
+	if err := t.saveAsset(ctx, asset); err != nil {
+		return err
+	}
+	if policy != nil {
+		if err := ctx.GetStub().SetStateValidationParameter(assetKey(newID), policy); err != nil {
+			return fmt.Errorf("failed to move endorsement policy of %s: %v", assetID, err)
+		}
+	}
+	return nil
+}
+
+// normalizePath trims surrounding separators and rejects empty segments
+func normalizePath(path string) (string, error) {
+	path = strings.Trim(path, pathSeparator)
+	if path == "" {
+		return "", fmt.Errorf("path must not be empty")
+	}
+	for _, segment := range strings.Split(path, pathSeparator) {
+		if segment == "" {
+			return "", fmt.Errorf("path %s contains an empty segment", path)
+		}
+	}
+	return path, nil
+}
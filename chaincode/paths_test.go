@@ -0,0 +1,58 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMoveAssetSubtree tests that a subtree is re-keyed with its indexes and siblings stay put
+func TestMoveAssetSubtree(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	for _, id := range []string{"org1/site1/line1/a", "org1/site1/line2/b", "org1/site10/line1/c"} {
+		require.NoError(t, cc.CreateAsset(ctx, id, "blue", 5, "John", 100))
+	}
+	require.NoError(t, stub.SetStateValidationParameter(assetKey("org1/site1/line1/a"), []byte("policy")))
+
+	result, err := cc.MoveAssetSubtree(ctx, "/org1/site1/", "org1/site2")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"org1/site1/line1/a": "org1/site2/line1/a",
+		"org1/site1/line2/b": "org1/site2/line2/b",
+	}, result.Moved)
+
+	exists, err := qc.AssetExists(ctx, "org1/site1/line1/a")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	asset, err := qc.ReadAsset(ctx, "org1/site2/line1/a")
+	require.NoError(t, err)
+	assert.Equal(t, "org1/site2/line1/a", asset.ID)
+	policy, err := stub.GetStateValidationParameter(assetKey("org1/site2/line1/a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("policy"), policy)
+	policy, err = stub.GetStateValidationParameter(assetKey("org1/site2/line2/b"))
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+	exists, err = qc.AssetExists(ctx, "org1/site10/line1/c")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	oldIndexKey, _ := stub.CreateCompositeKey(index, []string{"blue", "org1/site1/line1/a"})
+	newIndexKey, _ := stub.CreateCompositeKey(index, []string{"blue", "org1/site2/line1/a"})
+	assert.NotContains(t, stub.State, oldIndexKey)
+	assert.Contains(t, stub.State, newIndexKey)
+	newMSPKey, _ := stub.CreateCompositeKey(mspAssetIndex, []string{"Org1MSP", "org1/site2/line1/a"})
+	assert.Contains(t, stub.State, newMSPKey)
+
+	_, err = cc.MoveAssetSubtree(ctx, "org1", "org1/site3")
+	assert.Error(t, err)
+	_, err = cc.MoveAssetSubtree(ctx, "org1//site1", "org2")
+	assert.Error(t, err)
+
+	require.NoError(t, cc.CreateAsset(ctx, "org1/site3/line1/a", "red", 5, "John", 100))
+	_, err = cc.MoveAssetSubtree(ctx, "org1/site3", "org1/site2")
+	assert.Error(t, err)
+}
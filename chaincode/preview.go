@@ -0,0 +1,131 @@
+package chaincode
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+// StateWrite is a single entry of a previewed write set
+type StateWrite struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	IsDelete bool   `json:"isDelete"`
+}
+
+// WriteSetPreview lists the state changes a transaction would make if it were submitted
+type WriteSetPreview struct {
+	Function string       `json:"function"`
+	Writes   []StateWrite `json:"writes"`
+	Event    string       `json:"event,omitempty" metadata:",optional"`
+}
+
+// PreviewTransfer returns the keys and serialized values TransferAsset would write, without
+// writing them. Use it for client-side diffs and four-eyes approval before submission.
+func (t *AssetContract) PreviewTransfer(ctx contractapi.TransactionContextInterface, assetID, newOwner string) (*WriteSetPreview, error) {
+	t.logger(ctx).Info().Str("function", "PreviewTransfer").Str("assetID", assetID).Str("newOwner", newOwner).Msg("Previewing asset transfer")
+
+	return t.previewWrites(ctx, "TransferAsset", func(previewCtx contractapi.TransactionContextInterface) error {
+		return t.TransferAsset(previewCtx, assetID, newOwner, 0)
+	})
+}
+
+// PreviewUpdate returns the keys and serialized values an update of all mutable asset fields
+// would write, including the color index changes, without writing them.
+func (t *AssetContract) PreviewUpdate(ctx contractapi.TransactionContextInterface, assetID, color string, size int, owner string, appraisedValue int) (*WriteSetPreview, error) {
+	t.logger(ctx).Info().Str("function", "PreviewUpdate").Str("assetID", assetID).Msg("Previewing asset update")
+
+	return t.previewWrites(ctx, "UpdateAsset", func(previewCtx contractapi.TransactionContextInterface) error {
+		return t.UpdateAsset(previewCtx, assetID, color, size, owner, appraisedValue, 0)
+	})
+}
+
+// previewWrites runs fn against a stub that records writes instead of applying them. Values are
+// the exact bytes that would be stored; restricted roles get asset values masked as JSON and only
+// the asset writes, since index keys and change records carry the unmasked owner.
+func (t *AssetContract) previewWrites(ctx contractapi.TransactionContextInterface, function string, fn func(contractapi.TransactionContextInterface) error) (*WriteSetPreview, error) {
+	presenter, err := t.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recorder := &recordingStub{ChaincodeStubInterface: ctx.GetStub()}
+	previewCtx := &contractapi.TransactionContext{}
+	previewCtx.SetStub(recorder)
+	previewCtx.SetClientIdentity(ctx.GetClientIdentity())
+
+	if err := fn(previewCtx); err != nil {
+		log.Warn().Err(err).Str("target", function).Msg("Previewed transaction would fail")
+		return nil, err
+	}
+
+	preview := &WriteSetPreview{Function: function, Writes: []StateWrite{}, Event: recorder.event}
+	for _, key := range recorder.order {
+		write := recorder.writes[key]
+		if asset := previewedAsset(write); asset != nil {
+			if !presenter.visible(asset) {
+				continue
+			}
+			if presenter.redact {
+				assetBytes, err := json.Marshal(presenter.mask(asset))
+				if err != nil {
+					return nil, err
+				}
+				write.Value = string(assetBytes)
+			}
+		} else if presenter.redact {
+			continue
+		}
+		preview.Writes = append(preview.Writes, write)
+	}
+
+	log.Info().Str("target", function).Int("writeCount", len(preview.Writes)).Msg("Write set preview completed")
+	return preview, nil
+}
+
+// previewedAsset returns the asset a write stores, nil for other writes
+func previewedAsset(write StateWrite) *Asset {
+	if write.IsDelete {
+		return nil
+	}
+	asset, err := decodeAsset([]byte(write.Value))
+	if err != nil || asset.DocType != AssetDocType || assetKey(asset.ID) != write.Key {
+		return nil
+	}
+	return asset
+}
+
+// recordingStub captures PutState/DelState/SetEvent calls instead of forwarding them.
+// Like the peer, the last write to a key wins and reads never observe pending writes.
+type recordingStub struct {
+	shim.ChaincodeStubInterface
+	writes map[string]StateWrite
+	order  []string
+	event  string
+}
+
+func (r *recordingStub) PutState(key string, value []byte) error {
+	r.record(StateWrite{Key: key, Value: string(value)})
+	return nil
+}
+
+func (r *recordingStub) DelState(key string) error {
+	r.record(StateWrite{Key: key, IsDelete: true})
+	return nil
+}
+
+func (r *recordingStub) SetEvent(name string, payload []byte) error {
+	r.event = name
+	return nil
+}
+
+func (r *recordingStub) record(write StateWrite) {
+	if r.writes == nil {
+		r.writes = map[string]StateWrite{}
+	}
+	if _, seen := r.writes[write.Key]; !seen {
+		r.order = append(r.order, write.Key)
+	}
+	r.writes[write.Key] = write
+}
@@ -0,0 +1,72 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreviewUpdate tests that previews list index moves and leave the ledger untouched
+func TestPreviewUpdate(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	before := len(stub.State)
+
+	preview, err := cc.PreviewUpdate(ctx, "asset1", "red", 6, "Jane", 200)
+	require.NoError(t, err)
+	assert.Equal(t, "UpdateAsset", preview.Function)
+	// color and owner index moves, asset, outbox record and outbox head
+	require.Len(t, preview.Writes, 7)
+
+	oldKey, _ := stub.CreateCompositeKey(index, []string{"blue", "asset1"})
+	newKey, _ := stub.CreateCompositeKey(index, []string{"red", "asset1"})
+	assert.Equal(t, StateWrite{Key: oldKey, IsDelete: true}, preview.Writes[0])
+	assert.Equal(t, newKey, preview.Writes[1].Key)
+	assert.Equal(t, "asset1", preview.Writes[4].Key)
+	assert.Contains(t, preview.Writes[4].Value, `"owner":"Jane"`)
+
+	asset, err := qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "blue", asset.Color)
+	assert.Len(t, stub.State, before)
+
+	// The preview holds the exact bytes the update stores, without computed fields
+	assert.NotContains(t, preview.Writes[4].Value, "valuationBand")
+	require.NoError(t, cc.UpdateAsset(ctx, "asset1", "red", 6, "Jane", 200, 0))
+	assert.Equal(t, string(stub.State[assetKey("asset1")]), preview.Writes[4].Value)
+}
+
+// TestPreviewTransfer tests that transfer previews surface the same errors as the transaction
+func TestPreviewTransfer(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+
+	preview, err := cc.PreviewTransfer(ctx, "asset1", "Jane")
+	require.NoError(t, err)
+	// owner index move, asset, outbox record and outbox head
+	require.Len(t, preview.Writes, 5)
+	assert.Contains(t, preview.Writes[2].Value, `"owner":"Jane"`)
+
+	_, err = cc.PreviewTransfer(ctx, "missing", "Jane")
+	assert.Error(t, err)
+}
+
+// TestPreviewIsMaskedForAuditors tests that auditors only see the masked asset of a preview
+func TestPreviewIsMaskedForAuditors(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "auditor1", map[string]string{"role": "auditor"})
+	preview, err := cc.PreviewTransfer(ctx, "asset1", "Jane")
+	require.NoError(t, err)
+	require.Len(t, preview.Writes, 1)
+	assert.Equal(t, "asset1", preview.Writes[0].Key)
+	assert.Contains(t, preview.Writes[0].Value, `"owner":"`+redactedValue+`"`)
+	assert.Contains(t, preview.Writes[0].Value, `"appraisedValue":0`)
+	assert.NotContains(t, preview.Writes[0].Value, "Jane")
+}
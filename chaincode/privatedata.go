@@ -0,0 +1,103 @@
+package chaincode
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DeletePrivateAsset deletes the private details of an asset from a collection of the caller's
+// organization, its implicit collection when collection is empty. The asset's owner or an admin
+// may delete them. Deleted details stay in the private data history of the peers until purged.
+func (t *AssetContract) DeletePrivateAsset(ctx contractapi.TransactionContextInterface, assetID, collection string) error {
+	t.logger(ctx).Info().Str("function", "DeletePrivateAsset").Str("assetID", assetID).Str("collection", collection).Msg("Deleting private asset details")
+
+	mspID, err := assertOrgCanWrite(ctx)
+	if err != nil {
+		return err
+	}
+	collection, err = privateAssetCollection(ctx, mspID, collection)
+	if err != nil {
+		return err
+	}
+	isAdmin, err := callerIsAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		if _, _, err := callerOwnedAsset(ctx, assetID); err != nil {
+			return err
+		}
+	}
+
+	key := assetKey(assetID)
+	hash, err := ctx.GetStub().GetPrivateDataHash(collection, key)
+	if err != nil {
+		return fmt.Errorf("failed to read private details of asset %s: %v", assetID, err)
+	}
+	if hash == nil {
+		return fmt.Errorf("asset %s has no private details in %s", assetID, collection)
+	}
+	if err := ctx.GetStub().DelPrivateData(collection, key); err != nil {
+		t.logger(ctx).Error().Err(err).Str("assetID", assetID).Str("collection", collection).Msg("Failed to delete private asset details")
+		return fmt.Errorf("failed to delete private details of asset %s: %v", assetID, err)
+	}
+	if err := recordAudit(ctx, "DeletePrivateAsset", assetID, collection); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("assetID", assetID).Str("collection", collection).Msg("Private asset details deleted")
+	return nil
+}
+
+// PurgePrivateAsset purges the private details of an asset from a collection of the caller's
+// organization, as DeletePrivateAsset resolves it, including their history, so no peer keeps
+// them, e.g. to honor an erasure request. Details already deleted can still be purged. Admins
+// only; peers must run Fabric 2.5 or later.
+func (t *AssetContract) PurgePrivateAsset(ctx contractapi.TransactionContextInterface, assetID, collection string) error {
+	t.logger(ctx).Info().Str("function", "PurgePrivateAsset").Str("assetID", assetID).Str("collection", collection).Msg("Purging private asset details")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	mspID, err := assertOrgCanWrite(ctx)
+	if err != nil {
+		return err
+	}
+	collection, err = privateAssetCollection(ctx, mspID, collection)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PurgePrivateData(collection, assetKey(assetID)); err != nil {
+		t.logger(ctx).Error().Err(err).Str("assetID", assetID).Str("collection", collection).Msg("Failed to purge private asset details")
+		return fmt.Errorf("failed to purge private details of asset %s: %v", assetID, err)
+	}
+	if err := recordAudit(ctx, "PurgePrivateAsset", assetID, collection); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("assetID", assetID).Str("collection", collection).Msg("Private asset details purged")
+	return nil
+}
+
+// privateAssetCollection resolves the collection a caller of mspID manages private details in:
+// its implicit collection when empty, never the implicit collection of another organization, and
+// otherwise one its registry record declares, if it declares any
+func privateAssetCollection(ctx contractapi.TransactionContextInterface, mspID, collection string) (string, error) {
+	if collection == "" || collection == implicitCollection(mspID) {
+		return implicitCollection(mspID), nil
+	}
+	if strings.HasPrefix(collection, implicitCollection("")) {
+		return "", denyAccess(ctx, "collection membership", "%s is the implicit collection of another organization", collection)
+	}
+	org, err := getOrganization(ctx, mspID)
+	if err != nil {
+		return "", err
+	}
+	if org != nil && len(org.Collections) > 0 && !slices.Contains(org.Collections, collection) {
+		return "", denyAccess(ctx, "collection membership", "organization %s does not declare collection %s", mspID, collection)
+	}
+	return collection, nil
+}
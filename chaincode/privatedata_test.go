@@ -0,0 +1,49 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeletePrivateAsset tests that only the owner or an admin deletes details from their collections
+func TestDeletePrivateAsset(t *testing.T) {
+	ctx, stub := newPrivateDataContext(t, "Org1MSP", "admin", adminAttrs)
+	ac := NewAssetContract()
+	require.NoError(t, (&AdminContract{}).RegisterOrg(ctx, "Org1MSP", []string{"member"}, []string{"tradeCollection"}))
+
+	switchIdentity(t, ctx, stub.MockStub, "Org1MSP", "alice", nil)
+	stub.TransientMap = map[string][]byte{TransientAssetProperties: []byte(`{"appraisedValue":700}`)}
+	require.NoError(t, ac.CreateAssetPrivate(ctx, "asset1", "blue", 5, "alice"))
+
+	assert.ErrorContains(t, ac.DeletePrivateAsset(ctx, "asset1", implicitCollection("Org2MSP")), ErrCodeAccessDenied)
+	assert.ErrorContains(t, ac.DeletePrivateAsset(ctx, "asset1", "otherCollection"), ErrCodeAccessDenied)
+	assert.ErrorContains(t, ac.DeletePrivateAsset(ctx, "asset1", "tradeCollection"), "no private details in tradeCollection")
+
+	switchIdentity(t, ctx, stub.MockStub, "Org1MSP", "mallory", nil)
+	assert.ErrorContains(t, ac.DeletePrivateAsset(ctx, "asset1", ""), ErrCodeAccessDenied)
+
+	switchIdentity(t, ctx, stub.MockStub, "Org1MSP", "alice", nil)
+	require.NoError(t, ac.DeletePrivateAsset(ctx, "asset1", ""))
+	assert.Empty(t, stub.PvtState[implicitCollection("Org1MSP")])
+	assert.ErrorContains(t, ac.DeletePrivateAsset(ctx, "asset1", ""), "no private details")
+}
+
+// TestPurgePrivateAsset tests that admins purge details from their organization's collections
+func TestPurgePrivateAsset(t *testing.T) {
+	ctx, stub := newPrivateDataContext(t, "Org1MSP", "alice", nil)
+	ac := NewAssetContract()
+	stub.TransientMap = map[string][]byte{TransientAssetProperties: []byte(`{"appraisedValue":700}`)}
+	require.NoError(t, ac.CreateAssetPrivate(ctx, "asset1", "blue", 5, "alice"))
+
+	assert.Error(t, ac.PurgePrivateAsset(ctx, "asset1", ""))
+
+	switchIdentity(t, ctx, stub.MockStub, "Org1MSP", "admin", adminAttrs)
+	assert.ErrorContains(t, ac.PurgePrivateAsset(ctx, "asset1", implicitCollection("Org2MSP")), ErrCodeAccessDenied)
+	require.NoError(t, ac.PurgePrivateAsset(ctx, "asset1", ""))
+	assert.Empty(t, stub.PvtState[implicitCollection("Org1MSP")])
+
+	_, err := NewQueryContract().ReadAssetPrivateDetails(ctx, "asset1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package chaincode
+
+//go:generate protoc --proto_path=../proto --go_out=.. --go_opt=module=github.com/chainlaunch/chaincode-fabric-go-tmpl asset/v1/asset.proto
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/chainlaunch/chaincode-fabric-go-tmpl/chaincode/assetpb"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"google.golang.org/protobuf/proto"
+)
+
+// CreateAssetProto creates an asset from a base64-encoded assetpb.CreateAssetRequest and sets
+// an AssetCreated event with a protobuf-encoded assetpb.AssetEvent payload
+func (t *AssetContract) CreateAssetProto(ctx contractapi.TransactionContextInterface, request string) error {
+	t.logger(ctx).Info().Str("function", "CreateAssetProto").Msg("Creating asset from protobuf request")
+
+	req := &assetpb.CreateAssetRequest{}
+	if err := decodeProtoArg(request, req); err != nil {
+		return err
+	}
+	if err := t.CreateAsset(ctx, req.GetId(), req.GetColor(), int(req.GetSize()), req.GetOwner(), int(req.GetAppraisedValue())); err != nil {
+		return err
+	}
+	asset, err := getAsset(ctx, req.GetId())
+	if err != nil {
+		return err
+	}
+	return t.emitProtoEvent(ctx, EventAssetCreated, &assetpb.AssetEvent{
+		Type:  assetpb.AssetEvent_TYPE_CREATED,
+		TxId:  ctx.GetStub().GetTxID(),
+		Asset: toProtoAsset(asset),
+	})
+}
+
+// TransferAssetProto transfers an asset from a base64-encoded assetpb.TransferAssetRequest and
+// sets an AssetTransferred event with a protobuf-encoded assetpb.AssetEvent payload
+func (t *AssetContract) TransferAssetProto(ctx contractapi.TransactionContextInterface, request string) error {
+	t.logger(ctx).Info().Str("function", "TransferAssetProto").Msg("Transferring asset from protobuf request")
+
+	req := &assetpb.TransferAssetRequest{}
+	if err := decodeProtoArg(request, req); err != nil {
+		return err
+	}
+	asset, err := getAsset(ctx, req.GetId())
+	if err != nil {
+		return err
+	}
+	previousOwner := asset.Owner
+	if err := t.TransferAsset(ctx, req.GetId(), req.GetNewOwner(), 0); err != nil {
+		return err
+	}
+	if err := setAssetOwner(ctx, asset, req.GetNewOwner()); err != nil {
+		return err
+	}
+	return t.emitProtoEvent(ctx, EventAssetTransferred, &assetpb.AssetEvent{
+		Type:          assetpb.AssetEvent_TYPE_TRANSFERRED,
+		TxId:          ctx.GetStub().GetTxID(),
+		Asset:         toProtoAsset(asset),
+		PreviousOwner: previousOwner,
+	})
+}
+
+// ReadAssetProto reads the asset named by a base64-encoded assetpb.ReadAssetRequest and returns
+// it as a base64-encoded assetpb.Asset, masked for the caller like ReadAsset
+func (q *QueryContract) ReadAssetProto(ctx contractapi.TransactionContextInterface, request string) (string, error) {
+	q.logger(ctx).Info().Str("function", "ReadAssetProto").Msg("Reading asset for protobuf request")
+
+	req := &assetpb.ReadAssetRequest{}
+	if err := decodeProtoArg(request, req); err != nil {
+		return "", err
+	}
+	asset, err := q.ReadAsset(ctx, req.GetId())
+	if err != nil {
+		return "", err
+	}
+	assetBytes, err := proto.Marshal(toProtoAsset(asset))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(assetBytes), nil
+}
+
+// decodeProtoArg decodes a base64-encoded protobuf argument into message
+func decodeProtoArg(arg string, message proto.Message) error {
+	argBytes, err := base64.StdEncoding.DecodeString(arg)
+	if err != nil {
+		return fmt.Errorf("argument is not valid base64: %v", err)
+	}
+	if err := proto.Unmarshal(argBytes, message); err != nil {
+		return fmt.Errorf("argument is not a valid %s: %v", message.ProtoReflect().Descriptor().FullName(), err)
+	}
+	return nil
+}
+
+// emitProtoEvent sets a chaincode event with a protobuf payload, unless events are disabled.
+// Protobuf events are never wrapped in CloudEvents envelopes, since consumers decode the
+// payload as the message directly.
+func (t *AssetContract) emitProtoEvent(ctx contractapi.TransactionContextInterface, name string, event *assetpb.AssetEvent) error {
+	if t.skipEvents {
+		return nil
+	}
+	payload, err := proto.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().SetEvent(name, payload); err != nil {
+		t.logger(ctx).Error().Err(err).Str("event", name).Msg("Failed to set protobuf event")
+		return err
+	}
+	return nil
+}
+
+func toProtoAsset(asset *Asset) *assetpb.Asset {
+	return &assetpb.Asset{
+		Id:             asset.ID,
+		Color:          asset.Color,
+		Size:           int64(asset.Size),
+		Owner:          asset.Owner,
+		AppraisedValue: int64(asset.AppraisedValue),
+		OwnerMsp:       asset.OwnerMSP,
+		Frozen:         asset.Frozen,
+		Residency:      asset.Residency,
+	}
+}
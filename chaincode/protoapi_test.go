@@ -0,0 +1,54 @@
+package chaincode
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/chainlaunch/chaincode-fabric-go-tmpl/chaincode/assetpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func encodeProtoArg(t *testing.T, message proto.Message) string {
+	t.Helper()
+	messageBytes, err := proto.Marshal(message)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(messageBytes)
+}
+
+// TestProtoAPI tests the protobuf function variants and their events
+func TestProtoAPI(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+
+	create := &assetpb.CreateAssetRequest{Id: "asset1", Color: "blue", Size: 5, Owner: "Tom", AppraisedValue: 100}
+	require.NoError(t, cc.CreateAssetProto(ctx, encodeProtoArg(t, create)))
+	event := lastEvent(t, stub)
+	assert.Equal(t, "AssetCreated", event.EventName)
+	created := &assetpb.AssetEvent{}
+	require.NoError(t, proto.Unmarshal(event.Payload, created))
+	assert.Equal(t, assetpb.AssetEvent_TYPE_CREATED, created.GetType())
+	assert.Equal(t, "Org1MSP", created.GetAsset().GetOwnerMsp())
+
+	transfer := &assetpb.TransferAssetRequest{Id: "asset1", NewOwner: "Ann"}
+	require.NoError(t, cc.TransferAssetProto(ctx, encodeProtoArg(t, transfer)))
+	event = lastEvent(t, stub)
+	transferred := &assetpb.AssetEvent{}
+	require.NoError(t, proto.Unmarshal(event.Payload, transferred))
+	assert.Equal(t, "Tom", transferred.GetPreviousOwner())
+	assert.Equal(t, "Ann", transferred.GetAsset().GetOwner())
+
+	response, err := qc.ReadAssetProto(ctx, encodeProtoArg(t, &assetpb.ReadAssetRequest{Id: "asset1"}))
+	require.NoError(t, err)
+	responseBytes, err := base64.StdEncoding.DecodeString(response)
+	require.NoError(t, err)
+	asset := &assetpb.Asset{}
+	require.NoError(t, proto.Unmarshal(responseBytes, asset))
+	assert.Equal(t, "Ann", asset.GetOwner())
+	assert.Equal(t, int64(100), asset.GetAppraisedValue())
+
+	assert.Error(t, cc.CreateAssetProto(ctx, "not base64!"))
+	assert.Error(t, cc.CreateAssetProto(ctx, base64.StdEncoding.EncodeToString([]byte{0xff, 0xff})))
+}
@@ -0,0 +1,72 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// queryBuilder assembles a CouchDB query from Go values. Every value is JSON-encoded when the
+// query is built, never spliced into a string, so caller input can only ever be a value and
+// cannot inject selector clauses or operators. Field names are constants of the chaincode.
+type queryBuilder struct {
+	selector map[string]interface{}
+	err      error
+}
+
+// newQueryBuilder returns a builder with an empty selector
+func newQueryBuilder() *queryBuilder {
+	return &queryBuilder{selector: map[string]interface{}{}}
+}
+
+// newAssetQuery returns a builder whose selector only matches asset documents
+func newAssetQuery() *queryBuilder {
+	return newQueryBuilder().equals("docType", AssetDocType)
+}
+
+// equals matches documents whose field is exactly value
+func (b *queryBuilder) equals(field string, value interface{}) *queryBuilder {
+	b.selector[field] = value
+	return b
+}
+
+// equalsIfSet adds equals for a non-empty value, leaving the field unconstrained otherwise
+func (b *queryBuilder) equalsIfSet(field, value string) *queryBuilder {
+	if value != "" {
+		b.equals(field, value)
+	}
+	return b
+}
+
+// between matches documents whose field lies within min and max, inclusive. Zero leaves that
+// bound open; an inverted range fails build.
+func (b *queryBuilder) between(field string, min, max int) *queryBuilder {
+	if min != 0 && max != 0 && min > max {
+		if b.err == nil {
+			b.err = fmt.Errorf("invalid %s range: min %d is greater than max %d", field, min, max)
+		}
+		return b
+	}
+	bounds := map[string]int{}
+	if min != 0 {
+		bounds["$gte"] = min
+	}
+	if max != 0 {
+		bounds["$lte"] = max
+	}
+	if len(bounds) > 0 {
+		b.selector[field] = bounds
+	}
+	return b
+}
+
+// build returns the query string, or the first error recorded while adding constraints
+func (b *queryBuilder) build() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+	queryBytes, err := json.Marshal(map[string]interface{}{"selector": b.selector})
+	if err != nil {
+		return "", err
+	}
+	return string(queryBytes), nil
+}
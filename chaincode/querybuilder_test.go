@@ -0,0 +1,32 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQueryBuilder tests that values are encoded as values and invalid ranges fail
+func TestQueryBuilder(t *testing.T) {
+	query, err := newAssetQuery().equals("owner", `Tom"},"docType":{"$gt":null`).equalsIfSet("color", "").between("size", 1, 0).build()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"selector":{"docType":"asset","owner":"Tom\"},\"docType\":{\"$gt\":null","size":{"$gte":1}}}`, query)
+
+	_, err = newQueryBuilder().between("size", 5, 1).between("appraisedValue", 9, 1).build()
+	assert.EqualError(t, err, "invalid size range: min 5 is greater than max 1")
+}
+
+// TestQueryAssetsByOwnerEncodesOwner tests that an owner cannot inject selector clauses
+func TestQueryAssetsByOwnerEncodesOwner(t *testing.T) {
+	_, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	tracking := &keyTrackingStub{ChaincodeStubInterface: stub, read: map[string]bool{}, written: map[string]bool{}}
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(tracking)
+
+	// The mock stub does not implement rich queries, so only the issued query is checked
+	_, _ = NewQueryContract(WithQueryScoping(false)).QueryAssetsByOwner(ctx, `x","docType":{"$ne":"asset`)
+	require.Len(t, tracking.queries, 1)
+	assert.Equal(t, `rich {"selector":{"docType":"asset","owner":"x\",\"docType\":{\"$ne\":\"asset"}}`, tracking.queries[0])
+}
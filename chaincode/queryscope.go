@@ -0,0 +1,64 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// scopeQuery narrows the selector of a rich query to the records the caller may read. Admins
+// and auditors query the whole ledger; everyone else only matches assets they own, however
+// the selector was written. Every rich query path runs through it, so functions do not
+// repeat the check.
+func (r *contractRuntime) scopeQuery(ctx contractapi.TransactionContextInterface, queryString string) (string, error) {
+	if r.skipQueryScoping {
+		return queryString, nil
+	}
+	unrestricted, err := callerReadsAll(ctx)
+	if err != nil || unrestricted {
+		return queryString, err
+	}
+	owner, err := getCallerEnrollmentID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var query map[string]interface{}
+	if err := json.Unmarshal([]byte(queryString), &query); err != nil {
+		return "", fmt.Errorf("invalid query string: %v", err)
+	}
+	selector, ok := query["selector"]
+	if !ok {
+		return "", fmt.Errorf("query string has no selector")
+	}
+	query["selector"] = map[string]interface{}{
+		"$and": []interface{}{selector, map[string]interface{}{"owner": owner}},
+	}
+	scoped, err := json.Marshal(query)
+	if err != nil {
+		return "", err
+	}
+	r.logger(ctx).Debug().Str("owner", owner).Str("queryString", string(scoped)).Msg("Scoped query to caller")
+	return string(scoped), nil
+}
+
+// callerReadsAll reports whether the caller may query every record: admins, including those
+// named by the genesis document, and auditors
+func callerReadsAll(ctx contractapi.TransactionContextInterface) (bool, error) {
+	for _, role := range []string{roleAdmin, roleAuditor} {
+		hasRole, err := callerHasRole(ctx, role)
+		if err != nil || hasRole {
+			return hasRole, err
+		}
+	}
+	return isGenesisAdmin(ctx)
+}
+
+// requireRawQueries refuses functions taking client-written selectors when raw queries are disabled
+func (r *contractRuntime) requireRawQueries(function string) error {
+	if r.skipRawQueries {
+		return fmt.Errorf("%s is disabled: raw CouchDB queries are turned off, use QueryAssetsByFilter or a saved query", function)
+	}
+	return nil
+}
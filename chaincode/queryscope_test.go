@@ -0,0 +1,38 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScopeQuery tests that rich query selectors are restricted to the caller's assets unless
+// the caller is an admin or auditor
+func TestScopeQuery(t *testing.T) {
+	query := `{"selector":{"docType":"asset","color":"blue"},"sort":[{"size":"asc"}]}`
+	ctx, stub := newTestContext(t, "Org1MSP", "alice", nil)
+	qc := &QueryContract{}
+
+	scoped, err := qc.scopeQuery(ctx, query)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"selector":{"$and":[{"docType":"asset","color":"blue"},{"owner":"alice"}]},"sort":[{"size":"asc"}]}`, scoped)
+
+	_, err = qc.scopeQuery(ctx, `{"use_index":"idx"}`)
+	assert.Error(t, err)
+
+	unscoped := NewQueryContract(WithQueryScoping(false))
+	scoped, err = unscoped.scopeQuery(ctx, query)
+	require.NoError(t, err)
+	assert.Equal(t, query, scoped)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "auditor1", map[string]string{"role": "auditor"})
+	scoped, err = qc.scopeQuery(ctx, query)
+	require.NoError(t, err)
+	assert.Equal(t, query, scoped)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "admin", adminAttrs)
+	scoped, err = qc.scopeQuery(ctx, query)
+	require.NoError(t, err)
+	assert.Equal(t, query, scoped)
+}
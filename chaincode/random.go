@@ -0,0 +1,81 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// txRandom is a deterministic pseudo-random source seeded from the transaction ID.
+// Every endorsing peer derives the same sequence for the same transaction, unlike math/rand
+// or crypto/rand, which produce diverging write sets and failed endorsements.
+// It is not suitable for secrets: the transaction ID is known to the submitting client.
+type txRandom struct {
+	seed    [sha256.Size]byte
+	counter uint64
+}
+
+// newTxRandom returns a deterministic source for the current transaction. The label separates
+// independent streams within one transaction, e.g. "auditor" and "sample".
+func newTxRandom(ctx contractapi.TransactionContextInterface, label string) (*txRandom, error) {
+	txID := ctx.GetStub().GetTxID()
+	if txID == "" {
+		return nil, fmt.Errorf("transaction ID is not available")
+	}
+	return newSeededRandom(ctx.GetStub().GetChannelID() + "\x00" + txID + "\x00" + label), nil
+}
+
+// newSeededRandom returns a deterministic source for an arbitrary seed
+func newSeededRandom(seed string) *txRandom {
+	return &txRandom{seed: sha256.Sum256([]byte(seed))}
+}
+
+// Uint64 returns the next value of the sequence
+func (r *txRandom) Uint64() uint64 {
+	var block [sha256.Size + 8]byte
+	copy(block[:], r.seed[:])
+	binary.BigEndian.PutUint64(block[sha256.Size:], r.counter)
+	r.counter++
+
+	sum := sha256.Sum256(block[:])
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// Intn returns a uniformly distributed value in [0, n). It panics if n <= 0.
+func (r *txRandom) Intn(n int) int {
+	if n <= 0 {
+		panic("txRandom: invalid argument to Intn")
+	}
+	// Reject values from the incomplete last bucket to avoid modulo bias
+	bound := uint64(n)
+	limit := ^uint64(0) - (^uint64(0) % bound)
+	for {
+		v := r.Uint64()
+		if v < limit {
+			return int(v % bound)
+		}
+	}
+}
+
+// Shuffle pseudo-randomizes the order of n elements using a Fisher-Yates shuffle
+func (r *txRandom) Shuffle(n int, swap func(i, j int)) {
+	for i := n - 1; i > 0; i-- {
+		swap(i, r.Intn(i+1))
+	}
+}
+
+// Sample returns k distinct elements of items chosen deterministically, e.g. for auditor
+// assignment, or all of them in random order when k exceeds their number
+func (r *txRandom) Sample(items []string, k int) ([]string, error) {
+	if k < 0 {
+		return nil, fmt.Errorf("sample size must not be negative, got %d", k)
+	}
+	if k > len(items) {
+		k = len(items)
+	}
+	shuffled := append([]string(nil), items...)
+	r.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	return shuffled[:k], nil
+}
@@ -0,0 +1,62 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTxRandomIsDeterministicAcrossPeers tests that two peers simulating the same
+// transaction derive identical values
+func TestTxRandomIsDeterministicAcrossPeers(t *testing.T) {
+	peer1, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	peer2, _ := newTestContext(t, "Org1MSP", "user1", nil)
+
+	r1, err := newTxRandom(peer1, "auditor")
+	require.NoError(t, err)
+	r2, err := newTxRandom(peer2, "auditor")
+	require.NoError(t, err)
+
+	auditors := []string{"Org1MSP", "Org2MSP", "Org3MSP", "Org4MSP", "Org5MSP"}
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, r1.Uint64(), r2.Uint64())
+	}
+	sample1, err := r1.Sample(auditors, 2)
+	require.NoError(t, err)
+	sample2, err := r2.Sample(auditors, 2)
+	require.NoError(t, err)
+	assert.Equal(t, sample1, sample2)
+}
+
+// TestTxRandomStreamsDiffer tests that labels and transaction IDs give independent streams
+func TestTxRandomStreamsDiffer(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+
+	auditor, err := newTxRandom(ctx, "auditor")
+	require.NoError(t, err)
+	sample, err := newTxRandom(ctx, "sample")
+	require.NoError(t, err)
+	assert.NotEqual(t, auditor.Uint64(), sample.Uint64())
+
+	first, _ := newTxRandom(ctx, "auditor")
+	stub.MockTransactionStart("tx2")
+	second, _ := newTxRandom(ctx, "auditor")
+	assert.NotEqual(t, first.Uint64(), second.Uint64())
+}
+
+// TestTxRandomIntn tests the range of Intn and the distinctness of samples
+func TestTxRandomIntn(t *testing.T) {
+	r := newSeededRandom("seed")
+	for i := 0; i < 1000; i++ {
+		v := r.Intn(7)
+		assert.True(t, v >= 0 && v < 7)
+	}
+
+	picked, err := r.Sample([]string{"a", "b", "c"}, 5)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, picked)
+
+	_, err = r.Sample([]string{"a", "b", "c"}, -1)
+	assert.ErrorContains(t, err, "must not be negative")
+}
@@ -0,0 +1,195 @@
+package chaincode
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// digestIndex keys the collection digests posted by each organization per period
+const digestIndex = "digest~collection~period~msp"
+
+// CollectionDigest is an organization's digest of its records in a private data collection at
+// the end of a period, e.g. "2026-10-17". The digest is computed off-chain by the organization,
+// since only its own peers hold the records.
+type CollectionDigest struct {
+	Collection  string    `json:"collection"`
+	Period      string    `json:"period"`
+	MSPID       string    `json:"mspId"`
+	Digest      string    `json:"digest"`
+	RecordCount int       `json:"recordCount"`
+	PostedBy    string    `json:"postedBy"`
+	PostedAt    time.Time `json:"postedAt"`
+	TxID        string    `json:"txId"`
+}
+
+// DigestMismatch flags two organizations whose digests of a collection disagree
+type DigestMismatch struct {
+	MSPID            string `json:"mspId"`
+	OtherMSPID       string `json:"otherMspId"`
+	Digest           string `json:"digest"`
+	OtherDigest      string `json:"otherDigest"`
+	RecordCount      int    `json:"recordCount"`
+	OtherRecordCount int    `json:"otherRecordCount"`
+}
+
+// DigestComparison is the result of CompareDigests. Missing lists the active registered
+// organizations that declare the collection but have not posted a digest for the period.
+type DigestComparison struct {
+	Collection string              `json:"collection"`
+	Period     string              `json:"period"`
+	Consistent bool                `json:"consistent"`
+	Digests    []*CollectionDigest `json:"digests"`
+	Mismatches []*DigestMismatch   `json:"mismatches"`
+	Missing    []string            `json:"missing"`
+}
+
+// PostCollectionDigest records the caller organization's SHA-256 digest (hex) of its records in
+// a private data collection for a period. Posting again for the same period replaces the digest.
+// Registered organizations that declare collections may only post digests of those.
+func (t *AssetContract) PostCollectionDigest(ctx contractapi.TransactionContextInterface, collection, period, digest string, recordCount int) (*CollectionDigest, error) {
+	t.logger(ctx).Info().Str("function", "PostCollectionDigest").Str("collection", collection).Str("period", period).Int("recordCount", recordCount).Msg("Posting collection digest")
+
+	mspID, err := assertOrgCanWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if collection == "" || period == "" {
+		return nil, fmt.Errorf("collection and period must not be empty")
+	}
+	if decoded, err := hex.DecodeString(digest); err != nil || len(decoded) != 32 {
+		return nil, fmt.Errorf("digest must be a hex-encoded SHA-256 hash")
+	}
+	if recordCount < 0 {
+		return nil, fmt.Errorf("recordCount must not be negative")
+	}
+	org, err := getOrganization(ctx, mspID)
+	if err != nil {
+		return nil, err
+	}
+	if org != nil && len(org.Collections) > 0 && !slices.Contains(org.Collections, collection) {
+		return nil, denyAccess(ctx, "collection membership", "organization %s does not declare collection %s", mspID, collection)
+	}
+	_, poster, err := getCallerSubmitter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := t.now(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &CollectionDigest{
+		Collection:  collection,
+		Period:      period,
+		MSPID:       mspID,
+		Digest:      digest,
+		RecordCount: recordCount,
+		PostedBy:    poster,
+		PostedAt:    now,
+		TxID:        ctx.GetStub().GetTxID(),
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(digestIndex, []string{collection, period, mspID})
+	if err != nil {
+		return nil, err
+	}
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(key, recordBytes); err != nil {
+		return nil, fmt.Errorf("failed to store collection digest: %v", err)
+	}
+	return record, nil
+}
+
+// CompareDigests compares the digests the organizations posted for a collection and period and
+// flags every pair that disagrees on the digest or the record count. The collection is
+// consistent once at least two organizations posted and none of them disagree.
+func (q *QueryContract) CompareDigests(ctx contractapi.TransactionContextInterface, collection, period string) (*DigestComparison, error) {
+	q.logger(ctx).Info().Str("function", "CompareDigests").Str("collection", collection).Str("period", period).Msg("Comparing collection digests")
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(digestIndex, []string{collection, period})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read digests of %s: %v", collection, err)
+	}
+	defer iterator.Close()
+
+	comparison := &DigestComparison{Collection: collection, Period: period, Digests: []*CollectionDigest{}, Mismatches: []*DigestMismatch{}, Missing: []string{}}
+	posted := map[string]bool{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var digest CollectionDigest
+		if err := json.Unmarshal(response.Value, &digest); err != nil {
+			return nil, err
+		}
+		comparison.Digests = append(comparison.Digests, &digest)
+		posted[digest.MSPID] = true
+	}
+
+	// Digests come in MSP ID order, so each pair is reported once with the lower MSP ID first
+	for i, digest := range comparison.Digests {
+		for _, other := range comparison.Digests[i+1:] {
+			if digest.Digest == other.Digest && digest.RecordCount == other.RecordCount {
+				continue
+			}
+			comparison.Mismatches = append(comparison.Mismatches, &DigestMismatch{
+				MSPID:            digest.MSPID,
+				OtherMSPID:       other.MSPID,
+				Digest:           digest.Digest,
+				OtherDigest:      other.Digest,
+				RecordCount:      digest.RecordCount,
+				OtherRecordCount: other.RecordCount,
+			})
+		}
+	}
+
+	members, err := collectionMembers(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	for _, mspID := range members {
+		if !posted[mspID] {
+			comparison.Missing = append(comparison.Missing, mspID)
+		}
+	}
+	comparison.Consistent = len(comparison.Digests) >= 2 && len(comparison.Mismatches) == 0
+
+	q.logger(ctx).Info().Str("collection", collection).Str("period", period).Int("digests", len(comparison.Digests)).Int("mismatches", len(comparison.Mismatches)).Msg("Collection digests compared")
+	return comparison, nil
+}
+
+// collectionMembers returns the sorted MSP IDs of the active registered organizations that
+// declare a collection
+func collectionMembers(ctx contractapi.TransactionContextInterface, collection string) ([]string, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(orgIndex, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read organizations: %v", err)
+	}
+	defer iterator.Close()
+
+	members := []string{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var org Organization
+		if err := json.Unmarshal(response.Value, &org); err != nil {
+			return nil, err
+		}
+		if org.Status == orgStatusActive && slices.Contains(org.Collections, collection) {
+			members = append(members, org.MSPID)
+		}
+	}
+	sort.Strings(members)
+	return members, nil
+}
@@ -0,0 +1,67 @@
+package chaincode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompareDigests tests that orgs disagreeing on a collection digest are flagged pairwise
+func TestCompareDigests(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	admin := &AdminContract{}
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	for _, mspID := range []string{"Org1MSP", "Org2MSP", "Org3MSP"} {
+		require.NoError(t, admin.RegisterOrg(ctx, mspID, nil, []string{"tradeCollection"}))
+	}
+	digestA := strings.Repeat("ab", 32)
+	digestB := strings.Repeat("cd", 32)
+
+	_, err := cc.PostCollectionDigest(ctx, "tradeCollection", "2026-10-17", digestA, 12)
+	require.NoError(t, err)
+	comparison, err := qc.CompareDigests(ctx, "tradeCollection", "2026-10-17")
+	require.NoError(t, err)
+	assert.False(t, comparison.Consistent)
+	assert.Equal(t, []string{"Org2MSP", "Org3MSP"}, comparison.Missing)
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "user2", nil)
+	_, err = cc.PostCollectionDigest(ctx, "tradeCollection", "2026-10-17", digestA, 12)
+	require.NoError(t, err)
+	comparison, err = qc.CompareDigests(ctx, "tradeCollection", "2026-10-17")
+	require.NoError(t, err)
+	assert.True(t, comparison.Consistent)
+	assert.Empty(t, comparison.Mismatches)
+
+	switchIdentity(t, ctx, stub, "Org3MSP", "user3", nil)
+	_, err = cc.PostCollectionDigest(ctx, "tradeCollection", "2026-10-17", digestB, 11)
+	require.NoError(t, err)
+	comparison, err = qc.CompareDigests(ctx, "tradeCollection", "2026-10-17")
+	require.NoError(t, err)
+	assert.False(t, comparison.Consistent)
+	assert.Empty(t, comparison.Missing)
+	require.Len(t, comparison.Mismatches, 2)
+	assert.Equal(t, "Org1MSP", comparison.Mismatches[0].MSPID)
+	assert.Equal(t, "Org3MSP", comparison.Mismatches[0].OtherMSPID)
+	assert.Equal(t, "Org2MSP", comparison.Mismatches[1].MSPID)
+	assert.Equal(t, 11, comparison.Mismatches[1].OtherRecordCount)
+}
+
+// TestPostCollectionDigestValidation tests the digest format and the collection membership check
+func TestPostCollectionDigestValidation(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	require.NoError(t, (&AdminContract{}).RegisterOrg(ctx, "Org1MSP", nil, []string{"tradeCollection"}))
+	cc := &AssetContract{}
+	digest := strings.Repeat("ab", 32)
+
+	_, err := cc.PostCollectionDigest(ctx, "tradeCollection", "2026-10-17", "not-a-digest", 1)
+	assert.Error(t, err)
+	_, err = cc.PostCollectionDigest(ctx, "tradeCollection", "", digest, 1)
+	assert.Error(t, err)
+	_, err = cc.PostCollectionDigest(ctx, "tradeCollection", "2026-10-17", digest, -1)
+	assert.Error(t, err)
+	_, err = cc.PostCollectionDigest(ctx, "otherCollection", "2026-10-17", digest, 1)
+	assert.True(t, hasErrorCode(err, ErrCodeAccessDenied))
+}
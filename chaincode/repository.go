@@ -0,0 +1,237 @@
+package chaincode
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+// redactedValue replaces masked string fields in responses for restricted roles
+const redactedValue = "REDACTED"
+
+// getAsset reads and decodes an asset from world state. It is the raw read used by
+// transactions; responses returned to clients go through presentAsset.
+func getAsset(ctx contractapi.TransactionContextInterface, assetID string) (*Asset, error) {
+	assetBytes, err := ctx.GetStub().GetState(assetKey(assetID))
+	if err != nil {
+		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to get asset from ledger")
+		return nil, fmt.Errorf("failed to get asset %s: %v", assetID, err)
+	}
+	if assetBytes == nil {
+		log.Warn().Str("assetID", assetID).Msg("Asset does not exist")
+		return nil, fmt.Errorf("asset %s does not exist", assetID)
+	}
+
+	asset, err := decodeAsset(assetBytes)
+	if err != nil {
+		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to decode asset")
+		return nil, err
+	}
+	if asset.Deleted {
+		return nil, fmt.Errorf("asset %s does not exist", assetID)
+	}
+	return asset, nil
+}
+
+// putAsset seals an asset with its checksum, encodes it with the codec of its docType, writes it
+// to world state with the entries of every registered index and records the change in the outbox
+func putAsset(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	// Stamped from the transaction timestamp, which every endorsing peer agrees on
+	now, err := txClock{}.Now(ctx)
+	if err != nil {
+		return err
+	}
+	return putAssetIndexed(ctx, asset, assetIndexes, now)
+}
+
+// putAssetIndexed is putAsset maintaining only the given indexes and stamping the asset with
+// updatedAt, so contracts can pass the time of their injected clock
+func putAssetIndexed(ctx contractapi.TransactionContextInterface, asset *Asset, indexes []assetIndex, updatedAt time.Time) error {
+	clearComputedFields(asset)
+	codec, err := getCodec(ctx, asset.DocType)
+	if err != nil {
+		return err
+	}
+	asset.UpdatedAt = updatedAt
+	asset.Version++
+	if err := sealAsset(asset); err != nil {
+		return err
+	}
+	if err := validateDocument(ctx, asset.DocType, asset); err != nil {
+		log.Warn().Err(err).Str("assetID", asset.ID).Msg("Asset rejected by document schema")
+		return err
+	}
+	if err := updateIndexEntries(ctx, asset.ID, asset, indexes); err != nil {
+		log.Error().Err(err).Str("assetID", asset.ID).Msg("Failed to update index entries")
+		return err
+	}
+	assetBytes, err := codec.marshal(asset)
+	if err != nil {
+		log.Error().Err(err).Str("assetID", asset.ID).Str("codec", codec.name()).Msg("Failed to encode asset")
+		return err
+	}
+	if err := recordAssetWrite(ctx, asset.ID, asset); err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(assetKey(asset.ID), assetBytes)
+	if err != nil {
+		log.Error().Err(err).Str("assetID", asset.ID).Msg("Failed to put asset in ledger")
+		return err
+	}
+	return appendChange(ctx, changeOperationPut, asset.ID, asset)
+}
+
+// storeAssetRecord writes an asset as is with the codec of its docType, for maintenance that does
+// not change the asset's content and so leaves its version, indexes and the outbox untouched
+func storeAssetRecord(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	codec, err := getCodec(ctx, asset.DocType)
+	if err != nil {
+		return err
+	}
+	assetBytes, err := codec.marshal(asset)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(assetKey(asset.ID), assetBytes); err != nil {
+		log.Error().Err(err).Str("assetID", asset.ID).Msg("Failed to put asset in ledger")
+		return err
+	}
+	return nil
+}
+
+// moveAssetKey rewrites a record under its new key and removes the old one, refusing to
+// overwrite an asset already stored under the new key
+func moveAssetKey(ctx contractapi.TransactionContextInterface, oldKey, newKey string, value []byte) error {
+	existing, err := ctx.GetStub().GetState(newKey)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", newKey, err)
+	}
+	if existing != nil {
+		return fmt.Errorf("cannot move %s, %s already exists", oldKey, newKey)
+	}
+	policy, err := ctx.GetStub().GetStateValidationParameter(oldKey)
+	if err != nil {
+		return fmt.Errorf("failed to read endorsement policy of %s: %v", oldKey, err)
+	}
+	if err := ctx.GetStub().PutState(newKey, value); err != nil {
+		return err
+	}
+	if policy != nil {
+		if err := ctx.GetStub().SetStateValidationParameter(newKey, policy); err != nil {
+			return fmt.Errorf("failed to move endorsement policy of %s: %v", oldKey, err)
+		}
+	}
+	return ctx.GetStub().DelState(oldKey)
+}
+
+// deleteAsset removes an asset and the entries of every registered index from world state
+func deleteAsset(ctx contractapi.TransactionContextInterface, assetID string) error {
+	return deleteAssetIndexed(ctx, assetID, assetIndexes)
+}
+
+// deleteAssetIndexed is deleteAsset maintaining only the given indexes
+func deleteAssetIndexed(ctx contractapi.TransactionContextInterface, assetID string, indexes []assetIndex) error {
+	if err := updateIndexEntries(ctx, assetID, nil, indexes); err != nil {
+		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to remove index entries")
+		return err
+	}
+	if err := recordAssetWrite(ctx, assetID, nil); err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(assetKey(assetID)); err != nil {
+		log.Error().Err(err).Str("assetID", assetID).Msg("Failed to delete asset from ledger")
+		return fmt.Errorf("failed to delete asset %s: %v", assetID, err)
+	}
+	return appendChange(ctx, changeOperationDelete, assetID, nil)
+}
+
+// assetPresenter prepares assets for a response to the calling identity
+type assetPresenter struct {
+	redact   bool
+	region   string
+	enricher *assetEnricher
+}
+
+// newAssetPresenter inspects the caller's roles and region once per request. Identities whose
+// only role is auditor get the owner and appraised value masked; every other identity sees full
+// records. The region is looked up from the MSP mapping managed by the ConfigContract, and ages
+// are computed at the time of clock.
+func newAssetPresenter(ctx contractapi.TransactionContextInterface, clock Clock) (*assetPresenter, error) {
+	roles, err := getCallerRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	region, err := getMSPRegion(ctx, mspID)
+	if err != nil {
+		return nil, err
+	}
+	enricher, err := newAssetEnricher(ctx, clock)
+	if err != nil {
+		return nil, err
+	}
+	return &assetPresenter{
+		redact:   len(roles) == 1 && roles[0] == roleAuditor,
+		region:   region,
+		enricher: enricher,
+	}, nil
+}
+
+// visible reports whether the caller may read the asset. Assets tagged with a residency
+// are only readable by identities whose MSP is mapped to the same region.
+func (p *assetPresenter) visible(asset *Asset) bool {
+	return asset.Residency == "" || asset.Residency == p.region
+}
+
+// assertResident denies a write to an asset the caller may not read under its residency, so an
+// organization cannot transfer, change or delete an asset restricted to another region
+func assertResident(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	if asset.Residency == "" {
+		return nil
+	}
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		return err
+	}
+	region, err := getMSPRegion(ctx, mspID)
+	if err != nil {
+		return err
+	}
+	if region != asset.Residency {
+		return denyAccess(ctx, "data residency", "asset %s is restricted to region %s", asset.ID, asset.Residency)
+	}
+	return nil
+}
+
+// present returns the asset as the caller may see it: a copy with schema defaults, masked for
+// restricted roles and with the computed fields, so the stored record is never modified. Computed
+// fields follow the masking, so they cannot reveal a masked value.
+func (p *assetPresenter) present(asset *Asset) *Asset {
+	if asset == nil {
+		return nil
+	}
+	presented := p.mask(p.enricher.withDefaults(asset))
+	p.enricher.compute(presented)
+	return presented
+}
+
+// mask returns the stored asset with the fields restricted roles may not read masked, without
+// defaults or computed fields. For other identities it returns the asset unchanged.
+func (p *assetPresenter) mask(asset *Asset) *Asset {
+	if !p.redact {
+		return asset
+	}
+	masked := *asset
+	if masked.Owner != "" {
+		masked.Owner = redactedValue
+	}
+	masked.AppraisedValue = 0
+	// The checksum covers the stored record, not the masked copy
+	masked.Checksum = ""
+	return &masked
+}
@@ -0,0 +1,38 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditorReadsAreMasked tests that auditor-only identities get owner and value masked
+func TestAuditorReadsAreMasked(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "auditor1", map[string]string{"role": "auditor"})
+	asset, err := qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, redactedValue, asset.Owner)
+	assert.Equal(t, 0, asset.AppraisedValue)
+	assert.Equal(t, "blue", asset.Color)
+
+	assets, err := qc.GetAssetsByRange(ctx, "asset0", "asset9")
+	require.NoError(t, err)
+	require.Len(t, assets, 1)
+	assert.Equal(t, redactedValue, assets[0].Owner)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "auditor2", map[string]string{"role": "auditor,admin"})
+	asset, err = qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "John", asset.Owner)
+	assert.Equal(t, 100, asset.AppraisedValue)
+
+	stored, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "John", stored.Owner)
+}
@@ -0,0 +1,160 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// savedQueryIndex keys saved queries by the identity that owns them and their name
+const savedQueryIndex = "savedquery~identity~name"
+
+// AssetFilter is a vetted set of asset constraints. Zero values leave a field unconstrained.
+type AssetFilter struct {
+	Owner    string `json:"owner"`
+	Color    string `json:"color"`
+	MinSize  int    `json:"minSize"`
+	MaxSize  int    `json:"maxSize"`
+	MinValue int    `json:"minValue"`
+	MaxValue int    `json:"maxValue"`
+}
+
+// SavedQuery is a named filter owned by a single client identity
+type SavedQuery struct {
+	Name     string      `json:"name"`
+	Identity string      `json:"identity"`
+	Filter   AssetFilter `json:"filter"`
+	Selector string      `json:"selector"`
+	TxID     string      `json:"txId"`
+}
+
+// SaveQuery stores a named filter for the calling identity, replacing an existing one with the same name.
+// The CouchDB selector is generated from the filter, so clients never submit raw selectors.
+func (t *AssetContract) SaveQuery(ctx contractapi.TransactionContextInterface, name string, filter AssetFilter) error {
+	t.logger(ctx).Info().Str("function", "SaveQuery").Str("name", name).Interface("filter", filter).Msg("Saving query")
+
+	if name == "" {
+		return fmt.Errorf("query name must not be empty")
+	}
+	identity, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity: %v", err)
+	}
+	selector, err := buildAssetSelector(filter)
+	if err != nil {
+		return err
+	}
+
+	query := &SavedQuery{
+		Name:     name,
+		Identity: identity,
+		Filter:   filter,
+		Selector: selector,
+		TxID:     ctx.GetStub().GetTxID(),
+	}
+	queryBytes, err := json.Marshal(query)
+	if err != nil {
+		return err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(savedQueryIndex, []string{identity, name})
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, queryBytes); err != nil {
+		t.logger(ctx).Error().Err(err).Str("name", name).Msg("Failed to store saved query")
+		return err
+	}
+
+	t.logger(ctx).Info().Str("name", name).Str("selector", selector).Msg("Query saved successfully")
+	return nil
+}
+
+// RunSavedQuery executes one of the caller's saved queries with pagination
+func (q *QueryContract) RunSavedQuery(ctx contractapi.TransactionContextInterface, name string, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+	q.logger(ctx).Info().Str("function", "RunSavedQuery").Str("name", name).Int("pageSize", pageSize).Str("bookmark", bookmark).Msg("Running saved query")
+
+	query, err := getSavedQuery(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return q.getQueryResultForQueryStringWithPagination(ctx, query.Selector, int32(pageSize), bookmark)
+}
+
+// GetSavedQueries lists the saved queries of the calling identity
+func (q *QueryContract) GetSavedQueries(ctx contractapi.TransactionContextInterface) ([]*SavedQuery, error) {
+	q.logger(ctx).Info().Str("function", "GetSavedQueries").Msg("Listing saved queries")
+
+	identity, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity: %v", err)
+	}
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(savedQueryIndex, []string{identity})
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	queries := []*SavedQuery{}
+	for iterator.HasNext() {
+		result, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var query SavedQuery
+		if err := json.Unmarshal(result.Value, &query); err != nil {
+			return nil, err
+		}
+		queries = append(queries, &query)
+	}
+	return queries, nil
+}
+
+// DeleteSavedQuery removes one of the caller's saved queries
+func (t *AssetContract) DeleteSavedQuery(ctx contractapi.TransactionContextInterface, name string) error {
+	t.logger(ctx).Info().Str("function", "DeleteSavedQuery").Str("name", name).Msg("Deleting saved query")
+
+	query, err := getSavedQuery(ctx, name)
+	if err != nil {
+		return err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(savedQueryIndex, []string{query.Identity, name})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().DelState(key)
+}
+
+// getSavedQuery reads a saved query of the calling identity
+func getSavedQuery(ctx contractapi.TransactionContextInterface, name string) (*SavedQuery, error) {
+	identity, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity: %v", err)
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(savedQueryIndex, []string{identity, name})
+	if err != nil {
+		return nil, err
+	}
+	queryBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved query %s: %v", name, err)
+	}
+	if queryBytes == nil {
+		return nil, fmt.Errorf("saved query %s does not exist", name)
+	}
+	var query SavedQuery
+	if err := json.Unmarshal(queryBytes, &query); err != nil {
+		return nil, err
+	}
+	return &query, nil
+}
+
+// buildAssetSelector generates the CouchDB query of a filter
+func buildAssetSelector(filter AssetFilter) (string, error) {
+	return newAssetQuery().
+		equalsIfSet("owner", filter.Owner).
+		equalsIfSet("color", filter.Color).
+		between("size", filter.MinSize, filter.MaxSize).
+		between("appraisedValue", filter.MinValue, filter.MaxValue).
+		build()
+}
@@ -0,0 +1,43 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuildAssetSelector tests selector generation and that values cannot inject clauses
+func TestBuildAssetSelector(t *testing.T) {
+	selector, err := buildAssetSelector(AssetFilter{Owner: "Tom", MinSize: 5, MaxValue: 300})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"selector":{"docType":"asset","owner":"Tom","size":{"$gte":5},"appraisedValue":{"$lte":300}}}`, selector)
+
+	selector, err = buildAssetSelector(AssetFilter{Owner: `Tom","docType":{"$ne":"x"}`})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"selector":{"docType":"asset","owner":"Tom\",\"docType\":{\"$ne\":\"x\"}"}}`, selector)
+
+	_, err = buildAssetSelector(AssetFilter{MinSize: 10, MaxSize: 5})
+	assert.Error(t, err)
+}
+
+// TestSavedQueriesArePerIdentity tests that saved queries are scoped to their owner
+func TestSavedQueriesArePerIdentity(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "ops1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.SaveQuery(ctx, "blue", AssetFilter{Color: "blue"}))
+
+	queries, err := qc.GetSavedQueries(ctx)
+	require.NoError(t, err)
+	require.Len(t, queries, 1)
+	assert.Equal(t, "blue", queries[0].Name)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "ops2", nil)
+	queries, err = qc.GetSavedQueries(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, queries)
+	assert.Error(t, cc.DeleteSavedQuery(ctx, "blue"))
+	_, err = qc.RunSavedQuery(ctx, "blue", 10, "")
+	assert.Error(t, err)
+}
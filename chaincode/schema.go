@@ -0,0 +1,290 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	// schemaIndex keys every registered schema version of a docType
+	schemaIndex = "schema~doctype~version"
+	// schemaConfig is the configuration key prefix of the active schema version per docType
+	schemaConfig = "schema"
+
+	// SchemaProposed is a registered schema waiting for a second admin's approval
+	SchemaProposed = "proposed"
+	// SchemaActive is the schema writes of its docType are validated against
+	SchemaActive = "active"
+	// SchemaSuperseded is a formerly active schema replaced by a newer version
+	SchemaSuperseded = "superseded"
+)
+
+// DocumentSchema is a version of the JSON Schema of a docType
+type DocumentSchema struct {
+	DocType    string    `json:"docType"`
+	Version    int       `json:"version"`
+	Schema     string    `json:"schema"`
+	Status     string    `json:"status"`
+	ProposedBy string    `json:"proposedBy"`
+	ProposedAt time.Time `json:"proposedAt"`
+	ApprovedBy string    `json:"approvedBy,omitempty" metadata:",optional"`
+	ApprovedAt time.Time `json:"approvedAt,omitzero" metadata:",optional"`
+}
+
+// RegisterSchema proposes version of the JSON Schema for a docType. It takes effect once
+// another admin approves it with ApproveSchema; versions must increase.
+func (c *ConfigContract) RegisterSchema(ctx contractapi.TransactionContextInterface, docType, jsonSchema string, version int) error {
+	log.Info().Str("function", "RegisterSchema").Str("docType", docType).Int("version", version).Msg("Registering document schema")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if docType == "" {
+		return fmt.Errorf("docType must not be empty")
+	}
+	var schemaDocument interface{}
+	if err := json.Unmarshal([]byte(jsonSchema), &schemaDocument); err != nil {
+		return fmt.Errorf("invalid JSON schema: %v", err)
+	}
+	if err := checkLocalRefs(schemaDocument); err != nil {
+		return err
+	}
+	if _, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(jsonSchema)); err != nil {
+		return fmt.Errorf("invalid JSON schema: %v", err)
+	}
+	if version <= 0 {
+		return fmt.Errorf("schema version must be positive")
+	}
+	history, err := getSchemaHistory(ctx, docType)
+	if err != nil {
+		return err
+	}
+	if len(history) > 0 && version <= history[len(history)-1].Version {
+		return fmt.Errorf("schema version must be greater than %d", history[len(history)-1].Version)
+	}
+	proposer, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity: %v", err)
+	}
+	now, err := c.now(ctx)
+	if err != nil {
+		return err
+	}
+
+	schema := &DocumentSchema{DocType: docType, Version: version, Schema: jsonSchema, Status: SchemaProposed, ProposedBy: proposer, ProposedAt: now}
+	if err := putSchema(ctx, schema); err != nil {
+		return err
+	}
+	return recordAudit(ctx, "RegisterSchema", docType, fmt.Sprintf("version=%d", version))
+}
+
+// ApproveSchema activates a proposed schema version. The approver must be a different admin
+// than the proposer. The previously active version is superseded.
+func (c *ConfigContract) ApproveSchema(ctx contractapi.TransactionContextInterface, docType string, version int) error {
+	log.Info().Str("function", "ApproveSchema").Str("docType", docType).Int("version", version).Msg("Approving document schema")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	history, err := getSchemaHistory(ctx, docType)
+	if err != nil {
+		return err
+	}
+	var schema *DocumentSchema
+	for _, candidate := range history {
+		if candidate.Version == version {
+			schema = candidate
+		}
+	}
+	if schema == nil || schema.Status != SchemaProposed {
+		return fmt.Errorf("schema version %d of %s is not awaiting approval", version, docType)
+	}
+	approver, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity: %v", err)
+	}
+	if approver == schema.ProposedBy {
+		return denyAccess(ctx, "four-eyes", "schema version %d of %s must be approved by another admin", version, docType)
+	}
+	now, err := c.now(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, previous := range history {
+		if previous.Status == SchemaActive {
+			previous.Status = SchemaSuperseded
+			if err := putSchema(ctx, previous); err != nil {
+				return err
+			}
+		}
+	}
+	schema.Status = SchemaActive
+	schema.ApprovedBy = approver
+	schema.ApprovedAt = now
+	if err := putSchema(ctx, schema); err != nil {
+		return err
+	}
+	if err := putConfig(ctx, version, schemaConfig, docType); err != nil {
+		return err
+	}
+	return recordAudit(ctx, "ApproveSchema", docType, fmt.Sprintf("version=%d", version))
+}
+
+// GetSchemaHistory returns every registered schema version of a docType, oldest first
+func (c *ConfigContract) GetSchemaHistory(ctx contractapi.TransactionContextInterface, docType string) ([]*DocumentSchema, error) {
+	log.Info().Str("function", "GetSchemaHistory").Str("docType", docType).Msg("Reading document schema history")
+	return getSchemaHistory(ctx, docType)
+}
+
+// validateDocument checks a record about to be written against the active schema of its
+// docType. docTypes without an approved schema are not checked.
+func validateDocument(ctx contractapi.TransactionContextInterface, docType string, document interface{}) error {
+	var version int
+	found, err := getConfig(ctx, &version, schemaConfig, docType)
+	if err != nil || !found {
+		return err
+	}
+	schema, err := getSchema(ctx, docType, version)
+	if err != nil {
+		return err
+	}
+	documentBytes, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewStringLoader(schema.Schema), gojsonschema.NewBytesLoader(documentBytes))
+	if err != nil {
+		return fmt.Errorf("failed to validate %s against schema version %d: %v", docType, version, err)
+	}
+	if !result.Valid() {
+		violationsJSON, err := json.Marshal(fieldErrors(result.Errors()))
+		if err != nil {
+			return err
+		}
+		return newChaincodeError(ErrCodeSchemaViolation, "%s does not match schema version %d: %s", docType, version, violationsJSON)
+	}
+	return nil
+}
+
+// FieldError is a schema violation of one field, located by a JSON pointer (RFC 6901) such as
+// "/size" or "/tags/0". Code is the violated constraint, e.g. REQUIRED or NUMBER_LTE.
+type FieldError struct {
+	Path    string `json:"path"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// fieldErrors converts validation errors to field errors sorted by path and code. A missing
+// required property is reported at the path of the property rather than of its parent.
+func fieldErrors(violations []gojsonschema.ResultError) []FieldError {
+	fields := make([]FieldError, 0, len(violations))
+	for _, violation := range violations {
+		// Segments are joined with NUL, which cannot be confused with dots in property names;
+		// the first segment is the document root
+		segments := strings.Split(violation.Context().String("\x00"), "\x00")[1:]
+		if property, ok := violation.Details()["property"].(string); ok && violation.Type() == "required" {
+			segments = append(segments, property)
+		}
+		path := ""
+		for _, segment := range segments {
+			path += "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(segment)
+		}
+		fields = append(fields, FieldError{Path: path, Code: strings.ToUpper(violation.Type()), Message: violation.Description()})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].Path != fields[j].Path {
+			return fields[i].Path < fields[j].Path
+		}
+		return fields[i].Code < fields[j].Code
+	})
+	return fields
+}
+
+// checkLocalRefs rejects $ref values that point outside the schema. Resolving them would make
+// each peer fetch a URL or file during validation, which can differ between endorsers.
+func checkLocalRefs(node interface{}) error {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if ref, ok := child.(string); ok && key == "$ref" && !strings.HasPrefix(ref, "#") {
+				return fmt.Errorf("schema reference %q must be local to the schema", ref)
+			}
+			if err := checkLocalRefs(child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range value {
+			if err := checkLocalRefs(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func getSchema(ctx contractapi.TransactionContextInterface, docType string, version int) (*DocumentSchema, error) {
+	key, err := schemaKey(ctx, docType, version)
+	if err != nil {
+		return nil, err
+	}
+	schemaBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema version %d of %s: %v", version, docType, err)
+	}
+	if schemaBytes == nil {
+		return nil, fmt.Errorf("schema version %d of %s does not exist", version, docType)
+	}
+	var schema DocumentSchema
+	if err := json.Unmarshal(schemaBytes, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+func getSchemaHistory(ctx contractapi.TransactionContextInterface, docType string) ([]*DocumentSchema, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(schemaIndex, []string{docType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schemas of %s: %v", docType, err)
+	}
+	defer iterator.Close()
+
+	history := []*DocumentSchema{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var schema DocumentSchema
+		if err := json.Unmarshal(response.Value, &schema); err != nil {
+			return nil, err
+		}
+		history = append(history, &schema)
+	}
+	return history, nil
+}
+
+func putSchema(ctx contractapi.TransactionContextInterface, schema *DocumentSchema) error {
+	key, err := schemaKey(ctx, schema.DocType, schema.Version)
+	if err != nil {
+		return err
+	}
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, schemaBytes)
+}
+
+// schemaKey zero-pads the version so schemas iterate in version order
+func schemaKey(ctx contractapi.TransactionContextInterface, docType string, version int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(schemaIndex, []string{docType, fmt.Sprintf("%010d", version)})
+}
@@ -0,0 +1,77 @@
+package chaincode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const testAssetSchema = `{"type":"object","required":["owner"],"properties":{"size":{"type":"integer","maximum":10}}}`
+
+// TestSchemaRegistry tests that approved schemas validate writes and changes need a second admin
+func TestSchemaRegistry(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin1", adminAttrs)
+	now := time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)
+	config := NewConfigContract(WithClock(fixedClock(now)))
+	cc := &AssetContract{}
+
+	require.NoError(t, config.RegisterSchema(ctx, "asset", testAssetSchema, 1))
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 20, "John", 100), "proposed schemas are not enforced")
+
+	err := config.ApproveSchema(ctx, "asset", 1)
+	assert.True(t, hasErrorCode(err, ErrCodeAccessDenied), "the proposer cannot approve")
+	switchIdentity(t, ctx, stub, "Org1MSP", "admin2", adminAttrs)
+	require.NoError(t, config.ApproveSchema(ctx, "asset", 1))
+
+	err = cc.CreateAsset(ctx, "asset2", "blue", 20, "John", 100)
+	assert.True(t, hasErrorCode(err, ErrCodeSchemaViolation))
+	assert.ErrorContains(t, err, `"path":"/size","code":"NUMBER_LTE"`)
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "blue", 5, "John", 100))
+
+	assert.Error(t, config.RegisterSchema(ctx, "asset", testAssetSchema, 1), "versions must increase")
+	require.NoError(t, config.RegisterSchema(ctx, "asset", `{"type":"object"}`, 2))
+	switchIdentity(t, ctx, stub, "Org1MSP", "admin1", adminAttrs)
+	require.NoError(t, config.ApproveSchema(ctx, "asset", 2))
+	require.NoError(t, cc.CreateAsset(ctx, "asset3", "blue", 20, "John", 100))
+
+	history, err := config.GetSchemaHistory(ctx, "asset")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, SchemaSuperseded, history[0].Status)
+	assert.Equal(t, SchemaActive, history[1].Status)
+	assert.NotEqual(t, history[1].ProposedBy, history[1].ApprovedBy)
+	assert.Equal(t, now, history[1].ProposedAt)
+	assert.Equal(t, now, history[1].ApprovedAt)
+}
+
+// TestSchemaFieldErrors tests that violations are located by JSON pointers, including missing properties
+func TestSchemaFieldErrors(t *testing.T) {
+	schema := `{"type":"object","required":["owner","a/b"],"properties":{"tags":{"type":"array","items":{"type":"string"}},"size":{"maximum":10}}}`
+	result, err := gojsonschema.Validate(gojsonschema.NewStringLoader(schema), gojsonschema.NewStringLoader(`{"size":20,"tags":["ok",7]}`))
+	require.NoError(t, err)
+
+	fields := fieldErrors(result.Errors())
+	require.Len(t, fields, 4)
+	assert.Equal(t, []string{"/a~1b", "/owner", "/size", "/tags/1"}, []string{fields[0].Path, fields[1].Path, fields[2].Path, fields[3].Path})
+	assert.Equal(t, "REQUIRED", fields[1].Code)
+	assert.Equal(t, "NUMBER_LTE", fields[2].Code)
+	assert.Equal(t, "INVALID_TYPE", fields[3].Code)
+	assert.NotEmpty(t, fields[3].Message)
+}
+
+// TestRegisterSchemaRejectsInvalidSchemas tests that only compilable, self-contained schemas are accepted
+func TestRegisterSchemaRejectsInvalidSchemas(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "admin1", adminAttrs)
+	config := &ConfigContract{}
+
+	assert.Error(t, config.RegisterSchema(ctx, "asset", `{"type":`, 1))
+	assert.Error(t, config.RegisterSchema(ctx, "asset", `{"type":"no-such-type"}`, 1))
+	assert.ErrorContains(t, config.RegisterSchema(ctx, "asset", `{"properties":{"owner":{"$ref":"https://example.com/owner.json"}}}`, 1), "local")
+	assert.Error(t, config.RegisterSchema(ctx, "asset", testAssetSchema, 0))
+
+	ctx, _ = newTestContext(t, "Org1MSP", "user1", nil)
+	assert.True(t, hasErrorCode(config.RegisterSchema(ctx, "asset", testAssetSchema, 1), ErrCodeAccessDenied))
+}
@@ -0,0 +1,224 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransientTradeTerms is the transient map key AgreeToSell and AgreeToBuy read TradeTerms from
+const TransientTradeTerms = "asset_price"
+
+const (
+	// saleTermsIndex keys the terms a seller agreed to in their organization's implicit collection
+	saleTermsIndex = "saleterms~assetId"
+	// bidTermsIndex keys the terms a buyer agreed to in their organization's implicit collection
+	bidTermsIndex = "bidterms~assetId"
+)
+
+// TradeTerms are the terms of a secured transfer. Seller and buyer each store them privately
+// and the transfer only goes through when both stored the same terms. TradeID is a random value
+// the two agree on off-chain; it salts the terms so their public hashes cannot be guessed.
+type TradeTerms struct {
+	AssetID string `json:"assetId"`
+	Price   int    `json:"price"`
+	TradeID string `json:"tradeId"`
+	Buyer   string `json:"buyer"`
+}
+
+// AgreeToSell records the owner's terms for selling an asset, read from TradeTerms under
+// TransientTradeTerms in the transient map, in the implicit collection of their organization
+func (t *AssetContract) AgreeToSell(ctx contractapi.TransactionContextInterface, assetID string) error {
+	t.logger(ctx).Info().Str("function", "AgreeToSell").Str("assetID", assetID).Msg("Agreeing to sell asset")
+
+	mspID, err := assertOrgCanWrite(ctx)
+	if err != nil {
+		return err
+	}
+	terms, err := getTradeTerms(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	asset, caller, err := callerOwnedAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	if asset.Frozen {
+		return fmt.Errorf("asset %s is frozen", assetID)
+	}
+	if terms.Buyer == caller {
+		return fmt.Errorf("owner %s cannot sell asset %s to themselves", caller, assetID)
+	}
+	if err := putTradeTerms(ctx, implicitCollection(mspID), saleTermsIndex, terms); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("assetID", assetID).Str("mspID", mspID).Msg("Sale terms recorded")
+	return nil
+}
+
+// AgreeToBuy records the buyer's terms for buying an asset, read like AgreeToSell, in the
+// implicit collection of their organization. The terms must name the caller as the buyer.
+func (t *AssetContract) AgreeToBuy(ctx contractapi.TransactionContextInterface, assetID string) error {
+	t.logger(ctx).Info().Str("function", "AgreeToBuy").Str("assetID", assetID).Msg("Agreeing to buy asset")
+
+	mspID, err := assertOrgCanWrite(ctx)
+	if err != nil {
+		return err
+	}
+	terms, err := getTradeTerms(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	caller, err := getCallerEnrollmentID(ctx)
+	if err != nil {
+		return err
+	}
+	if terms.Buyer != caller {
+		return fmt.Errorf("terms name %s as the buyer, not %s", terms.Buyer, caller)
+	}
+	asset, err := getAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	if asset.Owner == caller {
+		return fmt.Errorf("asset %s is already owned by %s", assetID, caller)
+	}
+	if err := putTradeTerms(ctx, implicitCollection(mspID), bidTermsIndex, terms); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("assetID", assetID).Str("mspID", mspID).Msg("Bid terms recorded")
+	return nil
+}
+
+// TransferAssetSecured transfers an asset to the buyer its owner agreed to sell it to, once the
+// buyer's organization stored the same terms. Only the hashes of the two agreements are
+// compared, so the price stays private to both organizations. Both agreements are deleted.
+// Endorse it with peers of the seller's and the buyer's organization.
+func (t *AssetContract) TransferAssetSecured(ctx contractapi.TransactionContextInterface, assetID, buyerMSP string) error {
+	t.logger(ctx).Info().Str("function", "TransferAssetSecured").Str("assetID", assetID).Str("buyerMSP", buyerMSP).Msg("Transferring asset on agreed terms")
+
+	sellerMSP, err := assertOrgCanWrite(ctx)
+	if err != nil {
+		return err
+	}
+	asset, caller, err := callerOwnedAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	if asset.Frozen {
+		return fmt.Errorf("asset %s is frozen", assetID)
+	}
+
+	saleKey, err := ctx.GetStub().CreateCompositeKey(saleTermsIndex, []string{assetID})
+	if err != nil {
+		return err
+	}
+	bidKey, err := ctx.GetStub().CreateCompositeKey(bidTermsIndex, []string{assetID})
+	if err != nil {
+		return err
+	}
+	sellerCollection, buyerCollection := implicitCollection(sellerMSP), implicitCollection(buyerMSP)
+	termsBytes, err := ctx.GetStub().GetPrivateData(sellerCollection, saleKey)
+	if err != nil {
+		return fmt.Errorf("failed to read sale terms of asset %s: %v", assetID, err)
+	}
+	if termsBytes == nil {
+		return fmt.Errorf("%s has not agreed to sell asset %s", caller, assetID)
+	}
+	var terms TradeTerms
+	if err := json.Unmarshal(termsBytes, &terms); err != nil {
+		return err
+	}
+
+	sellerHash, err := ctx.GetStub().GetPrivateDataHash(sellerCollection, saleKey)
+	if err != nil {
+		return fmt.Errorf("failed to read the hash of the sale terms of asset %s: %v", assetID, err)
+	}
+	buyerHash, err := ctx.GetStub().GetPrivateDataHash(buyerCollection, bidKey)
+	if err != nil {
+		return fmt.Errorf("failed to read the hash of the bid terms of asset %s: %v", assetID, err)
+	}
+	if buyerHash == nil {
+		return fmt.Errorf("no buyer of %s has agreed to buy asset %s", buyerMSP, assetID)
+	}
+	if !bytes.Equal(sellerHash, buyerHash) {
+		t.logger(ctx).Warn().Str("assetID", assetID).Str("buyerMSP", buyerMSP).Msg("Sale and bid terms differ")
+		return fmt.Errorf("the sale and bid terms of asset %s differ", assetID)
+	}
+
+	oldOwner := asset.Owner
+	asset.Owner = terms.Buyer
+	asset.OwnerMSP = buyerMSP
+	if err := t.saveAsset(ctx, asset); err != nil {
+		t.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to update asset in ledger during secured transfer")
+		return err
+	}
+	if err := ctx.GetStub().DelPrivateData(sellerCollection, saleKey); err != nil {
+		return fmt.Errorf("failed to delete the sale terms of asset %s: %v", assetID, err)
+	}
+	if err := ctx.GetStub().DelPrivateData(buyerCollection, bidKey); err != nil {
+		return fmt.Errorf("failed to delete the bid terms of asset %s: %v", assetID, err)
+	}
+	if err := t.hooks.runAfterTransfer(ctx, asset, oldOwner); err != nil {
+		t.logger(ctx).Warn().Err(err).Str("assetID", assetID).Msg("Asset transfer rejected by hook")
+		return err
+	}
+	if err := t.emitAssetChange(ctx, EventAssetTransferred, asset); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("assetID", assetID).Str("oldOwner", oldOwner).Str("newOwner", asset.Owner).Msg("Secured asset transfer completed successfully")
+	return nil
+}
+
+// getTradeTerms reads the trade terms of an asset from the transient map
+func getTradeTerms(ctx contractapi.TransactionContextInterface, assetID string) (*TradeTerms, error) {
+	terms, err := GetTransientJSON[TradeTerms](ctx, TransientTradeTerms)
+	if err != nil {
+		return nil, err
+	}
+	if terms.AssetID != assetID {
+		return nil, fmt.Errorf("trade terms are for asset %s, not %s", terms.AssetID, assetID)
+	}
+	if terms.Price <= 0 || terms.TradeID == "" || terms.Buyer == "" {
+		return nil, fmt.Errorf("trade terms need a positive price, a trade ID and a buyer")
+	}
+	return terms, nil
+}
+
+// putTradeTerms stores trade terms in their canonical JSON encoding, so seller and buyer terms
+// hash the same however each client formatted them
+func putTradeTerms(ctx contractapi.TransactionContextInterface, collection, index string, terms *TradeTerms) error {
+	key, err := ctx.GetStub().CreateCompositeKey(index, []string{terms.AssetID})
+	if err != nil {
+		return err
+	}
+	termsBytes, err := json.Marshal(terms)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutPrivateData(collection, key, termsBytes); err != nil {
+		return fmt.Errorf("failed to store trade terms of asset %s: %v", terms.AssetID, err)
+	}
+	return nil
+}
+
+// callerOwnedAsset reads an asset the caller must own, and the caller
+func callerOwnedAsset(ctx contractapi.TransactionContextInterface, assetID string) (*Asset, string, error) {
+	caller, err := getCallerEnrollmentID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	asset, err := getAsset(ctx, assetID)
+	if err != nil {
+		return nil, "", err
+	}
+	if asset.Owner != caller {
+		return nil, "", denyAccess(ctx, "asset owner", "asset %s is not owned by %s", assetID, caller)
+	}
+	return asset, caller, nil
+}
@@ -0,0 +1,49 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTransferAssetSecured tests that an asset only changes hands once both sides stored equal terms
+func TestTransferAssetSecured(t *testing.T) {
+	ctx, stub := newPrivateDataContext(t, "Org1MSP", "alice", nil)
+	ac := NewAssetContract()
+	require.NoError(t, ac.CreateAsset(ctx, "asset1", "blue", 5, "alice", 100))
+
+	terms := func(body string) {
+		stub.TransientMap = map[string][]byte{TransientTradeTerms: []byte(body)}
+	}
+	terms(`{"assetId":"asset2","price":500,"tradeId":"t1","buyer":"bob"}`)
+	assert.ErrorContains(t, ac.AgreeToSell(ctx, "asset1"), "not asset1")
+	terms(`{"assetId":"asset1","price":500,"tradeId":"t1","buyer":"bob"}`)
+	require.NoError(t, ac.AgreeToSell(ctx, "asset1"))
+	assert.ErrorContains(t, ac.TransferAssetSecured(ctx, "asset1", "Org2MSP"), "no buyer of Org2MSP")
+
+	switchIdentity(t, ctx, stub.MockStub, "Org2MSP", "bob", nil)
+	assert.Error(t, ac.AgreeToSell(ctx, "asset1"))
+	terms(`{"assetId":"asset1","price":400,"tradeId":"t1","buyer":"bob"}`)
+	require.NoError(t, ac.AgreeToBuy(ctx, "asset1"))
+	assert.Error(t, ac.TransferAssetSecured(ctx, "asset1", "Org2MSP"))
+
+	switchIdentity(t, ctx, stub.MockStub, "Org1MSP", "alice", nil)
+	assert.ErrorContains(t, ac.TransferAssetSecured(ctx, "asset1", "Org2MSP"), "terms of asset asset1 differ")
+
+	// The buyer agrees to the seller's price, formatted differently
+	switchIdentity(t, ctx, stub.MockStub, "Org2MSP", "bob", nil)
+	terms(`{ "buyer": "bob", "tradeId": "t1", "price": 500, "assetId": "asset1" }`)
+	require.NoError(t, ac.AgreeToBuy(ctx, "asset1"))
+
+	switchIdentity(t, ctx, stub.MockStub, "Org1MSP", "alice", nil)
+	require.NoError(t, ac.TransferAssetSecured(ctx, "asset1", "Org2MSP"))
+	assert.Equal(t, EventAssetTransferred, lastEvent(t, stub.MockStub).EventName)
+
+	asset, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", asset.Owner)
+	assert.Equal(t, "Org2MSP", asset.OwnerMSP)
+	assert.Empty(t, stub.PvtState[implicitCollection("Org1MSP")])
+	assert.Empty(t, stub.PvtState[implicitCollection("Org2MSP")])
+}
@@ -0,0 +1,40 @@
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SequenceNumber is a number issued by NextSequence
+type SequenceNumber struct {
+	Name  string `json:"name"`
+	Value uint64 `json:"value"`
+	Shard int    `json:"shard"`
+}
+
+// NextSequence issues a unique number from the named sequence, e.g. for invoice numbers or
+// document references. Numbers are unique and increase per shard, but the sequence is
+// gap-tolerant: numbers are not contiguous and a later transaction can receive a smaller number.
+// Call it at most once per name in a transaction.
+func (t *AssetContract) NextSequence(ctx contractapi.TransactionContextInterface, name string) (*SequenceNumber, error) {
+	t.logger(ctx).Info().Str("function", "NextSequence").Str("name", name).Msg("Issuing sequence number")
+
+	if name == "" {
+		return nil, fmt.Errorf("sequence name must not be empty")
+	}
+	counter, err := newShardedCounter("sequence:" + name)
+	if err != nil {
+		return nil, err
+	}
+	shard, issued, err := counter.add(ctx, 1)
+	if err != nil {
+		t.logger(ctx).Error().Err(err).Str("name", name).Msg("Failed to issue sequence number")
+		return nil, err
+	}
+
+	// Interleave the shards so every shard owns a disjoint set of numbers
+	number := &SequenceNumber{Name: name, Value: issued*counterShards + uint64(shard) + 1, Shard: shard}
+	t.logger(ctx).Info().Str("name", name).Uint64("value", number.Value).Int("shard", shard).Msg("Sequence number issued")
+	return number, nil
+}
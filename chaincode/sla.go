@@ -0,0 +1,315 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// slaIndex keys SLA timers by subject and workflow step
+	slaIndex = "sla~subject~step"
+	// slaStatusIndex orders SLA timers of a status by deadline so overdue timers sort first
+	slaStatusIndex = "slastatus~status~deadline~subject~step"
+	// slaDeadlineLayout is a fixed width UTC layout, so deadlines sort lexically in the status index
+	slaDeadlineLayout = "2006-01-02T15:04:05.000000000Z"
+
+	// SLA timer statuses
+	SLAStatusOpen      = "open"
+	SLAStatusBreached  = "breached"
+	SLAStatusCompleted = "completed"
+
+	// maxSLABreachBatch caps the timers CheckSLABreaches flags in one transaction
+	maxSLABreachBatch = 500
+)
+
+// SLATimer tracks the deadline of a workflow step, e.g. step "approval" of subject "asset1"
+type SLATimer struct {
+	DocType     string    `json:"docType"`
+	Subject     string    `json:"subject"`
+	Step        string    `json:"step"`
+	Status      string    `json:"status"`
+	StartedAt   time.Time `json:"startedAt"`
+	Deadline    time.Time `json:"deadline"`
+	BreachedAt  time.Time `json:"breachedAt,omitzero" metadata:",optional"`
+	CompletedAt time.Time `json:"completedAt,omitzero" metadata:",optional"`
+	StartedBy   string    `json:"startedBy"`
+	TxID        string    `json:"txId"`
+}
+
+// SLABreachReport lists the timers flagged by one CheckSLABreaches run
+type SLABreachReport struct {
+	Breached []*SLATimer `json:"breached"`
+	More     bool        `json:"more"`
+}
+
+// StartSLA starts the timer of a workflow step. duration is a Go duration such as "48h" or a
+// number of business days in the calendar of the caller's region such as "3bd". A completed
+// timer of the same step is replaced; a running one is an error.
+func (t *AssetContract) StartSLA(ctx contractapi.TransactionContextInterface, subject, step, duration string) (*SLATimer, error) {
+	t.logger(ctx).Info().Str("function", "StartSLA").Str("subject", subject).Str("step", step).Str("duration", duration).Msg("Starting SLA timer")
+
+	if subject == "" || step == "" {
+		return nil, fmt.Errorf("subject and step must not be empty")
+	}
+	existing, err := getSLATimer(ctx, subject, step)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Status != SLAStatusCompleted {
+		return nil, fmt.Errorf("SLA timer of step %s on %s is already %s", step, subject, existing.Status)
+	}
+
+	now, err := t.now(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var deadline time.Time
+	if days, ok := parseBusinessDays(duration); ok {
+		if deadline, err = addCallerBusinessDays(ctx, now, days); err != nil {
+			return nil, err
+		}
+	} else {
+		parsed, err := time.ParseDuration(duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SLA duration %q: %v", duration, err)
+		}
+		deadline = now.Add(parsed)
+	}
+	if !deadline.After(now) {
+		return nil, fmt.Errorf("SLA duration must be positive")
+	}
+	startedBy, err := getCallerEnrollmentID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		if err := delSLAStatusKey(ctx, existing); err != nil {
+			return nil, err
+		}
+	}
+	timer := &SLATimer{
+		DocType:   "sla",
+		Subject:   subject,
+		Step:      step,
+		Status:    SLAStatusOpen,
+		StartedAt: now,
+		Deadline:  deadline,
+		StartedBy: startedBy,
+		TxID:      ctx.GetStub().GetTxID(),
+	}
+	if err := putSLATimer(ctx, timer); err != nil {
+		return nil, err
+	}
+	return timer, nil
+}
+
+// CompleteSLA stops the timer of a workflow step. Breached timers can still be completed and keep
+// their breach time.
+func (t *AssetContract) CompleteSLA(ctx contractapi.TransactionContextInterface, subject, step string) (*SLATimer, error) {
+	t.logger(ctx).Info().Str("function", "CompleteSLA").Str("subject", subject).Str("step", step).Msg("Completing SLA timer")
+
+	timer, err := getSLATimer(ctx, subject, step)
+	if err != nil {
+		return nil, err
+	}
+	if timer == nil {
+		return nil, fmt.Errorf("no SLA timer for step %s on %s", step, subject)
+	}
+	if timer.Status == SLAStatusCompleted {
+		return nil, fmt.Errorf("SLA timer of step %s on %s is already completed", step, subject)
+	}
+	now, err := t.now(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := delSLAStatusKey(ctx, timer); err != nil {
+		return nil, err
+	}
+	timer.Status = SLAStatusCompleted
+	timer.CompletedAt = now
+	if err := putSLATimer(ctx, timer); err != nil {
+		return nil, err
+	}
+	return timer, nil
+}
+
+// CheckSLABreaches flags up to limit open timers whose deadline has passed and emits one
+// SLABreached event listing them. It is meant to be submitted periodically by an operator job;
+// More reports that overdue timers remain for the next run.
+func (t *AssetContract) CheckSLABreaches(ctx contractapi.TransactionContextInterface, limit int) (*SLABreachReport, error) {
+	t.logger(ctx).Info().Str("function", "CheckSLABreaches").Int("limit", limit).Msg("Checking SLA breaches")
+
+	if limit <= 0 || limit > maxSLABreachBatch {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxSLABreachBatch)
+	}
+	now, err := t.now(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(slaStatusIndex, []string{SLAStatusOpen})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read open SLA timers: %v", err)
+	}
+	defer iterator.Close()
+
+	overdue := []*SLATimer{}
+	report := &SLABreachReport{Breached: []*SLATimer{}}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil {
+			return nil, err
+		}
+		deadline, err := time.Parse(slaDeadlineLayout, attributes[1])
+		if err != nil {
+			return nil, err
+		}
+		if deadline.After(now) {
+			break
+		}
+		if len(overdue) == limit {
+			report.More = true
+			break
+		}
+		timer, err := getSLATimer(ctx, attributes[2], attributes[3])
+		if err != nil {
+			return nil, err
+		}
+		overdue = append(overdue, timer)
+	}
+
+	for _, timer := range overdue {
+		if err := delSLAStatusKey(ctx, timer); err != nil {
+			return nil, err
+		}
+		timer.Status = SLAStatusBreached
+		timer.BreachedAt = now
+		if err := putSLATimer(ctx, timer); err != nil {
+			return nil, err
+		}
+		report.Breached = append(report.Breached, timer)
+	}
+
+	if len(report.Breached) > 0 {
+		t.logger(ctx).Warn().Int("breached", len(report.Breached)).Bool("more", report.More).Msg("SLA breaches detected")
+		if !t.skipEvents {
+			if err := emitEvent(ctx, EventSLABreached, "", report); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return report, nil
+}
+
+// GetSLATimersByStatus returns the timers with a status, ordered by deadline
+func (q *QueryContract) GetSLATimersByStatus(ctx contractapi.TransactionContextInterface, status string) ([]*SLATimer, error) {
+	q.logger(ctx).Info().Str("function", "GetSLATimersByStatus").Str("status", status).Msg("Reading SLA timers by status")
+
+	if status != SLAStatusOpen && status != SLAStatusBreached && status != SLAStatusCompleted {
+		return nil, fmt.Errorf("status must be %s, %s or %s", SLAStatusOpen, SLAStatusBreached, SLAStatusCompleted)
+	}
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(slaStatusIndex, []string{status})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SLA timers: %v", err)
+	}
+	defer iterator.Close()
+
+	budget := newQueryBudget(q.configProvider())
+	timers := []*SLATimer{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil {
+			return nil, err
+		}
+		timer, err := getSLATimer(ctx, attributes[2], attributes[3])
+		if err != nil {
+			return nil, err
+		}
+		if err := budget.consume(len(response.Key)); err != nil {
+			return nil, err
+		}
+		timers = append(timers, timer)
+	}
+	return timers, nil
+}
+
+// GetSLATimer returns the timer of a workflow step
+func (q *QueryContract) GetSLATimer(ctx contractapi.TransactionContextInterface, subject, step string) (*SLATimer, error) {
+	q.logger(ctx).Info().Str("function", "GetSLATimer").Str("subject", subject).Str("step", step).Msg("Reading SLA timer")
+
+	timer, err := getSLATimer(ctx, subject, step)
+	if err != nil {
+		return nil, err
+	}
+	if timer == nil {
+		return nil, fmt.Errorf("no SLA timer for step %s on %s", step, subject)
+	}
+	return timer, nil
+}
+
+// getSLATimer reads the timer of a workflow step, returning nil when there is none
+func getSLATimer(ctx contractapi.TransactionContextInterface, subject, step string) (*SLATimer, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(slaIndex, []string{subject, step})
+	if err != nil {
+		return nil, err
+	}
+	timerBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SLA timer: %v", err)
+	}
+	if timerBytes == nil {
+		return nil, nil
+	}
+	var timer SLATimer
+	if err := json.Unmarshal(timerBytes, &timer); err != nil {
+		return nil, err
+	}
+	return &timer, nil
+}
+
+// putSLATimer stores a timer and its status index entry
+func putSLATimer(ctx contractapi.TransactionContextInterface, timer *SLATimer) error {
+	key, err := ctx.GetStub().CreateCompositeKey(slaIndex, []string{timer.Subject, timer.Step})
+	if err != nil {
+		return err
+	}
+	timerBytes, err := json.Marshal(timer)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, timerBytes); err != nil {
+		return err
+	}
+	statusKey, err := slaStatusKey(ctx, timer)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(statusKey, []byte{0x00})
+}
+
+// delSLAStatusKey removes the status index entry of a timer before its status changes
+func delSLAStatusKey(ctx contractapi.TransactionContextInterface, timer *SLATimer) error {
+	statusKey, err := slaStatusKey(ctx, timer)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().DelState(statusKey)
+}
+
+func slaStatusKey(ctx contractapi.TransactionContextInterface, timer *SLATimer) (string, error) {
+	deadline := timer.Deadline.UTC().Format(slaDeadlineLayout)
+	return ctx.GetStub().CreateCompositeKey(slaStatusIndex, []string{timer.Status, deadline, timer.Subject, timer.Step})
+}
@@ -0,0 +1,66 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSLABreaches tests that overdue timers are flagged in deadline order and reported by status
+func TestSLABreaches(t *testing.T) {
+	start := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	qc := &QueryContract{}
+
+	cc := NewAssetContract(WithClock(fixedClock(start)))
+	_, err := cc.StartSLA(ctx, "asset1", "approval", "2h")
+	require.NoError(t, err)
+	_, err = cc.StartSLA(ctx, "asset2", "approval", "1h")
+	require.NoError(t, err)
+	_, err = cc.StartSLA(ctx, "asset3", "approval", "1bd")
+	require.NoError(t, err)
+	_, err = cc.StartSLA(ctx, "asset1", "approval", "1h")
+	assert.Error(t, err)
+	_, err = cc.StartSLA(ctx, "asset4", "approval", "-1h")
+	assert.Error(t, err)
+
+	cc = NewAssetContract(WithClock(fixedClock(start.Add(3 * time.Hour))))
+	report, err := cc.CheckSLABreaches(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, report.Breached, 1)
+	assert.Equal(t, "asset2", report.Breached[0].Subject)
+	assert.True(t, report.More)
+	event := <-stub.ChaincodeEventsChannel
+	assert.Equal(t, "SLABreached", event.EventName)
+
+	report, err = cc.CheckSLABreaches(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, report.Breached, 1)
+	assert.Equal(t, "asset1", report.Breached[0].Subject)
+	assert.False(t, report.More)
+	<-stub.ChaincodeEventsChannel
+
+	timer, err := cc.CompleteSLA(ctx, "asset1", "approval")
+	require.NoError(t, err)
+	assert.Equal(t, SLAStatusCompleted, timer.Status)
+	assert.False(t, timer.BreachedAt.IsZero())
+
+	open, err := qc.GetSLATimersByStatus(ctx, SLAStatusOpen)
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	assert.Equal(t, "asset3", open[0].Subject)
+	timerBytes, err := json.Marshal(open[0])
+	require.NoError(t, err)
+	assert.NotContains(t, string(timerBytes), "breachedAt")
+	assert.NotContains(t, string(timerBytes), "completedAt")
+	breached, err := qc.GetSLATimersByStatus(ctx, SLAStatusBreached)
+	require.NoError(t, err)
+	require.Len(t, breached, 1)
+	assert.Equal(t, "asset2", breached[0].Subject)
+
+	_, err = cc.StartSLA(ctx, "asset1", "approval", "1h")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,167 @@
+package chaincode
+
+import (
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/rs/zerolog/log"
+)
+
+// maxSlowLogKeys caps the keys listed in a slow transaction record
+const maxSlowLogKeys = 20
+
+// SlowTransactionChaincode logs a "Slow transaction" record for every invocation that takes
+// longer than a threshold. The record lists the state the invocation touched: the keys it read
+// and wrote, how many keys its range and rich queries scanned and the queries themselves, which
+// is usually enough to spot a rich query missing its CouchDB index.
+type SlowTransactionChaincode struct {
+	shim.Chaincode
+	threshold time.Duration
+}
+
+// NewSlowTransactionChaincode wraps a chaincode with slow transaction logging. A threshold of
+// zero or less disables it.
+func NewSlowTransactionChaincode(cc shim.Chaincode, threshold time.Duration) shim.Chaincode {
+	if threshold <= 0 {
+		return cc
+	}
+	return &SlowTransactionChaincode{Chaincode: cc, threshold: threshold}
+}
+
+// Init forwards to the wrapped chaincode and logs the call if it was slow
+func (s *SlowTransactionChaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return s.observe(stub, s.Chaincode.Init)
+}
+
+// Invoke forwards to the wrapped chaincode and logs the call if it was slow
+func (s *SlowTransactionChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	return s.observe(stub, s.Chaincode.Invoke)
+}
+
+func (s *SlowTransactionChaincode) observe(stub shim.ChaincodeStubInterface, call func(shim.ChaincodeStubInterface) peer.Response) peer.Response {
+	tracking := &keyTrackingStub{ChaincodeStubInterface: stub, read: map[string]bool{}, written: map[string]bool{}}
+	started := time.Now()
+	response := call(tracking)
+	duration := time.Since(started)
+	if duration < s.threshold {
+		return response
+	}
+
+	function := ""
+	if args := stub.GetArgs(); len(args) > 0 {
+		function = string(args[0])
+	}
+	log.Warn().
+		Str("function", function).
+		Str("txId", stub.GetTxID()).
+		Dur("duration", duration).
+		Dur("threshold", s.threshold).
+		Int32("status", response.Status).
+		Int("resultSize", len(response.Payload)).
+		Int("keysRead", len(tracking.read)).
+		Int("keysWritten", len(tracking.written)).
+		Int("keysScanned", tracking.scanned).
+		Strs("readKeys", sampleKeys(tracking.read)).
+		Strs("writtenKeys", sampleKeys(tracking.written)).
+		Strs("queries", tracking.queries).
+		Msg("Slow transaction")
+	return response
+}
+
+// sampleKeys returns up to maxSlowLogKeys keys in sorted order
+func sampleKeys(keys map[string]bool) []string {
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	if len(sorted) > maxSlowLogKeys {
+		sorted = sorted[:maxSlowLogKeys]
+	}
+	return sorted
+}
+
+// keyTrackingStub records the keys an invocation reads and writes and the queries it runs.
+// Invocations are handled one at a time per stub, so it needs no locking.
+type keyTrackingStub struct {
+	shim.ChaincodeStubInterface
+	read    map[string]bool
+	written map[string]bool
+	queries []string
+	scanned int
+}
+
+func (s *keyTrackingStub) GetState(key string) ([]byte, error) {
+	s.read[key] = true
+	return s.ChaincodeStubInterface.GetState(key)
+}
+
+func (s *keyTrackingStub) PutState(key string, value []byte) error {
+	s.written[key] = true
+	return s.ChaincodeStubInterface.PutState(key, value)
+}
+
+func (s *keyTrackingStub) DelState(key string) error {
+	s.written[key] = true
+	return s.ChaincodeStubInterface.DelState(key)
+}
+
+func (s *keyTrackingStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	s.queries = append(s.queries, "range ["+startKey+", "+endKey+")")
+	iterator, err := s.ChaincodeStubInterface.GetStateByRange(startKey, endKey)
+	return s.track(iterator), err
+}
+
+func (s *keyTrackingStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	s.queries = append(s.queries, "range ["+startKey+", "+endKey+")")
+	iterator, metadata, err := s.ChaincodeStubInterface.GetStateByRangeWithPagination(startKey, endKey, pageSize, bookmark)
+	return s.track(iterator), metadata, err
+}
+
+func (s *keyTrackingStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	s.queries = append(s.queries, "composite "+objectType)
+	iterator, err := s.ChaincodeStubInterface.GetStateByPartialCompositeKey(objectType, keys)
+	return s.track(iterator), err
+}
+
+func (s *keyTrackingStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	s.queries = append(s.queries, "composite "+objectType)
+	iterator, metadata, err := s.ChaincodeStubInterface.GetStateByPartialCompositeKeyWithPagination(objectType, keys, pageSize, bookmark)
+	return s.track(iterator), metadata, err
+}
+
+func (s *keyTrackingStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	s.queries = append(s.queries, "rich "+query)
+	iterator, err := s.ChaincodeStubInterface.GetQueryResult(query)
+	return s.track(iterator), err
+}
+
+func (s *keyTrackingStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	s.queries = append(s.queries, "rich "+query)
+	iterator, metadata, err := s.ChaincodeStubInterface.GetQueryResultWithPagination(query, pageSize, bookmark)
+	return s.track(iterator), metadata, err
+}
+
+// track counts the keys returned by iterator, which may be nil when the query failed
+func (s *keyTrackingStub) track(iterator shim.StateQueryIteratorInterface) shim.StateQueryIteratorInterface {
+	if iterator == nil {
+		return nil
+	}
+	return &countingIterator{StateQueryIteratorInterface: iterator, stub: s}
+}
+
+type countingIterator struct {
+	shim.StateQueryIteratorInterface
+	stub *keyTrackingStub
+}
+
+func (i *countingIterator) Next() (*queryresult.KV, error) {
+	kv, err := i.StateQueryIteratorInterface.Next()
+	if err == nil {
+		i.stub.scanned++
+	}
+	return kv, err
+}
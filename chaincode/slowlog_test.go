@@ -0,0 +1,79 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sleepingChaincode touches a few keys and takes delay to answer
+type sleepingChaincode struct {
+	delay time.Duration
+}
+
+func (s *sleepingChaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (s *sleepingChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	if _, err := stub.GetState("a"); err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := stub.PutState("b", []byte("1")); err != nil {
+		return shim.Error(err.Error())
+	}
+	iterator, err := stub.GetStateByRange("a", "c")
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	for iterator.HasNext() {
+		if _, err := iterator.Next(); err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	iterator.Close()
+	time.Sleep(s.delay)
+	return shim.Success([]byte("done"))
+}
+
+// TestSlowTransactionChaincode tests that only invocations over the threshold are logged, with the keys they touched
+func TestSlowTransactionChaincode(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = logger }()
+
+	inner := &sleepingChaincode{}
+	cc := NewSlowTransactionChaincode(inner, 20*time.Millisecond)
+	stub := shimtest.NewMockStub("chaincode", cc)
+	stub.MockTransactionStart("tx0")
+	require.NoError(t, stub.PutState("a", []byte("0")))
+	stub.MockTransactionEnd("tx0")
+
+	require.Equal(t, int32(shim.OK), stub.MockInvoke("tx1", [][]byte{[]byte("Fast")}).Status)
+	assert.Empty(t, buf.String())
+
+	inner.delay = 30 * time.Millisecond
+	require.Equal(t, int32(shim.OK), stub.MockInvoke("tx2", [][]byte{[]byte("Slow")}).Status)
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "Slow transaction", record["message"])
+	assert.Equal(t, "Slow", record["function"])
+	assert.Equal(t, "tx2", record["txId"])
+	assert.EqualValues(t, 4, record["resultSize"])
+	assert.Equal(t, []interface{}{"a"}, record["readKeys"])
+	assert.Equal(t, []interface{}{"b"}, record["writtenKeys"])
+	assert.EqualValues(t, 2, record["keysScanned"])
+	assert.Equal(t, []interface{}{"range [a, c)"}, record["queries"])
+
+	assert.Same(t, inner, NewSlowTransactionChaincode(inner, 0), "a zero threshold disables the log")
+}
@@ -0,0 +1,112 @@
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// missingVersion is the version a snapshot records for assets that do not exist, were
+// soft-deleted or are not visible to the caller
+const missingVersion = -1
+
+// SnapshotEntry is an asset of a snapshot with the version it was read at
+type SnapshotEntry struct {
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+	Asset   *Asset `json:"asset,omitempty" metadata:",optional"`
+}
+
+// AssetSnapshot is a set of related assets read together, in the order they were requested
+type AssetSnapshot struct {
+	Entries []SnapshotEntry `json:"entries"`
+}
+
+// Versions returns the read versions in the order of the entries, as ValidateUnchanged takes them
+func (s *AssetSnapshot) Versions() []int {
+	versions := make([]int, len(s.Entries))
+	for i, entry := range s.Entries {
+		versions[i] = entry.Version
+	}
+	return versions
+}
+
+// ReadSnapshot reads a set of related assets with their versions for a client-side
+// read-then-write workflow. Assets that are missing get version -1. The client decides on the
+// snapshot and passes its IDs and versions to AssetContract:ValidateUnchanged, or to a custom
+// transaction calling ValidateUnchanged, in the submit that acts on the decision.
+func (q *QueryContract) ReadSnapshot(ctx contractapi.TransactionContextInterface, assetIDs []string) (*AssetSnapshot, error) {
+	q.logger(ctx).Info().Str("function", "ReadSnapshot").Strs("assetIDs", assetIDs).Msg("Reading asset snapshot")
+
+	if len(assetIDs) == 0 || len(assetIDs) > maxReadAssetsBatch {
+		return nil, fmt.Errorf("between 1 and %d asset IDs must be given", maxReadAssetsBatch)
+	}
+	presenter, err := q.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &AssetSnapshot{Entries: make([]SnapshotEntry, 0, len(assetIDs))}
+	for _, assetID := range assetIDs {
+		asset, err := readSnapshotAsset(ctx, presenter, assetID)
+		if err != nil {
+			return nil, err
+		}
+		entry := SnapshotEntry{ID: assetID, Version: missingVersion}
+		if asset != nil {
+			entry.Version = asset.Version
+			entry.Asset = presenter.present(asset)
+		}
+		snapshot.Entries = append(snapshot.Entries, entry)
+	}
+	return snapshot, nil
+}
+
+// ValidateUnchanged fails with VERSION_CONFLICT unless every asset is still at the version a
+// ReadSnapshot returned for it. Submitted, it also puts the assets in the transaction's read set,
+// so the transaction is invalidated at commit if another one changes them in the meantime.
+func (t *AssetContract) ValidateUnchanged(ctx contractapi.TransactionContextInterface, assetIDs []string, versions []int) error {
+	t.logger(ctx).Info().Str("function", "ValidateUnchanged").Strs("assetIDs", assetIDs).Ints("versions", versions).Msg("Validating asset snapshot")
+	return ValidateUnchanged(ctx, assetIDs, versions)
+}
+
+// ValidateUnchanged checks that every asset is still at the version read by ReadSnapshot, for
+// transactions that write based on a snapshot the client read earlier
+func ValidateUnchanged(ctx contractapi.TransactionContextInterface, assetIDs []string, versions []int) error {
+	if len(assetIDs) != len(versions) {
+		return fmt.Errorf("%d asset IDs but %d versions were given", len(assetIDs), len(versions))
+	}
+	if len(assetIDs) == 0 || len(assetIDs) > maxReadAssetsBatch {
+		return fmt.Errorf("between 1 and %d asset IDs must be given", maxReadAssetsBatch)
+	}
+	presenter, err := newAssetPresenter(ctx, txClock{})
+	if err != nil {
+		return err
+	}
+	for i, assetID := range assetIDs {
+		asset, err := readSnapshotAsset(ctx, presenter, assetID)
+		if err != nil {
+			return err
+		}
+		version := missingVersion
+		if asset != nil {
+			version = asset.Version
+		}
+		if version != versions[i] {
+			return newChaincodeError(ErrCodeVersionConflict, "asset %s is at version %d, the snapshot read version %d", assetID, version, versions[i])
+		}
+	}
+	return nil
+}
+
+// readSnapshotAsset reads an asset as the caller sees it, nil when it is missing, soft-deleted
+// or not visible to the caller
+func readSnapshotAsset(ctx contractapi.TransactionContextInterface, presenter *assetPresenter, assetID string) (*Asset, error) {
+	asset, err := readCommittedAsset(ctx, assetID)
+	if err != nil || asset == nil {
+		return nil, err
+	}
+	if asset.Deleted || !presenter.visible(asset) {
+		return nil, nil
+	}
+	return asset, nil
+}
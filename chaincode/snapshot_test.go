@@ -0,0 +1,40 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReadSnapshot tests that a snapshot validates until one of its assets changes
+func TestReadSnapshot(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	ac := NewAssetContract()
+	qc := NewQueryContract()
+	require.NoError(t, ac.CreateAsset(ctx, "asset1", "blue", 5, "user1", 100))
+	require.NoError(t, ac.CreateAsset(ctx, "asset2", "red", 5, "user1", 200))
+
+	stub.MockTransactionStart("tx2")
+	snapshot, err := qc.ReadSnapshot(ctx, []string{"asset1", "asset2", "asset3"})
+	require.NoError(t, err)
+	require.Len(t, snapshot.Entries, 3)
+	assert.Equal(t, "asset1", snapshot.Entries[0].Asset.ID)
+	assert.Equal(t, []int{1, 1, missingVersion}, snapshot.Versions())
+	assert.Nil(t, snapshot.Entries[2].Asset)
+
+	ids := []string{"asset1", "asset2", "asset3"}
+	require.NoError(t, ac.ValidateUnchanged(ctx, ids, snapshot.Versions()))
+	assert.ErrorContains(t, ac.ValidateUnchanged(ctx, ids, []int{1, 1}), "3 asset IDs but 2 versions")
+
+	require.NoError(t, ac.TransferAsset(ctx, "asset2", "user2", 0))
+	stub.MockTransactionStart("tx3")
+	err = ac.ValidateUnchanged(ctx, ids, snapshot.Versions())
+	assert.ErrorContains(t, err, ErrCodeVersionConflict)
+	assert.ErrorContains(t, err, "asset asset2 is at version 2")
+
+	require.NoError(t, ac.CreateAsset(ctx, "asset3", "green", 5, "user1", 300))
+	stub.MockTransactionStart("tx4")
+	err = ValidateUnchanged(ctx, []string{"asset3"}, []int{missingVersion})
+	assert.ErrorContains(t, err, ErrCodeVersionConflict)
+}
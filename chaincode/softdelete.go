@@ -0,0 +1,106 @@
+package chaincode
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// tombstoneAsset marks an asset deleted in place. It keeps its fields and ID for RestoreAsset,
+// but loses its index entries so queries no longer find it.
+func (t *AssetContract) tombstoneAsset(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	now, err := t.now(ctx)
+	if err != nil {
+		return err
+	}
+	tombstone := *asset
+	tombstone.Deleted = true
+	tombstone.DeletedAt = now
+	return t.saveAsset(ctx, &tombstone)
+}
+
+// RestoreAsset brings back an asset soft-deleted by DeleteAsset, with its index entries
+func (t *AssetContract) RestoreAsset(ctx contractapi.TransactionContextInterface, assetID string) error {
+	t.logger(ctx).Info().Str("function", "RestoreAsset").Str("assetID", assetID).Msg("Restoring deleted asset")
+
+	if _, err := assertOrgCanWrite(ctx); err != nil {
+		return err
+	}
+	asset, err := getTombstone(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	asset.Deleted = false
+	asset.DeletedAt = time.Time{}
+	if err := t.saveAsset(ctx, asset); err != nil {
+		return err
+	}
+	if err := t.emitAssetEvent(ctx, EventAssetRestored, assetID); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("assetID", assetID).Msg("Asset restored successfully")
+	return nil
+}
+
+// PurgeAsset permanently removes an asset soft-deleted by DeleteAsset. Its history stays on the
+// ledger as for any deleted key.
+func (t *AssetContract) PurgeAsset(ctx contractapi.TransactionContextInterface, assetID string) error {
+	t.logger(ctx).Info().Str("function", "PurgeAsset").Str("assetID", assetID).Msg("Purging deleted asset")
+
+	if _, err := assertOrgCanWrite(ctx); err != nil {
+		return err
+	}
+	if _, err := getTombstone(ctx, assetID); err != nil {
+		return err
+	}
+	if err := t.removeAsset(ctx, assetID); err != nil {
+		return err
+	}
+	if err := t.emitAssetEvent(ctx, EventAssetPurged, assetID); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("assetID", assetID).Msg("Asset purged successfully")
+	return nil
+}
+
+// ReadDeletedAsset returns the tombstone of a soft-deleted asset, e.g. to review it before
+// RestoreAsset or PurgeAsset
+func (q *QueryContract) ReadDeletedAsset(ctx contractapi.TransactionContextInterface, assetID string) (*Asset, error) {
+	q.logger(ctx).Info().Str("function", "ReadDeletedAsset").Str("assetID", assetID).Msg("Reading deleted asset")
+
+	asset, err := getTombstone(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	presenter, err := q.presenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !presenter.visible(asset) {
+		return nil, denyAccess(ctx, "data residency", "asset %s is restricted to region %s", assetID, asset.Residency)
+	}
+	return presenter.present(asset), nil
+}
+
+// getTombstone reads an asset that must be soft-deleted
+func getTombstone(ctx contractapi.TransactionContextInterface, assetID string) (*Asset, error) {
+	assetBytes, err := ctx.GetStub().GetState(assetKey(assetID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset %s: %v", assetID, err)
+	}
+	if assetBytes == nil {
+		return nil, fmt.Errorf("asset %s does not exist", assetID)
+	}
+	asset, err := decodeAsset(assetBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !asset.Deleted {
+		return nil, fmt.Errorf("asset %s is not deleted", assetID)
+	}
+	return asset, nil
+}
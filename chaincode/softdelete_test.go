@@ -0,0 +1,60 @@
+package chaincode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSoftDeleteLifecycle tests that deleted assets are hidden until restored and gone once purged
+func TestSoftDeleteLifecycle(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	now := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	cc := NewAssetContract(WithSoftDelete(), WithClock(fixedClock(now)))
+	qc := NewQueryContract(WithSoftDelete())
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "blue", 5, "John", 100))
+	colorKey, err := stub.CreateCompositeKey(index, []string{"blue", "asset1"})
+	require.NoError(t, err)
+
+	require.NoError(t, cc.DeleteAsset(ctx, "asset1"))
+	_, err = qc.ReadAsset(ctx, "asset1")
+	assert.ErrorContains(t, err, "does not exist")
+	assets, err := qc.GetAssetsByRange(ctx, "asset1", "asset3")
+	require.NoError(t, err)
+	require.Len(t, assets, 1)
+	assert.Equal(t, "asset2", assets[0].ID)
+	assert.NotContains(t, stub.State, colorKey)
+	assert.Error(t, cc.CreateAsset(ctx, "asset1", "red", 5, "Jane", 100), "a tombstone keeps its ID")
+
+	tombstone, err := qc.ReadDeletedAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.True(t, tombstone.Deleted)
+	assert.Equal(t, now, tombstone.DeletedAt)
+
+	require.NoError(t, cc.RestoreAsset(ctx, "asset1"))
+	asset, err := qc.ReadAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.False(t, asset.Deleted)
+	assert.Equal(t, "John", asset.Owner)
+	assert.Contains(t, stub.State, colorKey)
+	assert.Error(t, cc.RestoreAsset(ctx, "asset1"), "only deleted assets can be restored")
+	assert.Error(t, cc.PurgeAsset(ctx, "asset1"), "only deleted assets can be purged")
+
+	require.NoError(t, cc.DeleteAsset(ctx, "asset1"))
+	require.NoError(t, cc.PurgeAsset(ctx, "asset1"))
+	assert.NotContains(t, stub.State, "asset1")
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "red", 5, "Jane", 100))
+}
+
+// TestHardDeleteByDefault tests that without soft delete DeleteAsset removes the asset
+func TestHardDeleteByDefault(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "John", 100))
+	require.NoError(t, cc.DeleteAsset(ctx, "asset1"))
+	assert.NotContains(t, stub.State, "asset1")
+	assert.Error(t, cc.RestoreAsset(ctx, "asset1"))
+}
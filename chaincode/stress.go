@@ -0,0 +1,148 @@
+//go:build stress
+
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// The functions in this file generate load for performance tests on dev networks. They are only
+// compiled with the stress build tag (go build -tags stress) and never ship in production builds.
+
+const (
+	// stressAssetPrefix prefixes the IDs of generated assets, followed by the seed
+	stressAssetPrefix = "stress"
+	// maxStressBatch caps the assets or operations of one stress transaction
+	maxStressBatch = 1000
+)
+
+var (
+	stressColors = []string{"blue", "red", "green", "yellow", "black", "white"}
+	stressOwners = []string{"Tomoko", "Brad", "Jin Soo", "Max", "Adriana", "Michel"}
+)
+
+// WorkloadReport counts the operations a RandomizedWorkload transaction performed
+type WorkloadReport struct {
+	Seed        string `json:"seed"`
+	Created     int    `json:"created"`
+	Read        int    `json:"read"`
+	Updated     int    `json:"updated"`
+	Transferred int    `json:"transferred"`
+	Deleted     int    `json:"deleted"`
+}
+
+// GenerateAssets creates n assets with IDs stress-<seed>-<index> and fields derived from seed,
+// so the same seed produces the same data on every network
+func (t *AssetContract) GenerateAssets(ctx contractapi.TransactionContextInterface, n int, seed string) ([]string, error) {
+	t.logger(ctx).Info().Str("function", "GenerateAssets").Int("n", n).Str("seed", seed).Msg("Generating stress assets")
+
+	if n <= 0 || n > maxStressBatch {
+		return nil, fmt.Errorf("n must be between 1 and %d", maxStressBatch)
+	}
+	random := newSeededRandom(stressAssetPrefix + "\x00" + seed)
+	assetIDs := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		assetID := fmt.Sprintf("%s-%s-%06d", stressAssetPrefix, seed, i)
+		if err := t.createStressAsset(ctx, random, assetID); err != nil {
+			return nil, err
+		}
+		assetIDs = append(assetIDs, assetID)
+	}
+
+	t.logger(ctx).Info().Int("n", n).Str("seed", seed).Msg("Stress assets generated")
+	return assetIDs, nil
+}
+
+// RandomizedWorkload runs ops random creates, reads, updates, transfers and deletes over the
+// assets generated with seed. The sequence of operations only depends on seed and the assets
+// committed so far. Since a transaction does not read its own writes, each existing asset is
+// written at most once and created assets are only touched by later transactions.
+func (t *AssetContract) RandomizedWorkload(ctx contractapi.TransactionContextInterface, ops int, seed string) (*WorkloadReport, error) {
+	t.logger(ctx).Info().Str("function", "RandomizedWorkload").Int("ops", ops).Str("seed", seed).Msg("Running randomized workload")
+
+	if ops <= 0 || ops > maxStressBatch {
+		return nil, fmt.Errorf("ops must be between 1 and %d", maxStressBatch)
+	}
+	assetIDs, err := stressAssetIDs(ctx, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	random := newSeededRandom(fmt.Sprintf("%s\x00%s\x00%d", stressAssetPrefix, seed, len(assetIDs)))
+	report := &WorkloadReport{Seed: seed}
+	txID := ctx.GetStub().GetTxID()
+	for i := 0; i < ops; i++ {
+		op := random.Intn(5)
+		if len(assetIDs) == 0 {
+			op = 0
+		}
+		if op == 0 {
+			// Created IDs embed the transaction ID so repeated workloads never collide
+			if err := t.createStressAsset(ctx, random, fmt.Sprintf("%s-%s-%.8s-%06d", stressAssetPrefix, seed, txID, i)); err != nil {
+				return nil, err
+			}
+			report.Created++
+			continue
+		}
+
+		pick := random.Intn(len(assetIDs))
+		assetID := assetIDs[pick]
+		if op == 1 {
+			if _, err := getAsset(ctx, assetID); err != nil {
+				return nil, err
+			}
+			report.Read++
+			continue
+		}
+		assetIDs = append(assetIDs[:pick], assetIDs[pick+1:]...)
+		switch op {
+		case 2:
+			err = t.UpdateAsset(ctx, assetID, stressColors[random.Intn(len(stressColors))], 1+random.Intn(20), stressOwners[random.Intn(len(stressOwners))], 100+random.Intn(900), 0)
+			report.Updated++
+		case 3:
+			err = t.TransferAsset(ctx, assetID, stressOwners[random.Intn(len(stressOwners))], 0)
+			report.Transferred++
+		default:
+			err = t.DeleteAsset(ctx, assetID)
+			report.Deleted++
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t.logger(ctx).Info().Str("seed", seed).Int("created", report.Created).Int("read", report.Read).Int("updated", report.Updated).
+		Int("transferred", report.Transferred).Int("deleted", report.Deleted).Msg("Randomized workload completed")
+	return report, nil
+}
+
+// createStressAsset creates an asset with fields drawn from random
+func (t *AssetContract) createStressAsset(ctx contractapi.TransactionContextInterface, random *txRandom, assetID string) error {
+	return t.CreateAsset(ctx, assetID,
+		stressColors[random.Intn(len(stressColors))],
+		1+random.Intn(20),
+		stressOwners[random.Intn(len(stressOwners))],
+		100+random.Intn(900))
+}
+
+// stressAssetIDs returns the IDs of the committed assets generated with seed, in key order
+func stressAssetIDs(ctx contractapi.TransactionContextInterface, seed string) ([]string, error) {
+	prefix := fmt.Sprintf("%s-%s-", stressAssetPrefix, seed)
+	iterator, err := ctx.GetStub().GetStateByRange(assetKey(prefix), prefixRangeEnd(assetKey(prefix)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stress assets: %v", err)
+	}
+	defer iterator.Close()
+
+	assetIDs := []string{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		assetIDs = append(assetIDs, assetIDFromKey(response.Key))
+	}
+	return assetIDs, nil
+}
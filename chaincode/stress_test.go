@@ -0,0 +1,53 @@
+//go:build stress
+
+package chaincode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGenerateAssets tests that the same seed generates the same assets
+func TestGenerateAssets(t *testing.T) {
+	ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	// The timestamps of the two stubs differ, the fixed clock keeps the records comparable
+	cc := NewAssetContract(WithClock(fixedClock(time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC))))
+	assetIDs, err := cc.GenerateAssets(ctx, 20, "a")
+	require.NoError(t, err)
+	require.Len(t, assetIDs, 20)
+	assert.Equal(t, "stress-a-000000", assetIDs[0])
+
+	other, _ := newTestContext(t, "Org1MSP", "user1", nil)
+	_, err = cc.GenerateAssets(other, 20, "a")
+	require.NoError(t, err)
+	for _, assetID := range assetIDs {
+		first, err := getAsset(ctx, assetID)
+		require.NoError(t, err)
+		second, err := getAsset(other, assetID)
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+	}
+
+	_, err = cc.GenerateAssets(ctx, maxStressBatch+1, "a")
+	assert.Error(t, err)
+}
+
+// TestRandomizedWorkload tests that a workload over generated assets is reproducible
+func TestRandomizedWorkload(t *testing.T) {
+	run := func() *WorkloadReport {
+		ctx, _ := newTestContext(t, "Org1MSP", "user1", nil)
+		cc := &AssetContract{}
+		_, err := cc.GenerateAssets(ctx, 50, "w")
+		require.NoError(t, err)
+		report, err := cc.RandomizedWorkload(ctx, 40, "w")
+		require.NoError(t, err)
+		return report
+	}
+
+	report := run()
+	assert.Equal(t, 40, report.Created+report.Read+report.Updated+report.Transferred+report.Deleted)
+	assert.Equal(t, report, run())
+}
@@ -0,0 +1,135 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// subscriptionIndex keys the identities watching an asset
+	subscriptionIndex = "subscription~asset~msp~identity"
+	// maxEventSubscribers caps the subscribers listed in one asset event; SubscriberCount
+	// always gives the total
+	maxEventSubscribers = 100
+)
+
+// Subscription records an identity watching an asset for changes
+type Subscription struct {
+	AssetID      string    `json:"assetId"`
+	MSPID        string    `json:"mspId"`
+	Subscriber   string    `json:"subscriber"`
+	SubscribedAt time.Time `json:"subscribedAt"`
+	TxID         string    `json:"txId"`
+}
+
+// Subscribe records the caller as a watcher of an asset. Asset events then list the caller
+// as "<mspId>:<subscriber>" so off-chain services can route notifications. Subscribing again
+// refreshes the subscription.
+func (t *AssetContract) Subscribe(ctx contractapi.TransactionContextInterface, assetID string) (*Subscription, error) {
+	t.logger(ctx).Info().Str("function", "Subscribe").Str("assetID", assetID).Msg("Subscribing to asset")
+
+	if _, err := getAsset(ctx, assetID); err != nil {
+		return nil, err
+	}
+	mspID, subscriber, err := getCallerSubmitter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := t.now(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := &Subscription{AssetID: assetID, MSPID: mspID, Subscriber: subscriber, SubscribedAt: now, TxID: ctx.GetStub().GetTxID()}
+	key, err := ctx.GetStub().CreateCompositeKey(subscriptionIndex, []string{assetID, mspID, subscriber})
+	if err != nil {
+		return nil, err
+	}
+	subscriptionBytes, err := json.Marshal(subscription)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(key, subscriptionBytes); err != nil {
+		return nil, fmt.Errorf("failed to store subscription: %v", err)
+	}
+	return subscription, nil
+}
+
+// Unsubscribe removes the caller's subscription to an asset
+func (t *AssetContract) Unsubscribe(ctx contractapi.TransactionContextInterface, assetID string) error {
+	t.logger(ctx).Info().Str("function", "Unsubscribe").Str("assetID", assetID).Msg("Unsubscribing from asset")
+
+	mspID, subscriber, err := getCallerSubmitter(ctx)
+	if err != nil {
+		return err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(subscriptionIndex, []string{assetID, mspID, subscriber})
+	if err != nil {
+		return err
+	}
+	subscriptionBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read subscription: %v", err)
+	}
+	if subscriptionBytes == nil {
+		return fmt.Errorf("%s is not subscribed to asset %s", subscriber, assetID)
+	}
+	return ctx.GetStub().DelState(key)
+}
+
+// GetSubscriptions returns the subscriptions to an asset
+func (q *QueryContract) GetSubscriptions(ctx contractapi.TransactionContextInterface, assetID string) ([]*Subscription, error) {
+	q.logger(ctx).Info().Str("function", "GetSubscriptions").Str("assetID", assetID).Msg("Reading asset subscriptions")
+	return getSubscriptions(ctx, assetID)
+}
+
+// getSubscriptions returns the subscriptions to an asset in key order
+func getSubscriptions(ctx contractapi.TransactionContextInterface, assetID string) ([]*Subscription, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(subscriptionIndex, []string{assetID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subscriptions of asset %s: %v", assetID, err)
+	}
+	defer iterator.Close()
+
+	subscriptions := []*Subscription{}
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var subscription Subscription
+		if err := json.Unmarshal(response.Value, &subscription); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, &subscription)
+	}
+	return subscriptions, nil
+}
+
+// eventSubscribers returns the sorted, distinct "<mspId>:<subscriber>" watchers of the assets,
+// capped at maxEventSubscribers, and their total count
+func eventSubscribers(ctx contractapi.TransactionContextInterface, assetIDs []string) ([]string, int, error) {
+	seen := map[string]bool{}
+	for _, assetID := range assetIDs {
+		subscriptions, err := getSubscriptions(ctx, assetID)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, subscription := range subscriptions {
+			seen[subscription.MSPID+":"+subscription.Subscriber] = true
+		}
+	}
+	subscribers := make([]string, 0, len(seen))
+	for subscriber := range seen {
+		subscribers = append(subscribers, subscriber)
+	}
+	sort.Strings(subscribers)
+	if len(subscribers) > maxEventSubscribers {
+		return subscribers[:maxEventSubscribers], len(subscribers), nil
+	}
+	return subscribers, len(subscribers), nil
+}
@@ -0,0 +1,41 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSubscriptions tests that asset events name the identities subscribed to the asset
+func TestSubscriptions(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "user1", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "user1", 100))
+	_, err := cc.Subscribe(ctx, "missing")
+	assert.Error(t, err)
+
+	_, err = cc.Subscribe(ctx, "asset1")
+	require.NoError(t, err)
+	switchIdentity(t, ctx, stub, "Org2MSP", "auditor7", nil)
+	_, err = cc.Subscribe(ctx, "asset1")
+	require.NoError(t, err)
+	subscriptions, err := qc.GetSubscriptions(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Len(t, subscriptions, 2)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "user1", nil)
+	require.NoError(t, cc.TransferAsset(ctx, "asset1", "Jane", 0))
+	var event AssetEvent
+	require.NoError(t, json.Unmarshal(lastEvent(t, stub).Payload, &event))
+	assert.Equal(t, []string{"Org1MSP:user1", "Org2MSP:auditor7"}, event.Subscribers)
+	assert.Equal(t, 2, event.SubscriberCount)
+
+	require.NoError(t, cc.Unsubscribe(ctx, "asset1"))
+	assert.Error(t, cc.Unsubscribe(ctx, "asset1"))
+	require.NoError(t, cc.UpdateAsset(ctx, "asset1", "red", 5, "Jane", 100, 0))
+	require.NoError(t, json.Unmarshal(lastEvent(t, stub).Payload, &event))
+	assert.Equal(t, []string{"Org2MSP:auditor7"}, event.Subscribers)
+}
@@ -0,0 +1,309 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// swapAction is the consent action recorded by ApproveSwap, with the swap ID as subject
+const swapAction = "swap"
+
+// swapTerms are the consent terms of a swap. AssetDigests pins the state of every asset at
+// consent time so later edits void the consent.
+type swapTerms struct {
+	Give         []string          `json:"give"`
+	Receive      []string          `json:"receive"`
+	AssetDigests map[string]string `json:"assetDigests"`
+}
+
+// ApproveSwap records the consent of the caller's account to give the assets in give in exchange
+// for the assets in receive. The caller must own every asset being given.
+func (t *AssetContract) ApproveSwap(ctx contractapi.TransactionContextInterface, swapID string, give []string, receive []string) error {
+	t.logger(ctx).Info().Str("function", "ApproveSwap").Str("swapID", swapID).Strs("give", give).Strs("receive", receive).Msg("Approving asset swap")
+
+	if swapID == "" || len(give) == 0 || len(receive) == 0 {
+		return fmt.Errorf("swapID, give and receive must not be empty")
+	}
+	grantor, err := getCallerAccount(ctx)
+	if err != nil {
+		return err
+	}
+
+	digests := map[string]string{}
+	for _, assetID := range append(append([]string{}, give...), receive...) {
+		if _, duplicate := digests[assetID]; duplicate {
+			return fmt.Errorf("asset %s appears more than once in swap %s", assetID, swapID)
+		}
+		digest, err := assetDigest(ctx, assetID)
+		if err != nil {
+			return err
+		}
+		digests[assetID] = digest
+	}
+	for _, assetID := range give {
+		asset, err := getAsset(ctx, assetID)
+		if err != nil {
+			return err
+		}
+		if !heldByAccount(asset, grantor) {
+			return denyAccess(ctx, "asset owner", "asset %s is not owned by %s", assetID, grantor)
+		}
+	}
+
+	terms, err := json.Marshal(swapTerms{
+		Give:         sortedCopy(give),
+		Receive:      sortedCopy(receive),
+		AssetDigests: digests,
+	})
+	if err != nil {
+		return err
+	}
+	now, err := t.now(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := createConsent(ctx, now, grantor, swapID, swapAction, string(terms), time.Time{}); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("swapID", swapID).Str("grantor", grantor).Msg("Swap consent recorded")
+	return nil
+}
+
+// SwapAssets atomically exchanges the ownership of two asset bundles. The owners are accounts
+// "<MSP ID>:<enrollment ID>", or enrollment IDs of the caller's organization. Both must have
+// approved exactly these bundles with ApproveSwap, and no asset may have changed since.
+func (t *AssetContract) SwapAssets(ctx contractapi.TransactionContextInterface, swapID string, assetIDsA []string, ownerA string, assetIDsB []string, ownerB string) error {
+	t.logger(ctx).Info().
+		Str("function", "SwapAssets").
+		Str("swapID", swapID).
+		Strs("assetIDsA", assetIDsA).
+		Str("ownerA", ownerA).
+		Strs("assetIDsB", assetIDsB).
+		Str("ownerB", ownerB).
+		Msg("Swapping asset bundles")
+
+	if _, err := assertOrgCanWrite(ctx); err != nil {
+		return err
+	}
+	var err error
+	if ownerA, err = resolveAccount(ctx, ownerA); err != nil {
+		return err
+	}
+	if ownerB, err = resolveAccount(ctx, ownerB); err != nil {
+		return err
+	}
+	if ownerA == ownerB {
+		return fmt.Errorf("cannot swap assets between the same owner")
+	}
+
+	now, err := t.now(ctx)
+	if err != nil {
+		return err
+	}
+	consentA, termsA, err := getSwapConsent(ctx, now, swapID, ownerA)
+	if err != nil {
+		return err
+	}
+	consentB, termsB, err := getSwapConsent(ctx, now, swapID, ownerB)
+	if err != nil {
+		return err
+	}
+	if !sameIDs(termsA.Give, assetIDsA) || !sameIDs(termsA.Receive, assetIDsB) {
+		return fmt.Errorf("swap %s does not match the consent of %s", swapID, ownerA)
+	}
+	if !sameIDs(termsB.Give, assetIDsB) || !sameIDs(termsB.Receive, assetIDsA) {
+		return fmt.Errorf("swap %s does not match the consent of %s", swapID, ownerB)
+	}
+
+	for _, terms := range []*swapTerms{termsA, termsB} {
+		if err := t.checkSwapDigests(ctx, swapID, terms); err != nil {
+			return err
+		}
+	}
+
+	if err := t.swapBundle(ctx, assetIDsA, ownerA, ownerB); err != nil {
+		return err
+	}
+	if err := t.swapBundle(ctx, assetIDsB, ownerB, ownerA); err != nil {
+		return err
+	}
+
+	for _, consent := range []*Consent{consentA, consentB} {
+		if err := deleteConsent(ctx, consent); err != nil {
+			return err
+		}
+	}
+
+	swapped := append(append([]string{}, assetIDsA...), assetIDsB...)
+	if err := t.emitAssetEvent(ctx, EventAssetTransferred, swapped...); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("swapID", swapID).Str("ownerA", ownerA).Str("ownerB", ownerB).Msg("Asset swap completed successfully")
+	return nil
+}
+
+// SwapAssetPair atomically exchanges the owners of two assets. The caller must own one of them
+// and the owner of the other must have approved the exchange with ApproveSwap under the swap ID
+// PairSwapID(assetA, assetB), giving their asset for the caller's.
+func (t *AssetContract) SwapAssetPair(ctx contractapi.TransactionContextInterface, assetA, assetB string) error {
+	t.logger(ctx).Info().Str("function", "SwapAssetPair").Str("assetA", assetA).Str("assetB", assetB).Msg("Swapping asset pair")
+
+	if _, err := assertOrgCanWrite(ctx); err != nil {
+		return err
+	}
+	if assetA == assetB {
+		return fmt.Errorf("cannot swap asset %s with itself", assetA)
+	}
+	caller, err := getCallerAccount(ctx)
+	if err != nil {
+		return err
+	}
+	mine, err := getAsset(ctx, assetA)
+	if err != nil {
+		return err
+	}
+	theirs, err := getAsset(ctx, assetB)
+	if err != nil {
+		return err
+	}
+	if !heldByAccount(mine, caller) {
+		mine, theirs = theirs, mine
+	}
+	if !heldByAccount(mine, caller) {
+		return denyAccess(ctx, "asset owner", "neither %s nor %s is owned by %s", assetA, assetB, caller)
+	}
+	counterparty := holderAccount(theirs)
+	if counterparty == caller {
+		return fmt.Errorf("cannot swap assets between the same owner")
+	}
+
+	swapID := PairSwapID(assetA, assetB)
+	now, err := t.now(ctx)
+	if err != nil {
+		return err
+	}
+	consent, terms, err := getSwapConsent(ctx, now, swapID, counterparty)
+	if err != nil {
+		return err
+	}
+	if !sameIDs(terms.Give, []string{theirs.ID}) || !sameIDs(terms.Receive, []string{mine.ID}) {
+		return fmt.Errorf("swap %s does not match the consent of %s", swapID, counterparty)
+	}
+	if err := t.checkSwapDigests(ctx, swapID, terms); err != nil {
+		return err
+	}
+
+	if err := t.swapBundle(ctx, []string{mine.ID}, caller, counterparty); err != nil {
+		return err
+	}
+	if err := t.swapBundle(ctx, []string{theirs.ID}, counterparty, caller); err != nil {
+		return err
+	}
+	if err := deleteConsent(ctx, consent); err != nil {
+		return err
+	}
+	if err := t.emitAssetEvent(ctx, EventAssetTransferred, assetA, assetB); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("swapID", swapID).Str("caller", caller).Str("counterparty", counterparty).Msg("Asset pair swap completed successfully")
+	return nil
+}
+
+// PairSwapID returns the swap ID under which the counterparty of SwapAssetPair approves the
+// exchange of two assets. It does not depend on the order of the assets.
+func PairSwapID(assetA, assetB string) string {
+	ids := sortedCopy([]string{assetA, assetB})
+	return "pair~" + ids[0] + "~" + ids[1]
+}
+
+// checkSwapDigests fails when an asset changed since the consent pinned it in terms
+func (t *AssetContract) checkSwapDigests(ctx contractapi.TransactionContextInterface, swapID string, terms *swapTerms) error {
+	for assetID, consentedDigest := range terms.AssetDigests {
+		digest, err := assetDigest(ctx, assetID)
+		if err != nil {
+			return err
+		}
+		if digest != consentedDigest {
+			t.logger(ctx).Warn().Str("swapID", swapID).Str("assetID", assetID).Msg("Asset changed since swap consent")
+			return fmt.Errorf("asset %s changed since consent to swap %s", assetID, swapID)
+		}
+	}
+	return nil
+}
+
+// swapBundle moves every asset of a bundle from the account of its current owner to the account
+// of the counterparty
+func (t *AssetContract) swapBundle(ctx contractapi.TransactionContextInterface, assetIDs []string, from, to string) error {
+	for _, assetID := range assetIDs {
+		asset, err := getAsset(ctx, assetID)
+		if err != nil {
+			return err
+		}
+		if !heldByAccount(asset, from) {
+			return fmt.Errorf("asset %s is not owned by %s", assetID, from)
+		}
+		if asset.Frozen {
+			return fmt.Errorf("asset %s is frozen", assetID)
+		}
+		asset.OwnerMSP, asset.Owner = splitAccount(to)
+		if err := t.saveAsset(ctx, asset); err != nil {
+			t.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to update asset during swap")
+			return err
+		}
+	}
+	return nil
+}
+
+// getSwapConsent loads an active swap consent and decodes its terms
+func getSwapConsent(ctx contractapi.TransactionContextInterface, now time.Time, swapID, grantor string) (*Consent, *swapTerms, error) {
+	consent, err := getActiveConsent(ctx, now, swapID, swapAction, grantor)
+	if err != nil {
+		return nil, nil, err
+	}
+	var terms swapTerms
+	if err := json.Unmarshal([]byte(consent.Terms), &terms); err != nil {
+		return nil, nil, fmt.Errorf("invalid terms in swap consent of %s: %v", grantor, err)
+	}
+	return consent, &terms, nil
+}
+
+// assetDigest returns the SHA-256 of an asset's stored bytes
+func assetDigest(ctx contractapi.TransactionContextInterface, assetID string) (string, error) {
+	assetBytes, err := ctx.GetStub().GetState(assetKey(assetID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get asset %s: %v", assetID, err)
+	}
+	if assetBytes == nil {
+		return "", fmt.Errorf("asset %s does not exist", assetID)
+	}
+	sum := sha256.Sum256(assetBytes)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func sortedCopy(ids []string) []string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func sameIDs(sorted []string, ids []string) bool {
+	other := sortedCopy(ids)
+	if len(sorted) != len(other) {
+		return false
+	}
+	for i := range sorted {
+		if sorted[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
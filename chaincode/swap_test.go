@@ -0,0 +1,84 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSwapAssets tests that consented bundles are exchanged atomically
+func TestSwapAssets(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "alice", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "a1", "blue", 5, "alice", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "a2", "blue", 5, "alice", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "b1", "red", 5, "Org2MSP:bob", 200))
+
+	require.Error(t, cc.ApproveSwap(ctx, "swap1", []string{"b1"}, []string{"a1"}))
+	require.NoError(t, cc.ApproveSwap(ctx, "swap1", []string{"a1", "a2"}, []string{"b1"}))
+	require.Error(t, cc.SwapAssets(ctx, "swap1", []string{"a1", "a2"}, "Org1MSP:alice", []string{"b1"}, "Org2MSP:bob"))
+
+	// A same-named identity of another organization owns nothing here
+	switchIdentity(t, ctx, stub, "Org1MSP", "bob", nil)
+	require.True(t, hasErrorCode(cc.ApproveSwap(ctx, "swap1", []string{"b1"}, []string{"a2", "a1"}), ErrCodeAccessDenied))
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "bob", nil)
+	require.NoError(t, cc.ApproveSwap(ctx, "swap1", []string{"b1"}, []string{"a2", "a1"}))
+	require.Error(t, cc.SwapAssets(ctx, "swap1", []string{"a1"}, "Org1MSP:alice", []string{"b1"}, "Org2MSP:bob"))
+	require.NoError(t, cc.SwapAssets(ctx, "swap1", []string{"a2", "a1"}, "Org1MSP:alice", []string{"b1"}, "Org2MSP:bob"))
+
+	for id, owner := range map[string]string{"a1": "Org2MSP:bob", "a2": "Org2MSP:bob", "b1": "Org1MSP:alice"} {
+		asset, err := qc.ReadAsset(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, owner, holderAccount(asset))
+	}
+}
+
+// TestSwapAssetsRejectsChangedAsset tests that editing an asset after consent voids the swap
+func TestSwapAssetsRejectsChangedAsset(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "alice", nil)
+	cc := &AssetContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "a1", "blue", 5, "alice", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "b1", "red", 5, "Org2MSP:bob", 200))
+	require.NoError(t, cc.ApproveSwap(ctx, "swap1", []string{"a1"}, []string{"b1"}))
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "bob", nil)
+	require.NoError(t, cc.ApproveSwap(ctx, "swap1", []string{"b1"}, []string{"a1"}))
+	require.NoError(t, cc.UpdateAsset(ctx, "b1", "red", 5, "bob", 1, 0))
+
+	err := cc.SwapAssets(ctx, "swap1", []string{"a1"}, "Org1MSP:alice", []string{"b1"}, "Org2MSP:bob")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "changed since")
+}
+
+// TestSwapAssetPair tests that the owner of one asset swaps it against the consent of the other
+func TestSwapAssetPair(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "alice", nil)
+	cc := &AssetContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "a1", "blue", 5, "alice", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "b1", "red", 5, "Org2MSP:bob", 200))
+	assert.Equal(t, PairSwapID("a1", "b1"), PairSwapID("b1", "a1"))
+
+	require.Error(t, cc.SwapAssetPair(ctx, "a1", "b1"))
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "bob", nil)
+	require.NoError(t, cc.ApproveSwap(ctx, PairSwapID("a1", "b1"), []string{"b1"}, []string{"a1"}))
+
+	switchIdentity(t, ctx, stub, "Org3MSP", "mallory", nil)
+	err := cc.SwapAssetPair(ctx, "a1", "b1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "neither a1 nor b1")
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "alice", nil)
+	require.NoError(t, cc.SwapAssetPair(ctx, "b1", "a1"))
+	for id, owner := range map[string]string{"a1": "Org2MSP:bob", "b1": "Org1MSP:alice"} {
+		asset, err := getAsset(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, owner, holderAccount(asset))
+	}
+
+	// The consent is used up by the swap
+	require.Error(t, cc.SwapAssetPair(ctx, "a1", "b1"))
+}
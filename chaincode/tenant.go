@@ -0,0 +1,239 @@
+package chaincode
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// tenantUsageIndex keys the shards of the monthly usage counters of each tenant
+	tenantUsageIndex = "tenantusage~msp~month~metric~shard"
+	// tenantMonthLayout is the layout of billing months, in UTC
+	tenantMonthLayout = "2006-01"
+	// tenantQuotaConfig is the configuration key prefix of the tenant quotas
+	tenantQuotaConfig = "tenantquota"
+
+	tenantTransactions = "transactions"
+	tenantBytes        = "bytes"
+)
+
+// TenantQuota caps the usage of a tenant per month. Zero means unlimited.
+type TenantQuota struct {
+	MaxTransactions uint64 `json:"maxTransactions"`
+	MaxBytes        uint64 `json:"maxBytes"`
+}
+
+// TenantUsage is the usage of a tenant, a member organization identified by its MSP ID, in one
+// billing month
+type TenantUsage struct {
+	MSPID        string      `json:"mspId"`
+	Month        string      `json:"month"`
+	Transactions uint64      `json:"transactions"`
+	BytesWritten uint64      `json:"bytesWritten"`
+	Quota        TenantQuota `json:"quota"`
+}
+
+// tenantCounter is the counter of one metric of a tenant in a month
+func tenantCounter(mspID, month, metric string) *shardedCounter {
+	return &shardedCounter{
+		name:       "tenant:" + mspID + ":" + month + ":" + metric,
+		index:      tenantUsageIndex,
+		attributes: []string{mspID, month, metric},
+	}
+}
+
+// tenantMonth returns the caller's MSP and the billing month of the transaction
+func (r *contractRuntime) tenantMonth(ctx contractapi.TransactionContextInterface) (string, string, error) {
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	now, err := r.now(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return mspID, now.UTC().Format(tenantMonthLayout), nil
+}
+
+// recordTenantTransaction counts the transaction against the caller's organization, after
+// checking its transaction quota when quotas are enforced, and starts metering the bytes the
+// transaction writes. GetTenantUsage is neither counted nor refused, so organizations past
+// their quota can still see their usage.
+func (r *contractRuntime) recordTenantTransaction(ctx contractapi.TransactionContextInterface) error {
+	if function, _ := ctx.GetStub().GetFunctionAndParameters(); strings.HasSuffix(function, "GetTenantUsage") {
+		return nil
+	}
+	mspID, month, err := r.tenantMonth(ctx)
+	if err != nil {
+		return err
+	}
+	counter := tenantCounter(mspID, month, tenantTransactions)
+	if r.tenantQuotas {
+		quota, err := getTenantQuota(ctx, mspID)
+		if err != nil {
+			return err
+		}
+		if err := r.checkTenantQuota(ctx, counter, mspID, month, tenantTransactions, quota.MaxTransactions); err != nil {
+			return err
+		}
+	}
+	if _, _, err := counter.add(ctx, 1); err != nil {
+		return err
+	}
+	if meter, ok := ctx.(writeMeter); ok {
+		meter.meterWrites()
+	}
+	return nil
+}
+
+// recordTenantBytes adds the bytes the transaction wrote to the caller's organization, failing
+// the transaction when its storage quota is used up and quotas are enforced
+func (r *contractRuntime) recordTenantBytes(ctx contractapi.TransactionContextInterface) error {
+	meter, ok := ctx.(writeMeter)
+	if !r.tenantUsage || !ok {
+		return nil
+	}
+	written := meter.meteredBytes()
+	if written == 0 {
+		return nil
+	}
+	mspID, month, err := r.tenantMonth(ctx)
+	if err != nil {
+		return err
+	}
+	counter := tenantCounter(mspID, month, tenantBytes)
+	if r.tenantQuotas {
+		quota, err := getTenantQuota(ctx, mspID)
+		if err != nil {
+			return err
+		}
+		if err := r.checkTenantQuota(ctx, counter, mspID, month, tenantBytes, quota.MaxBytes); err != nil {
+			return err
+		}
+	}
+	_, _, err = counter.add(ctx, written)
+	return err
+}
+
+// checkTenantQuota refuses the transaction when the counter shard it increments holds its share
+// of limit. Summing every shard would make each transaction read all of them, so concurrent
+// transactions of an organization would invalidate each other's read sets. Enforcement is
+// approximate instead: an organization can go past its quota by one transaction per shard, and a
+// transaction landing on a full shard can be refused before the whole quota is used up.
+func (r *contractRuntime) checkTenantQuota(ctx contractapi.TransactionContextInterface, counter *shardedCounter, mspID, month, metric string, limit uint64) error {
+	if limit == 0 {
+		return nil
+	}
+	used, err := counter.current(ctx)
+	if err != nil {
+		return err
+	}
+	if used >= shardLimit(limit) {
+		r.logger(ctx).Warn().Str("mspId", mspID).Str("metric", metric).Uint64("shardUsage", used).Msg("Tenant quota exceeded")
+		return newChaincodeError(ErrCodeQuotaExceeded, "%s used its quota of %d %s in %s", mspID, limit, metric, month)
+	}
+	return nil
+}
+
+// GetTenantUsage returns the transactions and bytes an organization submitted in a month
+// (YYYY-MM, UTC, empty for the current month) together with its quota, for consortium operators
+// billing member organizations. Members may read their own organization; admins and auditors
+// every organization. Usage is only counted when the contracts run with WithTenantUsage.
+func (q *QueryContract) GetTenantUsage(ctx contractapi.TransactionContextInterface, mspID, month string) (*TenantUsage, error) {
+	q.logger(ctx).Info().Str("function", "GetTenantUsage").Str("mspID", mspID).Str("month", month).Msg("Reading tenant usage")
+
+	if mspID == "" {
+		return nil, fmt.Errorf("mspID must not be empty")
+	}
+	if month == "" {
+		now, err := q.now(ctx)
+		if err != nil {
+			return nil, err
+		}
+		month = now.UTC().Format(tenantMonthLayout)
+	} else if _, err := time.Parse(tenantMonthLayout, month); err != nil {
+		return nil, fmt.Errorf("invalid month %q, expected YYYY-MM: %v", month, err)
+	}
+
+	readsAll, err := callerReadsAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !readsAll {
+		callerMSP, err := getCallerMSPID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if callerMSP != mspID {
+			return nil, denyAccess(ctx, "tenant member", "%s members cannot read the usage of %s", callerMSP, mspID)
+		}
+	}
+
+	usage := &TenantUsage{MSPID: mspID, Month: month}
+	if usage.Transactions, err = tenantCounter(mspID, month, tenantTransactions).total(ctx); err != nil {
+		return nil, err
+	}
+	if usage.BytesWritten, err = tenantCounter(mspID, month, tenantBytes).total(ctx); err != nil {
+		return nil, err
+	}
+	quota, err := getTenantQuota(ctx, mspID)
+	if err != nil {
+		return nil, err
+	}
+	usage.Quota = *quota
+	return usage, nil
+}
+
+// SetTenantQuota caps the transactions and bytes written per month of an organization. Zero
+// lifts a limit. Quotas are only enforced when the contracts run with WithTenantQuotas.
+func (c *ConfigContract) SetTenantQuota(ctx contractapi.TransactionContextInterface, mspID string, maxTransactions, maxBytes uint64) error {
+	log.Info().Str("function", "SetTenantQuota").Str("mspID", mspID).Uint64("maxTransactions", maxTransactions).Uint64("maxBytes", maxBytes).Msg("Setting tenant quota")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if mspID == "" {
+		return fmt.Errorf("mspID must not be empty")
+	}
+	quota := &TenantQuota{MaxTransactions: maxTransactions, MaxBytes: maxBytes}
+	if err := putConfig(ctx, quota, tenantQuotaConfig, mspID); err != nil {
+		return err
+	}
+	return recordAudit(ctx, "SetTenantQuota", mspID, fmt.Sprintf("%d transactions, %d bytes", maxTransactions, maxBytes))
+}
+
+// getTenantQuota reads the quota of an organization, unlimited when none was set
+func getTenantQuota(ctx contractapi.TransactionContextInterface, mspID string) (*TenantQuota, error) {
+	quota := &TenantQuota{}
+	if _, err := getConfig(ctx, quota, tenantQuotaConfig, mspID); err != nil {
+		return nil, err
+	}
+	return quota, nil
+}
+
+// writeMeter is implemented by transaction contexts that can count the bytes a transaction writes
+type writeMeter interface {
+	meterWrites()
+	meteredBytes() uint64
+}
+
+// meteringStub counts the size of the keys and values written to world state and private data
+type meteringStub struct {
+	shim.ChaincodeStubInterface
+	written uint64
+}
+
+func (s *meteringStub) PutState(key string, value []byte) error {
+	s.written += uint64(len(key) + len(value))
+	return s.ChaincodeStubInterface.PutState(key, value)
+}
+
+func (s *meteringStub) PutPrivateData(collection, key string, value []byte) error {
+	s.written += uint64(len(key) + len(value))
+	return s.ChaincodeStubInterface.PutPrivateData(collection, key, value)
+}
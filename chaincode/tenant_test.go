@@ -0,0 +1,110 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTenantStub(t *testing.T, opts ...Option) *shimtest.MockStub {
+	t.Helper()
+
+	cc, err := contractapi.NewChaincode(NewAssetContract(opts...), NewQueryContract(opts...), &ConfigContract{})
+	require.NoError(t, err)
+	return shimtest.NewMockStub("chaincode", cc)
+}
+
+func getTenantUsage(t *testing.T, stub *shimtest.MockStub, mspID string) *TenantUsage {
+	t.Helper()
+
+	response := stub.MockInvoke("usage", [][]byte{[]byte("QueryContract:GetTenantUsage"), []byte(mspID), nil})
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	var usage TenantUsage
+	require.NoError(t, json.Unmarshal(response.Payload, &usage))
+	return &usage
+}
+
+// TestTenantUsage tests that transactions and bytes written are counted per organization
+func TestTenantUsage(t *testing.T) {
+	stub := newTenantStub(t, WithTenantUsage())
+
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "user1", nil)
+	for _, id := range []string{"asset1", "asset2"} {
+		status, message := invoke(stub, "create-"+id, "CreateAsset", id, "blue", "5", "user1", "100")
+		require.Equal(t, int32(shim.OK), status, message)
+	}
+	stub.Creator = newSerializedIdentity(t, "Org2MSP", "user2", nil)
+	status, message := invoke(stub, "create-asset3", "CreateAsset", "asset3", "red", "5", "user2", "100")
+	require.Equal(t, int32(shim.OK), status, message)
+
+	usage := getTenantUsage(t, stub, "Org2MSP")
+	assert.Equal(t, time.Now().UTC().Format(tenantMonthLayout), usage.Month)
+	assert.Equal(t, uint64(1), usage.Transactions)
+	assert.Greater(t, usage.BytesWritten, uint64(len("asset3")))
+
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "user1", nil)
+	usage = getTenantUsage(t, stub, "Org1MSP")
+	assert.Equal(t, uint64(2), usage.Transactions)
+	assert.Equal(t, TenantQuota{}, usage.Quota)
+
+	status, message = invoke(stub, "other", "QueryContract:GetTenantUsage", "Org2MSP", "")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, ErrCodeAccessDenied)
+
+	status, message = invoke(stub, "month", "QueryContract:GetTenantUsage", "Org1MSP", "October")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, "expected YYYY-MM")
+}
+
+// TestTenantQuotas tests that organizations past their transaction or storage quota are rejected
+func TestTenantQuotas(t *testing.T) {
+	stub := newTenantStub(t, WithTenantQuotas())
+
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "admin", adminAttrs)
+	for txID, args := range map[string][]string{
+		"quota-org2": {"ConfigContract:SetTenantQuota", "Org2MSP", "16", "0"},
+		"quota-org3": {"ConfigContract:SetTenantQuota", "Org3MSP", "0", "10"},
+	} {
+		status, message := invoke(stub, txID, args...)
+		require.Equal(t, int32(shim.OK), status, message)
+	}
+
+	// Each shard holds a share of the quota, so an organization is refused once the shard of a
+	// transaction is full, after at most one transaction per shard past its quota
+	createUntilRefused := func(mspID, user string) int {
+		stub.Creator = newSerializedIdentity(t, mspID, user, nil)
+		for i := 0; i <= counterShards; i++ {
+			id := fmt.Sprintf("%s-asset%d", mspID, i)
+			status, message := invoke(stub, "create-"+id, "CreateAsset", id, "blue", "5", user, "100")
+			if status != shim.OK {
+				assert.Contains(t, message, ErrCodeQuotaExceeded)
+				return i
+			}
+		}
+		t.Fatalf("%s was never refused", mspID)
+		return 0
+	}
+
+	created := createUntilRefused("Org2MSP", "user2")
+	assert.GreaterOrEqual(t, created, 1)
+	usage := getTenantUsage(t, stub, "Org2MSP")
+	assert.Equal(t, uint64(created), usage.Transactions)
+	assert.Equal(t, TenantQuota{MaxTransactions: 16}, usage.Quota)
+
+	// A share of one byte admits a single transaction per shard, however much it writes
+	created = createUntilRefused("Org3MSP", "user3")
+	assert.GreaterOrEqual(t, created, 1)
+	assert.Greater(t, getTenantUsage(t, stub, "Org3MSP").BytesWritten, uint64(10))
+
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "user1", nil)
+	status, message := invoke(stub, "quota", "ConfigContract:SetTenantQuota", "Org1MSP", "0", "0")
+	assert.Equal(t, int32(shim.ERROR), status)
+	assert.Contains(t, message, ErrCodeAccessDenied)
+}
@@ -0,0 +1,335 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// tokenInfoIndex keys the name, symbol and total supply of the fungible token
+	tokenInfoIndex = "tokeninfo"
+	// balanceIndex keys the token balance of an account
+	balanceIndex = "balance~account"
+	// allowanceIndex keys the amount a spender may transfer from an owner's account
+	allowanceIndex = "allowance~owner~spender"
+)
+
+// TokenContract is a fungible token in the style of ERC-20, registered next to the asset
+// contracts to show a chaincode with several contracts. Accounts are "<MSP ID>:<enrollment ID>",
+// see getCallerAccount. Admins initialize the token and mint; holders transfer, burn and approve
+// spenders from their own account.
+type TokenContract struct {
+	contractapi.Contract
+}
+
+// TokenInfo describes the fungible token
+type TokenInfo struct {
+	Name        string `json:"name"`
+	Symbol      string `json:"symbol"`
+	Decimals    int    `json:"decimals"`
+	TotalSupply int    `json:"totalSupply"`
+}
+
+// TokenTransferEvent is the payload of the TokenTransfer event. From is empty for mints and To
+// for burns.
+type TokenTransferEvent struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Value int    `json:"value"`
+}
+
+// TokenApprovalEvent is the payload of the TokenApproval event
+type TokenApprovalEvent struct {
+	Owner   string `json:"owner"`
+	Spender string `json:"spender"`
+	Value   int    `json:"value"`
+}
+
+// Initialize sets the name, symbol and decimals of the token. It can only run once.
+func (c *TokenContract) Initialize(ctx contractapi.TransactionContextInterface, name, symbol string, decimals int) error {
+	log.Info().Str("function", "Initialize").Str("name", name).Str("symbol", symbol).Int("decimals", decimals).Msg("Initializing token")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if name == "" || symbol == "" || decimals < 0 {
+		return fmt.Errorf("name and symbol must not be empty and decimals must not be negative")
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(tokenInfoIndex, []string{})
+	if err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read token info: %v", err)
+	}
+	if existing != nil {
+		return newChaincodeError(ErrCodeAlreadyInitialized, "token is already initialized")
+	}
+	return putTokenInfo(ctx, &TokenInfo{Name: name, Symbol: symbol, Decimals: decimals})
+}
+
+// Mint creates amount tokens in account
+func (c *TokenContract) Mint(ctx contractapi.TransactionContextInterface, account string, amount int) error {
+	log.Info().Str("function", "Mint").Str("account", account).Int("amount", amount).Msg("Minting tokens")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if err := checkAccount(account); err != nil {
+		return err
+	}
+	info, err := getTokenInfo(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkTokenAmount(amount); err != nil {
+		return err
+	}
+	if info.TotalSupply > math.MaxInt64-amount {
+		return fmt.Errorf("minting %d would overflow the total supply", amount)
+	}
+	if err := addBalance(ctx, account, amount); err != nil {
+		return err
+	}
+	info.TotalSupply += amount
+	if err := putTokenInfo(ctx, info); err != nil {
+		return err
+	}
+	return emitEvent(ctx, EventTokenTransfer, account, &TokenTransferEvent{To: account, Value: amount})
+}
+
+// Burn destroys amount tokens of the caller's account
+func (c *TokenContract) Burn(ctx contractapi.TransactionContextInterface, amount int) error {
+	log.Info().Str("function", "Burn").Int("amount", amount).Msg("Burning tokens")
+
+	info, err := getTokenInfo(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkTokenAmount(amount); err != nil {
+		return err
+	}
+	caller, err := getCallerAccount(ctx)
+	if err != nil {
+		return err
+	}
+	if err := addBalance(ctx, caller, -amount); err != nil {
+		return err
+	}
+	info.TotalSupply -= amount
+	if err := putTokenInfo(ctx, info); err != nil {
+		return err
+	}
+	return emitEvent(ctx, EventTokenTransfer, caller, &TokenTransferEvent{From: caller, Value: amount})
+}
+
+// Transfer moves amount tokens from the caller's account to recipient
+func (c *TokenContract) Transfer(ctx contractapi.TransactionContextInterface, recipient string, amount int) error {
+	log.Info().Str("function", "Transfer").Str("recipient", recipient).Int("amount", amount).Msg("Transferring tokens")
+
+	caller, err := getCallerAccount(ctx)
+	if err != nil {
+		return err
+	}
+	return transferTokens(ctx, caller, recipient, amount)
+}
+
+// Approve lets spender transfer up to amount tokens from the caller's account with
+// TransferFrom, replacing a previous allowance
+func (c *TokenContract) Approve(ctx contractapi.TransactionContextInterface, spender string, amount int) error {
+	log.Info().Str("function", "Approve").Str("spender", spender).Int("amount", amount).Msg("Approving token spender")
+
+	if _, err := getTokenInfo(ctx); err != nil {
+		return err
+	}
+	if err := checkAccount(spender); err != nil {
+		return err
+	}
+	if amount < 0 {
+		return fmt.Errorf("amount must not be negative")
+	}
+	owner, err := getCallerAccount(ctx)
+	if err != nil {
+		return err
+	}
+	if err := putAllowance(ctx, owner, spender, amount); err != nil {
+		return err
+	}
+	return emitEvent(ctx, EventTokenApproval, owner, &TokenApprovalEvent{Owner: owner, Spender: spender, Value: amount})
+}
+
+// TransferFrom moves amount tokens from owner to recipient on behalf of the caller, spending
+// the allowance owner granted the caller
+func (c *TokenContract) TransferFrom(ctx contractapi.TransactionContextInterface, owner, recipient string, amount int) error {
+	log.Info().Str("function", "TransferFrom").Str("owner", owner).Str("recipient", recipient).Int("amount", amount).Msg("Transferring tokens on behalf of owner")
+
+	spender, err := getCallerAccount(ctx)
+	if err != nil {
+		return err
+	}
+	allowance, err := getAllowance(ctx, owner, spender)
+	if err != nil {
+		return err
+	}
+	if allowance < amount {
+		return denyAccess(ctx, "token allowance", "%s may transfer %d tokens of %s, not %d", spender, allowance, owner, amount)
+	}
+	if err := transferTokens(ctx, owner, recipient, amount); err != nil {
+		return err
+	}
+	return putAllowance(ctx, owner, spender, allowance-amount)
+}
+
+// BalanceOf returns the token balance of account
+func (c *TokenContract) BalanceOf(ctx contractapi.TransactionContextInterface, account string) (int, error) {
+	log.Info().Str("function", "BalanceOf").Str("account", account).Msg("Reading token balance")
+	return getBalance(ctx, account)
+}
+
+// Allowance returns the amount spender may still transfer from owner's account
+func (c *TokenContract) Allowance(ctx contractapi.TransactionContextInterface, owner, spender string) (int, error) {
+	log.Info().Str("function", "Allowance").Str("owner", owner).Str("spender", spender).Msg("Reading token allowance")
+	return getAllowance(ctx, owner, spender)
+}
+
+// GetTokenInfo returns the name, symbol, decimals and total supply of the token
+func (c *TokenContract) GetTokenInfo(ctx contractapi.TransactionContextInterface) (*TokenInfo, error) {
+	log.Info().Str("function", "GetTokenInfo").Msg("Reading token info")
+	return getTokenInfo(ctx)
+}
+
+// transferTokens moves amount tokens between two accounts and sets the TokenTransfer event
+func transferTokens(ctx contractapi.TransactionContextInterface, from, to string, amount int) error {
+	if _, err := getTokenInfo(ctx); err != nil {
+		return err
+	}
+	if err := checkTokenAmount(amount); err != nil {
+		return err
+	}
+	if err := checkAccount(to); err != nil {
+		return err
+	}
+	if to == from {
+		return fmt.Errorf("recipient must not be the sender")
+	}
+	if err := addBalance(ctx, from, -amount); err != nil {
+		return err
+	}
+	if err := addBalance(ctx, to, amount); err != nil {
+		return err
+	}
+	return emitEvent(ctx, EventTokenTransfer, from, &TokenTransferEvent{From: from, To: to, Value: amount})
+}
+
+func checkTokenAmount(amount int) error {
+	if amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	return nil
+}
+
+// getTokenInfo reads the token info, failing until Initialize ran
+func getTokenInfo(ctx contractapi.TransactionContextInterface) (*TokenInfo, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(tokenInfoIndex, []string{})
+	if err != nil {
+		return nil, err
+	}
+	infoBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token info: %v", err)
+	}
+	if infoBytes == nil {
+		return nil, newChaincodeError(ErrCodeNotInitialized, "token is not initialized, call TokenContract:Initialize first")
+	}
+	var info TokenInfo
+	if err := json.Unmarshal(infoBytes, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func putTokenInfo(ctx contractapi.TransactionContextInterface, info *TokenInfo) error {
+	key, err := ctx.GetStub().CreateCompositeKey(tokenInfoIndex, []string{})
+	if err != nil {
+		return err
+	}
+	infoBytes, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, infoBytes)
+}
+
+// getBalance returns the balance of account, zero for accounts that never held tokens
+func getBalance(ctx contractapi.TransactionContextInterface, account string) (int, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(balanceIndex, []string{account})
+	if err != nil {
+		return 0, err
+	}
+	return getTokenAmount(ctx, key)
+}
+
+// addBalance changes the balance of account by delta, refusing to overdraw or overflow it.
+// Emptied accounts are deleted.
+func addBalance(ctx contractapi.TransactionContextInterface, account string, delta int) error {
+	key, err := ctx.GetStub().CreateCompositeKey(balanceIndex, []string{account})
+	if err != nil {
+		return err
+	}
+	balance, err := getTokenAmount(ctx, key)
+	if err != nil {
+		return err
+	}
+	if delta < 0 && balance < -delta {
+		return fmt.Errorf("account %s has %d tokens, %d are needed", account, balance, -delta)
+	}
+	if delta > 0 && balance > math.MaxInt64-delta {
+		return fmt.Errorf("balance of account %s would overflow", account)
+	}
+	return putTokenAmount(ctx, key, balance+delta)
+}
+
+func getAllowance(ctx contractapi.TransactionContextInterface, owner, spender string) (int, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(allowanceIndex, []string{owner, spender})
+	if err != nil {
+		return 0, err
+	}
+	return getTokenAmount(ctx, key)
+}
+
+func putAllowance(ctx contractapi.TransactionContextInterface, owner, spender string, amount int) error {
+	key, err := ctx.GetStub().CreateCompositeKey(allowanceIndex, []string{owner, spender})
+	if err != nil {
+		return err
+	}
+	return putTokenAmount(ctx, key, amount)
+}
+
+// getTokenAmount reads an amount stored as a decimal string, zero when the key is unset
+func getTokenAmount(ctx contractapi.TransactionContextInterface, key string) (int, error) {
+	amountBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %v", key, err)
+	}
+	if amountBytes == nil {
+		return 0, nil
+	}
+	amount, err := strconv.Atoi(string(amountBytes))
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount stored under %s: %v", key, err)
+	}
+	return amount, nil
+}
+
+func putTokenAmount(ctx contractapi.TransactionContextInterface, key string, amount int) error {
+	if amount == 0 {
+		return ctx.GetStub().DelState(key)
+	}
+	return ctx.GetStub().PutState(key, []byte(strconv.Itoa(amount)))
+}
@@ -0,0 +1,81 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTokenContract tests minting, transfers, allowances and burns with the total supply
+func TestTokenContract(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	tc := &TokenContract{}
+
+	assert.ErrorContains(t, tc.Mint(ctx, "Org1MSP:alice", 100), "NOT_INITIALIZED")
+	require.NoError(t, tc.Initialize(ctx, "Example Token", "EXT", 2))
+	assert.ErrorContains(t, tc.Initialize(ctx, "Other", "OTH", 0), "ALREADY_INITIALIZED")
+	require.NoError(t, tc.Mint(ctx, "Org1MSP:alice", 100))
+	assert.Error(t, tc.Mint(ctx, "Org1MSP:alice", 0))
+
+	var transfer TokenTransferEvent
+	require.NoError(t, json.Unmarshal(lastEvent(t, stub).Payload, &transfer))
+	assert.Equal(t, TokenTransferEvent{To: "Org1MSP:alice", Value: 100}, transfer)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "alice", nil)
+	assert.ErrorContains(t, tc.Mint(ctx, "Org1MSP:alice", 100), "ACCESS_DENIED")
+	require.NoError(t, tc.Transfer(ctx, "Org1MSP:bob", 30))
+	assert.Error(t, tc.Transfer(ctx, "Org1MSP:bob", 71))
+	assert.Error(t, tc.Transfer(ctx, "Org1MSP:alice", 1))
+	require.NoError(t, tc.Approve(ctx, "Org1MSP:carol", 20))
+	assert.ErrorContains(t, tc.Transfer(ctx, "bob", 1), "<MSP ID>:<enrollment ID>")
+
+	// The same enrollment ID in another organization is another account
+	switchIdentity(t, ctx, stub, "Org2MSP", "alice", nil)
+	assert.Error(t, tc.Transfer(ctx, "Org2MSP:bob", 1))
+	assert.ErrorContains(t, tc.TransferFrom(ctx, "Org1MSP:alice", "Org2MSP:bob", 1), "ACCESS_DENIED")
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "carol", nil)
+	assert.ErrorContains(t, tc.TransferFrom(ctx, "Org1MSP:alice", "Org1MSP:dave", 21), "ACCESS_DENIED")
+	require.NoError(t, tc.TransferFrom(ctx, "Org1MSP:alice", "Org1MSP:dave", 15))
+	allowance, err := tc.Allowance(ctx, "Org1MSP:alice", "Org1MSP:carol")
+	require.NoError(t, err)
+	assert.Equal(t, 5, allowance)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "bob", nil)
+	require.NoError(t, tc.Burn(ctx, 10))
+	assert.Error(t, tc.Burn(ctx, 21))
+
+	for account, expected := range map[string]int{"Org1MSP:alice": 55, "Org1MSP:bob": 20, "Org1MSP:carol": 0, "Org1MSP:dave": 15} {
+		balance, err := tc.BalanceOf(ctx, account)
+		require.NoError(t, err)
+		assert.Equal(t, expected, balance, account)
+	}
+	info, err := tc.GetTokenInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, TokenInfo{Name: "Example Token", Symbol: "EXT", Decimals: 2, TotalSupply: 90}, *info)
+}
+
+// TestTokenContractRegistration tests that the token contract is served next to the asset
+// contract under its own namespace
+func TestTokenContractRegistration(t *testing.T) {
+	cc, err := contractapi.NewChaincode(NewAssetContract(), &TokenContract{})
+	require.NoError(t, err)
+	stub := shimtest.NewMockStub("chaincode", cc)
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "admin", adminAttrs)
+
+	status, message := invoke(stub, "tx1", "TokenContract:Initialize", "Example Token", "EXT", "2")
+	require.Equal(t, int32(shim.OK), status, message)
+	status, message = invoke(stub, "tx2", "TokenContract:Mint", "Org1MSP:admin", "50")
+	require.Equal(t, int32(shim.OK), status, message)
+	status, message = invoke(stub, "tx3", "CreateAsset", "asset1", "blue", "5", "Org1MSP:admin", "100")
+	require.Equal(t, int32(shim.OK), status, message)
+
+	response := stub.MockInvoke("tx4", [][]byte{[]byte("TokenContract:BalanceOf"), []byte("Org1MSP:admin")})
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	assert.Equal(t, "50", string(response.Payload))
+}
@@ -0,0 +1,228 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// tokenInteropConfig is the configuration key of the token chaincode reference
+	tokenInteropConfig = "tokeninterop"
+	// tokenPriceIndex keys the token price an owner asks for an asset
+	tokenPriceIndex = "tokenprice~asset"
+	// tokenUsedIndex records tokens already used as payment so they cannot be presented twice
+	tokenUsedIndex = "tokenused~id"
+)
+
+// TokenInteropConfig locates the Fabric Token SDK chaincode and the query function that
+// returns a TokenReference JSON document for a token ID
+type TokenInteropConfig struct {
+	Chaincode     string `json:"chaincode"`
+	Channel       string `json:"channel"`
+	QueryFunction string `json:"queryFunction"`
+}
+
+// TokenReference is the ownership view of a token recorded by the token chaincode.
+// Quantity follows the Token SDK encoding, a hex ("0x..") or decimal string. Sender and
+// Reference describe the transfer that created the token: its previous owner and the payment
+// reference attached to it.
+type TokenReference struct {
+	TokenID   string `json:"tokenId"`
+	Owner     string `json:"owner"`
+	Type      string `json:"type"`
+	Quantity  string `json:"quantity"`
+	Sender    string `json:"sender,omitempty" metadata:",optional"`
+	Reference string `json:"reference,omitempty" metadata:",optional"`
+}
+
+// TokenPrice is the payment an owner requires before an asset can be transferred for tokens
+type TokenPrice struct {
+	AssetID  string `json:"assetId"`
+	Seller   string `json:"seller"`
+	Type     string `json:"type"`
+	Quantity uint64 `json:"quantity"`
+}
+
+// SetTokenInterop configures the token chaincode queried to verify token ownership
+func (c *ConfigContract) SetTokenInterop(ctx contractapi.TransactionContextInterface, chaincodeName, channel, queryFunction string) error {
+	log.Info().
+		Str("function", "SetTokenInterop").
+		Str("chaincode", chaincodeName).
+		Str("channel", channel).
+		Str("queryFunction", queryFunction).
+		Msg("Configuring token chaincode interop")
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if chaincodeName == "" || queryFunction == "" {
+		return fmt.Errorf("chaincode name and query function must not be empty")
+	}
+	interop := &TokenInteropConfig{Chaincode: chaincodeName, Channel: channel, QueryFunction: queryFunction}
+	if err := putConfig(ctx, interop, tokenInteropConfig); err != nil {
+		return err
+	}
+	return recordAudit(ctx, "SetTokenInterop", chaincodeName, queryFunction)
+}
+
+// VerifyTokenOwnership queries the token chaincode and checks that expectedOwner holds the token
+func (q *QueryContract) VerifyTokenOwnership(ctx contractapi.TransactionContextInterface, tokenID, expectedOwner string) (*TokenReference, error) {
+	q.logger(ctx).Info().Str("function", "VerifyTokenOwnership").Str("tokenID", tokenID).Str("expectedOwner", expectedOwner).Msg("Verifying token ownership")
+	return q.verifyTokenOwnership(ctx, tokenID, expectedOwner)
+}
+
+// verifyTokenOwnership returns the token after checking that expectedOwner holds it
+func (r *contractRuntime) verifyTokenOwnership(ctx contractapi.TransactionContextInterface, tokenID, expectedOwner string) (*TokenReference, error) {
+	token, err := queryToken(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if token.Owner != expectedOwner {
+		r.logger(ctx).Warn().Str("tokenID", tokenID).Str("owner", token.Owner).Str("expectedOwner", expectedOwner).Msg("Token owned by someone else")
+		return nil, fmt.Errorf("token %s is not owned by %s", tokenID, expectedOwner)
+	}
+	return token, nil
+}
+
+// SetAssetTokenPrice lets the asset owner require a token payment of at least quantity tokens
+// of tokenType before TransferAssetWithToken hands the asset to a buyer
+func (t *AssetContract) SetAssetTokenPrice(ctx contractapi.TransactionContextInterface, assetID, tokenType string, quantity int) error {
+	t.logger(ctx).Info().Str("function", "SetAssetTokenPrice").Str("assetID", assetID).Str("tokenType", tokenType).Int("quantity", quantity).Msg("Setting asset token price")
+
+	if tokenType == "" || quantity <= 0 {
+		return fmt.Errorf("token type must not be empty and quantity must be positive")
+	}
+	asset, err := getAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	caller, err := getCallerEnrollmentID(ctx)
+	if err != nil {
+		return err
+	}
+	if asset.Owner != caller {
+		return denyAccess(ctx, "asset owner", "asset %s is not owned by %s", assetID, caller)
+	}
+
+	price := &TokenPrice{AssetID: assetID, Seller: asset.Owner, Type: tokenType, Quantity: uint64(quantity)}
+	priceBytes, err := json.Marshal(price)
+	if err != nil {
+		return err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(tokenPriceIndex, []string{assetID})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, priceBytes)
+}
+
+// TransferAssetWithToken transfers an asset to newOwner, the caller, once the token chaincode
+// shows that newOwner paid tokenID to the seller with the asset ID as payment reference, of the
+// asked type and quantity. Each token can pay for one transfer only. The payment and the transfer
+// are committed together with a receipt and an AssetPurchased event.
+func (t *AssetContract) TransferAssetWithToken(ctx contractapi.TransactionContextInterface, assetID, newOwner, tokenID string) error {
+	t.logger(ctx).Info().Str("function", "TransferAssetWithToken").Str("assetID", assetID).Str("newOwner", newOwner).Str("tokenID", tokenID).Msg("Transferring asset against token payment")
+
+	caller, err := getCallerEnrollmentID(ctx)
+	if err != nil {
+		return err
+	}
+	if caller != newOwner {
+		return denyAccess(ctx, "token buyer", "%s cannot buy asset %s for %s", caller, assetID, newOwner)
+	}
+
+	priceKey, err := ctx.GetStub().CreateCompositeKey(tokenPriceIndex, []string{assetID})
+	if err != nil {
+		return err
+	}
+	priceBytes, err := ctx.GetStub().GetState(priceKey)
+	if err != nil {
+		return fmt.Errorf("failed to read token price of asset %s: %v", assetID, err)
+	}
+	if priceBytes == nil {
+		return fmt.Errorf("asset %s has no token price", assetID)
+	}
+	var price TokenPrice
+	if err := json.Unmarshal(priceBytes, &price); err != nil {
+		return err
+	}
+
+	usedKey, err := ctx.GetStub().CreateCompositeKey(tokenUsedIndex, []string{tokenID})
+	if err != nil {
+		return err
+	}
+	used, err := ctx.GetStub().GetState(usedKey)
+	if err != nil {
+		return err
+	}
+	if used != nil {
+		return fmt.Errorf("token %s was already used as payment", tokenID)
+	}
+
+	token, err := t.verifyTokenOwnership(ctx, tokenID, price.Seller)
+	if err != nil {
+		return err
+	}
+	if token.Sender != newOwner || token.Reference != assetID {
+		t.logger(ctx).Warn().Str("tokenID", tokenID).Str("sender", token.Sender).Str("reference", token.Reference).Msg("Token is not a payment for the asset")
+		return fmt.Errorf("token %s was not paid by %s for asset %s", tokenID, newOwner, assetID)
+	}
+	if token.Type != price.Type {
+		return fmt.Errorf("token %s has type %s, expected %s", tokenID, token.Type, price.Type)
+	}
+	quantity, err := strconv.ParseUint(token.Quantity, 0, 64)
+	if err != nil {
+		return fmt.Errorf("invalid quantity %q on token %s: %v", token.Quantity, tokenID, err)
+	}
+	if quantity < price.Quantity {
+		return fmt.Errorf("token %s holds %d %s, price is %d", tokenID, quantity, price.Type, price.Quantity)
+	}
+
+	// The token leg's bookkeeping is staged and only applied once the asset leg succeeded
+	journal := newOperationJournal("TransferAssetWithToken", price.Seller, newOwner)
+	journal.record("token", "pay", tokenID, fmt.Sprintf("%d %s held by %s", quantity, token.Type, price.Seller))
+	journal.stage(usedKey, []byte(assetID))
+	journal.stage(priceKey, nil)
+	if err := t.TransferAsset(ctx, assetID, newOwner, 0); err != nil {
+		return err
+	}
+	journal.record("asset", "transfer", assetID, fmt.Sprintf("%s to %s", price.Seller, newOwner))
+	if _, err := t.commitJournal(ctx, journal, EventAssetPurchased, "token", "asset"); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("assetID", assetID).Str("tokenID", tokenID).Str("newOwner", newOwner).Msg("Asset transferred against token payment")
+	return nil
+}
+
+// queryToken invokes the configured token chaincode query function for a token ID
+func queryToken(ctx contractapi.TransactionContextInterface, tokenID string) (*TokenReference, error) {
+	interop := &TokenInteropConfig{}
+	found, err := getConfig(ctx, interop, tokenInteropConfig)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("token chaincode interop is not configured")
+	}
+
+	response := ctx.GetStub().InvokeChaincode(interop.Chaincode, [][]byte{[]byte(interop.QueryFunction), []byte(tokenID)}, interop.Channel)
+	if response.Status != shim.OK {
+		log.Error().Str("tokenID", tokenID).Str("chaincode", interop.Chaincode).Str("message", response.Message).Msg("Token chaincode query failed")
+		return nil, fmt.Errorf("token chaincode %s query failed: %s", interop.Chaincode, response.Message)
+	}
+
+	var token TokenReference
+	if err := json.Unmarshal(response.Payload, &token); err != nil {
+		return nil, fmt.Errorf("invalid token chaincode response: %v", err)
+	}
+	if token.TokenID != tokenID {
+		return nil, fmt.Errorf("token chaincode returned token %s, expected %s", token.TokenID, tokenID)
+	}
+	return &token, nil
+}
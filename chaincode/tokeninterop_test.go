@@ -0,0 +1,76 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokenChaincode answers queryToken calls from a fixed set of tokens
+type fakeTokenChaincode struct {
+	tokens map[string]TokenReference
+}
+
+func (f *fakeTokenChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (f *fakeTokenChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	_, args := stub.GetFunctionAndParameters()
+	token, ok := f.tokens[args[0]]
+	if !ok {
+		return shim.Error("token not found")
+	}
+	payload, _ := json.Marshal(token)
+	return shim.Success(payload)
+}
+
+// TestTransferAssetWithToken tests that transfers require a matching unused token the buyer paid
+// to the seller for the asset, and are made by the buyer
+func TestTransferAssetWithToken(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "admin", adminAttrs)
+	tokenStub := shimtest.NewMockStub("tokens", &fakeTokenChaincode{tokens: map[string]TokenReference{
+		"tok1": {TokenID: "tok1", Owner: "alice", Type: "USD", Quantity: "0x64", Sender: "bob", Reference: "asset1"},
+		"tok2": {TokenID: "tok2", Owner: "alice", Type: "USD", Quantity: "10", Sender: "bob", Reference: "asset1"},
+		"tok3": {TokenID: "tok3", Owner: "alice", Type: "USD", Quantity: "0x64"},
+		"tok4": {TokenID: "tok4", Owner: "alice", Type: "USD", Quantity: "0x64", Sender: "carol", Reference: "asset1"},
+		"tok5": {TokenID: "tok5", Owner: "alice", Type: "USD", Quantity: "0x64", Sender: "bob", Reference: "asset2"},
+	}})
+	stub.MockPeerChaincode("tokens", tokenStub, "mychannel")
+	require.NoError(t, (&ConfigContract{}).SetTokenInterop(ctx, "tokens", "mychannel", "queryToken"))
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "alice", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "alice", 100))
+	require.NoError(t, cc.CreateAsset(ctx, "asset2", "blue", 5, "alice", 100))
+	require.NoError(t, cc.SetAssetTokenPrice(ctx, "asset1", "USD", 50))
+	require.NoError(t, cc.SetAssetTokenPrice(ctx, "asset2", "USD", 50))
+
+	// Only the buyer takes the asset, even with a token the seller holds
+	assert.ErrorContains(t, cc.TransferAssetWithToken(ctx, "asset1", "bob", "tok1"), ErrCodeAccessDenied)
+	switchIdentity(t, ctx, stub, "Org1MSP", "carol", nil)
+	assert.ErrorContains(t, cc.TransferAssetWithToken(ctx, "asset1", "bob", "tok1"), ErrCodeAccessDenied)
+	assert.ErrorContains(t, cc.TransferAssetWithToken(ctx, "asset1", "carol", "tok1"), "was not paid by carol")
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "bob", nil)
+	assert.Error(t, cc.TransferAssetWithToken(ctx, "asset1", "bob", "tok2"))
+	assert.Error(t, cc.TransferAssetWithToken(ctx, "asset1", "bob", "missing"))
+	assert.ErrorContains(t, cc.TransferAssetWithToken(ctx, "asset1", "bob", "tok3"), "was not paid by bob")
+	assert.ErrorContains(t, cc.TransferAssetWithToken(ctx, "asset1", "bob", "tok4"), "was not paid by bob")
+	assert.ErrorContains(t, cc.TransferAssetWithToken(ctx, "asset1", "bob", "tok5"), "for asset asset1")
+	require.NoError(t, cc.TransferAssetWithToken(ctx, "asset1", "bob", "tok1"))
+
+	asset, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", asset.Owner)
+	assert.Error(t, cc.TransferAssetWithToken(ctx, "asset2", "bob", "tok1"))
+
+	_, err = qc.VerifyTokenOwnership(ctx, "tok3", "bob")
+	assert.Error(t, err)
+}
@@ -0,0 +1,214 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// pendingTransferIndex keys the pending two-phase transfer of an asset
+const pendingTransferIndex = "transfer~asset"
+
+// PendingTransfer is a proposed ownership change waiting for the recipient's decision
+type PendingTransfer struct {
+	AssetID    string    `json:"assetId"`
+	From       string    `json:"from"` // account "<MSP ID>:<enrollment ID>" of the owner
+	FromMSP    string    `json:"fromMsp"`
+	To         string    `json:"to"` // account of the recipient
+	ProposedAt time.Time `json:"proposedAt"`
+	TxID       string    `json:"txId"`
+}
+
+// ProposeTransfer offers an asset owned by the caller to recipient, an account
+// "<MSP ID>:<enrollment ID>" or an enrollment ID of the caller's organization. The owner only
+// changes once the recipient calls AcceptTransfer; proposing again replaces the pending proposal.
+func (t *AssetContract) ProposeTransfer(ctx contractapi.TransactionContextInterface, assetID, recipient string) (*PendingTransfer, error) {
+	t.logger(ctx).Info().Str("function", "ProposeTransfer").Str("assetID", assetID).Str("recipient", recipient).Msg("Proposing asset transfer")
+
+	mspID, err := assertOrgCanWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	caller, err := getCallerAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	asset, err := getAsset(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if !heldByAccount(asset, caller) {
+		return nil, denyAccess(ctx, "asset owner", "asset %s is not owned by %s", assetID, caller)
+	}
+	if asset.Frozen {
+		return nil, fmt.Errorf("asset %s is frozen", assetID)
+	}
+	if recipient == "" {
+		return nil, fmt.Errorf("recipient must be another identity")
+	}
+	if recipient, err = resolveAccount(ctx, recipient); err != nil {
+		return nil, err
+	}
+	if recipient == caller {
+		return nil, fmt.Errorf("recipient must be another identity")
+	}
+	now, err := t.now(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer := &PendingTransfer{
+		AssetID:    assetID,
+		From:       caller,
+		FromMSP:    mspID,
+		To:         recipient,
+		ProposedAt: now,
+		TxID:       ctx.GetStub().GetTxID(),
+	}
+	if err := putPendingTransfer(ctx, transfer); err != nil {
+		return nil, err
+	}
+	if err := t.emitTransferEvent(ctx, EventTransferProposed, transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// AcceptTransfer makes the caller the owner of an asset proposed to them. It fails if the asset
+// changed owner or was frozen since the proposal.
+func (t *AssetContract) AcceptTransfer(ctx contractapi.TransactionContextInterface, assetID string) error {
+	t.logger(ctx).Info().Str("function", "AcceptTransfer").Str("assetID", assetID).Msg("Accepting asset transfer")
+
+	if _, err := assertOrgCanWrite(ctx); err != nil {
+		return err
+	}
+	transfer, err := t.getTransferForCaller(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	asset, err := getAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	if !heldByAccount(asset, transfer.From) {
+		return fmt.Errorf("asset %s changed owner since the transfer was proposed", assetID)
+	}
+	if asset.Frozen {
+		return fmt.Errorf("asset %s is frozen", assetID)
+	}
+
+	previousOwner := asset.Owner
+	asset.OwnerMSP, asset.Owner = splitAccount(transfer.To)
+	if err := t.saveAsset(ctx, asset); err != nil {
+		return err
+	}
+	if err := t.hooks.runAfterTransfer(ctx, asset, previousOwner); err != nil {
+		t.logger(ctx).Warn().Err(err).Str("assetID", assetID).Msg("Asset transfer rejected by hook")
+		return err
+	}
+	if err := deletePendingTransfer(ctx, assetID); err != nil {
+		return err
+	}
+	if err := t.emitTransferEvent(ctx, EventTransferAccepted, transfer); err != nil {
+		return err
+	}
+
+	t.logger(ctx).Info().Str("assetID", assetID).Str("oldOwner", transfer.From).Str("newOwner", transfer.To).Msg("Asset transfer accepted")
+	return nil
+}
+
+// RejectTransfer declines a transfer proposed to the caller, leaving the owner unchanged
+func (t *AssetContract) RejectTransfer(ctx contractapi.TransactionContextInterface, assetID string) error {
+	t.logger(ctx).Info().Str("function", "RejectTransfer").Str("assetID", assetID).Msg("Rejecting asset transfer")
+
+	transfer, err := t.getTransferForCaller(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	if err := deletePendingTransfer(ctx, assetID); err != nil {
+		return err
+	}
+	return t.emitTransferEvent(ctx, EventTransferRejected, transfer)
+}
+
+// GetPendingTransfer returns the pending transfer of an asset
+func (q *QueryContract) GetPendingTransfer(ctx contractapi.TransactionContextInterface, assetID string) (*PendingTransfer, error) {
+	q.logger(ctx).Info().Str("function", "GetPendingTransfer").Str("assetID", assetID).Msg("Reading pending transfer")
+
+	transfer, err := getPendingTransfer(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil {
+		return nil, fmt.Errorf("asset %s has no pending transfer", assetID)
+	}
+	return transfer, nil
+}
+
+// getTransferForCaller returns the pending transfer of an asset if the caller is its recipient
+func (t *AssetContract) getTransferForCaller(ctx contractapi.TransactionContextInterface, assetID string) (*PendingTransfer, error) {
+	transfer, err := getPendingTransfer(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil {
+		return nil, fmt.Errorf("asset %s has no pending transfer", assetID)
+	}
+	caller, err := getCallerAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.To != caller {
+		return nil, denyAccess(ctx, "transfer recipient", "transfer of asset %s is not proposed to %s", assetID, caller)
+	}
+	return transfer, nil
+}
+
+// emitTransferEvent sets the event of a transfer phase, unless events are disabled
+func (t *AssetContract) emitTransferEvent(ctx contractapi.TransactionContextInterface, name string, transfer *PendingTransfer) error {
+	if t.skipEvents {
+		return nil
+	}
+	return emitEvent(ctx, name, transfer.AssetID, transfer)
+}
+
+func getPendingTransfer(ctx contractapi.TransactionContextInterface, assetID string) (*PendingTransfer, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(pendingTransferIndex, []string{assetID})
+	if err != nil {
+		return nil, err
+	}
+	transferBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending transfer of asset %s: %v", assetID, err)
+	}
+	if transferBytes == nil {
+		return nil, nil
+	}
+	var transfer PendingTransfer
+	if err := json.Unmarshal(transferBytes, &transfer); err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func putPendingTransfer(ctx contractapi.TransactionContextInterface, transfer *PendingTransfer) error {
+	key, err := ctx.GetStub().CreateCompositeKey(pendingTransferIndex, []string{transfer.AssetID})
+	if err != nil {
+		return err
+	}
+	transferBytes, err := json.Marshal(transfer)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, transferBytes)
+}
+
+func deletePendingTransfer(ctx contractapi.TransactionContextInterface, assetID string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(pendingTransferIndex, []string{assetID})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().DelState(key)
+}
@@ -0,0 +1,70 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTwoPhaseTransfer tests that an asset only changes owner once the recipient accepts
+func TestTwoPhaseTransfer(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "alice", nil)
+	cc := &AssetContract{}
+	qc := &QueryContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "alice", 100))
+
+	_, err := cc.ProposeTransfer(ctx, "asset1", "Org1MSP:alice")
+	assert.Error(t, err)
+	_, err = cc.ProposeTransfer(ctx, "asset1", "Org2MSP:")
+	assert.ErrorContains(t, err, "must be of the form")
+	transfer, err := cc.ProposeTransfer(ctx, "asset1", "Org2MSP:bob")
+	require.NoError(t, err)
+	assert.Equal(t, "Org1MSP:alice", transfer.From)
+	assert.Equal(t, "Org2MSP:bob", transfer.To)
+	assert.Equal(t, EventTransferProposed, lastEvent(t, stub).EventName)
+	asset, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", asset.Owner)
+
+	assert.Error(t, cc.AcceptTransfer(ctx, "asset1"), "only the recipient may accept")
+	// The same enrollment ID in another organization is another identity
+	switchIdentity(t, ctx, stub, "Org1MSP", "bob", nil)
+	assert.True(t, hasErrorCode(cc.AcceptTransfer(ctx, "asset1"), ErrCodeAccessDenied))
+
+	switchIdentity(t, ctx, stub, "Org2MSP", "bob", nil)
+	_, err = cc.ProposeTransfer(ctx, "asset1", "carol")
+	assert.Error(t, err, "only the owner may propose")
+	require.NoError(t, cc.AcceptTransfer(ctx, "asset1"))
+	assert.Equal(t, EventTransferAccepted, lastEvent(t, stub).EventName)
+	asset, err = getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", asset.Owner)
+	assert.Equal(t, "Org2MSP", asset.OwnerMSP)
+	_, err = qc.GetPendingTransfer(ctx, "asset1")
+	assert.Error(t, err)
+	assert.Error(t, cc.AcceptTransfer(ctx, "asset1"))
+}
+
+// TestRejectTransfer tests that rejected and stale proposals leave the owner unchanged
+func TestRejectTransfer(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "alice", nil)
+	cc := &AssetContract{}
+	require.NoError(t, cc.CreateAsset(ctx, "asset1", "blue", 5, "alice", 100))
+
+	_, err := cc.ProposeTransfer(ctx, "asset1", "Org2MSP:bob")
+	require.NoError(t, err)
+	switchIdentity(t, ctx, stub, "Org2MSP", "bob", nil)
+	require.NoError(t, cc.RejectTransfer(ctx, "asset1"))
+	assert.Equal(t, EventTransferRejected, lastEvent(t, stub).EventName)
+	asset, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", asset.Owner)
+
+	switchIdentity(t, ctx, stub, "Org1MSP", "alice", nil)
+	_, err = cc.ProposeTransfer(ctx, "asset1", "Org2MSP:bob")
+	require.NoError(t, err)
+	require.NoError(t, cc.TransferAsset(ctx, "asset1", "carol", 0))
+	switchIdentity(t, ctx, stub, "Org2MSP", "bob", nil)
+	assert.ErrorContains(t, cc.AcceptTransfer(ctx, "asset1"), "changed owner")
+}
@@ -0,0 +1,112 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransientAssetProperties is the transient map key CreateAssetPrivate reads
+// AssetPrivateDetails from
+const TransientAssetProperties = "asset_properties"
+
+// AssetPrivateDetails are the sensitive properties of an asset, kept in the implicit private data
+// collection of the creating organization instead of world state
+type AssetPrivateDetails struct {
+	AppraisedValue int `json:"appraisedValue"`
+}
+
+// GetTransientJSON decodes the JSON stored under key in the transaction's transient map. Transient
+// data reaches the endorsing peers with the proposal but, unlike the arguments, is never written
+// to the block, so sensitive inputs are passed this way. Unknown fields are rejected.
+func GetTransientJSON[T any](ctx contractapi.TransactionContextInterface, key string) (*T, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the transient map: %v", err)
+	}
+	value, ok := transient[key]
+	if !ok || len(value) == 0 {
+		return nil, fmt.Errorf("transient field %s is required", key)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.DisallowUnknownFields()
+	var decoded T
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("invalid transient field %s: %v", key, err)
+	}
+	return &decoded, nil
+}
+
+// implicitCollection returns the implicit private data collection of an organization, which
+// every channel has without a collection configuration
+func implicitCollection(mspID string) string {
+	return "_implicit_org_" + mspID
+}
+
+// CreateAssetPrivate creates an asset like CreateAsset, reading its appraised value from the
+// AssetPrivateDetails under TransientAssetProperties in the transient map. The value is stored in
+// the creating organization's implicit collection, so neither the proposal nor the public write
+// set carries it; the public asset has no appraised value. Endorse it with a peer of the caller's
+// organization.
+func (t *AssetContract) CreateAssetPrivate(ctx contractapi.TransactionContextInterface, assetID, color string, size int, owner string) error {
+	t.logger(ctx).Info().
+		Str("function", "CreateAssetPrivate").
+		Str("assetID", assetID).
+		Str("color", color).
+		Int("size", size).
+		Str("owner", owner).
+		Msg("Creating new asset with private details")
+
+	details, err := GetTransientJSON[AssetPrivateDetails](ctx, TransientAssetProperties)
+	if err != nil {
+		return err
+	}
+	if details.AppraisedValue < 0 {
+		return fmt.Errorf("appraised value must not be negative")
+	}
+	if err := t.CreateAsset(ctx, assetID, color, size, owner, 0); err != nil {
+		return err
+	}
+
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		return err
+	}
+	detailsBytes, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutPrivateData(implicitCollection(mspID), assetKey(assetID), detailsBytes); err != nil {
+		t.logger(ctx).Error().Err(err).Str("assetID", assetID).Msg("Failed to store private asset details")
+		return fmt.Errorf("failed to store private details of asset %s: %v", assetID, err)
+	}
+
+	t.logger(ctx).Info().Str("assetID", assetID).Str("mspID", mspID).Msg("Private asset details stored")
+	return nil
+}
+
+// ReadAssetPrivateDetails returns the private details of an asset stored by CreateAssetPrivate
+// in the implicit collection of the caller's organization. Only peers of that organization
+// hold them, so query one of those.
+func (q *QueryContract) ReadAssetPrivateDetails(ctx contractapi.TransactionContextInterface, assetID string) (*AssetPrivateDetails, error) {
+	q.logger(ctx).Info().Str("function", "ReadAssetPrivateDetails").Str("assetID", assetID).Msg("Reading private asset details")
+
+	mspID, err := getCallerMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	detailsBytes, err := ctx.GetStub().GetPrivateData(implicitCollection(mspID), assetKey(assetID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private details of asset %s: %v", assetID, err)
+	}
+	if detailsBytes == nil {
+		return nil, fmt.Errorf("asset %s has no private details in %s", assetID, implicitCollection(mspID))
+	}
+	var details AssetPrivateDetails
+	if err := json.Unmarshal(detailsBytes, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
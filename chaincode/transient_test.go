@@ -0,0 +1,52 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetTransientJSON tests that transient inputs are required and decoded strictly
+func TestGetTransientJSON(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "alice", nil)
+
+	_, err := GetTransientJSON[AssetPrivateDetails](ctx, TransientAssetProperties)
+	assert.ErrorContains(t, err, "transient field asset_properties is required")
+
+	stub.TransientMap = map[string][]byte{TransientAssetProperties: []byte(`{"appraisedValue":1,"color":"red"}`)}
+	_, err = GetTransientJSON[AssetPrivateDetails](ctx, TransientAssetProperties)
+	assert.ErrorContains(t, err, "unknown field")
+
+	stub.TransientMap = map[string][]byte{TransientAssetProperties: []byte(`{"appraisedValue":700}`)}
+	details, err := GetTransientJSON[AssetPrivateDetails](ctx, TransientAssetProperties)
+	require.NoError(t, err)
+	assert.Equal(t, 700, details.AppraisedValue)
+}
+
+// TestCreateAssetPrivate tests that the appraised value only reaches the organization's collection
+func TestCreateAssetPrivate(t *testing.T) {
+	ctx, stub := newTestContext(t, "Org1MSP", "alice", nil)
+	ac := NewAssetContract()
+	qc := NewQueryContract()
+
+	require.Error(t, ac.CreateAssetPrivate(ctx, "asset1", "blue", 5, "alice"))
+	stub.TransientMap = map[string][]byte{TransientAssetProperties: []byte(`{"appraisedValue":-1}`)}
+	require.Error(t, ac.CreateAssetPrivate(ctx, "asset1", "blue", 5, "alice"))
+
+	stub.TransientMap = map[string][]byte{TransientAssetProperties: []byte(`{"appraisedValue":700}`)}
+	require.NoError(t, ac.CreateAssetPrivate(ctx, "asset1", "blue", 5, "alice"))
+
+	asset, err := getAsset(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, asset.AppraisedValue)
+
+	details, err := qc.ReadAssetPrivateDetails(ctx, "asset1")
+	require.NoError(t, err)
+	assert.Equal(t, 700, details.AppraisedValue)
+
+	// Other organizations hold no details in their own collection
+	switchIdentity(t, ctx, stub, "Org2MSP", "bob", nil)
+	_, err = qc.ReadAssetPrivateDetails(ctx, "asset1")
+	assert.ErrorContains(t, err, "_implicit_org_Org2MSP")
+}
@@ -0,0 +1,174 @@
+package chaincode
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransactionContextInterface is the transaction context the contracts run with. Functions keep
+// taking contractapi.TransactionContextInterface; the shared identity and clock helpers use the
+// memoized values whenever the context provides them.
+type TransactionContextInterface interface {
+	contractapi.TransactionContextInterface
+	CallerID() (string, error)
+	CallerMSP() (string, error)
+	TxTime() (time.Time, error)
+}
+
+// TransactionContext reads the caller and the transaction time once per transaction, instead of
+// every access check, audit record and timestamp going back to the creator certificate and
+// the proposal
+type TransactionContext struct {
+	contractapi.TransactionContext
+
+	callerID  memoized[string]
+	callerMSP memoized[string]
+	txTime    memoized[time.Time]
+
+	assetWrites *AssetWrites  // recorded for the invariants, nil unless tracked
+	metering    *meteringStub // counts the bytes written for the tenant usage, nil unless metered
+	journaled   bool          // a composite operation was committed, see commitJournal
+}
+
+// memoized holds a value computed at most once
+type memoized[T any] struct {
+	done  bool
+	value T
+	err   error
+}
+
+func (m *memoized[T]) get(compute func() (T, error)) (T, error) {
+	if !m.done {
+		m.value, m.err = compute()
+		m.done = true
+	}
+	return m.value, m.err
+}
+
+// SetStub starts a transaction, forgetting the values of the previous one
+func (c *TransactionContext) SetStub(stub shim.ChaincodeStubInterface) {
+	c.TransactionContext.SetStub(stub)
+	c.reset()
+	c.journaled = false
+}
+
+// SetClientIdentity replaces the caller, forgetting the values read from the previous one
+func (c *TransactionContext) SetClientIdentity(ci cid.ClientIdentity) {
+	c.TransactionContext.SetClientIdentity(ci)
+	c.reset()
+}
+
+func (c *TransactionContext) reset() {
+	c.callerID = memoized[string]{}
+	c.callerMSP = memoized[string]{}
+	c.txTime = memoized[time.Time]{}
+	c.metering = nil
+}
+
+func (c *TransactionContext) trackAssetWrites() {
+	c.assetWrites = &AssetWrites{writes: map[string]*AssetWrite{}}
+}
+
+func (c *TransactionContext) trackedAssetWrites() *AssetWrites {
+	return c.assetWrites
+}
+
+// meterWrites routes the writes of the rest of the transaction through a metering stub
+func (c *TransactionContext) meterWrites() {
+	if c.metering == nil {
+		c.metering = &meteringStub{ChaincodeStubInterface: c.GetStub()}
+		c.TransactionContext.SetStub(c.metering)
+	}
+}
+
+func (c *TransactionContext) meteredBytes() uint64 {
+	if c.metering == nil {
+		return 0
+	}
+	return c.metering.written
+}
+
+// markJournaled records that the transaction committed a composite operation and reports
+// whether it had already done so
+func (c *TransactionContext) markJournaled() bool {
+	journaled := c.journaled
+	c.journaled = true
+	return journaled
+}
+
+// CallerID returns the enrollment ID of the caller, see getCallerEnrollmentID
+func (c *TransactionContext) CallerID() (string, error) {
+	return c.callerID.get(func() (string, error) { return readCallerEnrollmentID(c) })
+}
+
+// CallerMSP returns the MSP ID of the caller
+func (c *TransactionContext) CallerMSP() (string, error) {
+	return c.callerMSP.get(func() (string, error) { return readCallerMSPID(c) })
+}
+
+// TxTime returns the transaction timestamp in UTC
+func (c *TransactionContext) TxTime() (time.Time, error) {
+	return c.txTime.get(func() (time.Time, error) { return readTxTime(c) })
+}
+
+// GetTransactionContextHandler makes contractapi create a TransactionContext for every
+// AssetContract transaction, unless another handler was set
+func (t *AssetContract) GetTransactionContextHandler() contractapi.SettableTransactionContextInterface {
+	return transactionContextHandler(t.TransactionContextHandler)
+}
+
+// GetTransactionContextHandler makes contractapi create a TransactionContext for every
+// QueryContract transaction, unless another handler was set
+func (q *QueryContract) GetTransactionContextHandler() contractapi.SettableTransactionContextInterface {
+	return transactionContextHandler(q.TransactionContextHandler)
+}
+
+// GetTransactionContextHandler makes contractapi create a TransactionContext for every
+// AdminContract transaction, unless another handler was set
+func (a *AdminContract) GetTransactionContextHandler() contractapi.SettableTransactionContextInterface {
+	return transactionContextHandler(a.TransactionContextHandler)
+}
+
+// GetTransactionContextHandler makes contractapi create a TransactionContext for every
+// ConfigContract transaction, unless another handler was set
+func (c *ConfigContract) GetTransactionContextHandler() contractapi.SettableTransactionContextInterface {
+	return transactionContextHandler(c.TransactionContextHandler)
+}
+
+// GetTransactionContextHandler makes contractapi create a TransactionContext for every
+// TokenContract transaction, unless another handler was set
+func (c *TokenContract) GetTransactionContextHandler() contractapi.SettableTransactionContextInterface {
+	return transactionContextHandler(c.TransactionContextHandler)
+}
+
+// GetTransactionContextHandler makes contractapi create a TransactionContext for every
+// NFTContract transaction, unless another handler was set
+func (c *NFTContract) GetTransactionContextHandler() contractapi.SettableTransactionContextInterface {
+	return transactionContextHandler(c.TransactionContextHandler)
+}
+
+// GetTransactionContextHandler makes contractapi create a TransactionContext for every
+// EscrowContract transaction, unless another handler was set
+func (e *EscrowContract) GetTransactionContextHandler() contractapi.SettableTransactionContextInterface {
+	return transactionContextHandler(e.TransactionContextHandler)
+}
+
+func transactionContextHandler(handler contractapi.SettableTransactionContextInterface) contractapi.SettableTransactionContextInterface {
+	if handler == nil {
+		return new(TransactionContext)
+	}
+	return handler
+}
+
+// readTxTime reads the transaction timestamp from the proposal
+func readTxTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	return timestamp.AsTime().UTC(), nil
+}
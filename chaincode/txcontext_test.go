@@ -0,0 +1,62 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTransactionContext tests that the caller and transaction time are read once and forgotten
+// when the caller changes
+func TestTransactionContext(t *testing.T) {
+	stub := shimtest.NewMockStub("chaincode", nil)
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "user1", nil)
+	stub.MockTransactionStart("tx1")
+	ctx := &TransactionContext{}
+	ctx.SetStub(stub)
+	identity, err := cid.New(stub)
+	require.NoError(t, err)
+	ctx.SetClientIdentity(identity)
+
+	callerID, err := ctx.CallerID()
+	require.NoError(t, err)
+	assert.Equal(t, "user1", callerID)
+	mspID, err := ctx.CallerMSP()
+	require.NoError(t, err)
+	assert.Equal(t, "Org1MSP", mspID)
+	txTime, err := ctx.TxTime()
+	require.NoError(t, err)
+	timestamp, err := stub.GetTxTimestamp()
+	require.NoError(t, err)
+	assert.Equal(t, timestamp.AsTime().UTC(), txTime)
+
+	// The memoized caller survives changes behind the context's back
+	stub.Creator = newSerializedIdentity(t, "Org2MSP", "user2", nil)
+	identity, err = cid.New(stub)
+	require.NoError(t, err)
+	ctx.TransactionContext.SetClientIdentity(identity)
+	callerID, _ = ctx.CallerID()
+	assert.Equal(t, "user1", callerID)
+
+	ctx.SetClientIdentity(identity)
+	callerID, _ = getCallerEnrollmentID(ctx)
+	assert.Equal(t, "user2", callerID)
+	mspID, _ = getCallerMSPID(ctx)
+	assert.Equal(t, "Org2MSP", mspID)
+}
+
+// TestTransactionContextHandler tests that every contract runs with a TransactionContext unless
+// another handler is set
+func TestTransactionContextHandler(t *testing.T) {
+	for _, contract := range []contractapi.ContractInterface{NewAssetContract(), NewQueryContract(), &AdminContract{}, &ConfigContract{}} {
+		assert.IsType(t, &TransactionContext{}, contract.GetTransactionContextHandler())
+	}
+	custom := &contractapi.TransactionContext{}
+	contract := &AssetContract{}
+	contract.TransactionContextHandler = custom
+	assert.Same(t, custom, contract.GetTransactionContextHandler())
+}
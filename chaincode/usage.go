@@ -0,0 +1,134 @@
+package chaincode
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// usageIndex keys the shards of the daily invocation counters of each function
+	usageIndex = "usage~day~function~shard"
+	// usageDateLayout is the layout of usage days, in UTC
+	usageDateLayout = "2006-01-02"
+	// maxUsageStatsDays bounds the days one GetUsageStats call sums
+	maxUsageStatsDays = 366
+)
+
+// FunctionUsage is the number of committed invocations of a contract function
+type FunctionUsage struct {
+	Function    string `json:"function"`
+	Invocations uint64 `json:"invocations"`
+	FirstDay    string `json:"firstDay"`
+	LastDay     string `json:"lastDay"`
+}
+
+// recordUsage counts the invocation of the current function on the transaction's day. Only
+// submitted transactions that commit are counted; evaluated calls never reach the ledger.
+func (r *contractRuntime) recordUsage(ctx contractapi.TransactionContextInterface) error {
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	// Functions are counted without their namespace, so calls through the default contract
+	// and its qualified name add up
+	if i := strings.LastIndex(function, ":"); i >= 0 {
+		function = function[i+1:]
+	}
+	if function == "" {
+		return nil
+	}
+	now, err := r.now(ctx)
+	if err != nil {
+		return err
+	}
+	day := now.UTC().Format(usageDateLayout)
+	counter := &shardedCounter{name: "usage:" + day + ":" + function, index: usageIndex, attributes: []string{day, function}}
+	if _, _, err := counter.add(ctx, 1); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetUsageStats returns the invocations of each contract function from fromDate (YYYY-MM-DD,
+// UTC) through today, most used first, to find unused functions before deprecating them.
+// At most 366 days are summed. Counts are only kept when the contracts run with WithUsageStats.
+func (q *QueryContract) GetUsageStats(ctx contractapi.TransactionContextInterface, fromDate string) ([]*FunctionUsage, error) {
+	q.logger(ctx).Info().Str("function", "GetUsageStats").Str("fromDate", fromDate).Msg("Reading usage statistics")
+
+	from, err := time.Parse(usageDateLayout, fromDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fromDate %q, expected YYYY-MM-DD: %v", fromDate, err)
+	}
+	now, err := q.now(ctx)
+	if err != nil {
+		return nil, err
+	}
+	today, _ := time.Parse(usageDateLayout, now.UTC().Format(usageDateLayout))
+	if from.After(today) {
+		return nil, fmt.Errorf("fromDate %s is in the future", fromDate)
+	}
+	if days := int(today.Sub(from).Hours()/24) + 1; days > maxUsageStatsDays {
+		return nil, fmt.Errorf("fromDate %s spans %d days, at most %d are allowed", fromDate, days, maxUsageStatsDays)
+	}
+
+	usage := map[string]*FunctionUsage{}
+	for day := from; !day.After(today); day = day.AddDate(0, 0, 1) {
+		if err := addDailyUsage(ctx, day.Format(usageDateLayout), usage); err != nil {
+			return nil, err
+		}
+	}
+
+	stats := make([]*FunctionUsage, 0, len(usage))
+	for _, functionUsage := range usage {
+		stats = append(stats, functionUsage)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Invocations != stats[j].Invocations {
+			return stats[i].Invocations > stats[j].Invocations
+		}
+		return stats[i].Function < stats[j].Function
+	})
+
+	q.logger(ctx).Info().Str("fromDate", fromDate).Int("functions", len(stats)).Msg("Usage statistics read successfully")
+	return stats, nil
+}
+
+// addDailyUsage adds the counter shards of one day to usage. Days are visited in order, so the
+// first visit of a function sets its first day.
+func addDailyUsage(ctx contractapi.TransactionContextInterface, day string, usage map[string]*FunctionUsage) error {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(usageIndex, []string{day})
+	if err != nil {
+		return fmt.Errorf("failed to read usage of %s: %v", day, err)
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		result, err := iterator.Next()
+		if err != nil {
+			return err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(result.Key)
+		if err != nil {
+			return err
+		}
+		if len(attributes) != 3 {
+			return fmt.Errorf("malformed usage key %q", result.Key)
+		}
+		invocations, err := strconv.ParseUint(string(result.Value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("malformed usage counter %q: %v", result.Key, err)
+		}
+
+		function := attributes[1]
+		functionUsage, ok := usage[function]
+		if !ok {
+			functionUsage = &FunctionUsage{Function: function, FirstDay: day}
+			usage[function] = functionUsage
+		}
+		functionUsage.Invocations += invocations
+		functionUsage.LastDay = day
+	}
+	return nil
+}
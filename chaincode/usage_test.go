@@ -0,0 +1,47 @@
+package chaincode
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUsageStats tests that committed invocations are counted per function across namespaces
+func TestUsageStats(t *testing.T) {
+	cc, err := contractapi.NewChaincode(NewAssetContract(WithUsageStats()), NewQueryContract(WithUsageStats()))
+	require.NoError(t, err)
+	stub := shimtest.NewMockStub("chaincode", cc)
+	stub.Creator = newSerializedIdentity(t, "Org1MSP", "user1", nil)
+
+	for i, args := range [][]string{
+		{"CreateAsset", "asset1", "blue", "5", "user1", "100"},
+		{"AssetContract:CreateAsset", "asset2", "red", "5", "user1", "100"},
+		{"QueryContract:ReadAsset", "asset1"},
+	} {
+		status, message := invoke(stub, fmt.Sprintf("tx%d", i), args...)
+		require.Equal(t, int32(shim.OK), status, message)
+	}
+
+	stub.MockTransactionStart("query")
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(stub)
+	qc := NewQueryContract()
+	today := time.Now().UTC().Format(usageDateLayout)
+	stats, err := qc.GetUsageStats(ctx, today)
+	require.NoError(t, err)
+	assert.Equal(t, []*FunctionUsage{
+		{Function: "CreateAsset", Invocations: 2, FirstDay: today, LastDay: today},
+		{Function: "ReadAsset", Invocations: 1, FirstDay: today, LastDay: today},
+	}, stats)
+
+	for _, fromDate := range []string{"yesterday", time.Now().UTC().AddDate(0, 0, 2).Format(usageDateLayout), time.Now().UTC().AddDate(-2, 0, 0).Format(usageDateLayout)} {
+		_, err := qc.GetUsageStats(ctx, fromDate)
+		assert.Error(t, err, fromDate)
+	}
+}
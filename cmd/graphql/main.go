@@ -0,0 +1,70 @@
+// Command graphql generates a GraphQL schema for the evaluate functions of this chaincode from
+// its contract metadata, for GraphQL gateways that resolve queries by evaluating the function
+// named in each field's @chaincode directive.
+//
+//	go run ./cmd/graphql -output schema.graphql
+//
+// Without -metadata the metadata is taken from the contracts compiled into the command; pass the
+// output of org.hyperledger.fabric:GetMetadata to generate the schema of a deployed chaincode.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/chainlaunch/chaincode-fabric-go-tmpl/chaincode"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func main() {
+	metadataPath := flag.String("metadata", "", "contract metadata JSON file, the compiled contracts by default")
+	output := flag.String("output", "", "path of the schema, stdout by default")
+	flag.Parse()
+
+	var metadata []byte
+	var err error
+	if *metadataPath != "" {
+		metadata, err = os.ReadFile(*metadataPath)
+	} else {
+		metadata, err = compiledMetadata()
+	}
+	if err != nil {
+		log.Fatalf("failed to read contract metadata: %s", err)
+	}
+	schema, err := chaincode.GraphQLSchema(metadata)
+	if err != nil {
+		log.Fatalf("failed to generate GraphQL schema: %s", err)
+	}
+	if *output == "" {
+		fmt.Print(schema)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(schema), 0o644); err != nil {
+		log.Fatalf("failed to write GraphQL schema: %s", err)
+	}
+}
+
+// compiledMetadata returns the metadata of the contracts registered by the chaincode server
+func compiledMetadata() ([]byte, error) {
+	cc, err := contractapi.NewChaincode(
+		chaincode.NewAssetContract(),
+		chaincode.NewQueryContract(),
+		&chaincode.AdminContract{},
+		&chaincode.ConfigContract{},
+		&chaincode.TokenContract{},
+		&chaincode.NFTContract{},
+		&chaincode.EscrowContract{},
+	)
+	if err != nil {
+		return nil, err
+	}
+	response := shimtest.NewMockStub("chaincode", cc).MockInvoke("metadata", [][]byte{[]byte("org.hyperledger.fabric:GetMetadata")})
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("%s", response.Message)
+	}
+	return response.Payload, nil
+}
@@ -0,0 +1,216 @@
+// Command package builds the chaincode-as-a-service (CCaaS) package that peers install for this
+// chaincode: a tar.gz holding metadata.json and code.tar.gz, which in turn holds connection.json
+// telling the peer where the chaincode server listens and how to reach it over TLS, and the
+// CouchDB index definitions of the chaincode.
+//
+//	go run ./cmd/package -label asset_1.0 -address asset-chaincode:9999 -root-cert tls/ca.crt
+//	peer lifecycle chaincode install asset_1.0.tar.gz
+//
+// Every flag can also be set with the environment variable given in its usage text.
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/chainlaunch/chaincode-fabric-go-tmpl/chaincode"
+)
+
+// packageOptions are the settings of the package to build
+type packageOptions struct {
+	Label       string
+	Type        string
+	Address     string
+	DialTimeout string
+	RootCert    string // path of the CA certificate of the chaincode server's TLS certificate
+	ClientAuth  bool
+	ClientKey   string // path of the key the peer presents when client auth is required
+	ClientCert  string // path of the certificate the peer presents when client auth is required
+	Output      string
+}
+
+// connection is the connection.json read by the peer's ccaas builder
+type connection struct {
+	Address            string `json:"address"`
+	DialTimeout        string `json:"dial_timeout"`
+	TLSRequired        bool   `json:"tls_required"`
+	ClientAuthRequired bool   `json:"client_auth_required"`
+	ClientKey          string `json:"client_key"`
+	ClientCert         string `json:"client_cert"`
+	RootCert           string `json:"root_cert"`
+}
+
+// metadata is the metadata.json that selects the builder and names the package
+type metadata struct {
+	Type  string `json:"type"`
+	Label string `json:"label"`
+}
+
+// archiveFile is a file written into a tar archive
+type archiveFile struct {
+	name    string
+	content []byte
+}
+
+func main() {
+	var opts packageOptions
+	flag.StringVar(&opts.Label, "label", os.Getenv("CHAINCODE_PACKAGE_LABEL"), "package label, e.g. asset_1.0 (CHAINCODE_PACKAGE_LABEL)")
+	flag.StringVar(&opts.Type, "type", envOrDefault("CHAINCODE_PACKAGE_TYPE", "ccaas"), "builder type, ccaas for the peer's built-in builder or external for custom builders (CHAINCODE_PACKAGE_TYPE)")
+	flag.StringVar(&opts.Address, "address", os.Getenv("CHAINCODE_PACKAGE_ADDRESS"), "host:port of the chaincode server as seen by the peer (CHAINCODE_PACKAGE_ADDRESS)")
+	flag.StringVar(&opts.DialTimeout, "dial-timeout", envOrDefault("CHAINCODE_PACKAGE_DIAL_TIMEOUT", "10s"), "how long the peer waits to connect (CHAINCODE_PACKAGE_DIAL_TIMEOUT)")
+	flag.StringVar(&opts.RootCert, "root-cert", os.Getenv("CHAINCODE_PACKAGE_ROOT_CERT"), "PEM CA certificate of the server's TLS certificate; enables TLS (CHAINCODE_PACKAGE_ROOT_CERT)")
+	flag.BoolVar(&opts.ClientAuth, "client-auth", envBool("CHAINCODE_PACKAGE_CLIENT_AUTH"), "the server requires the peer to present a client certificate (CHAINCODE_PACKAGE_CLIENT_AUTH)")
+	flag.StringVar(&opts.ClientKey, "client-key", os.Getenv("CHAINCODE_PACKAGE_CLIENT_KEY"), "PEM key the peer presents with client auth (CHAINCODE_PACKAGE_CLIENT_KEY)")
+	flag.StringVar(&opts.ClientCert, "client-cert", os.Getenv("CHAINCODE_PACKAGE_CLIENT_CERT"), "PEM certificate the peer presents with client auth (CHAINCODE_PACKAGE_CLIENT_CERT)")
+	flag.StringVar(&opts.Output, "output", os.Getenv("CHAINCODE_PACKAGE_OUTPUT"), "path of the package, <label>.tar.gz by default (CHAINCODE_PACKAGE_OUTPUT)")
+	flag.Parse()
+
+	if opts.Output == "" {
+		opts.Output = opts.Label + ".tar.gz"
+	}
+	pkg, err := buildPackage(opts)
+	if err != nil {
+		log.Fatalf("failed to build chaincode package: %s", err)
+	}
+	if err := os.WriteFile(opts.Output, pkg, 0o644); err != nil {
+		log.Fatalf("failed to write chaincode package: %s", err)
+	}
+	fmt.Printf("Wrote %s, install it with: peer lifecycle chaincode install %s\n", opts.Output, opts.Output)
+}
+
+// buildPackage returns the package archive for opts
+func buildPackage(opts packageOptions) ([]byte, error) {
+	if opts.Label == "" {
+		return nil, fmt.Errorf("label must be set")
+	}
+	if opts.Type != "ccaas" && opts.Type != "external" {
+		return nil, fmt.Errorf("type must be ccaas or external, got %q", opts.Type)
+	}
+	connectionBytes, err := connectionJSON(opts)
+	if err != nil {
+		return nil, err
+	}
+	codeFiles := []archiveFile{{name: "connection.json", content: connectionBytes}}
+	// Peers create the CouchDB indexes found under META-INF when the chaincode is installed
+	for _, index := range chaincode.CouchDBIndexes() {
+		indexBytes, err := json.MarshalIndent(index, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		codeFiles = append(codeFiles, archiveFile{name: path.Join(chaincode.CouchDBIndexDir, index.FileName()), content: indexBytes})
+	}
+	code, err := tarGz(codeFiles)
+	if err != nil {
+		return nil, err
+	}
+	metadataBytes, err := json.MarshalIndent(metadata{Type: opts.Type, Label: opts.Label}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return tarGz([]archiveFile{
+		{name: "metadata.json", content: metadataBytes},
+		{name: "code.tar.gz", content: code},
+	})
+}
+
+// connectionJSON validates the connection settings and embeds the PEM files they name
+func connectionJSON(opts packageOptions) ([]byte, error) {
+	if _, _, err := net.SplitHostPort(opts.Address); err != nil {
+		return nil, fmt.Errorf("address must be host:port: %v", err)
+	}
+	if _, err := time.ParseDuration(opts.DialTimeout); err != nil {
+		return nil, fmt.Errorf("invalid dial timeout: %v", err)
+	}
+	conn := connection{Address: opts.Address, DialTimeout: opts.DialTimeout}
+
+	if opts.RootCert != "" {
+		rootCert, err := readPEM(opts.RootCert)
+		if err != nil {
+			return nil, err
+		}
+		conn.TLSRequired = true
+		conn.RootCert = rootCert
+	}
+	if opts.ClientAuth {
+		if !conn.TLSRequired {
+			return nil, fmt.Errorf("client auth requires a root certificate")
+		}
+		if opts.ClientKey == "" || opts.ClientCert == "" {
+			return nil, fmt.Errorf("client auth requires a client key and certificate")
+		}
+		var err error
+		conn.ClientAuthRequired = true
+		if conn.ClientKey, err = readPEM(opts.ClientKey); err != nil {
+			return nil, err
+		}
+		if conn.ClientCert, err = readPEM(opts.ClientCert); err != nil {
+			return nil, err
+		}
+	}
+	return json.MarshalIndent(conn, "", "  ")
+}
+
+// readPEM reads a file that must hold at least one PEM block
+func readPEM(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if block, _ := pem.Decode(data); block == nil {
+		return "", fmt.Errorf("%s does not contain PEM data", path)
+	}
+	return string(data), nil
+}
+
+// tarGz archives files with fixed modes and timestamps, so the same inputs always produce the
+// same package and thus the same package ID
+func tarGz(files []archiveFile) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		header := &tar.Header{
+			Name:    file.name,
+			Mode:    0o644,
+			Size:    int64(len(file.content)),
+			ModTime: time.Unix(0, 0),
+			Format:  tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(file.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func envOrDefault(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+func envBool(key string) bool {
+	value, _ := strconv.ParseBool(os.Getenv(key))
+	return value
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// serverConfig holds the configuration parameters needed to start the chaincode server.
+// Values come from an optional config file and are overridden by environment variables.
+type serverConfig struct {
+	Profile string `yaml:"profile"` // dev, staging or prod, sets the defaults the rest of the settings override
+	CCID    string `yaml:"ccid"`    // Chaincode ID as registered with the fabric network
+	Address string `yaml:"address"` // Network address where the chaincode server will listen
+
+	TLS struct {
+		Disabled     bool   `yaml:"disabled"`     // Serve plain gRPC, for development only
+		Key          string `yaml:"key"`          // Path of the server TLS private key
+		Cert         string `yaml:"cert"`         // Path of the server TLS certificate
+		ClientCACert string `yaml:"clientCACert"` // Path of the CA that signs peer client certificates
+
+		AllowedClients []string `yaml:"allowedClients"` // Common or DNS names of the peers allowed to connect, e.g. *.org1.example.com
+	} `yaml:"tls"`
+
+	Log struct {
+		Level string `yaml:"level"` // trace, debug, info, warn or error
+	} `yaml:"log"`
+
+	InitRequired        bool          `yaml:"initRequired"`        // Require Init before other functions
+	KeyLevelEndorsement bool          `yaml:"keyLevelEndorsement"` // Require the creating org to endorse writes of new assets
+	ShutdownTimeout     time.Duration `yaml:"shutdownTimeout"`     // How long a shutdown waits for in-flight transactions
+	UsageStats          bool          `yaml:"usageStats"`          // Count invocations per function and day
+	SoftDelete          bool          `yaml:"softDelete"`          // DeleteAsset leaves a restorable tombstone
+	TenantUsage         bool          `yaml:"tenantUsage"`         // Count transactions and bytes written per organization and month
+	TenantQuotas        bool          `yaml:"tenantQuotas"`        // Reject transactions of organizations past their quota
+
+	AttributeAccessControl bool `yaml:"attributeAccessControl"` // Enforce the caller's role attribute on asset functions
+	RawQueries             bool `yaml:"rawQueries"`             // Serve QueryAssets and the other functions taking CouchDB selectors
+	SampleData             bool `yaml:"sampleData"`             // Allow InitLedger to seed the sample assets
+
+	SlowTransactionThreshold time.Duration `yaml:"slowTransactionThreshold"` // Invocations taking longer are logged, 0 disables
+
+	InputLimits struct {
+		MaxArgs         int `yaml:"maxArgs"`         // Arguments per invocation, the function name included
+		MaxArgBytes     int `yaml:"maxArgBytes"`     // Size of a single argument
+		MaxPayloadBytes int `yaml:"maxPayloadBytes"` // Size of all arguments and transient data together
+	} `yaml:"inputLimits"`
+
+	DevHTTP string `yaml:"devHTTP"` // Address of the dev REST gateway over an in-memory simulator, replaces the chaincode server
+}
+
+// defaultServerConfig returns the settings used when neither the file nor the environment sets them
+func defaultServerConfig() serverConfig {
+	config := serverConfig{ShutdownTimeout: 25 * time.Second, SlowTransactionThreshold: 2 * time.Second}
+	config.TLS.Disabled = true
+	config.Log.Level = "debug"
+	config.RawQueries = true
+	config.SampleData = true
+	return config
+}
+
+// configProfiles adjust the defaults for an environment. The config file and environment
+// variables still override every setting a profile changes.
+var configProfiles = map[string]func(config *serverConfig){
+	"dev": func(config *serverConfig) {
+		config.TLS.Disabled = true
+		config.Log.Level = "debug"
+	},
+	"staging": func(config *serverConfig) {
+		config.TLS.Disabled = false
+		config.Log.Level = "info"
+		config.SlowTransactionThreshold = time.Second
+		config.InputLimits.MaxArgs = 16
+	},
+	"prod": func(config *serverConfig) {
+		config.TLS.Disabled = false
+		config.Log.Level = "info"
+		config.SlowTransactionThreshold = time.Second
+		config.InputLimits.MaxArgs = 16
+		config.RawQueries = false
+		config.SampleData = false
+	},
+}
+
+// loadServerConfig reads the config file at path, YAML or JSON, when path is set on top of the
+// defaults of the profile named by CHAINCODE_PROFILE or the file, and then applies the
+// environment variables, which take precedence over the file
+func loadServerConfig(path string) (serverConfig, error) {
+	config := defaultServerConfig()
+	var data []byte
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return config, fmt.Errorf("failed to read config file: %v", err)
+		}
+		// YAML is a superset of JSON, so one decoder reads both formats
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return config, fmt.Errorf("invalid config file %s: %v", path, err)
+		}
+	}
+	if profile := getEnvOrDefault("CHAINCODE_PROFILE", config.Profile); profile != "" {
+		applyProfile, ok := configProfiles[profile]
+		if !ok {
+			return config, fmt.Errorf("unknown profile %q, expected dev, staging or prod", profile)
+		}
+		// The profile only changes defaults, so the file is read again on top of it
+		config = defaultServerConfig()
+		applyProfile(&config)
+		if data != nil {
+			if err := yaml.Unmarshal(data, &config); err != nil {
+				return config, fmt.Errorf("invalid config file %s: %v", path, err)
+			}
+		}
+		config.Profile = profile
+	}
+
+	config.CCID = getEnvOrDefault("CORE_CHAINCODE_ID", config.CCID)
+	config.Address = getEnvOrDefault("CORE_CHAINCODE_ADDRESS", config.Address)
+	config.TLS.Disabled = getBoolOrDefault(getEnvOrDefault("CHAINCODE_TLS_DISABLED", ""), config.TLS.Disabled)
+	config.TLS.Key = getEnvOrDefault("CHAINCODE_TLS_KEY", config.TLS.Key)
+	config.TLS.Cert = getEnvOrDefault("CHAINCODE_TLS_CERT", config.TLS.Cert)
+	config.TLS.ClientCACert = getEnvOrDefault("CHAINCODE_CLIENT_CA_CERT", config.TLS.ClientCACert)
+	if value, ok := os.LookupEnv("CHAINCODE_TLS_ALLOWED_CLIENTS"); ok {
+		config.TLS.AllowedClients = nil
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				config.TLS.AllowedClients = append(config.TLS.AllowedClients, name)
+			}
+		}
+	}
+	config.Log.Level = getEnvOrDefault("CHAINCODE_LOG_LEVEL", config.Log.Level)
+	config.InitRequired = getBoolOrDefault(getEnvOrDefault("CHAINCODE_INIT_REQUIRED", ""), config.InitRequired)
+	config.KeyLevelEndorsement = getBoolOrDefault(getEnvOrDefault("CHAINCODE_KEY_LEVEL_ENDORSEMENT", ""), config.KeyLevelEndorsement)
+	config.UsageStats = getBoolOrDefault(getEnvOrDefault("CHAINCODE_USAGE_STATS", ""), config.UsageStats)
+	config.SoftDelete = getBoolOrDefault(getEnvOrDefault("CHAINCODE_SOFT_DELETE", ""), config.SoftDelete)
+	config.TenantUsage = getBoolOrDefault(getEnvOrDefault("CHAINCODE_TENANT_USAGE", ""), config.TenantUsage)
+	config.TenantQuotas = getBoolOrDefault(getEnvOrDefault("CHAINCODE_TENANT_QUOTAS", ""), config.TenantQuotas)
+	config.AttributeAccessControl = getBoolOrDefault(getEnvOrDefault("CHAINCODE_ATTRIBUTE_ACCESS_CONTROL", ""), config.AttributeAccessControl)
+	config.RawQueries = getBoolOrDefault(getEnvOrDefault("CHAINCODE_RAW_QUERIES", ""), config.RawQueries)
+	config.SampleData = getBoolOrDefault(getEnvOrDefault("CHAINCODE_SAMPLE_DATA", ""), config.SampleData)
+	config.DevHTTP = getEnvOrDefault("CHAINCODE_DEV_HTTP", config.DevHTTP)
+	for env, limit := range map[string]*int{
+		"CHAINCODE_MAX_ARGS":          &config.InputLimits.MaxArgs,
+		"CHAINCODE_MAX_ARG_BYTES":     &config.InputLimits.MaxArgBytes,
+		"CHAINCODE_MAX_PAYLOAD_BYTES": &config.InputLimits.MaxPayloadBytes,
+	} {
+		if value, ok := os.LookupEnv(env); ok {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				return config, fmt.Errorf("invalid %s: %q", env, value)
+			}
+			*limit = parsed
+		}
+	}
+	if value, ok := os.LookupEnv("CHAINCODE_SHUTDOWN_TIMEOUT"); ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid shutdown timeout: %v", err)
+		}
+		config.ShutdownTimeout = timeout
+	}
+	if value, ok := os.LookupEnv("CHAINCODE_SLOW_TX_THRESHOLD"); ok {
+		threshold, err := time.ParseDuration(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid slow transaction threshold: %v", err)
+		}
+		config.SlowTransactionThreshold = threshold
+	}
+	if len(config.TLS.AllowedClients) > 0 && (config.TLS.Disabled || config.TLS.ClientCACert == "") {
+		return config, fmt.Errorf("the TLS client allowlist requires TLS and a client CA certificate")
+	}
+	return config, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configEnv lists the environment variables loadServerConfig reads
+var configEnv = []string{
+	"CHAINCODE_PROFILE", "CORE_CHAINCODE_ID", "CORE_CHAINCODE_ADDRESS", "CHAINCODE_TLS_DISABLED",
+	"CHAINCODE_TLS_KEY", "CHAINCODE_TLS_CERT", "CHAINCODE_CLIENT_CA_CERT", "CHAINCODE_TLS_ALLOWED_CLIENTS",
+	"CHAINCODE_LOG_LEVEL", "CHAINCODE_INIT_REQUIRED", "CHAINCODE_KEY_LEVEL_ENDORSEMENT",
+	"CHAINCODE_USAGE_STATS", "CHAINCODE_SOFT_DELETE", "CHAINCODE_TENANT_USAGE", "CHAINCODE_TENANT_QUOTAS",
+	"CHAINCODE_ATTRIBUTE_ACCESS_CONTROL", "CHAINCODE_RAW_QUERIES", "CHAINCODE_SAMPLE_DATA",
+	"CHAINCODE_DEV_HTTP", "CHAINCODE_MAX_ARGS", "CHAINCODE_MAX_ARG_BYTES", "CHAINCODE_MAX_PAYLOAD_BYTES",
+	"CHAINCODE_SHUTDOWN_TIMEOUT", "CHAINCODE_SLOW_TX_THRESHOLD",
+}
+
+// TestLoadServerConfig tests how the defaults, the profile, the config file and the environment
+// variables layer on each other
+func TestLoadServerConfig(t *testing.T) {
+	cases := []struct {
+		name  string
+		file  string
+		env   map[string]string
+		check func(t *testing.T, config serverConfig)
+		err   string
+	}{
+		{name: "defaults", check: func(t *testing.T, config serverConfig) {
+			assert.Equal(t, defaultServerConfig(), config)
+		}},
+		{name: "YAML file", file: "ccid: cc1\naddress: 0.0.0.0:9999\nlog:\n  level: warn\nshutdownTimeout: 5s\n", check: func(t *testing.T, config serverConfig) {
+			assert.Equal(t, "cc1", config.CCID)
+			assert.Equal(t, "0.0.0.0:9999", config.Address)
+			assert.Equal(t, "warn", config.Log.Level)
+			assert.Equal(t, 5*time.Second, config.ShutdownTimeout)
+			assert.True(t, config.TLS.Disabled)
+		}},
+		{name: "JSON file", file: `{"ccid":"cc1","softDelete":true}`, check: func(t *testing.T, config serverConfig) {
+			assert.Equal(t, "cc1", config.CCID)
+			assert.True(t, config.SoftDelete)
+		}},
+		{name: "profile from the file", file: "profile: prod\n", check: func(t *testing.T, config serverConfig) {
+			assert.Equal(t, "prod", config.Profile)
+			assert.False(t, config.TLS.Disabled)
+			assert.Equal(t, "info", config.Log.Level)
+			assert.False(t, config.RawQueries)
+			assert.False(t, config.SampleData)
+			assert.Equal(t, 16, config.InputLimits.MaxArgs)
+		}},
+		{name: "profile from the environment", env: map[string]string{"CHAINCODE_PROFILE": "staging"}, check: func(t *testing.T, config serverConfig) {
+			assert.Equal(t, "staging", config.Profile)
+			assert.False(t, config.TLS.Disabled)
+			assert.Equal(t, time.Second, config.SlowTransactionThreshold)
+			assert.True(t, config.RawQueries)
+		}},
+		{name: "environment profile overrides the file's", file: "profile: dev\n", env: map[string]string{"CHAINCODE_PROFILE": "prod"}, check: func(t *testing.T, config serverConfig) {
+			assert.Equal(t, "prod", config.Profile)
+			assert.False(t, config.SampleData)
+		}},
+		{name: "file overrides the profile", file: "profile: prod\nrawQueries: true\nlog:\n  level: error\n", check: func(t *testing.T, config serverConfig) {
+			assert.True(t, config.RawQueries)
+			assert.Equal(t, "error", config.Log.Level)
+			assert.False(t, config.SampleData)
+		}},
+		{name: "environment overrides the file", file: "ccid: cc1\nsoftDelete: true\ninputLimits:\n  maxArgs: 8\n", env: map[string]string{
+			"CORE_CHAINCODE_ID":     "cc2",
+			"CHAINCODE_SOFT_DELETE": "false",
+			"CHAINCODE_MAX_ARGS":    "32",
+		}, check: func(t *testing.T, config serverConfig) {
+			assert.Equal(t, "cc2", config.CCID)
+			assert.False(t, config.SoftDelete)
+			assert.Equal(t, 32, config.InputLimits.MaxArgs)
+		}},
+		{name: "environment overrides the profile", env: map[string]string{
+			"CHAINCODE_PROFILE":      "prod",
+			"CHAINCODE_TLS_DISABLED": "true",
+			"CHAINCODE_SAMPLE_DATA":  "true",
+		}, check: func(t *testing.T, config serverConfig) {
+			assert.True(t, config.TLS.Disabled)
+			assert.True(t, config.SampleData)
+			assert.False(t, config.RawQueries)
+		}},
+		{name: "allowed clients from the environment", file: "tls:\n  disabled: false\n  clientCACert: ca.pem\n  allowedClients: [peer0.org1.example.com]\n", env: map[string]string{
+			"CHAINCODE_TLS_ALLOWED_CLIENTS": " *.org2.example.com, ,peer1.org2.example.com",
+		}, check: func(t *testing.T, config serverConfig) {
+			assert.Equal(t, []string{"*.org2.example.com", "peer1.org2.example.com"}, config.TLS.AllowedClients)
+		}},
+		{name: "unknown profile", env: map[string]string{"CHAINCODE_PROFILE": "qa"}, err: "unknown profile"},
+		{name: "invalid file", file: "ccid: [", err: "invalid config file"},
+		{name: "invalid limit", env: map[string]string{"CHAINCODE_MAX_ARG_BYTES": "-1"}, err: "invalid CHAINCODE_MAX_ARG_BYTES"},
+		{name: "invalid shutdown timeout", env: map[string]string{"CHAINCODE_SHUTDOWN_TIMEOUT": "soon"}, err: "invalid shutdown timeout"},
+		{name: "allowlist without TLS", file: "tls:\n  allowedClients: [peer0.org1.example.com]\n", err: "requires TLS"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for _, name := range configEnv {
+				t.Setenv(name, "")
+				require.NoError(t, os.Unsetenv(name))
+			}
+			for name, value := range c.env {
+				t.Setenv(name, value)
+			}
+			path := ""
+			if c.file != "" {
+				path = filepath.Join(t.TempDir(), "chaincode.yaml")
+				require.NoError(t, os.WriteFile(path, []byte(c.file), 0o600))
+			}
+
+			config, err := loadServerConfig(path)
+			if c.err != "" {
+				assert.ErrorContains(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			c.check(t, config)
+		})
+	}
+
+	_, err := loadServerConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read config file")
+}
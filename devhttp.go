@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Identity used by dev gateway requests without identity headers
+	devDefaultMSPID = "Org1MSP"
+	devDefaultUser  = "devuser"
+)
+
+// attrOID is the X.509 extension Fabric CA embeds identity attributes in
+var attrOID = []int{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+// devGateway serves the contract functions over HTTP from an in-memory MockStub. It is for
+// frontend development before a network exists: there is no endorsement, ordering or CouchDB,
+// and the world state is lost on exit.
+type devGateway struct {
+	mu         sync.Mutex
+	stub       *shimtest.MockStub
+	txCount    int
+	identities map[string][]byte
+}
+
+// devResponse is the body of a successful dev gateway call
+type devResponse struct {
+	TxID   string          `json:"txId"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Event  *devEvent       `json:"event,omitempty"`
+}
+
+// devEvent is the chaincode event set by a submitted transaction
+type devEvent struct {
+	Name    string          `json:"name"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// serveDevHTTP serves cc on address until the process exits
+func serveDevHTTP(address string, cc shim.Chaincode) error {
+	gateway := &devGateway{stub: shimtest.NewMockStub("chaincode", cc), identities: map[string][]byte{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /submit/{function}", func(w http.ResponseWriter, r *http.Request) { gateway.handle(w, r, true) })
+	mux.HandleFunc("POST /evaluate/{function}", func(w http.ResponseWriter, r *http.Request) { gateway.handle(w, r, false) })
+
+	log.Info().Str("address", address).Msg("Dev HTTP gateway listening, backed by an in-memory simulator rather than Fabric")
+	return http.ListenAndServe(address, mux)
+}
+
+// handle invokes the function named in the path with the arguments in the body: a JSON array of
+// strings, or a JSON object for named arguments. Submitted calls keep their writes when they
+// succeed; evaluated and failed calls leave the world state untouched, as on a peer.
+func (g *devGateway) handle(w http.ResponseWriter, r *http.Request, submit bool) {
+	args, err := devArgs(r)
+	if err != nil {
+		writeDevError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	creator, err := g.identity(r.Header.Get("X-Msp-Id"), r.Header.Get("X-User"), r.Header.Get("X-Roles"))
+	if err != nil {
+		log.Error().Err(err).Str("mspId", r.Header.Get("X-Msp-Id")).Str("user", r.Header.Get("X-User")).Msg("Failed to create dev gateway identity")
+		writeDevError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.txCount++
+	txID := fmt.Sprintf("dev-tx-%d", g.txCount)
+	g.stub.Creator = creator
+	state, keys := g.snapshot()
+	response := g.stub.MockInvoke(txID, append([][]byte{[]byte(r.PathValue("function"))}, args...))
+	event := g.lastEvent()
+	if response.Status != shim.OK || !submit {
+		g.restore(state, keys)
+	}
+	log.Info().Str("txId", txID).Str("function", r.PathValue("function")).Bool("submit", submit).Int32("status", response.Status).Msg("Dev gateway call completed")
+	if response.Status != shim.OK {
+		writeDevError(w, http.StatusBadRequest, response.Message)
+		return
+	}
+
+	body := devResponse{TxID: txID}
+	if len(response.Payload) > 0 {
+		body.Result = devJSON(response.Payload)
+	}
+	if event != nil && submit {
+		body.Event = &devEvent{Name: event.name, Payload: devJSON(event.payload)}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// devArgs reads the arguments of a call from the request body
+func devArgs(r *http.Request) ([][]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, nil
+	}
+	if body[0] == '{' {
+		return [][]byte{body}, nil
+	}
+	var values []string
+	if err := json.Unmarshal(body, &values); err != nil {
+		return nil, fmt.Errorf("body must be a JSON array of string arguments or a JSON object of named arguments")
+	}
+	args := make([][]byte, len(values))
+	for i, value := range values {
+		args[i] = []byte(value)
+	}
+	return args, nil
+}
+
+// identity returns a serialized identity for the caller named by the request headers, signed by
+// a throwaway key; the simulator does not check signatures. Roles become the role attribute.
+func (g *devGateway) identity(mspID, user, roles string) ([]byte, error) {
+	if mspID == "" {
+		mspID = devDefaultMSPID
+	}
+	if user == "" {
+		user = devDefaultUser
+	}
+	cacheKey := mspID + "\x00" + user + "\x00" + roles
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if creator, ok := g.identities[cacheKey]; ok {
+		return creator, nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(int64(len(g.identities) + 1)),
+		Subject:      pkix.Name{CommonName: user, Organization: []string{mspID}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+	}
+	if roles != "" {
+		attrs, err := json.Marshal(map[string]interface{}{"attrs": map[string]string{"role": roles}})
+		if err != nil {
+			return nil, err
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{Id: attrOID, Value: attrs})
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	creator, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	g.identities[cacheKey] = creator
+	return creator, nil
+}
+
+// snapshot copies the world state of the stub
+func (g *devGateway) snapshot() (map[string][]byte, []string) {
+	state := make(map[string][]byte, len(g.stub.State))
+	for key, value := range g.stub.State {
+		state[key] = value
+	}
+	keys := make([]string, 0, g.stub.Keys.Len())
+	for element := g.stub.Keys.Front(); element != nil; element = element.Next() {
+		keys = append(keys, element.Value.(string))
+	}
+	return state, keys
+}
+
+// restore puts back a world state taken by snapshot
+func (g *devGateway) restore(state map[string][]byte, keys []string) {
+	g.stub.State = state
+	g.stub.Keys = list.New()
+	for _, key := range keys {
+		g.stub.Keys.PushBack(key)
+	}
+}
+
+type setEvent struct {
+	name    string
+	payload []byte
+}
+
+// lastEvent drains the events of the call and returns the last one, which Fabric would keep
+func (g *devGateway) lastEvent() *setEvent {
+	var event *setEvent
+	for {
+		select {
+		case chaincodeEvent := <-g.stub.ChaincodeEventsChannel:
+			event = &setEvent{name: chaincodeEvent.EventName, payload: chaincodeEvent.Payload}
+		default:
+			return event
+		}
+	}
+}
+
+// devJSON returns data as JSON, quoting it when it is not JSON already
+func devJSON(data []byte) json.RawMessage {
+	if json.Valid(data) {
+		return data
+	}
+	quoted, _ := json.Marshal(string(data))
+	return quoted
+}
+
+func writeDevError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": strings.TrimSpace(message)})
+}
@@ -1,66 +1,120 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/chainlaunch/chaincode-fabric-go-tmpl/chaincode"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/rs/zerolog"
 )
 
-// serverConfig holds the configuration parameters needed to start the chaincode server.
-// These values are typically provided through environment variables.
-type serverConfig struct {
-	CCID    string // Chaincode ID as registered with the fabric network
-	Address string // Network address where the chaincode server will listen
-}
-
 // main initializes and starts the chaincode server.
 func main() {
-	// See chaincode.env.example for required variables
-	config := serverConfig{
-		CCID:    os.Getenv("CORE_CHAINCODE_ID"),
-		Address: os.Getenv("CORE_CHAINCODE_ADDRESS"),
+	// Settings come from the --config file (or CONFIG_FILE) and environment variables,
+	// see chaincode.env.example for the variables
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path of a YAML or JSON config file")
+	devHTTP := flag.String("dev-http", "", "serve the contract functions over REST on this address from an in-memory simulator instead of Fabric")
+	flag.Parse()
+	config, err := loadServerConfig(*configPath)
+	if err != nil {
+		log.Panicf("invalid configuration: %s", err)
+	}
+	if *devHTTP != "" {
+		config.DevHTTP = *devHTTP
 	}
 
-	// Create a new chaincode instance with the SimpleChaincode
-	// SimpleCo implements the business logic for storing and retrieving hash records
-	chaincodeInstance, err := contractapi.NewChaincode(&chaincode.SimpleChaincode{})
+	// Pretty logging for development
+	level, err := zerolog.ParseLevel(config.Log.Level)
+	if err != nil {
+		log.Panicf("invalid log level: %s", err)
+	}
+	chaincode.Configure(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}, level)
+
+	// Reject asset and query functions until Init has run when the chaincode
+	// definition was approved with --init-required
+	var opts []chaincode.Option
+	if config.InitRequired {
+		opts = append(opts, chaincode.WithInitRequired())
+	}
+	if config.KeyLevelEndorsement {
+		opts = append(opts, chaincode.WithKeyLevelEndorsement())
+	}
+	if config.UsageStats {
+		opts = append(opts, chaincode.WithUsageStats())
+	}
+	if config.SoftDelete {
+		opts = append(opts, chaincode.WithSoftDelete())
+	}
+	if config.TenantQuotas {
+		opts = append(opts, chaincode.WithTenantQuotas())
+	} else if config.TenantUsage {
+		opts = append(opts, chaincode.WithTenantUsage())
+	}
+	if config.AttributeAccessControl {
+		opts = append(opts, chaincode.WithAttributeAccessControl())
+	}
+	opts = append(opts, chaincode.WithRawQueries(config.RawQueries), chaincode.WithSampleData(config.SampleData))
+
+	// Create a new chaincode instance with the AssetContract as the default contract
+	// AssetContract writes assets and QueryContract reads them over the same storage layer
+	// AdminContract and ConfigContract expose administrative transactions under their own namespaces
+	// TokenContract and NFTContract add fungible and non-fungible tokens, showing several contracts in one chaincode
+	// EscrowContract holds assets for conditional exchanges
+	chaincodeInstance, err := contractapi.NewChaincode(
+		chaincode.NewAssetContract(opts...),
+		chaincode.NewQueryContract(opts...),
+		chaincode.NewAdminContract(opts...),
+		&chaincode.ConfigContract{},
+		&chaincode.TokenContract{},
+		&chaincode.NFTContract{},
+		&chaincode.EscrowContract{},
+	)
 
 	if err != nil {
 		log.Panicf("error create  chaincode: %s", err)
 	}
 
+	// Reject oversized input before it is decoded, log invocations slower than the threshold
+	// with the state they touched, and track in-flight invocations so a shutdown can wait for them
+	limited := chaincode.NewInputLimitsChaincode(chaincode.NewNamedArgsChaincode(chaincodeInstance), chaincode.InputLimits{
+		MaxArgs:         config.InputLimits.MaxArgs,
+		MaxArgBytes:     config.InputLimits.MaxArgBytes,
+		MaxPayloadBytes: config.InputLimits.MaxPayloadBytes,
+	})
+	observed := chaincode.NewSlowTransactionChaincode(limited, config.SlowTransactionThreshold)
+	draining := chaincode.NewDrainingChaincode(observed)
+
+	// Without a network, frontend developers integrate against the REST interface instead
+	if config.DevHTTP != "" {
+		log.Fatal(serveDevHTTP(config.DevHTTP, draining))
+	}
+
 	// Configure the chaincode server with the appropriate settings
 	server := &shim.ChaincodeServer{
-		CCID:     config.CCID,        // Chaincode ID from environment
-		Address:  config.Address,     // Network address from environment
-		CC:       chaincodeInstance,  // The initialized chaincode
-		TLSProps: getTLSProperties(), // TLS configuration
+		CCID:     config.CCID,              // Chaincode ID from the configuration
+		Address:  config.Address,           // Network address from the configuration
+		CC:       draining,                 // The initialized chaincode, also accepting named arguments
+		TLSProps: getTLSProperties(config), // TLS configuration
 	}
 
-	// Start the chaincode server
-	// This will block until the server is shutdown or encounters an error
-	if err := server.Start(); err != nil {
-		log.Panicf("error starting  chaincode: %s", err)
-	}
+	// Serve until SIGTERM/SIGINT, then drain in-flight transactions before exiting
+	os.Exit(runServer(server, draining, config.ShutdownTimeout, config.TLS.AllowedClients))
 }
 
-// getTLSProperties configures and returns the TLS settings for the chaincode server.
-// It reads TLS configuration from environment variables and loads the necessary
-// cryptographic materials (keys and certificates) when TLS is enabled.
+// getTLSProperties loads the cryptographic materials (keys and certificates) named by the
+// configuration when TLS is enabled.
 // Returns a TLSProperties struct that can be used to configure the chaincode server.
-func getTLSProperties() shim.TLSProperties {
-	// Check if chaincode is TLS enabled by reading from environment variables
-	tlsDisabledStr := getEnvOrDefault("CHAINCODE_TLS_DISABLED", "true")
-	key := getEnvOrDefault("CHAINCODE_TLS_KEY", "")
-	cert := getEnvOrDefault("CHAINCODE_TLS_CERT", "")
-	clientCACert := getEnvOrDefault("CHAINCODE_CLIENT_CA_CERT", "")
-
-	// convert tlsDisabledStr to boolean
-	tlsDisabled := getBoolOrDefault(tlsDisabledStr, false)
+func getTLSProperties(config serverConfig) shim.TLSProperties {
+	tlsDisabled := config.TLS.Disabled
+	key := config.TLS.Key
+	cert := config.TLS.Cert
+	clientCACert := config.TLS.ClientCACert
+
 	var keyBytes, certBytes, clientCACertBytes []byte
 	var err error
 
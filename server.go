@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/chainlaunch/chaincode-fabric-go-tmpl/chaincode"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Server settings matching the defaults of shim.ChaincodeServer
+const (
+	maxMessageSize    = 100 * 1024 * 1024
+	connectionTimeout = 5 * time.Second
+)
+
+// runServer serves the chaincode until the server fails or SIGTERM/SIGINT arrives. On a signal
+// it rejects new invocations, waits up to drainTimeout for in-flight ones, then closes the gRPC
+// server and its listener. It returns the process exit code.
+//
+// shim.ChaincodeServer.Start offers no way to stop the server, so the gRPC server is built
+// here and the ChaincodeServer is only registered as its Chaincode service.
+func runServer(server *shim.ChaincodeServer, cc *chaincode.DrainingChaincode, drainTimeout time.Duration, allowedClients []string) int {
+	grpcServer, listener, err := newGRPCServer(server, allowedClients)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create chaincode server")
+		return 1
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(signals)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- grpcServer.Serve(listener)
+	}()
+	log.Info().Str("address", listener.Addr().String()).Str("ccid", server.CCID).Msg("Chaincode server started")
+
+	select {
+	case err := <-served:
+		log.Error().Err(err).Msg("Chaincode server stopped")
+		return 1
+	case sig := <-signals:
+		log.Info().Str("signal", sig.String()).Dur("timeout", drainTimeout).Msg("Shutting down, draining in-flight transactions")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	exitCode := 0
+	if err := cc.Drain(ctx); err != nil {
+		log.Warn().Err(err).Msg("In-flight transactions did not finish before the shutdown timeout")
+		exitCode = 1
+	}
+
+	// The peer keeps its stream open, so a graceful stop would wait for the peer; with no
+	// transaction left in flight the stream can be closed right away.
+	grpcServer.Stop()
+	if err := <-served; err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		log.Error().Err(err).Msg("Chaincode server failed during shutdown")
+		exitCode = 1
+	}
+	log.Info().Int("exitCode", exitCode).Msg("Chaincode server stopped")
+	return exitCode
+}
+
+// newGRPCServer validates the server settings and creates the listener and gRPC server with the
+// keepalive, message size and TLS settings shim.ChaincodeServer would use, plus the client
+// certificate allowlist
+func newGRPCServer(server *shim.ChaincodeServer, allowedClients []string) (*grpc.Server, net.Listener, error) {
+	if server.CCID == "" {
+		return nil, nil, errors.New("ccid must be specified")
+	}
+	if server.Address == "" {
+		return nil, nil, errors.New("address must be specified")
+	}
+	if server.CC == nil {
+		return nil, nil, errors.New("chaincode must be specified")
+	}
+
+	serverKeepalive := keepalive.ServerParameters{Time: time.Minute, Timeout: 20 * time.Second}
+	if server.KaOpts != nil {
+		serverKeepalive = *server.KaOpts
+	}
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveParams(serverKeepalive),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: time.Minute, PermitWithoutStream: true}),
+		grpc.MaxSendMsgSize(maxMessageSize),
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.ConnectionTimeout(connectionTimeout),
+	}
+	if !server.TLSProps.Disabled {
+		tlsConfig, err := newServerTLSConfig(server.TLSProps, allowedClients)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	listener, err := net.Listen("tcp", server.Address)
+	if err != nil {
+		return nil, nil, err
+	}
+	grpcServer := grpc.NewServer(opts...)
+	peer.RegisterChaincodeServer(grpcServer, server)
+	return grpcServer, listener, nil
+}
+
+// newServerTLSConfig builds the server TLS configuration, requiring client certificates when a
+// client CA is configured. With allowedClients, a client certificate signed by the CA must also
+// be issued to one of the allowed names.
+func newServerTLSConfig(props shim.TLSProperties, allowedClients []string) (*tls.Config, error) {
+	certificate, err := tls.X509KeyPair(props.Cert, props.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TLS key pair: %v", err)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:             tls.VersionTLS12,
+		Certificates:           []tls.Certificate{certificate},
+		SessionTicketsDisabled: true,
+	}
+	if props.ClientCACerts != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(props.ClientCACerts) {
+			return nil, errors.New("failed to load client CA certificate")
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if len(allowedClients) > 0 {
+		if props.ClientCACerts == nil {
+			return nil, errors.New("a client certificate allowlist requires a client CA")
+		}
+		tlsConfig.VerifyPeerCertificate = func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+			if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+				return errors.New("no verified client certificate")
+			}
+			client := verifiedChains[0][0]
+			if !clientAllowed(client, allowedClients) {
+				log.Warn().Str("subject", client.Subject.String()).Strs("dnsNames", client.DNSNames).Msg("Rejected chaincode client not on the allowlist")
+				return fmt.Errorf("client certificate %s is not on the allowlist", client.Subject.CommonName)
+			}
+			return nil
+		}
+	}
+	return tlsConfig, nil
+}
+
+// clientAllowed reports whether the common name or a DNS name of a client certificate matches an
+// allowlist entry. An entry like *.org1.example.com matches a single label in its place.
+func clientAllowed(cert *x509.Certificate, allowed []string) bool {
+	names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		for _, name := range names {
+			name = strings.ToLower(name)
+			if name == "" {
+				continue
+			}
+			if name == pattern {
+				return true
+			}
+			if suffix, ok := strings.CutPrefix(pattern, "*"); ok && strings.HasPrefix(suffix, ".") {
+				label, found := strings.CutSuffix(name, suffix)
+				if found && label != "" && !strings.Contains(label, ".") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
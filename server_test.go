@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestClientAllowed tests the matching of client certificate names against the TLS allowlist
+func TestClientAllowed(t *testing.T) {
+	cases := []struct {
+		name     string
+		cn       string
+		dnsNames []string
+		allowed  []string
+		want     bool
+	}{
+		{"exact common name", "peer0.org1.example.com", nil, []string{"peer0.org1.example.com"}, true},
+		{"exact name ignores case", "Peer0.Org1.example.com", nil, []string{"peer0.org1.EXAMPLE.com"}, true},
+		{"exact DNS name", "peer0", []string{"peer0.org1.example.com"}, []string{"peer0.org1.example.com"}, true},
+		{"wildcard label", "peer0.org1.example.com", nil, []string{"*.org1.example.com"}, true},
+		{"wildcard DNS name", "", []string{"peer1.org1.example.com"}, []string{"*.org1.example.com"}, true},
+		{"wildcard matches a single label", "peer0.zone.org1.example.com", nil, []string{"*.org1.example.com"}, false},
+		{"wildcard needs a label", ".org1.example.com", nil, []string{"*.org1.example.com"}, false},
+		{"wildcard without dot", "peer0org1.example.com", nil, []string{"*org1.example.com"}, false},
+		{"other domain", "peer0.org2.example.com", nil, []string{"peer0.org1.example.com", "*.org1.example.com"}, false},
+		{"suffix of an entry", "org1.example.com", nil, []string{"peer0.org1.example.com"}, false},
+		{"empty common name", "", nil, []string{""}, false},
+		{"empty common name against wildcard", "", nil, []string{"*.org1.example.com"}, false},
+		{"empty allowlist", "peer0.org1.example.com", nil, nil, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cert := &x509.Certificate{Subject: pkix.Name{CommonName: c.cn}, DNSNames: c.dnsNames}
+			assert.Equal(t, c.want, clientAllowed(cert, c.allowed))
+		})
+	}
+}